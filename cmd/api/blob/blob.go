@@ -0,0 +1,38 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned by Get when no blob is stored under the key.
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore keeps uploaded files under flat keys chosen by the server.
+// Implementations must be safe for concurrent use.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes a blob. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+const maxKeyLen = 128
+
+// ValidKey reports whether key is usable with every store: letters, digits,
+// '-', '_' and '.', not starting with a dot.
+func ValidKey(key string) bool {
+	if key == "" || len(key) > maxKeyLen || key[0] == '.' {
+		return false
+	}
+	for _, c := range key {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
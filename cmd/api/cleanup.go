@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/scardozos/rottenbikes/cmd/api/blob"
+	"github.com/scardozos/rottenbikes/cmd/api/ratelimit"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+// cleanupJob removes dead credentials, and magic links consumed or expired
+// more than linkRetention ago, then expired data exports and their files,
+// then station availability snapshots older than availabilityRetention.
+func cleanupJob(store *domain.Store, blobs blob.BlobStore, linkRetention, availabilityRetention time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		res, err := store.CleanupAuth(ctx, linkRetention)
+		if err != nil {
+			return err
+		}
+		cleanupRowsTotal.WithLabelValues("magic_links").Add(float64(res.MagicLinks))
+		cleanupRowsTotal.WithLabelValues("sealed_tokens").Add(float64(res.SealedTokens))
+		cleanupRowsTotal.WithLabelValues("sessions").Add(float64(res.Sessions))
+		if res.MagicLinks > 0 || res.SealedTokens > 0 || res.Sessions > 0 {
+			log.Info().
+				Int64("magic_links", res.MagicLinks).
+				Int64("sealed_tokens", res.SealedTokens).
+				Int64("sessions", res.Sessions).
+				Msg("cleaned up expired credentials")
+		}
+
+		keys, err := store.PurgeExpiredDataExports(ctx)
+		if err != nil {
+			return err
+		}
+		cleanupRowsTotal.WithLabelValues("data_exports").Add(float64(len(keys)))
+		for _, key := range keys {
+			// The row is gone either way; a failure only leaves a stray file.
+			if err := blobs.Delete(ctx, key); err != nil {
+				log.Warn().Err(err).Str("key", key).Msg("delete expired data export")
+			}
+		}
+		if len(keys) > 0 {
+			log.Info().Int("data_exports", len(keys)).Msg("purged expired data exports")
+		}
+
+		snapshots, err := store.PruneStationAvailability(ctx, availabilityRetention)
+		if err != nil {
+			return err
+		}
+		cleanupRowsTotal.WithLabelValues("station_availability").Add(float64(snapshots))
+		if snapshots > 0 {
+			log.Info().Int64("station_availability", snapshots).Msg("pruned station availability snapshots")
+		}
+		return nil
+	}
+}
+
+// pruneRateLimitsJob drops rate limit buckets that have refilled.
+func pruneRateLimitsJob(limiter *ratelimit.PostgresLimiter) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		n, err := limiter.Prune(ctx)
+		if err != nil {
+			return err
+		}
+		cleanupRowsTotal.WithLabelValues("rate_limit_buckets").Add(float64(n))
+		return nil
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/scardozos/rottenbikes/internal/domain"
+	"github.com/scardozos/rottenbikes/internal/gbfs"
+)
+
+// gbfsImportJob imports the GBFS station feeds at infoSrc and statusSrc.
+func gbfsImportJob(store *domain.Store, infoSrc, statusSrc string) func(ctx context.Context) error {
+	client := &http.Client{Timeout: 30 * time.Second}
+	return func(ctx context.Context) error {
+		feed, err := gbfs.Load(ctx, client, infoSrc, statusSrc)
+		if err != nil {
+			return err
+		}
+		res, err := store.ImportStationFeed(ctx, feed.Stations, feed.Availability)
+		if err != nil {
+			return err
+		}
+		log.Info().
+			Int("created", res.Created).
+			Int("updated", res.Updated).
+			Int("snapshots", res.Snapshots).
+			Msg("gbfs feed imported")
+		return nil
+	}
+}
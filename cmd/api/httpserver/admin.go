@@ -0,0 +1,177 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scardozos/rottenbikes/cmd/api/validate"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+// /admin/...
+func (s *HTTPServer) handleAdminSubroutes(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/"), "/")
+	parts := strings.Split(path, "/")
+
+	switch {
+	case path == "audit-events":
+		s.handleListAuditEvents(w, r)
+	case path == "stations/import":
+		s.handleImportStations(w, r)
+	case path == "bikes/import":
+		s.handleImportBikes(w, r)
+	case path == "bikes/export":
+		s.handleExportBikes(w, r)
+	case len(parts) == 3 && parts[0] == "bikes" && parts[2] == "restore":
+		s.handleRestoreBike(w, r, parts[1])
+	case len(parts) == 3 && parts[0] == "reviews" && parts[2] == "restore":
+		reviewID, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			s.sendError(w, "invalid review id", http.StatusBadRequest)
+			return
+		}
+		s.handleRestoreReview(w, r, reviewID)
+	default:
+		s.sendError(w, "not found", http.StatusNotFound)
+	}
+}
+
+// POST /admin/bikes/{id}/restore → undo a bike delete
+func (s *HTTPServer) handleRestoreBike(w http.ResponseWriter, r *http.Request, bikeID string) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !validate.Numeric(bikeID) {
+		s.sendError(w, "invalid bike id", http.StatusBadRequest)
+		return
+	}
+
+	posterID, ok := posterIDFromContext(r.Context())
+	if !ok {
+		s.sendError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := s.service.RestoreBike(ctx, bikeID, posterID); err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /admin/reviews/{id}/restore → undo a review delete
+func (s *HTTPServer) handleRestoreReview(w http.ResponseWriter, r *http.Request, reviewID int64) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	posterID, ok := posterIDFromContext(r.Context())
+	if !ok {
+		s.sendError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := s.service.RestoreReview(ctx, reviewID, posterID); err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type listAuditEventsResponse struct {
+	Events     []domain.AuditEvent `json:"events"`
+	NextCursor *string             `json:"next_cursor"`
+}
+
+// GET /admin/audit-events → the audit log, newest first
+//
+// Query parameters: cursor, limit, actor_id, action, entity_type, entity_id,
+// since and until (RFC 3339).
+func (s *HTTPServer) handleListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q, err := parseListAuditEventsQuery(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	events, cursor, err := s.service.ListAuditEvents(ctx, q)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	if events == nil {
+		events = []domain.AuditEvent{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(listAuditEventsResponse{
+		Events:     events,
+		NextCursor: nextCursor(cursor),
+	})
+}
+
+func parseListAuditEventsQuery(r *http.Request) (domain.ListAuditEventsQuery, error) {
+	var q domain.ListAuditEventsQuery
+	var err error
+
+	q.Cursor, q.Limit, err = parsePageParams(r)
+	if err != nil {
+		return q, err
+	}
+
+	params := r.URL.Query()
+
+	if v := params.Get("actor_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return q, errors.New("actor_id must be an integer")
+		}
+		q.ActorID = &id
+	}
+
+	q.Action = params.Get("action")
+	q.EntityType = params.Get("entity_type")
+	q.EntityID = params.Get("entity_id")
+
+	if v := params.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return q, errors.New("since must be an RFC 3339 timestamp")
+		}
+		q.Since = &t
+	}
+	if v := params.Get("until"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return q, errors.New("until must be an RFC 3339 timestamp")
+		}
+		q.Until = &t
+	}
+
+	return q, nil
+}
@@ -0,0 +1,135 @@
+package httpserver
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/scardozos/rottenbikes/cmd/api/email"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+func TestHandleListAuditEvents(t *testing.T) {
+	var got domain.ListAuditEventsQuery
+	mockService := &MockService{
+		GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
+			if token == "admin_token" {
+				return &domain.AuthPoster{PosterID: 1, Role: domain.RoleAdmin}, nil
+			}
+			return &domain.AuthPoster{PosterID: 2, Role: domain.RoleModerator}, nil
+		},
+		ListAuditEventsFunc: func(ctx context.Context, q domain.ListAuditEventsQuery) ([]domain.AuditEvent, string, error) {
+			got = q
+			return []domain.AuditEvent{{EventID: 1, Action: domain.AuditBikeDelete, EntityType: "bike", EntityID: "1234"}}, "", nil
+		},
+	}
+
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	t.Run("forbidden_below_admin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/admin/audit-events", nil)
+		req.Header.Set("Authorization", "Bearer moderator_token")
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected status 403, got %d", w.Code)
+		}
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/admin/audit-events?actor_id=3&action=bike.delete&entity_type=bike&entity_id=1234&since=2024-01-01T00:00:00Z", nil)
+		req.Header.Set("Authorization", "Bearer admin_token")
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		if got.ActorID == nil || *got.ActorID != 3 || got.Action != "bike.delete" || got.EntityType != "bike" || got.EntityID != "1234" || got.Since == nil {
+			t.Errorf("unexpected query: %+v", got)
+		}
+
+		var resp listAuditEventsResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if len(resp.Events) != 1 || resp.NextCursor != nil {
+			t.Errorf("unexpected response: %+v", resp)
+		}
+	})
+
+	t.Run("bad_filter", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/admin/audit-events?until=yesterday", nil)
+		req.Header.Set("Authorization", "Bearer admin_token")
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", w.Code)
+		}
+	})
+}
+
+func TestHandleRestore(t *testing.T) {
+	var restoredBike string
+	var restoredReview int64
+	mockService := &MockService{
+		GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
+			return &domain.AuthPoster{PosterID: 1, Role: domain.RoleAdmin}, nil
+		},
+		RestoreBikeFunc: func(ctx context.Context, id string, actorID int64) error {
+			if id == "404" {
+				return sql.ErrNoRows
+			}
+			restoredBike = id
+			return nil
+		},
+		RestoreReviewFunc: func(ctx context.Context, reviewID, actorID int64) error {
+			restoredReview = reviewID
+			return nil
+		},
+	}
+
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	tests := []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodPost, "/admin/bikes/1234/restore", http.StatusNoContent},
+		{http.MethodPost, "/admin/bikes/404/restore", http.StatusNotFound},
+		{http.MethodGet, "/admin/bikes/1234/restore", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/admin/reviews/7/restore", http.StatusNoContent},
+		{http.MethodPost, "/admin/reviews/abc/restore", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer admin_token")
+			w := httptest.NewRecorder()
+
+			srv.server.Handler.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+
+	if restoredBike != "1234" || restoredReview != 7 {
+		t.Errorf("expected bike 1234 and review 7 restored, got %q and %d", restoredBike, restoredReview)
+	}
+}
@@ -14,21 +14,39 @@ import (
 
 	"github.com/rs/zerolog"
 
+	"github.com/scardozos/rottenbikes/cmd/api/validate"
 	"github.com/scardozos/rottenbikes/internal/domain"
 )
 
 type magicLinkRequest struct {
-	Email    string `json:"email"`
-	Username string `json:"username"`
-	Origin   string `json:"origin"`
-	Captcha  string `json:"captcha_token"`
+	Email       string `json:"email"`
+	Username    string `json:"username"`
+	Origin      string `json:"origin"`
+	Captcha     string `json:"captcha_token"`
+	DeviceLabel string `json:"device_label"`
+}
+
+func (req magicLinkRequest) validate() error {
+	var v validate.Validator
+	v.Check(req.Email != "" || req.Username != "", "email", "email or username is required")
+	v.Check(req.Captcha != "", "captcha_token", "is required")
+	return v.Err()
 }
 
 type registerRequest struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Captcha  string `json:"captcha_token"`
-	Origin   string `json:"origin"`
+	Username    string `json:"username"`
+	Email       string `json:"email"`
+	Captcha     string `json:"captcha_token"`
+	Origin      string `json:"origin"`
+	DeviceLabel string `json:"device_label"`
+}
+
+func (req registerRequest) validate() error {
+	var v validate.Validator
+	v.Check(domain.ValidUsername(req.Username), "username", "must be letters, numbers and dots")
+	v.Check(validate.Email(req.Email), "email", "must be a valid email address")
+	v.Check(req.Captcha != "", "captcha_token", "is required")
+	return v.Err()
 }
 
 // POST /auth/register
@@ -44,8 +62,8 @@ func (s *HTTPServer) handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Email == "" || req.Username == "" || req.Captcha == "" {
-		s.sendError(w, "email, username, and captcha are required", http.StatusBadRequest)
+	if err := req.validate(); err != nil {
+		s.sendDomainError(w, r, err)
 		return
 	}
 
@@ -55,36 +73,13 @@ func (s *HTTPServer) handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	magicToken, err := s.service.Register(r.Context(), req.Username, req.Email)
+	magicToken, err := s.service.Register(r.Context(), req.Username, req.Email, deviceLabel(r, req.DeviceLabel))
 	if err != nil {
-		if strings.Contains(err.Error(), "email already exists") || strings.Contains(err.Error(), "username already exists") {
-			s.sendError(w, err.Error(), http.StatusConflict)
-			return
-		}
-		s.sendInternalServerError(w, r, err)
+		s.sendDomainError(w, r, err)
 		return
 	}
 
-	uiHost := os.Getenv("UI_HOST")
-	if uiHost == "" {
-		uiHost = "localhost"
-	}
-	uiPort := os.Getenv("UI_PORT")
-	if uiPort == "" {
-		uiPort = "8081"
-	}
-
-	scheme := "http"
-	if !isPrivateIP(uiHost) {
-		scheme = "https"
-	}
-	uiURL := fmt.Sprintf("%s://%s:%s/confirm/%s", scheme, uiHost, uiPort, magicToken)
-	if req.Origin != "" {
-		uiURL = fmt.Sprintf("%s?origin=%s", uiURL, req.Origin)
-	}
-	if req.Origin != "" {
-		uiURL = fmt.Sprintf("%s?origin=%s", uiURL, req.Origin)
-	}
+	uiURL := confirmURL(magicToken, req.Origin)
 	zerolog.Ctx(r.Context()).Info().Str("email", req.Email).Str("url", uiURL).Msg("sending UI confirmation link")
 
 	subject := "Welcome to RottenBikes!"
@@ -117,56 +112,29 @@ func (s *HTTPServer) handleRequestMagicLink(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if err := req.validate(); err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
 	identifier := req.Email
 	if identifier == "" {
 		identifier = req.Username
 	}
 
-	if identifier == "" || req.Captcha == "" {
-		s.sendError(w, "email or username, and captcha, are required", http.StatusBadRequest)
-		return
-	}
-
 	// Verify hCaptcha
 	if err := s.verifyCaptcha(r.Context(), req.Captcha, identifier); err != nil {
 		s.sendError(w, "invalid captcha", http.StatusForbidden)
 		return
 	}
 
-	magicToken, targetEmail, err := s.service.CreateMagicLink(r.Context(), identifier)
+	magicToken, targetEmail, err := s.service.CreateMagicLink(r.Context(), identifier, deviceLabel(r, req.DeviceLabel))
 	if err != nil {
-		if errors.Is(err, domain.ErrUserNotFound) {
-			s.sendError(w, "user not found", http.StatusNotFound)
-			return
-		}
-		if errors.Is(err, domain.ErrRateLimitExceeded) {
-			s.sendError(w, "daily magic link limit reached", http.StatusTooManyRequests)
-			return
-		}
-		s.sendInternalServerError(w, r, err)
+		s.sendDomainError(w, r, err)
 		return
 	}
 
-	uiHost := os.Getenv("UI_HOST")
-	if uiHost == "" {
-		uiHost = "localhost"
-	}
-	uiPort := os.Getenv("UI_PORT")
-	if uiPort == "" {
-		uiPort = "8081"
-	}
-
-	scheme := "http"
-	if !isPrivateIP(uiHost) {
-		scheme = "https"
-	}
-	uiURL := fmt.Sprintf("%s://%s:%s/confirm/%s", scheme, uiHost, uiPort, magicToken)
-	if req.Origin != "" {
-		uiURL = fmt.Sprintf("%s?origin=%s", uiURL, req.Origin)
-	}
-	if req.Origin != "" {
-		uiURL = fmt.Sprintf("%s?origin=%s", uiURL, req.Origin)
-	}
+	uiURL := confirmURL(magicToken, req.Origin)
 	zerolog.Ctx(r.Context()).Info().Str("email", targetEmail).Str("url", uiURL).Msg("sending magic link")
 
 	subject := "Your RottenBikes Magic Link"
@@ -223,9 +191,11 @@ func (s *HTTPServer) verifyCaptcha(ctx context.Context, token, email string) err
 }
 
 type confirmResponse struct {
-	APIToken        string    `json:"api_token"`
-	Email           string    `json:"email"`
-	APITokenExpires time.Time `json:"api_token_expires_at"`
+	APIToken            string    `json:"api_token"`
+	Email               string    `json:"email"`
+	APITokenExpires     time.Time `json:"api_token_expires_at"`
+	RefreshToken        string    `json:"refresh_token"`
+	RefreshTokenExpires time.Time `json:"refresh_token_expires_at"`
 }
 
 // GET /auth/confirm?token=...
@@ -250,17 +220,23 @@ func (s *HTTPServer) handleConfirmMagicLink(w http.ResponseWriter, r *http.Reque
 
 	res, err := s.service.ConfirmMagicLink(ctx, token)
 	if err != nil {
-		s.sendError(w, "invalid or expired token", http.StatusBadRequest)
+		s.sendDomainError(w, r, err)
 		return
 	}
 
 	zerolog.Ctx(r.Context()).Info().Str("email", res.Email).Msg("magic link confirmed")
 
+	if res.PreviousEmail != "" {
+		s.notifyEmailChanged(r.Context(), res.PreviousEmail, res.Email)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(confirmResponse{
-		APIToken:        res.APIToken,
-		Email:           res.Email,
-		APITokenExpires: res.APITokenExpiresAt,
+		APIToken:            res.APIToken,
+		Email:               res.Email,
+		APITokenExpires:     res.APITokenExpiresAt,
+		RefreshToken:        res.RefreshToken,
+		RefreshTokenExpires: res.RefreshTokenExpiresAt,
 	})
 }
 
@@ -283,6 +259,7 @@ func (s *HTTPServer) handleVerifyToken(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(map[string]interface{}{
 		"poster_id": posterID,
 		"username":  username,
+		"role":      roleFromContext(r.Context()),
 		"status":    "ok",
 	})
 }
@@ -295,21 +272,63 @@ func (s *HTTPServer) handlePollMagicLink(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	apiToken, err := s.service.CheckMagicLinkStatus(r.Context(), token)
+	tokens, err := s.service.CheckMagicLinkStatus(r.Context(), token)
 	if err != nil {
 		s.sendError(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	if apiToken == "" {
+	if tokens == nil {
 		s.sendError(w, "not confirmed", http.StatusNotFound)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(map[string]string{
-		"api_token": apiToken,
-	})
+	_ = json.NewEncoder(w).Encode(tokens)
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (req refreshRequest) validate() error {
+	var v validate.Validator
+	v.Check(req.RefreshToken != "", "refresh_token", "is required")
+	return v.Err()
+}
+
+// POST /auth/refresh
+func (s *HTTPServer) handleRefreshToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	tokens, err := s.service.RefreshSession(ctx, req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, domain.ErrRefreshTokenReused) {
+			zerolog.Ctx(r.Context()).Warn().Msg("refresh token reuse detected, session revoked")
+		}
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(tokens)
 }
 
 // DELETE /auth/user
@@ -340,13 +359,46 @@ func (s *HTTPServer) handleDeletePoster(w http.ResponseWriter, r *http.Request)
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 	// Pass the parsed flag
-	if err := s.service.DeletePoster(ctx, posterID, req.DeletePosterSubresources); err != nil {
-		s.sendInternalServerError(w, r, err)
+	blobKeys, err := s.service.DeletePoster(ctx, posterID, req.DeletePosterSubresources)
+	if err != nil {
+		s.sendDomainError(w, r, err)
 		return
 	}
+	s.deleteBlobs(r, blobKeys...)
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// deviceLabel names the session a login will create: the client's own label
+// when it sends one, its User-Agent otherwise.
+func deviceLabel(r *http.Request, label string) string {
+	if label = strings.TrimSpace(label); label != "" {
+		return label
+	}
+	return r.UserAgent()
+}
+
+// confirmURL builds the UI link that consumes a magic token.
+func confirmURL(magicToken, origin string) string {
+	uiHost := os.Getenv("UI_HOST")
+	if uiHost == "" {
+		uiHost = "localhost"
+	}
+	uiPort := os.Getenv("UI_PORT")
+	if uiPort == "" {
+		uiPort = "8081"
+	}
+
+	scheme := "http"
+	if !isPrivateIP(uiHost) {
+		scheme = "https"
+	}
+	uiURL := fmt.Sprintf("%s://%s:%s/confirm/%s", scheme, uiHost, uiPort, magicToken)
+	if origin != "" {
+		uiURL = fmt.Sprintf("%s?origin=%s", uiURL, url.QueryEscape(origin))
+	}
+	return uiURL
+}
+
 func isPrivateIP(host string) bool {
 	if host == "localhost" {
 		return true
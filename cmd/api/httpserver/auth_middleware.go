@@ -11,6 +11,11 @@ type contextKey string
 
 const contextKeyPosterID contextKey = "poster_id"
 const contextKeyUsername contextKey = "username"
+const contextKeySessionID contextKey = "session_id"
+
+// authLookupTimeout bounds the token lookup alone; handlers set their own
+// deadlines on the request context.
+const authLookupTimeout = 3 * time.Second
 
 func posterIDFromContext(ctx context.Context) (int64, bool) {
 	v := ctx.Value(contextKeyPosterID)
@@ -21,6 +26,15 @@ func posterIDFromContext(ctx context.Context) (int64, bool) {
 	return id, ok
 }
 
+func sessionIDFromContext(ctx context.Context) (int64, bool) {
+	v := ctx.Value(contextKeySessionID)
+	if v == nil {
+		return 0, false
+	}
+	id, ok := v.(int64)
+	return id, ok
+}
+
 func usernameFromContext(ctx context.Context) (string, bool) {
 	v := ctx.Value(contextKeyUsername)
 	if v == nil {
@@ -31,6 +45,7 @@ func usernameFromContext(ctx context.Context) (string, bool) {
 }
 
 // middlewareAuth enforces a valid Bearer API token and injects poster_id into context.
+// Requests that change data are then held to the poster's write limit.
 func (s *HTTPServer) middlewareAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		h := r.Header.Get("Authorization")
@@ -50,22 +65,23 @@ func (s *HTTPServer) middlewareAuth(next http.Handler) http.Handler {
 			return
 		}
 
-		ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
-		defer cancel()
-
-		poster, err := s.service.GetPosterByAPIToken(ctx, token)
+		lookupCtx, cancel := context.WithTimeout(r.Context(), authLookupTimeout)
+		poster, err := s.service.GetPosterByAPIToken(lookupCtx, token)
+		cancel()
 		if err != nil {
-			s.sendError(w, "invalid or expired api token", http.StatusUnauthorized)
+			s.sendDomainError(w, r, err)
 			return
 		}
 
-		ctx = context.WithValue(ctx, contextKeyPosterID, poster.PosterID)
+		ctx := context.WithValue(r.Context(), contextKeyPosterID, poster.PosterID)
 		ctx = context.WithValue(ctx, contextKeyUsername, poster.Username)
+		ctx = context.WithValue(ctx, contextKeySessionID, poster.SessionID)
+		ctx = context.WithValue(ctx, contextKeyRole, poster.Role)
 
 		if rw, ok := w.(*ResponseWriter); ok {
 			rw.Username = poster.Username
 		}
 
-		next.ServeHTTP(w, r.WithContext(ctx))
+		s.rateLimitWrites(next).ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -4,22 +4,23 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/scardozos/rottenbikes/cmd/api/blob"
 	"github.com/scardozos/rottenbikes/cmd/api/email"
 	"github.com/scardozos/rottenbikes/internal/domain"
 )
 
 func TestHandleRequestMagicLink(t *testing.T) {
 	mockService := &MockService{
-		RegisterFunc: func(ctx context.Context, username, email string) (string, error) {
+		RegisterFunc: func(ctx context.Context, username, email, deviceLabel string) (string, error) {
 			return "magic-token-for-" + email, nil
 		},
-		CreateMagicLinkFunc: func(ctx context.Context, email string) (string, string, error) {
+		CreateMagicLinkFunc: func(ctx context.Context, email, deviceLabel string) (string, string, error) {
 			if email == "test@example.com" || email == "testuser" {
 				return "magic-token-for-" + email, "test@example.com", nil
 			}
@@ -27,7 +28,7 @@ func TestHandleRequestMagicLink(t *testing.T) {
 		},
 	}
 
-	srv, err := New(mockService, &email.NoopSender{}, ":8080")
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
 	if err != nil {
 		t.Fatalf("failed to create server: %v", err)
 	}
@@ -49,6 +50,35 @@ func TestHandleRequestMagicLink(t *testing.T) {
 		}
 	})
 
+	t.Run("link_carries_origin_once", func(t *testing.T) {
+		sender := &recordingSender{}
+		srv, err := New(mockService, sender, nil, ":8080")
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		reqBody, _ := json.Marshal(map[string]string{
+			"email":         "test@example.com",
+			"captcha_token": "valid-captcha",
+			"origin":        "exp://192.168.1.5:8081",
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/auth/request-magic-link", bytes.NewReader(reqBody))
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		if len(sender.sent) != 1 {
+			t.Fatalf("expected one email, got %+v", sender.sent)
+		}
+		body := sender.sent[0].body
+		if !strings.Contains(body, "?origin=exp%3A%2F%2F192.168.1.5%3A8081\n") || strings.Count(body, "origin=") != 1 {
+			t.Errorf("expected one escaped origin in link, got %q", body)
+		}
+	})
+
 	t.Run("user_not_found", func(t *testing.T) {
 		reqBody, _ := json.Marshal(map[string]string{
 			"email":         "nonexistent@example.com",
@@ -73,11 +103,15 @@ func TestHandleRequestMagicLink(t *testing.T) {
 
 		srv.server.Handler.ServeHTTP(w, req)
 
-		if w.Code != http.StatusBadRequest {
-			t.Errorf("expected status 400, got %d", w.Code)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("expected status 422, got %d", w.Code)
 		}
-		if w.Code != http.StatusBadRequest {
-			t.Errorf("expected status 400, got %d", w.Code)
+		var resp errorResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(resp.Error.Fields) != 2 || resp.Error.Fields[0].Field != "email" || resp.Error.Fields[1].Field != "captcha_token" {
+			t.Errorf("expected email and captcha_token to be reported, got %+v", resp.Error.Fields)
 		}
 	})
 
@@ -108,7 +142,7 @@ func TestHandleRequestMagicLink(t *testing.T) {
 	})
 
 	t.Run("rate_limit_exceeded", func(t *testing.T) {
-		mockService.CreateMagicLinkFunc = func(ctx context.Context, email string) (string, string, error) {
+		mockService.CreateMagicLinkFunc = func(ctx context.Context, email, deviceLabel string) (string, string, error) {
 			return "", "", domain.ErrRateLimitExceeded
 		}
 
@@ -139,7 +173,7 @@ func TestHandleConfirmMagicLink(t *testing.T) {
 		},
 	}
 
-	srv, err := New(mockService, &email.NoopSender{}, ":8080")
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
 	if err != nil {
 		t.Fatalf("failed to create server: %v", err)
 	}
@@ -175,11 +209,11 @@ func TestHandleVerifyToken(t *testing.T) {
 			if token == "valid-token" {
 				return &domain.AuthPoster{PosterID: 123}, nil
 			}
-			return nil, fmt.Errorf("invalid token")
+			return nil, domain.ErrInvalidToken
 		},
 	}
 
-	srv, err := New(mockService, &email.NoopSender{}, ":8080")
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
 	if err != nil {
 		t.Fatalf("failed to create server: %v", err)
 	}
@@ -220,6 +254,58 @@ func TestHandleVerifyToken(t *testing.T) {
 	})
 }
 
+func TestHandleRefreshToken(t *testing.T) {
+	mockService := &MockService{
+		RefreshSessionFunc: func(ctx context.Context, refreshToken string) (*domain.TokenPair, error) {
+			switch refreshToken {
+			case "valid":
+				return &domain.TokenPair{APIToken: "new-access", RefreshToken: "new-refresh"}, nil
+			case "rotated":
+				return nil, domain.ErrRefreshTokenReused
+			}
+			return nil, domain.ErrInvalidRefreshToken
+		},
+	}
+
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		w := post(`{"refresh_token":"valid"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+
+		var resp map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp["api_token"] != "new-access" || resp["refresh_token"] != "new-refresh" {
+			t.Errorf("unexpected tokens %v", resp)
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		for _, tok := range []string{"rotated", "unknown"} {
+			if w := post(`{"refresh_token":"` + tok + `"}`); w.Code != http.StatusUnauthorized {
+				t.Errorf("%s: expected status 401, got %d", tok, w.Code)
+			}
+		}
+		if w := post(`{}`); w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("expected status 422, got %d", w.Code)
+		}
+	})
+}
+
 func TestIsPrivateIP(t *testing.T) {
 	tests := []struct {
 		host     string
@@ -245,3 +331,44 @@ func TestIsPrivateIP(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleDeletePoster(t *testing.T) {
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := blobs.Put(context.Background(), "photo.jpg", strings.NewReader("jpeg")); err != nil {
+		t.Fatal(err)
+	}
+
+	var gotDeleteContent bool
+	mockService := &MockService{
+		GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
+			return &domain.AuthPoster{PosterID: 1}, nil
+		},
+		DeletePosterFunc: func(ctx context.Context, posterID int64, deleteContent bool) ([]string, error) {
+			gotDeleteContent = deleteContent
+			return []string{"photo.jpg"}, nil
+		},
+	}
+	srv, err := New(mockService, &email.NoopSender{}, blobs, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/auth/user", strings.NewReader(`{"delete_poster_subresources":true}`))
+	req.Header.Set("Authorization", "Bearer valid_token")
+	w := httptest.NewRecorder()
+
+	srv.server.Handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", w.Code)
+	}
+	if !gotDeleteContent {
+		t.Error("expected content to be deleted")
+	}
+	if _, err := blobs.Get(context.Background(), "photo.jpg"); err == nil {
+		t.Error("expected the deleted account's photo to be removed from the blob store")
+	}
+}
@@ -0,0 +1,49 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+const contextKeyRole contextKey = "role"
+
+func roleFromContext(ctx context.Context) domain.Role {
+	if r, ok := ctx.Value(contextKeyRole).(domain.Role); ok {
+		return r
+	}
+	return ""
+}
+
+// sendForbidden is the 403 for a caller without the role an endpoint needs.
+// The body names the required role so clients can tell a missing privilege
+// apart from other errors.
+func (s *HTTPServer) sendForbidden(w http.ResponseWriter, message string, required domain.Role) {
+	s.writeError(w, http.StatusForbidden, apiError{
+		Code:         "forbidden",
+		Message:      message,
+		RequiredRole: required,
+	})
+}
+
+// requireRole lets a request through only if the caller's role is at least
+// min. It must run inside middlewareAuth.
+func (s *HTTPServer) requireRole(min domain.Role, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !roleFromContext(r.Context()).AtLeast(min) {
+			s.sendForbidden(w, "insufficient role", min)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// canEditBike: the poster who created the bike, or any moderator.
+func canEditBike(ctx context.Context, bike *domain.Bike) bool {
+	if roleFromContext(ctx).AtLeast(domain.RoleModerator) {
+		return true
+	}
+	posterID, ok := posterIDFromContext(ctx)
+	return ok && bike.CreatorID != nil && *bike.CreatorID == posterID
+}
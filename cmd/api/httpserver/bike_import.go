@@ -0,0 +1,348 @@
+package httpserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/scardozos/rottenbikes/cmd/api/validate"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+const (
+	// maxBikeImportBytes and maxBikeImportRows bound an import; a whole
+	// fleet is a few thousand bikes.
+	maxBikeImportBytes = 4 << 20
+	maxBikeImportRows  = 20000
+)
+
+var bikeImportColumns = []string{"numerical_id", "hash_id", "is_electric"}
+
+// bikeImportFormat picks the upload format from ?format= or the
+// Content-Type: "csv" or "ndjson".
+func bikeImportFormat(r *http.Request) string {
+	if f := r.URL.Query().Get("format"); f != "" {
+		return f
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return "csv"
+	case "application/x-ndjson", "application/jsonl":
+		return "ndjson"
+	}
+	return ""
+}
+
+// POST /admin/bikes/import → create many bikes from CSV or NDJSON
+//
+// CSV takes numerical_id,hash_id,is_electric columns, with an optional header
+// row; NDJSON takes one {"numerical_id", "hash_id", "is_electric"} object per
+// line. All bikes are created or none are. ?dry_run=true only checks.
+//
+// Rejected rows are listed in the error's fields as rows[<line>], or
+// rows[<line>].<column> when one column is at fault: with a 400 when they
+// are malformed, and a 409 when they clash with existing bikes.
+func (s *HTTPServer) handleImportBikes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	posterID, ok := posterIDFromContext(r.Context())
+	if !ok {
+		s.sendError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	dryRun := false
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			s.sendError(w, "dry_run must be true or false", http.StatusBadRequest)
+			return
+		}
+		dryRun = b
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxBikeImportBytes)
+	var rows []domain.BikeImportRow
+	var rowErrs []domain.FieldError
+	var err error
+	switch bikeImportFormat(r) {
+	case "csv":
+		rows, rowErrs, err = parseBikeImportCSV(body)
+	case "ndjson":
+		rows, rowErrs, err = parseBikeImportNDJSON(body)
+	default:
+		s.sendError(w, "send text/csv or application/x-ndjson, or set format to csv or ndjson", http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			s.sendError(w, "import is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(rowErrs) > 0 {
+		s.writeError(w, http.StatusBadRequest, apiError{Code: "invalid_bike_import", Message: "some rows are invalid", Fields: rowErrs})
+		return
+	}
+	if len(rows) == 0 {
+		s.sendError(w, "no bikes to import", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	res, err := s.service.ImportBikes(ctx, rows, posterID, dryRun)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	zerolog.Ctx(r.Context()).Info().
+		Int("created", res.Created).
+		Bool("dry_run", res.DryRun).
+		Msg("bikes imported")
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// rowError blames field of the row on line, or the whole row when field is
+// empty.
+func rowError(line int, field, message string) domain.FieldError {
+	return domain.FieldError{Field: domain.BikeImportField(line, field), Message: message}
+}
+
+// parseBikeImportRow applies the POST /bikes checks to one row. An empty
+// hash_id means none.
+func parseBikeImportRow(line int, numericalID, hashID string, isElectric bool) (domain.BikeImportRow, *domain.FieldError) {
+	row := domain.BikeImportRow{Line: line, NumericalID: numericalID, IsElectric: isElectric}
+	if !validNumericalID(numericalID) {
+		fe := rowError(line, "numerical_id", "numerical_id must be 4-5 digits")
+		return row, &fe
+	}
+	if hashID != "" {
+		if !validate.Alphanumeric(hashID) {
+			fe := rowError(line, "hash_id", "hash_id must be alphanumeric")
+			return row, &fe
+		}
+		row.HashID = &hashID
+	}
+	return row, nil
+}
+
+// parseBikeImportCSV reads the CSV body. Malformed rows are collected as row
+// errors; the error return is for a body that can't be read at all.
+func parseBikeImportCSV(body io.Reader) ([]domain.BikeImportRow, []domain.FieldError, error) {
+	cr := csv.NewReader(body)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var rows []domain.BikeImportRow
+	var rowErrs []domain.FieldError
+	first := true
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rowErrs = append(rowErrs, rowError(parseErr.StartLine, "", parseErr.Err.Error()))
+				continue
+			}
+			return nil, nil, err
+		}
+		line, _ := cr.FieldPos(0)
+
+		if first {
+			first = false
+			if strings.EqualFold(strings.TrimSpace(record[0]), bikeImportColumns[0]) {
+				if len(record) != len(bikeImportColumns) || !strings.EqualFold(strings.Join(record, ","), strings.Join(bikeImportColumns, ",")) {
+					return nil, nil, fmt.Errorf("header must be %s", strings.Join(bikeImportColumns, ","))
+				}
+				continue
+			}
+		}
+
+		if len(rows)+len(rowErrs) >= maxBikeImportRows {
+			return nil, nil, fmt.Errorf("at most %d bikes per import", maxBikeImportRows)
+		}
+		if len(record) != len(bikeImportColumns) {
+			rowErrs = append(rowErrs, rowError(line, "", fmt.Sprintf("expected %d columns, got %d", len(bikeImportColumns), len(record))))
+			continue
+		}
+
+		isElectric := false
+		if v := strings.TrimSpace(record[2]); v != "" {
+			if isElectric, err = strconv.ParseBool(v); err != nil {
+				rowErrs = append(rowErrs, rowError(line, "is_electric", "is_electric must be true or false"))
+				continue
+			}
+		}
+		row, fe := parseBikeImportRow(line, strings.TrimSpace(record[0]), strings.TrimSpace(record[1]), isElectric)
+		if fe != nil {
+			rowErrs = append(rowErrs, *fe)
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, rowErrs, nil
+}
+
+type bikeImportLine struct {
+	NumericalID string  `json:"numerical_id"`
+	HashID      *string `json:"hash_id"`
+	IsElectric  bool    `json:"is_electric"`
+}
+
+// parseBikeImportNDJSON reads the NDJSON body, skipping blank lines.
+func parseBikeImportNDJSON(body io.Reader) ([]domain.BikeImportRow, []domain.FieldError, error) {
+	sc := bufio.NewScanner(body)
+	var rows []domain.BikeImportRow
+	var rowErrs []domain.FieldError
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		if len(rows)+len(rowErrs) >= maxBikeImportRows {
+			return nil, nil, fmt.Errorf("at most %d bikes per import", maxBikeImportRows)
+		}
+
+		var l bikeImportLine
+		if err := json.Unmarshal([]byte(text), &l); err != nil {
+			rowErrs = append(rowErrs, rowError(line, "", "invalid JSON"))
+			continue
+		}
+		hashID := ""
+		if l.HashID != nil {
+			hashID = *l.HashID
+		}
+		row, fe := parseBikeImportRow(line, l.NumericalID, hashID, l.IsElectric)
+		if fe != nil {
+			rowErrs = append(rowErrs, *fe)
+			continue
+		}
+		rows = append(rows, row)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, err
+	}
+	return rows, rowErrs, nil
+}
+
+// bikeExportColumns are the CSV columns before the per-subcategory averages.
+var bikeExportColumns = []string{
+	"numerical_id", "hash_id", "is_electric", "review_count", "open_issue_count",
+	"last_station_id", "last_seen_ts", "created_ts", "updated_ts",
+}
+
+// GET /admin/bikes/export?format=csv|ndjson → every bike with its rating
+// averages, streamed as it is read. A failure mid-stream drops the
+// connection, so a cut-short file never looks complete.
+func (s *HTTPServer) handleExportBikes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+
+	var write func(domain.BikeExport) error
+	var flush func() error
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="bikes.csv"`)
+		cw := csv.NewWriter(w)
+		header := append([]string{}, bikeExportColumns...)
+		for _, sub := range domain.RatingSubcategories {
+			header = append(header, "avg_"+string(sub))
+		}
+		_ = cw.Write(header)
+		write = func(b domain.BikeExport) error { return cw.Write(bikeExportRecord(b)) }
+		flush = func() error { cw.Flush(); return cw.Error() }
+	case "ndjson":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="bikes.ndjson"`)
+		enc := json.NewEncoder(w)
+		write = func(b domain.BikeExport) error { return enc.Encode(b) }
+		flush = func() error { return nil }
+	default:
+		s.sendError(w, "format must be csv or ndjson", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
+	defer cancel()
+
+	n := 0
+	err := s.service.ExportBikes(ctx, func(b domain.BikeExport) error {
+		n++
+		return write(b)
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		abortResponse(r, fmt.Errorf("after %d bikes: %w", n, err), "bike export failed")
+	}
+
+	zerolog.Ctx(r.Context()).Info().Int("bikes", n).Msg("bikes exported")
+}
+
+func bikeExportRecord(b domain.BikeExport) []string {
+	optional := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	lastSeen := ""
+	if b.LastSeenAt != nil {
+		lastSeen = b.LastSeenAt.UTC().Format(time.RFC3339)
+	}
+
+	record := []string{
+		b.NumericalID,
+		optional(b.HashID),
+		strconv.FormatBool(b.IsElectric),
+		strconv.FormatInt(b.ReviewCount, 10),
+		strconv.FormatInt(b.OpenIssueCount, 10),
+		optional(b.LastStationID),
+		lastSeen,
+		b.CreatedAt.UTC().Format(time.RFC3339),
+		b.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	for _, sub := range domain.RatingSubcategories {
+		avg := ""
+		if v, ok := b.Ratings[sub]; ok {
+			avg = strconv.FormatFloat(v, 'f', 2, 64)
+		}
+		record = append(record, avg)
+	}
+	return record
+}
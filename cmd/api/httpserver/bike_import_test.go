@@ -0,0 +1,284 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scardozos/rottenbikes/cmd/api/email"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+func adminMockService() *MockService {
+	return &MockService{
+		GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
+			if token == "admin_token" {
+				return &domain.AuthPoster{PosterID: 1, Role: domain.RoleAdmin}, nil
+			}
+			return &domain.AuthPoster{PosterID: 2, Role: domain.RoleUser}, nil
+		},
+	}
+}
+
+func TestHandleImportBikes(t *testing.T) {
+	mockService := adminMockService()
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	importAs := func(token, url, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, url, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	var got []domain.BikeImportRow
+	var gotDryRun bool
+	mockService.ImportBikesFunc = func(ctx context.Context, rows []domain.BikeImportRow, creatorID int64, dryRun bool) (*domain.BikeImportResult, error) {
+		got, gotDryRun = rows, dryRun
+		return &domain.BikeImportResult{Created: len(rows), DryRun: dryRun}, nil
+	}
+
+	t.Run("csv_with_header", func(t *testing.T) {
+		w := importAs("admin_token", "/admin/bikes/import", "text/csv",
+			"numerical_id,hash_id,is_electric\n01234,abc123,true\n5678,,false\n")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if len(got) != 2 || got[0].NumericalID != "01234" || got[0].HashID == nil || *got[0].HashID != "abc123" ||
+			!got[0].IsElectric || got[0].Line != 2 || got[1].HashID != nil || got[1].IsElectric || gotDryRun {
+			t.Errorf("unexpected rows %+v", got)
+		}
+	})
+
+	t.Run("ndjson_dry_run", func(t *testing.T) {
+		w := importAs("admin_token", "/admin/bikes/import?dry_run=true", "application/x-ndjson",
+			`{"numerical_id":"01234","hash_id":"abc123","is_electric":true}`+"\n\n"+`{"numerical_id":"5678"}`+"\n")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if len(got) != 2 || got[1].Line != 3 || !gotDryRun {
+			t.Errorf("unexpected rows %+v (dry run %v)", got, gotDryRun)
+		}
+		var res domain.BikeImportResult
+		_ = json.NewDecoder(w.Body).Decode(&res)
+		if !res.DryRun || res.Created != 2 {
+			t.Errorf("unexpected result %+v", res)
+		}
+	})
+
+	t.Run("invalid_rows_reported_by_line", func(t *testing.T) {
+		got = nil
+		w := importAs("admin_token", "/admin/bikes/import", "text/csv",
+			"0123,,false\n01234,not-alnum,false\n99999,abc,maybe\n4567\n")
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got %d", w.Code)
+		}
+		if got != nil {
+			t.Error("expected nothing to be imported")
+		}
+		var res errorResponse
+		_ = json.NewDecoder(w.Body).Decode(&res)
+		if res.Error.Code != "invalid_bike_import" {
+			t.Errorf("unexpected error code %q", res.Error.Code)
+		}
+		want := []domain.FieldError{
+			{Field: "rows[2].hash_id", Message: "hash_id must be alphanumeric"},
+			{Field: "rows[3].is_electric", Message: "is_electric must be true or false"},
+			{Field: "rows[4]", Message: "expected 3 columns, got 1"},
+		}
+		if !slices.Equal(res.Error.Fields, want) {
+			t.Errorf("expected fields %+v, got %+v", want, res.Error.Fields)
+		}
+	})
+
+	t.Run("short_numerical_id", func(t *testing.T) {
+		w := importAs("admin_token", "/admin/bikes/import", "application/x-ndjson", `{"numerical_id":"123"}`)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"rows[1].numerical_id"`) {
+			t.Errorf("expected status 400 with a numerical_id error, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		mockService.ImportBikesFunc = func(ctx context.Context, rows []domain.BikeImportRow, creatorID int64, dryRun bool) (*domain.BikeImportResult, error) {
+			conflict := *domain.ErrBikeImportConflict
+			conflict.Fields = []domain.FieldError{{Field: "rows[1].numerical_id", Message: "bike with this numerical_id already exists"}}
+			return nil, &conflict
+		}
+		w := importAs("admin_token", "/admin/bikes/import?format=csv", "text/plain", "01234,,false\n")
+		if w.Code != http.StatusConflict {
+			t.Fatalf("expected status 409, got %d", w.Code)
+		}
+		var res errorResponse
+		_ = json.NewDecoder(w.Body).Decode(&res)
+		if res.Error.Code != "bike_import_conflict" || len(res.Error.Fields) != 1 || res.Error.Fields[0].Field != "rows[1].numerical_id" {
+			t.Errorf("unexpected error %+v", res.Error)
+		}
+	})
+
+	t.Run("unsupported_format", func(t *testing.T) {
+		if w := importAs("admin_token", "/admin/bikes/import", "application/xml", "<bikes/>"); w.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("expected status 415, got %d", w.Code)
+		}
+	})
+
+	t.Run("forbidden_for_users", func(t *testing.T) {
+		if w := importAs("user_token", "/admin/bikes/import", "text/csv", "01234,,false\n"); w.Code != http.StatusForbidden {
+			t.Errorf("expected status 403, got %d", w.Code)
+		}
+	})
+}
+
+func TestHandleExportBikes(t *testing.T) {
+	mockService := adminMockService()
+	avg := 4.5
+	created := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	mockService.ExportBikesFunc = func(ctx context.Context, fn func(domain.BikeExport) error) error {
+		hash := "abc123"
+		for _, b := range []domain.BikeExport{
+			{Bike: domain.Bike{NumericalID: "01234", HashID: &hash, IsElectric: true, AverageRating: &avg, ReviewCount: 2, CreatedAt: created, UpdatedAt: created},
+				Ratings: map[domain.RatingSubcategory]float64{domain.RatingSubcategoryOverall: 4.5, domain.RatingSubcategorySeat: 3}},
+			{Bike: domain.Bike{NumericalID: "5678", CreatedAt: created, UpdatedAt: created}, Ratings: map[domain.RatingSubcategory]float64{}},
+		} {
+			if err := fn(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	export := func(url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Authorization", "Bearer admin_token")
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		w := export("/admin/bikes/export")
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/csv" {
+			t.Fatalf("expected a 200 CSV response, got %d %s", w.Code, w.Header().Get("Content-Type"))
+		}
+		records, err := csv.NewReader(w.Body).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 3 {
+			t.Fatalf("expected a header and 2 bikes, got %d records", len(records))
+		}
+		want := "numerical_id,hash_id,is_electric,review_count,open_issue_count,last_station_id,last_seen_ts,created_ts,updated_ts,avg_overall,avg_breaks,avg_seat,avg_sturdiness,avg_power,avg_pedals"
+		if got := strings.Join(records[0], ","); got != want {
+			t.Errorf("unexpected header %s", got)
+		}
+		want = "01234,abc123,true,2,0,,,2025-03-01T12:00:00Z,2025-03-01T12:00:00Z,4.50,,3.00,,,"
+		if got := strings.Join(records[1], ","); got != want {
+			t.Errorf("unexpected row %s", got)
+		}
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		w := export("/admin/bikes/export?format=ndjson")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("expected 2 lines, got %d", len(lines))
+		}
+		var b domain.BikeExport
+		if err := json.Unmarshal([]byte(lines[0]), &b); err != nil {
+			t.Fatal(err)
+		}
+		if b.NumericalID != "01234" || b.Ratings[domain.RatingSubcategorySeat] != 3 {
+			t.Errorf("unexpected bike %+v", b)
+		}
+	})
+
+	t.Run("bad_format", func(t *testing.T) {
+		if w := export("/admin/bikes/export?format=xml"); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", w.Code)
+		}
+	})
+}
+
+func TestHandleExportBikesStreaming(t *testing.T) {
+	t.Parallel()
+
+	mockService := adminMockService()
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	ts := httptest.NewServer(srv.server.Handler)
+	defer ts.Close()
+
+	export := func() (*http.Response, error) {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/admin/bikes/export?format=ndjson", nil)
+		req.Header.Set("Authorization", "Bearer admin_token")
+		return ts.Client().Do(req)
+	}
+	bike := domain.BikeExport{Bike: domain.Bike{NumericalID: "1234"}}
+
+	t.Run("outlives_auth_timeout", func(t *testing.T) {
+		mockService.ExportBikesFunc = func(ctx context.Context, fn func(domain.BikeExport) error) error {
+			if err := fn(bike); err != nil {
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(authLookupTimeout + 500*time.Millisecond):
+			}
+			return fn(bike)
+		}
+
+		resp, err := export()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("expected the full export, got %v", err)
+		}
+		if n := strings.Count(string(body), "\n"); n != 2 {
+			t.Errorf("expected 2 bikes, got %d", n)
+		}
+	})
+
+	t.Run("failure_aborts_the_stream", func(t *testing.T) {
+		mockService.ExportBikesFunc = func(ctx context.Context, fn func(domain.BikeExport) error) error {
+			if err := fn(bike); err != nil {
+				return err
+			}
+			return context.DeadlineExceeded
+		}
+
+		resp, err := export()
+		if err != nil {
+			return // dropped before the headers arrived
+		}
+		defer resp.Body.Close()
+		if _, err := io.ReadAll(resp.Body); err == nil {
+			t.Error("expected reading a failed export to fail")
+		}
+	})
+}
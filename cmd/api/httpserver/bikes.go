@@ -2,31 +2,47 @@ package httpserver
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
-	"github.com/lib/pq"
 	"github.com/rs/zerolog"
+
+	"github.com/scardozos/rottenbikes/cmd/api/validate"
 	"github.com/scardozos/rottenbikes/internal/domain"
 )
 
-// GET /bikes → list (now includes average_rating)
+type listBikesResponse struct {
+	Bikes      []domain.Bike `json:"bikes"`
+	NextCursor *string       `json:"next_cursor"`
+}
+
+// GET /bikes → paginated list (includes average_rating and review_count)
+//
+// Query parameters: cursor, limit, hash_id, is_electric, min_rating, created_after
+// (RFC 3339), sort (numerical_id, average_rating, created_ts, review_count)
+// and order (asc, desc). numerical_id sorts ascending by default, everything
+// else descending.
 func (s *HTTPServer) handleListBikes(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	q, err := parseListBikesQuery(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
 	defer cancel()
 
-	bikes, err := s.service.ListBikes(ctx)
+	bikes, cursor, err := s.service.ListBikes(ctx, q)
 	if err != nil {
-		zerolog.Ctx(r.Context()).Error().Err(err).Msg("list bikes error")
-		s.sendError(w, "internal server error", http.StatusInternalServerError)
+		s.sendDomainError(w, r, err)
 		return
 	}
 
@@ -35,17 +51,96 @@ func (s *HTTPServer) handleListBikes(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(bikes); err != nil {
+	if err := json.NewEncoder(w).Encode(listBikesResponse{
+		Bikes:      bikes,
+		NextCursor: nextCursor(cursor),
+	}); err != nil {
 		zerolog.Ctx(r.Context()).Error().Err(err).Msg("encode bikes error")
 	}
 }
 
+func parseListBikesQuery(r *http.Request) (domain.ListBikesQuery, error) {
+	var q domain.ListBikesQuery
+	var err error
+
+	q.Cursor, q.Limit, err = parsePageParams(r)
+	if err != nil {
+		return q, err
+	}
+
+	params := r.URL.Query()
+
+	if v := params.Get("hash_id"); v != "" {
+		q.HashID = &v
+	}
+
+	if v := params.Get("is_electric"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return q, errors.New("is_electric must be true or false")
+		}
+		q.IsElectric = &b
+	}
+
+	if v := params.Get("min_rating"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 1 || f > 5 {
+			return q, errors.New("min_rating must be a number between 1 and 5")
+		}
+		q.MinRating = &f
+	}
+
+	if v := params.Get("created_after"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return q, errors.New("created_after must be an RFC 3339 timestamp")
+		}
+		q.CreatedAfter = &t
+	}
+
+	if v := params.Get("seen_after"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return q, errors.New("seen_after must be an RFC 3339 timestamp")
+		}
+		q.SeenAfter = &t
+	}
+
+	q.Sort = domain.BikeSortNumericalID
+	if v := params.Get("sort"); v != "" {
+		q.Sort = domain.BikeSort(v)
+		if !domain.ValidBikeSort(q.Sort) {
+			return q, errors.New("sort must be one of numerical_id, average_rating, created_ts, review_count, last_seen_ts")
+		}
+	}
+
+	switch params.Get("order") {
+	case "":
+		q.Descending = q.Sort != domain.BikeSortNumericalID
+	case "asc":
+		q.Descending = false
+	case "desc":
+		q.Descending = true
+	default:
+		return q, errors.New("order must be asc or desc")
+	}
+
+	return q, nil
+}
+
 type createBikeRequest struct {
 	NumericalID string  `json:"numerical_id"`
 	HashID      *string `json:"hash_id"`
 	IsElectric  bool    `json:"is_electric"`
 }
 
+func (req createBikeRequest) validate() error {
+	var v validate.Validator
+	v.Check(validNumericalID(req.NumericalID), "numerical_id", "must be 4-5 digits")
+	v.Check(req.HashID == nil || *req.HashID == "" || validate.Alphanumeric(*req.HashID), "hash_id", "must be alphanumeric")
+	return v.Err()
+}
+
 // POST /bikes → create a bike
 func (s *HTTPServer) handleCreateBike(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -65,23 +160,8 @@ func (s *HTTPServer) handleCreateBike(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate numerical_id consistency (4-5 digits)
-	// We don't parse to int64 anymore to preserve leading zeros, but strictly check format.
-	// Allow 0-9, length 4-5.
-	validID := true
-	if len(req.NumericalID) < 4 || len(req.NumericalID) > 5 {
-		validID = false
-	} else {
-		for _, r := range req.NumericalID {
-			if r < '0' || r > '9' {
-				validID = false
-				break
-			}
-		}
-	}
-
-	if !validID {
-		s.sendError(w, "numerical_id must be 4-5 digits", http.StatusBadRequest)
+	if err := req.validate(); err != nil {
+		s.sendDomainError(w, r, err)
 		return
 	}
 
@@ -92,34 +172,12 @@ func (s *HTTPServer) handleCreateBike(w http.ResponseWriter, r *http.Request) {
 		req.HashID = nil
 	}
 
-	if req.HashID != nil && !isAlphanumeric(*req.HashID) {
-		s.sendError(w, "hash_id must be alphanumeric", http.StatusBadRequest)
-		return
-	}
-
 	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
 	defer cancel()
 
 	bike, err := s.service.CreateBike(ctx, numericalID, req.HashID, req.IsElectric, creatorID)
 	if err != nil {
-		var pqErr *pq.Error
-		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
-			// Distinguish by constraint/index name
-			switch pqErr.Constraint {
-			case "bikes_pkey":
-				s.sendError(w, "bike with this numerical_id already exists", http.StatusConflict)
-				return
-			case "bikes_hash_id_key":
-				s.sendError(w, "bike with this hash_id already exists", http.StatusConflict)
-				return
-			default:
-				s.sendError(w, "bike already exists (duplicate key)", http.StatusConflict)
-				return
-			}
-		}
-
-		zerolog.Ctx(r.Context()).Error().Err(err).Msg("create bike error")
-		s.sendError(w, "internal server error", http.StatusInternalServerError)
+		s.sendDomainError(w, r, err)
 		return
 	}
 
@@ -134,6 +192,13 @@ type updateBikeRequest struct {
 	IsElectric  *bool   `json:"is_electric"`
 }
 
+func (req updateBikeRequest) validate() error {
+	var v validate.Validator
+	v.Check(req.NumericalID == nil, "numerical_id", "cannot be updated")
+	v.Check(req.HashID == nil || *req.HashID == "" || validate.Alphanumeric(*req.HashID), "hash_id", "must be alphanumeric")
+	return v.Err()
+}
+
 // PUT /bikes/{id} → update hash_id/is_electric
 func (s *HTTPServer) handleUpdateBike(w http.ResponseWriter, r *http.Request, bikeID string) {
 	if r.Method != http.MethodPut {
@@ -141,7 +206,7 @@ func (s *HTTPServer) handleUpdateBike(w http.ResponseWriter, r *http.Request, bi
 		return
 	}
 
-	if !isNumeric(bikeID) {
+	if !validate.Numeric(bikeID) {
 		s.sendError(w, "invalid bike id", http.StatusBadRequest)
 		return
 	}
@@ -152,22 +217,28 @@ func (s *HTTPServer) handleUpdateBike(w http.ResponseWriter, r *http.Request, bi
 		return
 	}
 
-	if req.NumericalID != nil {
-		s.sendError(w, "numerical_id cannot be updated", http.StatusBadRequest)
+	if err := req.validate(); err != nil {
+		s.sendDomainError(w, r, err)
 		return
 	}
 
-	if req.HashID != nil && *req.HashID != "" && !isAlphanumeric(*req.HashID) {
-		s.sendError(w, "hash_id must be alphanumeric", http.StatusBadRequest)
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	bike, err := s.service.GetBike(ctx, bikeID)
+	if err != nil {
+		s.sendDomainError(w, r, err)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
-	defer cancel()
+	if !canEditBike(ctx, bike) {
+		s.sendForbidden(w, "only the bike's creator or a moderator can edit it", domain.RoleModerator)
+		return
+	}
 
-	if err := s.service.UpdateBike(ctx, bikeID, req.HashID, req.IsElectric); err != nil {
-		zerolog.Ctx(r.Context()).Error().Err(err).Str("bike_id", bikeID).Msg("update bike error")
-		s.sendError(w, "internal server error", http.StatusInternalServerError)
+	posterID, _ := posterIDFromContext(ctx)
+	if err := s.service.UpdateBike(ctx, bikeID, req.HashID, req.IsElectric, posterID); err != nil {
+		s.sendDomainError(w, r, err)
 		return
 	}
 
@@ -181,7 +252,7 @@ func (s *HTTPServer) handleGetBike(w http.ResponseWriter, r *http.Request, bikeI
 		return
 	}
 
-	if !isNumeric(bikeID) {
+	if !validate.Numeric(bikeID) {
 		s.sendError(w, "invalid bike id", http.StatusBadRequest)
 		return
 	}
@@ -191,12 +262,7 @@ func (s *HTTPServer) handleGetBike(w http.ResponseWriter, r *http.Request, bikeI
 
 	bike, err := s.service.GetBike(ctx, bikeID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			s.sendError(w, "bike not found", http.StatusNotFound)
-			return
-		}
-		zerolog.Ctx(r.Context()).Error().Err(err).Str("bike_id", bikeID).Msg("get bike error")
-		s.sendError(w, "internal server error", http.StatusInternalServerError)
+		s.sendDomainError(w, r, err)
 		return
 	}
 
@@ -211,17 +277,22 @@ func (s *HTTPServer) handleDeleteBike(w http.ResponseWriter, r *http.Request, bi
 		return
 	}
 
-	if !isNumeric(bikeID) {
+	if !validate.Numeric(bikeID) {
 		s.sendError(w, "invalid bike id", http.StatusBadRequest)
 		return
 	}
 
+	posterID, ok := posterIDFromContext(r.Context())
+	if !ok {
+		s.sendError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
 	defer cancel()
 
-	if err := s.service.DeleteBike(ctx, bikeID); err != nil {
-		zerolog.Ctx(r.Context()).Error().Err(err).Str("bike_id", bikeID).Msg("delete bike error")
-		s.sendError(w, "internal server error", http.StatusInternalServerError)
+	if err := s.service.DeleteBike(ctx, bikeID, posterID); err != nil {
+		s.sendDomainError(w, r, err)
 		return
 	}
 
@@ -235,7 +306,7 @@ func (s *HTTPServer) handleGetBikeDetails(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if !isNumeric(bikeID) {
+	if !validate.Numeric(bikeID) {
 		s.sendError(w, "invalid bike id", http.StatusBadRequest)
 		return
 	}
@@ -245,12 +316,7 @@ func (s *HTTPServer) handleGetBikeDetails(w http.ResponseWriter, r *http.Request
 
 	details, err := s.service.GetBikeDetails(ctx, bikeID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			s.sendError(w, "bike not found", http.StatusNotFound)
-			return
-		}
-		zerolog.Ctx(r.Context()).Error().Err(err).Str("bike_id", bikeID).Msg("get bike details error")
-		s.sendError(w, "internal server error", http.StatusInternalServerError)
+		s.sendDomainError(w, r, err)
 		return
 	}
 
@@ -258,20 +324,8 @@ func (s *HTTPServer) handleGetBikeDetails(w http.ResponseWriter, r *http.Request
 	_ = json.NewEncoder(w).Encode(details)
 }
 
-func isAlphanumeric(s string) bool {
-	for _, r := range s {
-		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
-			return false
-		}
-	}
-	return true
-}
-
-func isNumeric(s string) bool {
-	for _, r := range s {
-		if r < '0' || r > '9' {
-			return false
-		}
-	}
-	return true
+// validNumericalID checks a new bike's numerical_id: 4-5 digits. It stays a
+// string rather than an int64 to preserve leading zeros.
+func validNumericalID(id string) bool {
+	return validate.Digits(id, 4, 5)
 }
@@ -8,10 +8,10 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 	"time"
 
-	"github.com/lib/pq"
 	"github.com/scardozos/rottenbikes/cmd/api/email"
 	"github.com/scardozos/rottenbikes/internal/domain"
 )
@@ -21,19 +21,21 @@ func strPtr(s string) *string {
 }
 
 func TestHandleListBikes(t *testing.T) {
+	var gotQuery domain.ListBikesQuery
 	mockService := &MockService{
-		ListBikesFunc: func(ctx context.Context) ([]domain.Bike, error) {
+		ListBikesFunc: func(ctx context.Context, q domain.ListBikesQuery) ([]domain.Bike, string, error) {
+			gotQuery = q
 			return []domain.Bike{
 				{NumericalID: "1", HashID: strPtr("hash1"), IsElectric: true},
 				{NumericalID: "2", HashID: strPtr("hash2"), IsElectric: false},
-			}, nil
+			}, "next-page", nil
 		},
 		GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
 			return &domain.AuthPoster{PosterID: 1}, nil
 		},
 	}
 
-	srv, err := New(mockService, &email.NoopSender{}, ":8080")
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
 	if err != nil {
 		t.Fatalf("failed to create server: %v", err)
 	}
@@ -48,11 +50,82 @@ func TestHandleListBikes(t *testing.T) {
 		if w.Code != http.StatusOK {
 			t.Errorf("expected status 200, got %d", w.Code)
 		}
+
+		var resp listBikesResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(resp.Bikes) != 2 {
+			t.Errorf("expected 2 bikes, got %d", len(resp.Bikes))
+		}
+		if resp.NextCursor == nil || *resp.NextCursor != "next-page" {
+			t.Errorf("expected next_cursor next-page, got %v", resp.NextCursor)
+		}
+		if gotQuery.Sort != domain.BikeSortNumericalID || gotQuery.Descending {
+			t.Errorf("expected default numerical_id ascending, got %+v", gotQuery)
+		}
+	})
+
+	t.Run("query_params", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/bikes?cursor=abc&limit=10&hash_id=hash1&is_electric=true&min_rating=3.5&created_after=2024-01-01T00:00:00Z&sort=average_rating", nil)
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		if gotQuery.Cursor != "abc" || gotQuery.Limit != 10 {
+			t.Errorf("unexpected pagination %+v", gotQuery)
+		}
+		if gotQuery.HashID == nil || *gotQuery.HashID != "hash1" {
+			t.Errorf("expected hash_id filter")
+		}
+		if gotQuery.IsElectric == nil || !*gotQuery.IsElectric {
+			t.Errorf("expected is_electric filter")
+		}
+		if gotQuery.MinRating == nil || *gotQuery.MinRating != 3.5 {
+			t.Errorf("expected min_rating 3.5")
+		}
+		if gotQuery.CreatedAfter == nil || !gotQuery.CreatedAfter.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("expected created_after filter")
+		}
+		if gotQuery.Sort != domain.BikeSortAverageRating || !gotQuery.Descending {
+			t.Errorf("expected average_rating descending, got %+v", gotQuery)
+		}
+	})
+
+	t.Run("bad_request_params", func(t *testing.T) {
+		for _, qs := range []string{"limit=0", "limit=abc", "is_electric=maybe", "min_rating=9", "created_after=yesterday", "sort=color", "order=up"} {
+			req := httptest.NewRequest(http.MethodGet, "/bikes?"+qs, nil)
+			w := httptest.NewRecorder()
+
+			srv.server.Handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", qs, w.Code)
+			}
+		}
+	})
+
+	t.Run("invalid_cursor", func(t *testing.T) {
+		mockService.ListBikesFunc = func(ctx context.Context, q domain.ListBikesQuery) ([]domain.Bike, string, error) {
+			return nil, "", domain.ErrInvalidCursor
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/bikes?cursor=garbage", nil)
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", w.Code)
+		}
 	})
 
 	t.Run("empty_list", func(t *testing.T) {
-		mockService.ListBikesFunc = func(ctx context.Context) ([]domain.Bike, error) {
-			return nil, nil // Simulate empty DB returning nil
+		mockService.ListBikesFunc = func(ctx context.Context, q domain.ListBikesQuery) ([]domain.Bike, string, error) {
+			return nil, "", nil // Simulate empty DB returning nil
 		}
 
 		req := httptest.NewRequest(http.MethodGet, "/bikes", nil)
@@ -65,9 +138,9 @@ func TestHandleListBikes(t *testing.T) {
 			t.Errorf("expected status 200, got %d", w.Code)
 		}
 
-		// Verify response body is []
-		if w.Body.String() != "[]\n" {
-			t.Errorf("expected body [], got %q", w.Body.String())
+		// Verify bikes is [] and there is no next page
+		if w.Body.String() != "{\"bikes\":[],\"next_cursor\":null}\n" {
+			t.Errorf("expected empty page, got %q", w.Body.String())
 		}
 	})
 }
@@ -91,7 +164,7 @@ func TestHandleCreateBike(t *testing.T) {
 		},
 	}
 
-	srv, err := New(mockService, &email.NoopSender{}, ":8080")
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
 	if err != nil {
 		t.Fatalf("failed to create server: %v", err)
 	}
@@ -144,14 +217,14 @@ func TestHandleCreateBike(t *testing.T) {
 
 		srv.server.Handler.ServeHTTP(w, req)
 
-		if w.Code != http.StatusBadRequest {
-			t.Errorf("expected status 400, got %d", w.Code)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("expected status 422, got %d", w.Code)
 		}
 	})
 
 	t.Run("conflict_numerical_id", func(t *testing.T) {
 		mockService.CreateBikeFunc = func(ctx context.Context, numericalID string, hashID *string, isElectric bool, creatorID int64) (*domain.Bike, error) {
-			return nil, &pq.Error{Code: "23505", Constraint: "bikes_pkey"}
+			return nil, domain.ErrBikeExists
 		}
 
 		token := "valid_token"
@@ -201,8 +274,8 @@ func TestHandleCreateBike(t *testing.T) {
 
 		srv.server.Handler.ServeHTTP(w, req)
 
-		if w.Code != http.StatusBadRequest {
-			t.Errorf("expected status 400, got %d", w.Code)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("expected status 422, got %d", w.Code)
 		}
 
 		// ID len > 6
@@ -213,8 +286,8 @@ func TestHandleCreateBike(t *testing.T) {
 		req2.Header.Set("Authorization", "Bearer "+token)
 		w2 := httptest.NewRecorder()
 		srv.server.Handler.ServeHTTP(w2, req2)
-		if w2.Code != http.StatusBadRequest {
-			t.Errorf("expected status 400, got %d", w2.Code)
+		if w2.Code != http.StatusUnprocessableEntity {
+			t.Errorf("expected status 422, got %d", w2.Code)
 		}
 	})
 
@@ -231,8 +304,35 @@ func TestHandleCreateBike(t *testing.T) {
 
 		srv.server.Handler.ServeHTTP(w, req)
 
-		if w.Code != http.StatusBadRequest {
-			t.Errorf("expected status 400, got %d", w.Code)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("expected status 422, got %d", w.Code)
+		}
+	})
+
+	t.Run("reports_every_invalid_field", func(t *testing.T) {
+		reqBody, _ := json.Marshal(map[string]interface{}{
+			"numerical_id": "12",
+			"hash_id":      "hash!",
+		})
+		req := httptest.NewRequest(http.MethodPost, "/bikes", bytes.NewReader(reqBody))
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("expected status 422, got %d", w.Code)
+		}
+		var resp errorResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		want := []domain.FieldError{
+			{Field: "numerical_id", Message: "must be 4-5 digits"},
+			{Field: "hash_id", Message: "must be alphanumeric"},
+		}
+		if resp.Error.Code != "validation_failed" || !reflect.DeepEqual(resp.Error.Fields, want) {
+			t.Errorf("unexpected error %+v", resp.Error)
 		}
 	})
 }
@@ -250,7 +350,7 @@ func TestHandleGetBike(t *testing.T) {
 		},
 	}
 
-	srv, err := New(mockService, &email.NoopSender{}, ":8080")
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
 	if err != nil {
 		t.Fatalf("failed to create server: %v", err)
 	}
@@ -269,16 +369,26 @@ func TestHandleGetBike(t *testing.T) {
 }
 
 func TestHandleUpdateBike(t *testing.T) {
+	creatorID := int64(1)
 	mockService := &MockService{
 		GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
-			return &domain.AuthPoster{PosterID: 1}, nil
+			switch token {
+			case "other_token":
+				return &domain.AuthPoster{PosterID: 2, Role: domain.RoleUser}, nil
+			case "moderator_token":
+				return &domain.AuthPoster{PosterID: 3, Role: domain.RoleModerator}, nil
+			}
+			return &domain.AuthPoster{PosterID: 1, Role: domain.RoleUser}, nil
 		},
-		UpdateBikeFunc: func(ctx context.Context, id string, hashID *string, isElectric *bool) error {
+		GetBikeFunc: func(ctx context.Context, id string) (*domain.Bike, error) {
+			return &domain.Bike{NumericalID: id, CreatorID: &creatorID}, nil
+		},
+		UpdateBikeFunc: func(ctx context.Context, id string, hashID *string, isElectric *bool, actorID int64) error {
 			return nil
 		},
 	}
 
-	srv, err := New(mockService, &email.NoopSender{}, ":8080")
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
 	if err != nil {
 		t.Fatalf("failed to create server: %v", err)
 	}
@@ -313,8 +423,8 @@ func TestHandleUpdateBike(t *testing.T) {
 
 		srv.server.Handler.ServeHTTP(w, req)
 
-		if w.Code != http.StatusBadRequest {
-			t.Errorf("expected status 400, got %d", w.Code)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("expected status 422, got %d", w.Code)
 		}
 	})
 
@@ -330,30 +440,81 @@ func TestHandleUpdateBike(t *testing.T) {
 
 		srv.server.Handler.ServeHTTP(w, req)
 
-		if w.Code != http.StatusBadRequest {
-			t.Errorf("expected status 400, got %d", w.Code)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("expected status 422, got %d", w.Code)
+		}
+	})
+
+	t.Run("forbidden_for_other_poster", func(t *testing.T) {
+		reqBody, _ := json.Marshal(map[string]interface{}{"hash_id": "newhash"})
+
+		req := httptest.NewRequest(http.MethodPut, "/bikes/1", bytes.NewReader(reqBody))
+		req.Header.Set("Authorization", "Bearer other_token")
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("expected status 403, got %d", w.Code)
+		}
+
+		var resp errorResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp.Error.Code != "forbidden" || resp.Error.RequiredRole != domain.RoleModerator {
+			t.Errorf("unexpected body %+v", resp)
+		}
+	})
+
+	t.Run("moderator_can_edit", func(t *testing.T) {
+		reqBody, _ := json.Marshal(map[string]interface{}{"hash_id": "newhash"})
+
+		req := httptest.NewRequest(http.MethodPut, "/bikes/1", bytes.NewReader(reqBody))
+		req.Header.Set("Authorization", "Bearer moderator_token")
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNoContent {
+			t.Errorf("expected status 204, got %d", w.Code)
 		}
 	})
 }
 
 func TestHandleDeleteBike(t *testing.T) {
 	mockService := &MockService{
-		DeleteBikeFunc: func(ctx context.Context, id string) error {
+		DeleteBikeFunc: func(ctx context.Context, id string, actorID int64) error {
 			if id == "1" {
 				return nil
 			}
 			return errors.New("delete error")
 		},
 		GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
-			return &domain.AuthPoster{PosterID: 1}, nil
+			if token == "moderator_token" {
+				return &domain.AuthPoster{PosterID: 2, Role: domain.RoleModerator}, nil
+			}
+			return &domain.AuthPoster{PosterID: 1, Role: domain.RoleAdmin}, nil
 		},
 	}
 
-	srv, err := New(mockService, &email.NoopSender{}, ":8080")
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
 	if err != nil {
 		t.Fatalf("failed to create server: %v", err)
 	}
 
+	t.Run("forbidden_below_admin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/bikes/1", nil)
+		req.Header.Set("Authorization", "Bearer moderator_token")
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected status 403, got %d", w.Code)
+		}
+	})
+
 	t.Run("success", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodDelete, "/bikes/1", nil)
 		req.Header.Set("Authorization", "Bearer valid_token")
@@ -396,7 +557,7 @@ func TestHandleGetBikeDetails(t *testing.T) {
 		},
 	}
 
-	srv, err := New(mockService, &email.NoopSender{}, ":8080")
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
 	if err != nil {
 		t.Fatalf("failed to create server: %v", err)
 	}
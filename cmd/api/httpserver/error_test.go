@@ -4,9 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/scardozos/rottenbikes/cmd/api/email"
 	"github.com/scardozos/rottenbikes/internal/domain"
@@ -29,29 +33,124 @@ func TestSendError(t *testing.T) {
 		t.Errorf("expected Content-Type application/json, got %s", contentType)
 	}
 
-	var resp map[string]string
+	var resp errorResponse
 	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
 		t.Fatalf("failed to decode response: %v", err)
 	}
 
-	if resp["error"] != msg {
-		t.Errorf("expected error message %q, got %q", msg, resp["error"])
+	if resp.Error.Message != msg {
+		t.Errorf("expected error message %q, got %q", msg, resp.Error.Message)
+	}
+	if resp.Error.Code != "im_a_teapot" {
+		t.Errorf("expected code im_a_teapot, got %q", resp.Error.Code)
+	}
+}
+
+func TestSendDomainError(t *testing.T) {
+	srv := &HTTPServer{}
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+		expectedFields []domain.FieldError
+		retryAfter     string
+	}{
+		{
+			name:           "not_found",
+			err:            domain.ErrBikeNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "bike_not_found",
+		},
+		{
+			name:           "wrapped_conflict",
+			err:            fmt.Errorf("create bike: %w", domain.ErrHashIDExists),
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "hash_id_exists",
+		},
+		{
+			name:           "validation_with_fields",
+			err:            domain.ErrInvalidEmail.At("email"),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "invalid_email",
+			expectedFields: []domain.FieldError{{Field: "email", Message: "invalid email format"}},
+		},
+		{
+			name:           "invalid_cursor",
+			err:            domain.ErrInvalidCursor,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_cursor",
+		},
+		{
+			name:           "rate_limited",
+			err:            domain.ErrTooFrequentReview.After(90*time.Second + time.Millisecond),
+			expectedStatus: http.StatusTooManyRequests,
+			expectedCode:   "review_too_frequent",
+			retryAfter:     "91",
+		},
+		{
+			name:           "unauthorized",
+			err:            domain.ErrRefreshTokenReused,
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   "refresh_token_reused",
+		},
+		{
+			name:           "bare_no_rows",
+			err:            sql.ErrNoRows,
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "not_found",
+		},
+		{
+			name:           "unknown",
+			err:            errors.New("connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   "internal_error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			srv.sendDomainError(w, httptest.NewRequest(http.MethodGet, "/", nil), tt.err)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if got := w.Header().Get("Retry-After"); got != tt.retryAfter {
+				t.Errorf("expected Retry-After %q, got %q", tt.retryAfter, got)
+			}
+
+			var resp errorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Error.Code != tt.expectedCode {
+				t.Errorf("expected code %q, got %q", tt.expectedCode, resp.Error.Code)
+			}
+			if !reflect.DeepEqual(resp.Error.Fields, tt.expectedFields) {
+				t.Errorf("expected fields %v, got %v", tt.expectedFields, resp.Error.Fields)
+			}
+			if tt.expectedStatus == http.StatusInternalServerError && resp.Error.Message != "internal server error" {
+				t.Errorf("internal error leaked: %q", resp.Error.Message)
+			}
+		})
 	}
 }
 
 func TestErrorSchemas(t *testing.T) {
 	mockService := &MockService{
 		GetBikeFunc: func(ctx context.Context, id string) (*domain.Bike, error) {
-			return nil, sql.ErrNoRows
+			return nil, domain.ErrBikeNotFound
 		},
-		GetReviewWithRatingsByIDFunc: func(ctx context.Context, reviewID int64) (*domain.ReviewWithRatings, error) {
-			return nil, sql.ErrNoRows
+		GetReviewWithRatingsByIDFunc: func(ctx context.Context, reviewID int64, includeHidden bool) (*domain.ReviewWithRatings, error) {
+			return nil, domain.ErrReviewNotFound
 		},
 		GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
 			return &domain.AuthPoster{PosterID: 1}, nil
 		},
 	}
-	srv, err := New(mockService, &email.NoopSender{}, ":8080")
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
 	if err != nil {
 		t.Fatalf("failed to create server: %v", err)
 	}
@@ -61,6 +160,7 @@ func TestErrorSchemas(t *testing.T) {
 		method         string
 		url            string
 		expectedStatus int
+		expectedCode   string
 		expectedMsg    string
 	}{
 		{
@@ -68,6 +168,7 @@ func TestErrorSchemas(t *testing.T) {
 			method:         http.MethodGet,
 			url:            "/bikes/invalid",
 			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "bad_request",
 			expectedMsg:    "invalid bike id",
 		},
 		{
@@ -75,6 +176,7 @@ func TestErrorSchemas(t *testing.T) {
 			method:         http.MethodGet,
 			url:            "/bikes/999",
 			expectedStatus: http.StatusNotFound,
+			expectedCode:   "bike_not_found",
 			expectedMsg:    "bike not found",
 		},
 		{
@@ -82,6 +184,7 @@ func TestErrorSchemas(t *testing.T) {
 			method:         http.MethodGet,
 			url:            "/reviews/999",
 			expectedStatus: http.StatusNotFound,
+			expectedCode:   "review_not_found",
 			expectedMsg:    "review not found",
 		},
 		{
@@ -89,6 +192,7 @@ func TestErrorSchemas(t *testing.T) {
 			method:         http.MethodGet,
 			url:            "/auth/register",
 			expectedStatus: http.StatusMethodNotAllowed,
+			expectedCode:   "method_not_allowed",
 			expectedMsg:    "method not allowed",
 		},
 	}
@@ -107,14 +211,41 @@ func TestErrorSchemas(t *testing.T) {
 				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
 			}
 
-			var resp map[string]string
+			var resp errorResponse
 			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
 				t.Fatalf("failed to decode response: %v", err)
 			}
 
-			if resp["error"] != tt.expectedMsg {
-				t.Errorf("expected error message %q, got %q", tt.expectedMsg, resp["error"])
+			if resp.Error.Code != tt.expectedCode {
+				t.Errorf("expected code %q, got %q", tt.expectedCode, resp.Error.Code)
+			}
+			if resp.Error.Message != tt.expectedMsg {
+				t.Errorf("expected error message %q, got %q", tt.expectedMsg, resp.Error.Message)
+			}
+			if id := w.Header().Get(requestIDHeader); id == "" || resp.Error.RequestID != id {
+				t.Errorf("expected request_id to match %s %q, got %q", requestIDHeader, id, resp.Error.RequestID)
 			}
 		})
 	}
 }
+
+// invalidFields asserts a 422 validation_failed response and returns the
+// reported field names, in order.
+func invalidFields(t *testing.T, w *httptest.ResponseRecorder) []string {
+	t.Helper()
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status 422, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp errorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Error.Code != "validation_failed" {
+		t.Errorf("expected code validation_failed, got %q", resp.Error.Code)
+	}
+	var fields []string
+	for _, f := range resp.Error.Fields {
+		fields = append(fields, f.Field)
+	}
+	return fields
+}
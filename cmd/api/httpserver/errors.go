@@ -0,0 +1,137 @@
+package httpserver
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/scardozos/rottenbikes/cmd/api/validate"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+// errorResponse is the body of every error response:
+//
+//	{"error": {"code": "bike_not_found", "message": "bike not found", "request_id": "..."}}
+//
+// Clients should switch on code; message is for people and may change.
+type errorResponse struct {
+	Error apiError `json:"error"`
+}
+
+type apiError struct {
+	Code    string              `json:"code"`
+	Message string              `json:"message"`
+	Fields  []domain.FieldError `json:"fields,omitempty"`
+	// RequiredRole is set on 403s from requireRole.
+	RequiredRole domain.Role `json:"required_role,omitempty"`
+	RequestID    string      `json:"request_id,omitempty"`
+}
+
+// statusCodes names the generic error code for each status. Errors from the
+// domain carry their own, more specific code.
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            "bad_request",
+	http.StatusUnauthorized:          "unauthorized",
+	http.StatusForbidden:             "forbidden",
+	http.StatusNotFound:              "not_found",
+	http.StatusMethodNotAllowed:      "method_not_allowed",
+	http.StatusConflict:              "conflict",
+	http.StatusRequestEntityTooLarge: "payload_too_large",
+	http.StatusUnsupportedMediaType:  "unsupported_media_type",
+	http.StatusUnprocessableEntity:   "validation_failed",
+	http.StatusTooManyRequests:       "rate_limited",
+	http.StatusInternalServerError:   "internal_error",
+	http.StatusServiceUnavailable:    "unavailable",
+}
+
+func statusCode(status int) string {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r
+		case r == ' ' || r == '-':
+			return '_'
+		}
+		return -1
+	}, strings.ToLower(http.StatusText(status)))
+}
+
+func (s *HTTPServer) writeError(w http.ResponseWriter, status int, e apiError) {
+	e.RequestID = w.Header().Get(requestIDHeader)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorResponse{Error: e})
+}
+
+func (s *HTTPServer) sendError(w http.ResponseWriter, message string, status int) {
+	s.writeError(w, status, apiError{Code: statusCode(status), Message: message})
+}
+
+func (s *HTTPServer) sendInternalServerError(w http.ResponseWriter, r *http.Request, err error) {
+	zerolog.Ctx(r.Context()).Error().Err(err).Msg("internal server error")
+	s.sendError(w, "internal server error", http.StatusInternalServerError)
+}
+
+// abortResponse ends a response whose status line has already been sent,
+// after logging err. The connection is dropped before the body is
+// terminated, so the client sees a failed download instead of a short file
+// that looks complete.
+func abortResponse(r *http.Request, err error, msg string) {
+	zerolog.Ctx(r.Context()).Error().Err(err).Msg(msg)
+	panic(http.ErrAbortHandler)
+}
+
+// sendDomainError maps an error from request validation or the service to a
+// response. Errors that aren't one of the domain error types are logged and
+// sent as a 500.
+func (s *HTTPServer) sendDomainError(w http.ResponseWriter, r *http.Request, err error) {
+	var (
+		invalidReq   validate.Errors
+		invalid      *domain.ValidationError
+		limited      *domain.RateLimitedError
+		notFound     *domain.NotFoundError
+		conflict     *domain.ConflictError
+		forbidden    *domain.ForbiddenError
+		unauthorized *domain.UnauthorizedError
+	)
+	switch {
+	case errors.As(err, &invalidReq):
+		s.writeError(w, http.StatusUnprocessableEntity, apiError{
+			Code:    statusCode(http.StatusUnprocessableEntity),
+			Message: "request has invalid fields",
+			Fields:  invalidReq,
+		})
+	case errors.As(err, &invalid):
+		status := http.StatusUnprocessableEntity
+		if errors.Is(err, domain.ErrInvalidCursor) || errors.Is(err, domain.ErrInvalidLocation) {
+			// These come from the query string, which is malformed rather
+			// than a body with invalid fields.
+			status = http.StatusBadRequest
+		}
+		s.writeError(w, status, apiError{Code: invalid.Code, Message: invalid.Message, Fields: invalid.Fields})
+	case errors.As(err, &limited):
+		if limited.RetryAfter > 0 {
+			w.Header().Set("Retry-After", ceilSeconds(limited.RetryAfter))
+		}
+		s.writeError(w, http.StatusTooManyRequests, apiError{Code: limited.Code, Message: limited.Message})
+	case errors.As(err, &notFound):
+		s.writeError(w, http.StatusNotFound, apiError{Code: notFound.Code, Message: notFound.Message})
+	case errors.As(err, &conflict):
+		s.writeError(w, http.StatusConflict, apiError{Code: conflict.Code, Message: conflict.Message, Fields: conflict.Fields})
+	case errors.As(err, &forbidden):
+		s.writeError(w, http.StatusForbidden, apiError{Code: forbidden.Code, Message: forbidden.Message})
+	case errors.As(err, &unauthorized):
+		s.writeError(w, http.StatusUnauthorized, apiError{Code: unauthorized.Code, Message: unauthorized.Message})
+	case errors.Is(err, sql.ErrNoRows):
+		s.sendError(w, "not found", http.StatusNotFound)
+	default:
+		s.sendInternalServerError(w, r, err)
+	}
+}
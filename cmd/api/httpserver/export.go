@@ -0,0 +1,308 @@
+package httpserver
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/scardozos/rottenbikes/cmd/api/blob"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+const (
+	// Exports with more rows or photos than this are built in the
+	// background and emailed as a link rather than sent inline.
+	maxInlineExportRecords = 1000
+	maxInlineExportPhotos  = 20
+
+	// maxPendingExports caps the exports being built in the background at
+	// once, across posters. Each poster has at most one of them.
+	maxPendingExports = 4
+)
+
+// Background runs work off the request goroutine for the server's owner,
+// who waits for it on shutdown. Go reports false when it takes no more work.
+type Background interface {
+	Go(name string, timeout time.Duration, fn func(ctx context.Context) error) bool
+}
+
+// WithBackground lets large data exports be built by bg and emailed.
+// Without it every export is sent inline.
+func WithBackground(bg Background) Option {
+	return func(s *HTTPServer) {
+		s.background = bg
+	}
+}
+
+var exportContentTypes = map[string]string{
+	"json": "application/json",
+	"zip":  "application/zip",
+}
+
+// GET /me/export?format=json|zip → everything stored about the caller
+//
+// JSON is the export document alone; ZIP holds it as export.json next to the
+// caller's uploaded review photos under images/. The document is gathered in
+// memory before it is encoded; only the photos are streamed from the blob
+// store. Large exports answer 202 and the download link is emailed once the
+// archive is ready. A poster asking again while theirs is being built gets
+// the same 202 without a second export. An inline export that fails partway
+// drops the connection rather than leave a corrupt file.
+func (s *HTTPServer) handleExportMe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if _, ok := exportContentTypes[format]; !ok {
+		s.sendError(w, "format must be json or zip", http.StatusBadRequest)
+		return
+	}
+
+	posterID, ok := posterIDFromContext(r.Context())
+	if !ok {
+		s.sendError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	export, err := s.service.ExportPosterData(ctx, posterID)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	var photos []string
+	if format == "zip" && s.blobs != nil {
+		photos = exportPhotoKeys(export)
+	}
+
+	if s.background != nil && s.blobs != nil && (export.Records() > maxInlineExportRecords || len(photos) > maxInlineExportPhotos) {
+		if !s.queueExport(r, export, format, photos) {
+			w.Header().Set("Retry-After", "60")
+			s.sendError(w, "too many exports are being prepared, try again later", http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"message": "your export is being prepared; a download link will be emailed to you",
+			"email":   export.Account.Email,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", exportContentTypes[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="rottenbikes-export.%s"`, format))
+	w.Header().Set("Cache-Control", "no-store")
+	if err := s.writeExport(ctx, w, export, format, photos); err != nil {
+		abortResponse(r, err, "write data export error")
+	}
+}
+
+// exportPhotoKeys lists the blob keys of the photos uploaded to the
+// export's reviews. Linked images live elsewhere and are left out.
+func exportPhotoKeys(export *domain.PosterExport) []string {
+	var keys []string
+	for _, review := range export.Reviews {
+		for _, img := range review.Images {
+			if key, ok := strings.CutPrefix(img.URL, domain.ImagePathPrefix); ok && blob.ValidKey(key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+func (s *HTTPServer) writeExport(ctx context.Context, w io.Writer, export *domain.PosterExport, format string, photos []string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(export)
+	}
+
+	zw := zip.NewWriter(w)
+	f, err := zw.Create("export.json")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(export); err != nil {
+		return err
+	}
+
+	for _, key := range photos {
+		rc, err := s.blobs.Get(ctx, key)
+		if err != nil {
+			if errors.Is(err, blob.ErrNotFound) {
+				continue
+			}
+			return err
+		}
+		f, err := zw.Create("images/" + key)
+		if err == nil {
+			_, err = io.Copy(f, rc)
+		}
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// queueExport hands an export to the background, unless the poster already
+// has one there. It reports false when no more exports can be taken.
+func (s *HTTPServer) queueExport(r *http.Request, export *domain.PosterExport, format string, photos []string) bool {
+	posterID := export.Account.PosterID
+	logger := zerolog.Ctx(r.Context()).With().Int64("poster_id", posterID).Logger()
+
+	s.exportMu.Lock()
+	defer s.exportMu.Unlock()
+	if s.exporting[posterID] {
+		logger.Info().Msg("data export already queued")
+		return true
+	}
+	if len(s.exporting) >= maxPendingExports {
+		return false
+	}
+
+	queued := s.background.Go("data_export", 10*time.Minute, func(ctx context.Context) error {
+		defer func() {
+			s.exportMu.Lock()
+			delete(s.exporting, posterID)
+			s.exportMu.Unlock()
+		}()
+		return s.deliverExport(logger.WithContext(ctx), export, format, photos)
+	})
+	if !queued {
+		return false
+	}
+	if s.exporting == nil {
+		s.exporting = make(map[int64]bool)
+	}
+	s.exporting[posterID] = true
+	logger.Info().Int("records", export.Records()).Int("photos", len(photos)).Msg("data export queued")
+	return true
+}
+
+// deliverExport builds an export in a temporary file, stores it as a blob and
+// emails the owner a link to it. On failure the poster can ask again.
+func (s *HTTPServer) deliverExport(ctx context.Context, export *domain.PosterExport, format string, photos []string) error {
+	logger := zerolog.Ctx(ctx)
+	posterID := export.Account.PosterID
+
+	tmp, err := os.CreateTemp("", "rottenbikes-export-*")
+	if err != nil {
+		return fmt.Errorf("create data export file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := s.writeExport(ctx, tmp, export, format, photos); err != nil {
+		return fmt.Errorf("write data export of poster %d: %w", posterID, err)
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		return fmt.Errorf("rewind data export: %w", err)
+	}
+
+	id, err := randomBlobID()
+	if err != nil {
+		return fmt.Errorf("generate data export key: %w", err)
+	}
+	key := "export_" + id + "." + format
+	if err := s.blobs.Put(ctx, key, tmp); err != nil {
+		return fmt.Errorf("store data export of poster %d: %w", posterID, err)
+	}
+
+	token, err := s.service.CreateDataExport(ctx, posterID, key, format, size)
+	if err != nil {
+		if delErr := s.blobs.Delete(ctx, key); delErr != nil {
+			logger.Warn().Err(delErr).Str("key", key).Msg("delete orphaned data export")
+		}
+		return fmt.Errorf("record data export of poster %d: %w", posterID, err)
+	}
+
+	subject := "Your RottenBikes data export is ready"
+	body := fmt.Sprintf("Hello,\n\nThe export of your RottenBikes data you asked for is ready. Download it within %d days from:\n\n%s\n\nIf you did not request this, please contact us.",
+		int(domain.DataExportTTL.Hours()/24), exportDownloadURL(token))
+	if err := s.emailSender.SendEmail(export.Account.Email, subject, body); err != nil {
+		return fmt.Errorf("send data export link to poster %d: %w", posterID, err)
+	}
+	logger.Info().Int64("bytes", size).Msg("data export ready")
+	return nil
+}
+
+// exportDownloadURL is the emailed link to a finished export. API_PUBLIC_URL
+// is where browsers reach the API.
+func exportDownloadURL(token string) string {
+	base := os.Getenv("API_PUBLIC_URL")
+	if base == "" {
+		base = "http://localhost:8080"
+	}
+	return strings.TrimRight(base, "/") + "/exports/" + token
+}
+
+// GET /exports/{token} → download a finished export from its emailed link
+func (s *HTTPServer) handleDownloadExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := strings.TrimPrefix(r.URL.Path, "/exports/")
+	if token == "" || strings.Contains(token, "/") || s.blobs == nil {
+		s.sendError(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	export, err := s.service.GetDataExport(ctx, token)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	rc, err := s.blobs.Get(r.Context(), export.BlobKey)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			s.sendError(w, "export not found or expired", http.StatusNotFound)
+			return
+		}
+		s.sendInternalServerError(w, r, err)
+		return
+	}
+	defer rc.Close()
+
+	w.Header().Set("Content-Type", exportContentTypes[export.Format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="rottenbikes-export.%s"`, export.Format))
+	w.Header().Set("Content-Length", strconv.FormatInt(export.SizeBytes, 10))
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := io.Copy(w, rc); err != nil {
+		zerolog.Ctx(r.Context()).Warn().Err(err).Msg("serve data export error")
+	}
+}
@@ -0,0 +1,303 @@
+package httpserver
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scardozos/rottenbikes/cmd/api/blob"
+	"github.com/scardozos/rottenbikes/cmd/api/email"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+// chanSender hands every email to a channel, for senders used off the
+// request goroutine.
+type chanSender struct {
+	sent chan sentEmail
+}
+
+func (s *chanSender) SendEmail(to, subject, body string) error {
+	s.sent <- sentEmail{to: to, subject: subject, body: body}
+	return nil
+}
+
+func (s *chanSender) Name() string { return "chan" }
+
+// heldBackground keeps the work handed to it until run is called.
+type heldBackground struct {
+	mu  sync.Mutex
+	fns []func(ctx context.Context) error
+}
+
+func (b *heldBackground) Go(name string, timeout time.Duration, fn func(ctx context.Context) error) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fns = append(b.fns, fn)
+	return true
+}
+
+func (b *heldBackground) run() {
+	b.mu.Lock()
+	fns := b.fns
+	b.fns = nil
+	b.mu.Unlock()
+	for _, fn := range fns {
+		_ = fn(context.Background())
+	}
+}
+
+func (b *heldBackground) queued() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.fns)
+}
+
+// brokenBlobs fails every read, as a store gone away mid-export would.
+type brokenBlobs struct {
+	blob.BlobStore
+}
+
+func (brokenBlobs) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return nil, errors.New("disk gone")
+}
+
+func testPosterExport(reviews int) *domain.PosterExport {
+	export := &domain.PosterExport{
+		Account: domain.Account{PosterID: 1, Email: "me@example.com", Username: "me"},
+		Bikes:   []domain.Bike{{NumericalID: "01234"}},
+		MagicLinks: []domain.MagicLinkRecord{
+			{CreatedAt: time.Now(), ExpiresAt: time.Now().Add(15 * time.Minute)},
+		},
+	}
+	for i := 0; i < reviews; i++ {
+		thumb := domain.ImagePathPrefix + "abc_thumb.jpg"
+		export.Reviews = append(export.Reviews, domain.ExportedReview{ReviewWithRatings: domain.ReviewWithRatings{
+			ReviewID: int64(i + 1),
+			Ratings:  map[domain.RatingSubcategory]int16{domain.RatingSubcategoryOverall: 4},
+			Images: []domain.ReviewImage{
+				{URL: domain.ImagePathPrefix + "abc.jpg", ThumbURL: &thumb},
+				{URL: "https://example.com/linked.jpg"},
+			},
+		}})
+	}
+	return export
+}
+
+func TestHandleExportMe(t *testing.T) {
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := blobs.Put(context.Background(), "abc.jpg", strings.NewReader("jpeg bytes")); err != nil {
+		t.Fatal(err)
+	}
+
+	export := testPosterExport(1)
+	mockService := &MockService{
+		GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
+			return &domain.AuthPoster{PosterID: 1, Username: "me"}, nil
+		},
+		ExportPosterDataFunc: func(ctx context.Context, posterID int64) (*domain.PosterExport, error) {
+			return export, nil
+		},
+	}
+	sender := &chanSender{sent: make(chan sentEmail, 1)}
+	bg := &heldBackground{}
+
+	srv, err := New(mockService, sender, blobs, ":8080", WithBackground(bg))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	get := func(url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("json", func(t *testing.T) {
+		w := get("/me/export")
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+			t.Fatalf("expected a 200 JSON response, got %d %s", w.Code, w.Header().Get("Content-Type"))
+		}
+		var got domain.PosterExport
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Account.Email != "me@example.com" || len(got.Reviews) != 1 || len(got.Bikes) != 1 || len(got.MagicLinks) != 1 {
+			t.Errorf("unexpected export %+v", got)
+		}
+	})
+
+	t.Run("zip_with_photos", func(t *testing.T) {
+		w := get("/me/export?format=zip")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, f := range zr.File {
+			names = append(names, f.Name)
+		}
+		if strings.Join(names, ",") != "export.json,images/abc.jpg" {
+			t.Errorf("unexpected archive entries %v", names)
+		}
+	})
+
+	t.Run("large_export_is_emailed", func(t *testing.T) {
+		export = testPosterExport(maxInlineExportPhotos + 1)
+		var storedKey string
+		mockService.CreateDataExportFunc = func(ctx context.Context, posterID int64, blobKey, format string, sizeBytes int64) (string, error) {
+			if posterID != 1 || format != "zip" || sizeBytes == 0 {
+				t.Errorf("unexpected export record %d %s %d", posterID, format, sizeBytes)
+			}
+			storedKey = blobKey
+			return "download-token", nil
+		}
+
+		w := get("/me/export?format=zip")
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("expected status 202, got %d: %s", w.Code, w.Body.String())
+		}
+		if w := get("/me/export?format=zip"); w.Code != http.StatusAccepted {
+			t.Fatalf("expected status 202 while pending, got %d: %s", w.Code, w.Body.String())
+		}
+		if n := bg.queued(); n != 1 {
+			t.Fatalf("expected one queued export, got %d", n)
+		}
+		bg.run()
+
+		var mail sentEmail
+		select {
+		case mail = <-sender.sent:
+		case <-time.After(5 * time.Second):
+			t.Fatal("no export email was sent")
+		}
+		if mail.to != "me@example.com" || !strings.Contains(mail.body, "/exports/download-token") {
+			t.Errorf("unexpected email %+v", mail)
+		}
+
+		mockService.GetDataExportFunc = func(ctx context.Context, token string) (*domain.DataExport, error) {
+			if token != "download-token" {
+				return nil, sql.ErrNoRows
+			}
+			return &domain.DataExport{PosterID: 1, BlobKey: storedKey, Format: "zip", SizeBytes: 1}, nil
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/exports/download-token", nil)
+		dw := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(dw, req)
+		if dw.Code != http.StatusOK || dw.Header().Get("Content-Type") != "application/zip" {
+			t.Fatalf("expected the archive, got %d %s", dw.Code, dw.Header().Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(dw.Body)
+		if !bytes.HasPrefix(body, []byte("PK")) {
+			t.Error("expected a zip archive")
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/exports/expired", nil)
+		dw = httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(dw, req)
+		if dw.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", dw.Code)
+		}
+	})
+
+	t.Run("bad_format", func(t *testing.T) {
+		if w := get("/me/export?format=xml"); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("pending_exports_are_capped", func(t *testing.T) {
+		export = testPosterExport(maxInlineExportPhotos + 1)
+		mockService.GetPosterByAPITokenFunc = func(ctx context.Context, token string) (*domain.AuthPoster, error) {
+			id, err := strconv.ParseInt(strings.TrimPrefix(token, "poster_"), 10, 64)
+			if err != nil {
+				id = 1
+			}
+			return &domain.AuthPoster{PosterID: id, Username: token}, nil
+		}
+		mockService.ExportPosterDataFunc = func(ctx context.Context, posterID int64) (*domain.PosterExport, error) {
+			e := *export
+			e.Account.PosterID = posterID
+			return &e, nil
+		}
+		getAs := func(posterID int) int {
+			req := httptest.NewRequest(http.MethodGet, "/me/export?format=zip", nil)
+			req.Header.Set("Authorization", "Bearer poster_"+strconv.Itoa(posterID))
+			w := httptest.NewRecorder()
+			srv.server.Handler.ServeHTTP(w, req)
+			return w.Code
+		}
+
+		for i := 1; i <= maxPendingExports; i++ {
+			if code := getAs(i); code != http.StatusAccepted {
+				t.Fatalf("expected status 202 for poster %d, got %d", i, code)
+			}
+		}
+		if code := getAs(maxPendingExports + 1); code != http.StatusServiceUnavailable {
+			t.Errorf("expected status 503 past the cap, got %d", code)
+		}
+
+		// Finished exports free their slots.
+		mockService.CreateDataExportFunc = func(ctx context.Context, posterID int64, blobKey, format string, sizeBytes int64) (string, error) {
+			return "token", nil
+		}
+		sender.sent = make(chan sentEmail, maxPendingExports)
+		bg.run()
+		if code := getAs(maxPendingExports + 1); code != http.StatusAccepted {
+			t.Errorf("expected status 202 once the queue drained, got %d", code)
+		}
+	})
+
+	t.Run("inline_without_blob_store", func(t *testing.T) {
+		srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/me/export?format=zip", nil)
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/zip" {
+			t.Errorf("expected an inline archive, got %d", w.Code)
+		}
+	})
+
+	t.Run("failed_inline_export_is_aborted", func(t *testing.T) {
+		srv, err := New(mockService, &email.NoopSender{}, brokenBlobs{blobs}, ":8080")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ts := httptest.NewServer(srv.server.Handler)
+		defer ts.Close()
+
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/me/export?format=zip", nil)
+		req.Header.Set("Authorization", "Bearer valid_token")
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			return // dropped before the headers arrived
+		}
+		defer resp.Body.Close()
+		if _, err := io.ReadAll(resp.Body); err == nil {
+			t.Error("expected reading a failed export to fail")
+		}
+	})
+}
@@ -2,29 +2,42 @@ package httpserver
 
 import (
 	"context"
-	"encoding/json"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 
-	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
+	"github.com/scardozos/rottenbikes/cmd/api/blob"
 	"github.com/scardozos/rottenbikes/cmd/api/email"
+	"github.com/scardozos/rottenbikes/cmd/api/ratelimit"
 	"github.com/scardozos/rottenbikes/internal/domain"
 )
 
 type HTTPServer struct {
 	service     domain.Service
 	emailSender email.EmailSender
+	blobs       blob.BlobStore
 	server      *http.Server
+
+	limiter    ratelimit.RateLimiter
+	policies   ratelimit.Policies
+	trustProxy bool
+
+	background Background
+	exportMu   sync.Mutex
+	exporting  map[int64]bool // posters with an export in the background
 }
 
-func New(service domain.Service, sender email.EmailSender, addr string) (*HTTPServer, error) {
+func New(service domain.Service, sender email.EmailSender, blobs blob.BlobStore, addr string, opts ...Option) (*HTTPServer, error) {
 	// Ping check removed as it belongs to the store/db layer, or we can add a HealthCheck method to Service
 	// For now, we'll assume the service is ready or check it if we add a method.
 
-	s := &HTTPServer{service: service, emailSender: sender}
+	s := &HTTPServer{service: service, emailSender: sender, blobs: blobs}
+	for _, opt := range opts {
+		opt(s)
+	}
 
 	mux := http.NewServeMux()
 
@@ -35,13 +48,20 @@ func New(service domain.Service, sender email.EmailSender, addr string) (*HTTPSe
 	})
 
 	// Auth endpoints (public)
-	mux.HandleFunc("/auth/request-magic-link", s.handleRequestMagicLink)
+	// The ones that send email are also limited per client IP
+	mux.Handle("/auth/request-magic-link", s.rateLimit(ratelimit.PolicyAuth, http.HandlerFunc(s.handleRequestMagicLink)))
 	mux.HandleFunc("/auth/confirm/", s.handleConfirmMagicLink)
 	mux.HandleFunc("/auth/poll", s.handlePollMagicLink)
-	mux.HandleFunc("/auth/register", s.handleRegister)
+	mux.Handle("/auth/register", s.rateLimit(ratelimit.PolicyAuth, http.HandlerFunc(s.handleRegister)))
+	mux.HandleFunc("/auth/refresh", s.handleRefreshToken)
 	mux.HandleFunc("/auth/verify", s.middlewareAuth(http.HandlerFunc(s.handleVerifyToken)).ServeHTTP)
 	mux.HandleFunc("/auth/user", s.middlewareAuth(http.HandlerFunc(s.handleDeletePoster)).ServeHTTP)
 
+	// /me, /me/reviews, /me/bikes, /me/export
+	// The caller's own account, auth required for everything
+	mux.HandleFunc("/me", s.middlewareAuth(http.HandlerFunc(s.handleMe)).ServeHTTP)
+	mux.HandleFunc("/me/", s.middlewareAuth(http.HandlerFunc(s.handleMeSubroutes)).ServeHTTP)
+
 	// /bikes → list and create (Auth required for everything)
 	// /bikes → list and create
 	// GET /bikes is public
@@ -59,7 +79,9 @@ func New(service domain.Service, sender email.EmailSender, addr string) (*HTTPSe
 
 	// /bikes/{id}, /bikes/{id}/reviews, /bikes/{id}/ratings
 	// Auth required for everything
-	// /bikes/{id}, /bikes/{id}/reviews, /bikes/{id}/details
+	// /bikes/nearby, /bikes/{id}, /bikes/{id}/reviews, /bikes/{id}/details,
+	// /bikes/{id}/issues, /bikes/{id}/sightings,
+	// /bikes/{id}/reviews/{rid}/images
 	// GET operations public, everything else authenticated
 	mux.HandleFunc("/bikes/", s.handleBikeSubroutes)
 
@@ -69,9 +91,38 @@ func New(service domain.Service, sender email.EmailSender, addr string) (*HTTPSe
 	// GET operations public, everything else authenticated
 	mux.HandleFunc("/reviews/", s.handleReviewSubroutes)
 
+	// /stations/nearby, /stations/{id}, /stations/{id}/bikes
+	// Public, GET only
+	mux.HandleFunc("/stations/", s.handleStationSubroutes)
+
+	// /issues/{id}/confirmations
+	// Auth required
+	mux.HandleFunc("/issues/", s.handleIssueSubroutes)
+
+	// /moderation/reports, /moderation/reviews/{id}/...
+	// Moderators and admins only
+	mux.HandleFunc("/moderation/", s.middlewareAuth(s.requireRole(domain.RoleModerator, http.HandlerFunc(s.handleModerationSubroutes))).ServeHTTP)
+
+	// /admin/audit-events, /admin/{bikes,reviews}/{id}/restore,
+	// /admin/stations/import, /admin/bikes/{import,export}
+	// Admins only
+	mux.HandleFunc("/admin/", s.middlewareAuth(s.requireRole(domain.RoleAdmin, http.HandlerFunc(s.handleAdminSubroutes))).ServeHTTP)
+
+	// /images/{key}
+	// Uploaded review photos and thumbnails, public
+	mux.HandleFunc(domain.ImagePathPrefix, s.handleGetImage)
+
+	// /exports/{token}
+	// Emailed data export links; the token is the credential
+	mux.HandleFunc("/exports/", s.handleDownloadExport)
+
+	// /posters/{username}, /posters/{username}/reviews
+	// Public profiles, GET only
+	mux.HandleFunc("/posters/", s.handlePosterSubroutes)
+
 	s.server = &http.Server{
 		Addr:    addr,
-		Handler: observabilityMiddleware(corsMiddleware(mux)),
+		Handler: observabilityMiddleware(corsMiddleware(s.rateLimitGlobal(mux))),
 	}
 
 	return s, nil
@@ -82,6 +133,12 @@ func (s *HTTPServer) handleBikeSubroutes(w http.ResponseWriter, r *http.Request)
 	path := strings.TrimPrefix(r.URL.Path, "/bikes/")
 	parts := strings.Split(strings.Trim(path, "/"), "/")
 
+	if len(parts) == 1 && parts[0] == "nearby" {
+		// /bikes/nearby
+		s.handleNearbyBikes(w, r)
+		return
+	}
+
 	if len(parts) == 1 && parts[0] != "" {
 		// /bikes/{id}
 		// /bikes/{id}
@@ -95,9 +152,9 @@ func (s *HTTPServer) handleBikeSubroutes(w http.ResponseWriter, r *http.Request)
 				s.handleUpdateBike(w, r, bikeID)
 			})).ServeHTTP(w, r)
 		case http.MethodDelete:
-			s.middlewareAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.middlewareAuth(s.requireRole(domain.RoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				s.handleDeleteBike(w, r, bikeID)
-			})).ServeHTTP(w, r)
+			}))).ServeHTTP(w, r)
 		default:
 			s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
 		}
@@ -109,13 +166,33 @@ func (s *HTTPServer) handleBikeSubroutes(w http.ResponseWriter, r *http.Request)
 
 		switch sub {
 		case "reviews":
-			if r.Method == http.MethodPost {
+			switch r.Method {
+			case http.MethodGet:
+				s.handleListBikeReviews(w, r, bikeID)
+			case http.MethodPost:
 				s.middlewareAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 					s.handleCreateBikeReview(w, r, bikeID)
 				})).ServeHTTP(w, r)
-				return
+			default:
+				s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
 			}
-			s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		case "issues":
+			switch r.Method {
+			case http.MethodGet:
+				s.handleListBikeIssues(w, r, bikeID)
+			case http.MethodPost:
+				s.middlewareAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					s.handleReportBikeIssue(w, r, bikeID)
+				})).ServeHTTP(w, r)
+			default:
+				s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		case "sightings":
+			s.middlewareAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				s.handleRecordSighting(w, r, bikeID)
+			})).ServeHTTP(w, r)
 			return
 		case "details":
 			if r.Method == http.MethodGet {
@@ -127,27 +204,60 @@ func (s *HTTPServer) handleBikeSubroutes(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	if len(parts) == 4 && parts[1] == "reviews" && parts[3] == "images" {
+		// /bikes/{id}/reviews/{rid}/images
+		bikeID := parts[0]
+		reviewID, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			s.sendError(w, "invalid review id", http.StatusBadRequest)
+			return
+		}
+		s.middlewareAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.handleUploadReviewPhoto(w, r, bikeID, reviewID)
+		})).ServeHTTP(w, r)
+		return
+	}
+
 	s.sendError(w, "not found", http.StatusNotFound)
 }
 
 // /reviews/{id}...
 func (s *HTTPServer) handleReviewSubroutes(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/reviews/")
-	idStr := strings.Trim(path, "/")
-	if idStr == "" {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if parts[0] == "" || len(parts) > 2 {
 		s.sendError(w, "not found", http.StatusNotFound)
 		return
 	}
 
-	reviewID, err := strconv.ParseInt(idStr, 10, 64)
+	reviewID, err := strconv.ParseInt(parts[0], 10, 64)
 	if err != nil {
 		s.sendError(w, "invalid review id", http.StatusBadRequest)
 		return
 	}
 
+	if len(parts) == 2 {
+		// /reviews/{id}/reports
+		if parts[1] != "reports" {
+			s.sendError(w, "not found", http.StatusNotFound)
+			return
+		}
+		s.middlewareAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.handleReportReview(w, r, reviewID)
+		})).ServeHTTP(w, r)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
-		s.handleGetReview(w, r, reviewID)
+		// Public, but a signed-in moderator may also see hidden reviews.
+		if r.Header.Get("Authorization") == "" {
+			s.handleGetReview(w, r, reviewID)
+			return
+		}
+		s.middlewareAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.handleGetReview(w, r, reviewID)
+		})).ServeHTTP(w, r)
 	case http.MethodPut:
 		s.middlewareAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			s.handleUpdateReview(w, r, reviewID)
@@ -161,17 +271,6 @@ func (s *HTTPServer) handleReviewSubroutes(w http.ResponseWriter, r *http.Reques
 	}
 }
 
-func (s *HTTPServer) sendError(w http.ResponseWriter, message string, status int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
-}
-
-func (s *HTTPServer) sendInternalServerError(w http.ResponseWriter, r *http.Request, err error) {
-	zerolog.Ctx(r.Context()).Error().Err(err).Msg("internal server error")
-	s.sendError(w, "internal server error", http.StatusInternalServerError)
-}
-
 func (s *HTTPServer) Start() error {
 	log.Info().Msgf("HTTP server listening on %s", s.server.Addr)
 	return s.server.ListenAndServe()
@@ -186,8 +285,9 @@ func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Allow all origins for now (dev/web UI on 8081)
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Expose-Headers", "RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After, X-Request-ID")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -0,0 +1,195 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/scardozos/rottenbikes/cmd/api/validate"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+type listIssuesResponse struct {
+	Issues     []domain.BikeIssue `json:"issues"`
+	NextCursor *string            `json:"next_cursor"`
+}
+
+// GET /bikes/{id}/issues?status=open|resolved → a bike's issues, newest first
+func (s *HTTPServer) handleListBikeIssues(w http.ResponseWriter, r *http.Request, bikeID string) {
+	if !validate.Numeric(bikeID) {
+		s.sendError(w, "invalid bike id", http.StatusBadRequest)
+		return
+	}
+
+	cursor, limit, err := parsePageParams(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q := domain.ListIssuesQuery{Cursor: cursor, Limit: limit}
+	if v := r.URL.Query().Get("status"); v != "" {
+		status, err := domain.ParseIssueStatus(v)
+		if err != nil {
+			s.sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		q.Status = &status
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	issues, next, err := s.service.ListBikeIssues(ctx, bikeID, q)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	if issues == nil {
+		issues = []domain.BikeIssue{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(listIssuesResponse{
+		Issues:     issues,
+		NextCursor: nextCursor(next),
+	})
+}
+
+type reportIssueRequest struct {
+	Type string  `json:"type"`
+	Note *string `json:"note"`
+}
+
+// validate checks the type and note; whether the type applies to the bike is
+// up to the store.
+func (req reportIssueRequest) validate() error {
+	var v validate.Validator
+	v.Check(domain.IssueType(req.Type).Valid(),
+		"type", "must be one of flat_tyre, broken_brake, missing_pedal, battery_dead")
+	v.Check(req.Note == nil || validate.MaxLen(strings.TrimSpace(*req.Note), domain.MaxIssueNoteLen),
+		"note", fmt.Sprintf("must be at most %d characters", domain.MaxIssueNoteLen))
+	return v.Err()
+}
+
+// POST /bikes/{id}/issues → report a problem with a bike
+func (s *HTTPServer) handleReportBikeIssue(w http.ResponseWriter, r *http.Request, bikeID string) {
+	if !validate.Numeric(bikeID) {
+		s.sendError(w, "invalid bike id", http.StatusBadRequest)
+		return
+	}
+
+	posterID, ok := posterIDFromContext(r.Context())
+	if !ok {
+		s.sendError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req reportIssueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(); err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	issueID, err := s.service.ReportBikeIssue(ctx, bikeID, posterID, domain.IssueType(req.Type), req.Note)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	zerolog.Ctx(r.Context()).Info().
+		Int64("issue_id", issueID).
+		Str("bike_id", bikeID).
+		Str("type", req.Type).
+		Msg("bike issue reported")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"issue_id": issueID,
+	})
+}
+
+// /issues/{id}/...
+func (s *HTTPServer) handleIssueSubroutes(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/issues/")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != 2 || parts[1] != "confirmations" {
+		s.sendError(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	issueID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		s.sendError(w, "invalid issue id", http.StatusBadRequest)
+		return
+	}
+
+	s.middlewareAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.handleConfirmBikeIssue(w, r, issueID)
+	})).ServeHTTP(w, r)
+}
+
+type confirmIssueRequest struct {
+	Status string `json:"status"`
+}
+
+func (req confirmIssueRequest) validate() error {
+	var v validate.Validator
+	status := domain.ConfirmationStatus(req.Status)
+	v.Check(status == domain.ConfirmStillBroken || status == domain.ConfirmFixed,
+		"status", "must be still_broken or fixed")
+	return v.Err()
+}
+
+// POST /issues/{id}/confirmations → say whether an open issue is still there
+func (s *HTTPServer) handleConfirmBikeIssue(w http.ResponseWriter, r *http.Request, issueID int64) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	posterID, ok := posterIDFromContext(r.Context())
+	if !ok {
+		s.sendError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req confirmIssueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(); err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	status, err := s.service.ConfirmBikeIssue(ctx, issueID, posterID, domain.ConfirmationStatus(req.Status))
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"issue_id": issueID,
+		"status":   status,
+	})
+}
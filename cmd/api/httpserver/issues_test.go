@@ -0,0 +1,227 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/scardozos/rottenbikes/cmd/api/email"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+func TestHandleListBikeIssues(t *testing.T) {
+	mockService := &MockService{}
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		var gotQuery domain.ListIssuesQuery
+		mockService.ListBikeIssuesFunc = func(ctx context.Context, bikeID string, q domain.ListIssuesQuery) ([]domain.BikeIssue, string, error) {
+			if bikeID != "0101" {
+				t.Errorf("expected bike 0101, got %s", bikeID)
+			}
+			gotQuery = q
+			return []domain.BikeIssue{{IssueID: 4, Type: domain.IssueFlatTyre, Status: domain.IssueOpen, StillBrokenCount: 2}}, "next", nil
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/bikes/0101/issues?status=open&limit=5", nil)
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if gotQuery.Limit != 5 || gotQuery.Status == nil || *gotQuery.Status != domain.IssueOpen {
+			t.Errorf("unexpected query %+v", gotQuery)
+		}
+
+		var resp listIssuesResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Issues) != 1 || resp.Issues[0].StillBrokenCount != 2 || resp.NextCursor == nil {
+			t.Errorf("unexpected response %+v", resp)
+		}
+	})
+
+	t.Run("invalid_status", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/bikes/0101/issues?status=pending", nil)
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		mockService.ListBikeIssuesFunc = func(ctx context.Context, bikeID string, q domain.ListIssuesQuery) ([]domain.BikeIssue, string, error) {
+			return nil, "", sql.ErrNoRows
+		}
+		req := httptest.NewRequest(http.MethodGet, "/bikes/0101/issues", nil)
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+}
+
+func TestHandleReportBikeIssue(t *testing.T) {
+	mockService := &MockService{
+		GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
+			return &domain.AuthPoster{PosterID: 1, Role: domain.RoleUser}, nil
+		},
+	}
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	report := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/bikes/0101/issues", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		var gotType domain.IssueType
+		var gotNote *string
+		mockService.ReportBikeIssueFunc = func(ctx context.Context, bikeID string, reporterID int64, issueType domain.IssueType, note *string) (int64, error) {
+			if bikeID != "0101" || reporterID != 1 {
+				t.Errorf("unexpected call: bike %s, reporter %d", bikeID, reporterID)
+			}
+			gotType, gotNote = issueType, note
+			return 9, nil
+		}
+
+		w := report(`{"type":"broken_brake","note":"rear"}`)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d", w.Code)
+		}
+		if gotType != domain.IssueBrokenBrake || gotNote == nil || *gotNote != "rear" {
+			t.Errorf("unexpected call: type %q, note %v", gotType, gotNote)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		cases := map[error]int{
+			domain.ErrInvalidIssueType:   http.StatusUnprocessableEntity,
+			domain.ErrIssueNotApplicable: http.StatusUnprocessableEntity,
+			sql.ErrNoRows:                http.StatusNotFound,
+			domain.ErrIssueAlreadyOpen:   http.StatusConflict,
+		}
+		for serr, status := range cases {
+			mockService.ReportBikeIssueFunc = func(ctx context.Context, bikeID string, reporterID int64, issueType domain.IssueType, note *string) (int64, error) {
+				return 0, serr
+			}
+			if w := report(`{"type":"battery_dead"}`); w.Code != status {
+				t.Errorf("%v: expected status %d, got %d", serr, status, w.Code)
+			}
+		}
+	})
+
+	t.Run("invalid_fields", func(t *testing.T) {
+		mockService.ReportBikeIssueFunc = func(ctx context.Context, bikeID string, reporterID int64, issueType domain.IssueType, note *string) (int64, error) {
+			t.Error("expected the report to be rejected before reaching the service")
+			return 0, nil
+		}
+		body, _ := json.Marshal(map[string]string{"type": "on_fire", "note": strings.Repeat("ñ", domain.MaxIssueNoteLen+1)})
+		if got := strings.Join(invalidFields(t, report(string(body))), ","); got != "type,note" {
+			t.Errorf("expected type and note to be reported, got %s", got)
+		}
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/bikes/0101/issues", bytes.NewBufferString(`{"type":"flat_tyre"}`))
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", w.Code)
+		}
+	})
+}
+
+func TestHandleConfirmBikeIssue(t *testing.T) {
+	mockService := &MockService{
+		GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
+			return &domain.AuthPoster{PosterID: 2, Role: domain.RoleUser}, nil
+		},
+	}
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	confirm := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockService.ConfirmBikeIssueFunc = func(ctx context.Context, issueID, posterID int64, status domain.ConfirmationStatus) (domain.IssueStatus, error) {
+			if issueID != 4 || posterID != 2 || status != domain.ConfirmFixed {
+				t.Errorf("unexpected call: issue %d, poster %d, status %q", issueID, posterID, status)
+			}
+			return domain.IssueResolved, nil
+		}
+
+		w := confirm("/issues/4/confirmations", `{"status":"fixed"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		var resp map[string]any
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp["status"] != "resolved" {
+			t.Errorf("expected resolved, got %v", resp["status"])
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		cases := map[error]int{
+			domain.ErrInvalidConfirmation: http.StatusUnprocessableEntity,
+			sql.ErrNoRows:                 http.StatusNotFound,
+			domain.ErrIssueResolved:       http.StatusConflict,
+		}
+		for serr, status := range cases {
+			mockService.ConfirmBikeIssueFunc = func(ctx context.Context, issueID, posterID int64, status domain.ConfirmationStatus) (domain.IssueStatus, error) {
+				return "", serr
+			}
+			if w := confirm("/issues/4/confirmations", `{"status":"still_broken"}`); w.Code != status {
+				t.Errorf("%v: expected status %d, got %d", serr, status, w.Code)
+			}
+		}
+	})
+
+	t.Run("invalid_status", func(t *testing.T) {
+		mockService.ConfirmBikeIssueFunc = func(ctx context.Context, issueID, posterID int64, status domain.ConfirmationStatus) (domain.IssueStatus, error) {
+			t.Error("expected the confirmation to be rejected before reaching the service")
+			return "", nil
+		}
+		if fields := invalidFields(t, confirm("/issues/4/confirmations", `{"status":"maybe"}`)); len(fields) != 1 || fields[0] != "status" {
+			t.Errorf("expected status to be reported, got %v", fields)
+		}
+	})
+
+	t.Run("bad_path", func(t *testing.T) {
+		if w := confirm("/issues/abc/confirmations", `{}`); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", w.Code)
+		}
+		if w := confirm("/issues/4/votes", `{}`); w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+}
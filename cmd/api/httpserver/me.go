@@ -0,0 +1,281 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/scardozos/rottenbikes/cmd/api/ratelimit"
+	"github.com/scardozos/rottenbikes/cmd/api/validate"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+// /me
+func (s *HTTPServer) handleMe(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.handleGetMe(w, r)
+	case http.MethodPatch:
+		s.handleUpdateMe(w, r)
+	default:
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// /me/...
+func (s *HTTPServer) handleMeSubroutes(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/me/")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	if parts[0] == "sessions" {
+		s.handleSessionSubroutes(w, r, parts[1:])
+		return
+	}
+
+	if len(parts) == 1 {
+		switch parts[0] {
+		case "reviews":
+			s.handleListMyReviews(w, r)
+			return
+		case "bikes":
+			s.handleListMyBikes(w, r)
+			return
+		case "email":
+			s.handleChangeEmail(w, r)
+			return
+		case "export":
+			s.rateLimit(ratelimit.PolicyExport, http.HandlerFunc(s.handleExportMe)).ServeHTTP(w, r)
+			return
+		}
+	}
+
+	s.sendError(w, "not found", http.StatusNotFound)
+}
+
+// GET /me → the caller's own account, with the expiry of the tokens it was
+// called with
+func (s *HTTPServer) handleGetMe(w http.ResponseWriter, r *http.Request) {
+	posterID, ok := posterIDFromContext(r.Context())
+	if !ok {
+		s.sendError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	sessionID, _ := sessionIDFromContext(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	account, err := s.service.GetAccount(ctx, posterID, sessionID)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(account)
+}
+
+type updateMeRequest struct {
+	Username *string `json:"username"`
+}
+
+func (req updateMeRequest) validate() error {
+	var v validate.Validator
+	v.Check(req.Username != nil && domain.ValidUsername(*req.Username), "username", "must be letters, numbers and dots")
+	return v.Err()
+}
+
+// PATCH /me → change the caller's username
+func (s *HTTPServer) handleUpdateMe(w http.ResponseWriter, r *http.Request) {
+	posterID, ok := posterIDFromContext(r.Context())
+	if !ok {
+		s.sendError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req updateMeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := s.service.UpdateUsername(ctx, posterID, *req.Username); err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	if rw, ok := w.(*ResponseWriter); ok {
+		rw.Username = *req.Username
+	}
+
+	sessionID, _ := sessionIDFromContext(r.Context())
+	account, err := s.service.GetAccount(ctx, posterID, sessionID)
+	if err != nil {
+		s.sendInternalServerError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(account)
+}
+
+// GET /me/reviews → the caller's reviews, paginated like /bikes/{id}/reviews
+func (s *HTTPServer) handleListMyReviews(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	username, ok := usernameFromContext(r.Context())
+	if !ok {
+		s.sendError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	q, err := parseListReviewsQuery(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	reviews, cursor, err := s.service.ListReviewsWithRatingsByPoster(ctx, username, q)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	s.sendReviewPage(w, r, reviews, cursor)
+}
+
+// GET /me/bikes → bikes the caller created, paginated like /bikes
+func (s *HTTPServer) handleListMyBikes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	posterID, ok := posterIDFromContext(r.Context())
+	if !ok {
+		s.sendError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	q, err := parseListBikesQuery(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q.CreatorID = &posterID
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	bikes, cursor, err := s.service.ListBikes(ctx, q)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	if bikes == nil {
+		bikes = []domain.Bike{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(listBikesResponse{
+		Bikes:      bikes,
+		NextCursor: nextCursor(cursor),
+	})
+}
+
+type changeEmailRequest struct {
+	Email  string `json:"email"`
+	Origin string `json:"origin"`
+}
+
+func (req changeEmailRequest) validate() error {
+	var v validate.Validator
+	v.Check(validate.Email(req.Email), "email", "must be a valid email address")
+	return v.Err()
+}
+
+// POST /me/email → send a confirmation link to the new address. The account
+// keeps its current email until that link is confirmed.
+func (s *HTTPServer) handleChangeEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	posterID, ok := posterIDFromContext(r.Context())
+	if !ok {
+		s.sendError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req changeEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	magicToken, err := s.service.RequestEmailChange(ctx, posterID, req.Email)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	uiURL := confirmURL(magicToken, req.Origin)
+	zerolog.Ctx(r.Context()).Info().Str("email", req.Email).Msg("sending email change link")
+
+	subject := "Confirm your new RottenBikes email"
+	body := fmt.Sprintf("Hello,\n\nYou asked to use this address for your RottenBikes account. Confirm the change by clicking the following link:\n\n%s\n\nIf you did not request this, please ignore this email.", uiURL)
+
+	if err := s.emailSender.SendEmail(req.Email, subject, body); err != nil {
+		zerolog.Ctx(r.Context()).Error().Err(err).Str("email", req.Email).Msg("failed to send email change link")
+		s.sendError(w, "failed to send confirmation email", http.StatusInternalServerError)
+		return
+	}
+
+	// The token is deliberately not returned: only the new inbox may confirm.
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"message":       "confirmation email sent",
+		"pending_email": req.Email,
+	})
+}
+
+// notifyEmailChanged tells the previous address that the account moved away
+// from it. Failures are logged only; the change is already committed.
+func (s *HTTPServer) notifyEmailChanged(ctx context.Context, previous, current string) {
+	subject := "Your RottenBikes email was changed"
+	body := fmt.Sprintf("Hello,\n\nThe email address of your RottenBikes account was changed to %s.\n\nIf you did not make this change, please contact us right away.", current)
+
+	if err := s.emailSender.SendEmail(previous, subject, body); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Str("email", previous).Msg("failed to send email change notice")
+	}
+}
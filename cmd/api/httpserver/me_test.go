@@ -0,0 +1,293 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scardozos/rottenbikes/cmd/api/email"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+func TestHandleMe(t *testing.T) {
+	username := "testuser"
+	accessExpires := time.Date(2026, 1, 2, 3, 15, 0, 0, time.UTC)
+	refreshExpires := time.Date(2026, 3, 3, 3, 0, 0, 0, time.UTC)
+	mockService := &MockService{
+		GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
+			return &domain.AuthPoster{PosterID: 1, Username: username, SessionID: 7}, nil
+		},
+		GetAccountFunc: func(ctx context.Context, posterID, sessionID int64) (*domain.Account, error) {
+			a := &domain.Account{PosterID: posterID, Email: "test@example.com", Username: username, EmailVerified: true}
+			if sessionID == 7 {
+				a.APITokenExpiresAt, a.RefreshTokenExpiresAt = &accessExpires, &refreshExpires
+			}
+			return a, nil
+		},
+		UpdateUsernameFunc: func(ctx context.Context, posterID int64, newUsername string) error {
+			switch newUsername {
+			case "taken":
+				return domain.ErrUsernameExists
+			case "bad!":
+				return domain.ErrInvalidUsername
+			}
+			username = newUsername
+			return nil
+		},
+	}
+
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	t.Run("get", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+
+		var resp map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp["email"] != "test@example.com" || resp["email_verified"] != true {
+			t.Errorf("unexpected account %v", resp)
+		}
+		if resp["api_token_expires_at"] != "2026-01-02T03:15:00Z" || resp["refresh_token_expires_at"] != "2026-03-03T03:00:00Z" {
+			t.Errorf("expected the current session's token expiry, got %v / %v", resp["api_token_expires_at"], resp["refresh_token_expires_at"])
+		}
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", w.Code)
+		}
+	})
+
+	t.Run("patch_username", func(t *testing.T) {
+		reqBody, _ := json.Marshal(map[string]string{"username": "renamed"})
+		req := httptest.NewRequest(http.MethodPatch, "/me", bytes.NewReader(reqBody))
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+
+		var resp domain.Account
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp.Username != "renamed" {
+			t.Errorf("expected username renamed, got %s", resp.Username)
+		}
+	})
+
+	t.Run("patch_errors", func(t *testing.T) {
+		tests := []struct {
+			body   string
+			status int
+		}{
+			{`{"username": "taken"}`, http.StatusConflict},
+			{`{"username": "bad!"}`, http.StatusUnprocessableEntity},
+			{`{}`, http.StatusUnprocessableEntity},
+			{`not-json`, http.StatusBadRequest},
+		}
+
+		for _, tt := range tests {
+			req := httptest.NewRequest(http.MethodPatch, "/me", bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("Authorization", "Bearer valid_token")
+			w := httptest.NewRecorder()
+
+			srv.server.Handler.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("%s: expected status %d, got %d", tt.body, tt.status, w.Code)
+			}
+		}
+	})
+}
+
+func TestHandleMeSubroutes(t *testing.T) {
+	var gotUsername string
+	var gotBikesQuery domain.ListBikesQuery
+	mockService := &MockService{
+		GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
+			return &domain.AuthPoster{PosterID: 42, Username: "me"}, nil
+		},
+		ListReviewsWithRatingsByPosterFunc: func(ctx context.Context, username string, q domain.ListReviewsQuery) ([]domain.ReviewWithRatings, string, error) {
+			gotUsername = username
+			return []domain.ReviewWithRatings{{ReviewID: 1}}, "", nil
+		},
+		ListBikesFunc: func(ctx context.Context, q domain.ListBikesQuery) ([]domain.Bike, string, error) {
+			gotBikesQuery = q
+			return nil, "", nil
+		},
+	}
+
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	t.Run("reviews", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/me/reviews", nil)
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		if gotUsername != "me" {
+			t.Errorf("expected reviews of me, got %s", gotUsername)
+		}
+	})
+
+	t.Run("bikes", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/me/bikes", nil)
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		if gotBikesQuery.CreatorID == nil || *gotBikesQuery.CreatorID != 42 {
+			t.Errorf("expected creator filter 42, got %v", gotBikesQuery.CreatorID)
+		}
+		if w.Body.String() != "{\"bikes\":[],\"next_cursor\":null}\n" {
+			t.Errorf("unexpected body %q", w.Body.String())
+		}
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/me/unknown", nil)
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+}
+
+type sentEmail struct {
+	to, subject, body string
+}
+
+// recordingSender keeps every email instead of delivering it.
+type recordingSender struct {
+	sent []sentEmail
+}
+
+func (s *recordingSender) SendEmail(to, subject, body string) error {
+	s.sent = append(s.sent, sentEmail{to: to, subject: subject, body: body})
+	return nil
+}
+
+func (s *recordingSender) Name() string { return "recording" }
+
+func TestHandleChangeEmail(t *testing.T) {
+	mockService := &MockService{
+		GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
+			return &domain.AuthPoster{PosterID: 1, Username: "me"}, nil
+		},
+		RequestEmailChangeFunc: func(ctx context.Context, posterID int64, newEmail string) (string, error) {
+			switch newEmail {
+			case "taken@example.com":
+				return "", domain.ErrEmailExists
+			case "bad":
+				return "", domain.ErrInvalidEmail
+			}
+			return "change-token", nil
+		},
+		ConfirmMagicLinkFunc: func(ctx context.Context, token string) (*domain.ConfirmResult, error) {
+			return &domain.ConfirmResult{
+				APIToken:      "api-token",
+				Email:         "new@example.com",
+				PreviousEmail: "old@example.com",
+			}, nil
+		},
+	}
+
+	sender := &recordingSender{}
+	srv, err := New(mockService, sender, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/me/email", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		sender.sent = nil
+		w := post(`{"email":"new@example.com"}`)
+
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("expected status 202, got %d", w.Code)
+		}
+		if strings.Contains(w.Body.String(), "change-token") {
+			t.Error("magic token must not be returned to the caller")
+		}
+		if len(sender.sent) != 1 || sender.sent[0].to != "new@example.com" {
+			t.Fatalf("expected one email to the new address, got %+v", sender.sent)
+		}
+		if !strings.Contains(sender.sent[0].body, "/confirm/change-token") {
+			t.Errorf("expected confirm link in body, got %q", sender.sent[0].body)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for body, code := range map[string]int{
+			`{}`:                            http.StatusUnprocessableEntity,
+			`{"email":"bad"}`:               http.StatusUnprocessableEntity,
+			`{"email":"taken@example.com"}`: http.StatusConflict,
+		} {
+			if w := post(body); w.Code != code {
+				t.Errorf("%s: expected status %d, got %d", body, code, w.Code)
+			}
+		}
+	})
+
+	t.Run("confirm_notifies_old_address", func(t *testing.T) {
+		sender.sent = nil
+		req := httptest.NewRequest(http.MethodGet, "/auth/confirm/change-token", nil)
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		if len(sender.sent) != 1 || sender.sent[0].to != "old@example.com" {
+			t.Errorf("expected notice to the old address, got %+v", sender.sent)
+		}
+	})
+}
@@ -13,6 +13,8 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/rs/zerolog/log"
+
+	"github.com/scardozos/rottenbikes/internal/domain"
 )
 
 var (
@@ -49,6 +51,10 @@ var (
 	)
 )
 
+// requestIDHeader carries the id observabilityMiddleware gives each request,
+// so clients can quote it when reporting a problem.
+const requestIDHeader = "X-Request-ID"
+
 // ResponseWriter wraps http.ResponseWriter to capture status code, size and username
 type ResponseWriter struct {
 	http.ResponseWriter
@@ -90,8 +96,9 @@ func observabilityMiddleware(next http.Handler) http.Handler {
 		defer httpRequestsInFlight.Dec()
 
 		requestID := generateRequestID()
+		w.Header().Set(requestIDHeader, requestID)
 		logger := log.With().Str("request_id", requestID).Logger()
-		ctx := logger.WithContext(r.Context())
+		ctx := domain.WithRequestID(logger.WithContext(r.Context()), requestID)
 
 		next.ServeHTTP(rw, r.WithContext(ctx))
 
@@ -106,11 +113,9 @@ func observabilityMiddleware(next http.Handler) http.Handler {
 		var errMsg string
 		if rw.Status >= 400 {
 			// Try to parse JSON error
-			var errBody struct {
-				Error string `json:"error"`
-			}
-			if json.Unmarshal(rw.Body.Bytes(), &errBody) == nil && errBody.Error != "" {
-				errMsg = errBody.Error
+			var errBody errorResponse
+			if json.Unmarshal(rw.Body.Bytes(), &errBody) == nil && errBody.Error.Message != "" {
+				errMsg = errBody.Error.Message
 			} else {
 				// Fallback to raw body or generic message if parsing fails or empty
 				errMsg = rw.Body.String()
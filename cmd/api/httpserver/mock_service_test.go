@@ -7,32 +7,60 @@ import (
 )
 
 type MockService struct {
-	RegisterFunc                     func(ctx context.Context, username, email string) (string, error)
-	CreateMagicLinkFunc              func(ctx context.Context, email string) (string, string, error)
-	ConfirmMagicLinkFunc             func(ctx context.Context, token string) (*domain.ConfirmResult, error)
-	GetPosterByAPITokenFunc          func(ctx context.Context, token string) (*domain.AuthPoster, error)
-	CheckMagicLinkStatusFunc         func(ctx context.Context, token string) (string, error)
-	DeletePosterFunc                 func(ctx context.Context, posterID int64, deleteContent bool) error
-	ListBikesFunc                    func(ctx context.Context) ([]domain.Bike, error)
-	CreateBikeFunc                   func(ctx context.Context, numericalID string, hashID *string, isElectric bool, creatorID int64) (*domain.Bike, error)
-	GetBikeFunc                      func(ctx context.Context, id string) (*domain.Bike, error)
-	GetBikeDetailsFunc               func(ctx context.Context, id string) (*domain.BikeDetails, error)
-	UpdateBikeFunc                   func(ctx context.Context, id string, hashID *string, isElectric *bool) error
-	DeleteBikeFunc                   func(ctx context.Context, id string) error
-	ListRatingAggregatesByBikeFunc   func(ctx context.Context, bikeID string) ([]domain.RatingAggregate, error)
-	ListReviewsWithRatingsByBikeFunc func(ctx context.Context, bikeID string) ([]domain.ReviewWithRatings, error)
-	CreateReviewWithRatingsFunc      func(ctx context.Context, in domain.CreateReviewInput) (int64, error)
-	UpdateReviewWithRatingsFunc      func(ctx context.Context, in domain.UpdateReviewInput) error
-	GetReviewWithRatingsByIDFunc     func(ctx context.Context, reviewID int64) (*domain.ReviewWithRatings, error)
-	DeleteReviewFunc                 func(ctx context.Context, reviewID int64, posterID int64) error
-}
-
-func (m *MockService) Register(ctx context.Context, username, email string) (string, error) {
-	return m.RegisterFunc(ctx, username, email)
-}
-
-func (m *MockService) CreateMagicLink(ctx context.Context, email string) (string, string, error) {
-	return m.CreateMagicLinkFunc(ctx, email)
+	RegisterFunc                       func(ctx context.Context, username, email, deviceLabel string) (string, error)
+	CreateMagicLinkFunc                func(ctx context.Context, email, deviceLabel string) (string, string, error)
+	ConfirmMagicLinkFunc               func(ctx context.Context, token string) (*domain.ConfirmResult, error)
+	GetPosterByAPITokenFunc            func(ctx context.Context, token string) (*domain.AuthPoster, error)
+	CheckMagicLinkStatusFunc           func(ctx context.Context, token string) (*domain.TokenPair, error)
+	DeletePosterFunc                   func(ctx context.Context, posterID int64, deleteContent bool) ([]string, error)
+	RequestEmailChangeFunc             func(ctx context.Context, posterID int64, newEmail string) (string, error)
+	RefreshSessionFunc                 func(ctx context.Context, refreshToken string) (*domain.TokenPair, error)
+	ListSessionsFunc                   func(ctx context.Context, posterID int64) ([]domain.Session, error)
+	RevokeSessionFunc                  func(ctx context.Context, posterID, sessionID int64) error
+	RevokeAllSessionsFunc              func(ctx context.Context, posterID int64) (int64, error)
+	GetPosterProfileFunc               func(ctx context.Context, username string) (*domain.PosterProfile, error)
+	ListReviewsWithRatingsByPosterFunc func(ctx context.Context, username string, q domain.ListReviewsQuery) ([]domain.ReviewWithRatings, string, error)
+	GetAccountFunc                     func(ctx context.Context, posterID, sessionID int64) (*domain.Account, error)
+	UpdateUsernameFunc                 func(ctx context.Context, posterID int64, username string) error
+	ExportPosterDataFunc               func(ctx context.Context, posterID int64) (*domain.PosterExport, error)
+	CreateDataExportFunc               func(ctx context.Context, posterID int64, blobKey, format string, sizeBytes int64) (string, error)
+	GetDataExportFunc                  func(ctx context.Context, token string) (*domain.DataExport, error)
+	ListBikesFunc                      func(ctx context.Context, q domain.ListBikesQuery) ([]domain.Bike, string, error)
+	CreateBikeFunc                     func(ctx context.Context, numericalID string, hashID *string, isElectric bool, creatorID int64) (*domain.Bike, error)
+	GetBikeFunc                        func(ctx context.Context, id string) (*domain.Bike, error)
+	GetBikeDetailsFunc                 func(ctx context.Context, id string) (*domain.BikeDetails, error)
+	UpdateBikeFunc                     func(ctx context.Context, id string, hashID *string, isElectric *bool, actorID int64) error
+	DeleteBikeFunc                     func(ctx context.Context, id string, actorID int64) error
+	ListRatingAggregatesByBikeFunc     func(ctx context.Context, bikeID string) ([]domain.RatingAggregate, error)
+	ListReviewsWithRatingsByBikeFunc   func(ctx context.Context, bikeID string, q domain.ListReviewsQuery) ([]domain.ReviewWithRatings, string, error)
+	CreateReviewWithRatingsFunc        func(ctx context.Context, in domain.CreateReviewInput) (int64, error)
+	UpdateReviewWithRatingsFunc        func(ctx context.Context, in domain.UpdateReviewInput) ([]string, error)
+	GetReviewWithRatingsByIDFunc       func(ctx context.Context, reviewID int64, includeHidden bool) (*domain.ReviewWithRatings, error)
+	DeleteReviewFunc                   func(ctx context.Context, reviewID int64, posterID int64) error
+	ReportReviewFunc                   func(ctx context.Context, reviewID, reporterID int64, reason string) (int64, error)
+	ListOpenReportsFunc                func(ctx context.Context, q domain.ListReportsQuery) ([]domain.ReviewReport, string, error)
+	ModerateReviewFunc                 func(ctx context.Context, reviewID, moderatorID int64, action domain.ModerationAction) error
+	ListAuditEventsFunc                func(ctx context.Context, q domain.ListAuditEventsQuery) ([]domain.AuditEvent, string, error)
+	RestoreBikeFunc                    func(ctx context.Context, id string, actorID int64) error
+	ImportBikesFunc                    func(ctx context.Context, rows []domain.BikeImportRow, creatorID int64, dryRun bool) (*domain.BikeImportResult, error)
+	ExportBikesFunc                    func(ctx context.Context, fn func(domain.BikeExport) error) error
+	RestoreReviewFunc                  func(ctx context.Context, reviewID, actorID int64) error
+	AddReviewPhotoFunc                 func(ctx context.Context, bikeID string, reviewID, posterID int64, imageKey, thumbKey string, caption *string) (*domain.ReviewImage, error)
+	ReportBikeIssueFunc                func(ctx context.Context, bikeID string, reporterID int64, issueType domain.IssueType, note *string) (int64, error)
+	ListBikeIssuesFunc                 func(ctx context.Context, bikeID string, q domain.ListIssuesQuery) ([]domain.BikeIssue, string, error)
+	ConfirmBikeIssueFunc               func(ctx context.Context, issueID, posterID int64, status domain.ConfirmationStatus) (domain.IssueStatus, error)
+	ImportStationsFunc                 func(ctx context.Context, stations []domain.Station) (*domain.StationImportResult, error)
+	GetStationFunc                     func(ctx context.Context, stationID string) (*domain.Station, error)
+	RecordSightingFunc                 func(ctx context.Context, bikeID, stationID string, posterID int64) (int64, error)
+	ListStationsNearFunc               func(ctx context.Context, near domain.GeoQuery, limit int) ([]domain.NearbyStation, error)
+}
+
+func (m *MockService) Register(ctx context.Context, username, email, deviceLabel string) (string, error) {
+	return m.RegisterFunc(ctx, username, email, deviceLabel)
+}
+
+func (m *MockService) CreateMagicLink(ctx context.Context, email, deviceLabel string) (string, string, error) {
+	return m.CreateMagicLinkFunc(ctx, email, deviceLabel)
 }
 
 func (m *MockService) ConfirmMagicLink(ctx context.Context, token string) (*domain.ConfirmResult, error) {
@@ -43,19 +71,67 @@ func (m *MockService) GetPosterByAPIToken(ctx context.Context, token string) (*d
 	return m.GetPosterByAPITokenFunc(ctx, token)
 }
 
-func (m *MockService) CheckMagicLinkStatus(ctx context.Context, token string) (string, error) {
+func (m *MockService) CheckMagicLinkStatus(ctx context.Context, token string) (*domain.TokenPair, error) {
 	return m.CheckMagicLinkStatusFunc(ctx, token)
 }
 
-func (m *MockService) DeletePoster(ctx context.Context, posterID int64, deleteContent bool) error {
+func (m *MockService) DeletePoster(ctx context.Context, posterID int64, deleteContent bool) ([]string, error) {
 	if m.DeletePosterFunc != nil {
 		return m.DeletePosterFunc(ctx, posterID, deleteContent)
 	}
-	return nil
+	return nil, nil
 }
 
-func (m *MockService) ListBikes(ctx context.Context) ([]domain.Bike, error) {
-	return m.ListBikesFunc(ctx)
+func (m *MockService) RequestEmailChange(ctx context.Context, posterID int64, newEmail string) (string, error) {
+	return m.RequestEmailChangeFunc(ctx, posterID, newEmail)
+}
+
+func (m *MockService) RefreshSession(ctx context.Context, refreshToken string) (*domain.TokenPair, error) {
+	return m.RefreshSessionFunc(ctx, refreshToken)
+}
+
+func (m *MockService) ListSessions(ctx context.Context, posterID int64) ([]domain.Session, error) {
+	return m.ListSessionsFunc(ctx, posterID)
+}
+
+func (m *MockService) RevokeSession(ctx context.Context, posterID, sessionID int64) error {
+	return m.RevokeSessionFunc(ctx, posterID, sessionID)
+}
+
+func (m *MockService) RevokeAllSessions(ctx context.Context, posterID int64) (int64, error) {
+	return m.RevokeAllSessionsFunc(ctx, posterID)
+}
+
+func (m *MockService) GetPosterProfile(ctx context.Context, username string) (*domain.PosterProfile, error) {
+	return m.GetPosterProfileFunc(ctx, username)
+}
+
+func (m *MockService) ListReviewsWithRatingsByPoster(ctx context.Context, username string, q domain.ListReviewsQuery) ([]domain.ReviewWithRatings, string, error) {
+	return m.ListReviewsWithRatingsByPosterFunc(ctx, username, q)
+}
+
+func (m *MockService) GetAccount(ctx context.Context, posterID, sessionID int64) (*domain.Account, error) {
+	return m.GetAccountFunc(ctx, posterID, sessionID)
+}
+
+func (m *MockService) UpdateUsername(ctx context.Context, posterID int64, username string) error {
+	return m.UpdateUsernameFunc(ctx, posterID, username)
+}
+
+func (m *MockService) ExportPosterData(ctx context.Context, posterID int64) (*domain.PosterExport, error) {
+	return m.ExportPosterDataFunc(ctx, posterID)
+}
+
+func (m *MockService) CreateDataExport(ctx context.Context, posterID int64, blobKey, format string, sizeBytes int64) (string, error) {
+	return m.CreateDataExportFunc(ctx, posterID, blobKey, format, sizeBytes)
+}
+
+func (m *MockService) GetDataExport(ctx context.Context, token string) (*domain.DataExport, error) {
+	return m.GetDataExportFunc(ctx, token)
+}
+
+func (m *MockService) ListBikes(ctx context.Context, q domain.ListBikesQuery) ([]domain.Bike, string, error) {
+	return m.ListBikesFunc(ctx, q)
 }
 
 func (m *MockService) CreateBike(ctx context.Context, numericalID string, hashID *string, isElectric bool, creatorID int64) (*domain.Bike, error) {
@@ -70,34 +146,98 @@ func (m *MockService) GetBikeDetails(ctx context.Context, id string) (*domain.Bi
 	return m.GetBikeDetailsFunc(ctx, id)
 }
 
-func (m *MockService) UpdateBike(ctx context.Context, id string, hashID *string, isElectric *bool) error {
-	return m.UpdateBikeFunc(ctx, id, hashID, isElectric)
+func (m *MockService) UpdateBike(ctx context.Context, id string, hashID *string, isElectric *bool, actorID int64) error {
+	return m.UpdateBikeFunc(ctx, id, hashID, isElectric, actorID)
 }
 
-func (m *MockService) DeleteBike(ctx context.Context, id string) error {
-	return m.DeleteBikeFunc(ctx, id)
+func (m *MockService) DeleteBike(ctx context.Context, id string, actorID int64) error {
+	return m.DeleteBikeFunc(ctx, id, actorID)
 }
 
 func (m *MockService) ListRatingAggregatesByBike(ctx context.Context, bikeID string) ([]domain.RatingAggregate, error) {
 	return m.ListRatingAggregatesByBikeFunc(ctx, bikeID)
 }
 
-func (m *MockService) ListReviewsWithRatingsByBike(ctx context.Context, bikeID string) ([]domain.ReviewWithRatings, error) {
-	return m.ListReviewsWithRatingsByBikeFunc(ctx, bikeID)
+func (m *MockService) ListReviewsWithRatingsByBike(ctx context.Context, bikeID string, q domain.ListReviewsQuery) ([]domain.ReviewWithRatings, string, error) {
+	return m.ListReviewsWithRatingsByBikeFunc(ctx, bikeID, q)
 }
 
 func (m *MockService) CreateReviewWithRatings(ctx context.Context, in domain.CreateReviewInput) (int64, error) {
 	return m.CreateReviewWithRatingsFunc(ctx, in)
 }
 
-func (m *MockService) UpdateReviewWithRatings(ctx context.Context, in domain.UpdateReviewInput) error {
+func (m *MockService) UpdateReviewWithRatings(ctx context.Context, in domain.UpdateReviewInput) ([]string, error) {
 	return m.UpdateReviewWithRatingsFunc(ctx, in)
 }
 
-func (m *MockService) GetReviewWithRatingsByID(ctx context.Context, reviewID int64) (*domain.ReviewWithRatings, error) {
-	return m.GetReviewWithRatingsByIDFunc(ctx, reviewID)
+func (m *MockService) GetReviewWithRatingsByID(ctx context.Context, reviewID int64, includeHidden bool) (*domain.ReviewWithRatings, error) {
+	return m.GetReviewWithRatingsByIDFunc(ctx, reviewID, includeHidden)
 }
 
 func (m *MockService) DeleteReview(ctx context.Context, reviewID int64, posterID int64) error {
 	return m.DeleteReviewFunc(ctx, reviewID, posterID)
 }
+
+func (m *MockService) ReportReview(ctx context.Context, reviewID, reporterID int64, reason string) (int64, error) {
+	return m.ReportReviewFunc(ctx, reviewID, reporterID, reason)
+}
+
+func (m *MockService) ListOpenReports(ctx context.Context, q domain.ListReportsQuery) ([]domain.ReviewReport, string, error) {
+	return m.ListOpenReportsFunc(ctx, q)
+}
+
+func (m *MockService) ModerateReview(ctx context.Context, reviewID, moderatorID int64, action domain.ModerationAction) error {
+	return m.ModerateReviewFunc(ctx, reviewID, moderatorID, action)
+}
+
+func (m *MockService) ListAuditEvents(ctx context.Context, q domain.ListAuditEventsQuery) ([]domain.AuditEvent, string, error) {
+	return m.ListAuditEventsFunc(ctx, q)
+}
+
+func (m *MockService) RestoreBike(ctx context.Context, id string, actorID int64) error {
+	return m.RestoreBikeFunc(ctx, id, actorID)
+}
+
+func (m *MockService) ImportBikes(ctx context.Context, rows []domain.BikeImportRow, creatorID int64, dryRun bool) (*domain.BikeImportResult, error) {
+	return m.ImportBikesFunc(ctx, rows, creatorID, dryRun)
+}
+
+func (m *MockService) ExportBikes(ctx context.Context, fn func(domain.BikeExport) error) error {
+	return m.ExportBikesFunc(ctx, fn)
+}
+
+func (m *MockService) RestoreReview(ctx context.Context, reviewID, actorID int64) error {
+	return m.RestoreReviewFunc(ctx, reviewID, actorID)
+}
+
+func (m *MockService) AddReviewPhoto(ctx context.Context, bikeID string, reviewID, posterID int64, imageKey, thumbKey string, caption *string) (*domain.ReviewImage, error) {
+	return m.AddReviewPhotoFunc(ctx, bikeID, reviewID, posterID, imageKey, thumbKey, caption)
+}
+
+func (m *MockService) ReportBikeIssue(ctx context.Context, bikeID string, reporterID int64, issueType domain.IssueType, note *string) (int64, error) {
+	return m.ReportBikeIssueFunc(ctx, bikeID, reporterID, issueType, note)
+}
+
+func (m *MockService) ListBikeIssues(ctx context.Context, bikeID string, q domain.ListIssuesQuery) ([]domain.BikeIssue, string, error) {
+	return m.ListBikeIssuesFunc(ctx, bikeID, q)
+}
+
+func (m *MockService) ConfirmBikeIssue(ctx context.Context, issueID, posterID int64, status domain.ConfirmationStatus) (domain.IssueStatus, error) {
+	return m.ConfirmBikeIssueFunc(ctx, issueID, posterID, status)
+}
+
+func (m *MockService) ImportStations(ctx context.Context, stations []domain.Station) (*domain.StationImportResult, error) {
+	return m.ImportStationsFunc(ctx, stations)
+}
+
+func (m *MockService) GetStation(ctx context.Context, stationID string) (*domain.Station, error) {
+	return m.GetStationFunc(ctx, stationID)
+}
+
+func (m *MockService) RecordSighting(ctx context.Context, bikeID, stationID string, posterID int64) (int64, error) {
+	return m.RecordSightingFunc(ctx, bikeID, stationID, posterID)
+}
+
+func (m *MockService) ListStationsNear(ctx context.Context, near domain.GeoQuery, limit int) ([]domain.NearbyStation, error) {
+	return m.ListStationsNearFunc(ctx, near, limit)
+}
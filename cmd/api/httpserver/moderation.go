@@ -0,0 +1,167 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/scardozos/rottenbikes/cmd/api/validate"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+type reportReviewRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (req reportReviewRequest) validate() error {
+	var v validate.Validator
+	reason := strings.TrimSpace(req.Reason)
+	v.Check(reason != "" && validate.MaxLen(reason, domain.MaxReportReasonLen),
+		"reason", fmt.Sprintf("must be between 1 and %d characters", domain.MaxReportReasonLen))
+	return v.Err()
+}
+
+// POST /reviews/{id}/reports → flag a review for moderators
+func (s *HTTPServer) handleReportReview(w http.ResponseWriter, r *http.Request, reviewID int64) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	posterID, ok := posterIDFromContext(r.Context())
+	if !ok {
+		s.sendError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req reportReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(); err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	reportID, err := s.service.ReportReview(ctx, reviewID, posterID, req.Reason)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"report_id": reportID,
+	})
+}
+
+// /moderation/...
+func (s *HTTPServer) handleModerationSubroutes(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/moderation/")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	switch {
+	case len(parts) == 1 && parts[0] == "reports":
+		s.handleListReports(w, r)
+		return
+	case len(parts) >= 2 && len(parts) <= 3 && parts[0] == "reviews":
+		reviewID, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			s.sendError(w, "invalid review id", http.StatusBadRequest)
+			return
+		}
+
+		// DELETE /moderation/reviews/{id}, POST /moderation/reviews/{id}/{hide,unhide}
+		var action domain.ModerationAction
+		method := http.MethodPost
+		if len(parts) == 2 {
+			action, method = domain.ModerationDelete, http.MethodDelete
+		} else if parts[2] == "hide" || parts[2] == "unhide" {
+			action = domain.ModerationAction(parts[2])
+		} else {
+			break
+		}
+
+		if r.Method != method {
+			s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.handleModerateReview(w, r, reviewID, action)
+		return
+	}
+
+	s.sendError(w, "not found", http.StatusNotFound)
+}
+
+type listReportsResponse struct {
+	Reports    []domain.ReviewReport `json:"reports"`
+	NextCursor *string               `json:"next_cursor"`
+}
+
+// GET /moderation/reports → open reports, oldest first
+func (s *HTTPServer) handleListReports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cursor, limit, err := parsePageParams(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	reports, next, err := s.service.ListOpenReports(ctx, domain.ListReportsQuery{Cursor: cursor, Limit: limit})
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	if reports == nil {
+		reports = []domain.ReviewReport{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(listReportsResponse{
+		Reports:    reports,
+		NextCursor: nextCursor(next),
+	})
+}
+
+func (s *HTTPServer) handleModerateReview(w http.ResponseWriter, r *http.Request, reviewID int64, action domain.ModerationAction) {
+	moderatorID, ok := posterIDFromContext(r.Context())
+	if !ok {
+		s.sendError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := s.service.ModerateReview(ctx, reviewID, moderatorID, action); err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	zerolog.Ctx(r.Context()).Info().
+		Int64("review_id", reviewID).
+		Int64("moderator_id", moderatorID).
+		Str("action", string(action)).
+		Msg("review moderated")
+
+	w.WriteHeader(http.StatusNoContent)
+}
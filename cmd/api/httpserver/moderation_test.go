@@ -0,0 +1,195 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/scardozos/rottenbikes/cmd/api/email"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+func moderationAuth(ctx context.Context, token string) (*domain.AuthPoster, error) {
+	if token == "moderator_token" {
+		return &domain.AuthPoster{PosterID: 3, Role: domain.RoleModerator}, nil
+	}
+	return &domain.AuthPoster{PosterID: 1, Role: domain.RoleUser}, nil
+}
+
+func TestHandleReportReview(t *testing.T) {
+	mockService := &MockService{
+		GetPosterByAPITokenFunc: moderationAuth,
+	}
+
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	report := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/reviews/7/reports", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		var gotReview, gotReporter int64
+		var gotReason string
+		mockService.ReportReviewFunc = func(ctx context.Context, reviewID, reporterID int64, reason string) (int64, error) {
+			gotReview, gotReporter, gotReason = reviewID, reporterID, reason
+			return 11, nil
+		}
+
+		w := report(`{"reason":"spam"}`)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d", w.Code)
+		}
+		if gotReview != 7 || gotReporter != 1 || gotReason != "spam" {
+			t.Errorf("unexpected call: review %d, reporter %d, reason %q", gotReview, gotReporter, gotReason)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		cases := map[error]int{
+			domain.ErrInvalidReportReason: http.StatusUnprocessableEntity,
+			sql.ErrNoRows:                 http.StatusNotFound,
+			domain.ErrAlreadyReported:     http.StatusConflict,
+		}
+		for serr, status := range cases {
+			mockService.ReportReviewFunc = func(ctx context.Context, reviewID, reporterID int64, reason string) (int64, error) {
+				return 0, serr
+			}
+			if w := report(`{"reason":"x"}`); w.Code != status {
+				t.Errorf("%v: expected status %d, got %d", serr, status, w.Code)
+			}
+		}
+	})
+
+	t.Run("invalid_reason", func(t *testing.T) {
+		mockService.ReportReviewFunc = func(ctx context.Context, reviewID, reporterID int64, reason string) (int64, error) {
+			t.Error("expected the report to be rejected before reaching the service")
+			return 0, nil
+		}
+		long, _ := json.Marshal(map[string]string{"reason": strings.Repeat("ñ", domain.MaxReportReasonLen+1)})
+		for _, body := range []string{`{"reason":"  "}`, string(long)} {
+			if fields := invalidFields(t, report(body)); len(fields) != 1 || fields[0] != "reason" {
+				t.Errorf("expected reason to be reported, got %v", fields)
+			}
+		}
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/reviews/7/reports", bytes.NewBufferString(`{"reason":"spam"}`))
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", w.Code)
+		}
+	})
+}
+
+func TestHandleListReports(t *testing.T) {
+	mockService := &MockService{
+		GetPosterByAPITokenFunc: moderationAuth,
+		ListOpenReportsFunc: func(ctx context.Context, q domain.ListReportsQuery) ([]domain.ReviewReport, string, error) {
+			return []domain.ReviewReport{{ReportID: 1, ReviewID: 7, Reason: "spam"}}, "next", nil
+		},
+	}
+
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	t.Run("forbidden_for_users", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/moderation/reports", nil)
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected status 403, got %d", w.Code)
+		}
+	})
+
+	t.Run("success", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/moderation/reports?limit=1", nil)
+		req.Header.Set("Authorization", "Bearer moderator_token")
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		var resp listReportsResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if len(resp.Reports) != 1 || resp.NextCursor == nil || *resp.NextCursor != "next" {
+			t.Errorf("unexpected response: %+v", resp)
+		}
+	})
+}
+
+func TestHandleModerateReview(t *testing.T) {
+	var gotAction domain.ModerationAction
+	var gotModerator int64
+	mockService := &MockService{
+		GetPosterByAPITokenFunc: moderationAuth,
+		ModerateReviewFunc: func(ctx context.Context, reviewID, moderatorID int64, action domain.ModerationAction) error {
+			if reviewID == 404 {
+				return sql.ErrNoRows
+			}
+			gotAction, gotModerator = action, moderatorID
+			return nil
+		},
+	}
+
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	tests := []struct {
+		method, path string
+		status       int
+		action       domain.ModerationAction
+	}{
+		{http.MethodPost, "/moderation/reviews/7/hide", http.StatusNoContent, domain.ModerationHide},
+		{http.MethodPost, "/moderation/reviews/7/unhide", http.StatusNoContent, domain.ModerationUnhide},
+		{http.MethodDelete, "/moderation/reviews/7", http.StatusNoContent, domain.ModerationDelete},
+		{http.MethodGet, "/moderation/reviews/7/hide", http.StatusMethodNotAllowed, ""},
+		{http.MethodPost, "/moderation/reviews/7/pin", http.StatusNotFound, ""},
+		{http.MethodPost, "/moderation/reviews/404/hide", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			gotAction, gotModerator = "", 0
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer moderator_token")
+			w := httptest.NewRecorder()
+
+			srv.server.Handler.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, w.Code)
+			}
+			if tt.action != "" && (gotAction != tt.action || gotModerator != 3) {
+				t.Errorf("expected %s by moderator 3, got %s by %d", tt.action, gotAction, gotModerator)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+const (
+	defaultNearbyRadiusM = 500
+	// defaultNearbyMaxAge is how recent a sighting must be for GET
+	// /bikes/nearby when seen_after isn't given; older ones say little about
+	// where a shared bike is now.
+	defaultNearbyMaxAge = 24 * time.Hour
+)
+
+var errInvalidLocation = errors.New("lat and lon are required; radius is in metres, at most 10000")
+
+// parseGeoQuery reads ?lat=&lon=&radius=. The range checks are left to the
+// store.
+func parseGeoQuery(r *http.Request) (domain.GeoQuery, error) {
+	params := r.URL.Query()
+	g := domain.GeoQuery{RadiusM: defaultNearbyRadiusM}
+
+	var err error
+	if g.Lat, err = strconv.ParseFloat(params.Get("lat"), 64); err != nil {
+		return g, errInvalidLocation
+	}
+	if g.Lon, err = strconv.ParseFloat(params.Get("lon"), 64); err != nil {
+		return g, errInvalidLocation
+	}
+	if v := params.Get("radius"); v != "" {
+		if g.RadiusM, err = strconv.ParseFloat(v, 64); err != nil {
+			return g, errInvalidLocation
+		}
+	}
+	return g, nil
+}
+
+// GET /bikes/nearby?lat=&lon=&radius= → recently sighted bikes around a point
+//
+// Bikes are ranked by distance to the station they were last seen at, then by
+// overall rating. Takes the GET /bikes filters plus seen_after, which defaults
+// to 24 hours ago.
+func (s *HTTPServer) handleNearbyBikes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	near, err := parseGeoQuery(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q, err := parseListBikesQuery(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q.Near = &near
+	if q.SeenAfter == nil {
+		since := time.Now().Add(-defaultNearbyMaxAge)
+		q.SeenAfter = &since
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	bikes, cursor, err := s.service.ListBikes(ctx, q)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	if bikes == nil {
+		bikes = []domain.Bike{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(listBikesResponse{
+		Bikes:      bikes,
+		NextCursor: nextCursor(cursor),
+	})
+}
+
+type nearbyStationsResponse struct {
+	Stations []domain.NearbyStation `json:"stations"`
+}
+
+// GET /stations/nearby?lat=&lon=&radius=&limit= → stations around a point,
+// nearest first
+func (s *HTTPServer) handleNearbyStations(w http.ResponseWriter, r *http.Request) {
+	near, err := parseGeoQuery(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, limit, err := parsePageParams(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	stations, err := s.service.ListStationsNear(ctx, near, limit)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	if stations == nil {
+		stations = []domain.NearbyStation{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(nearbyStationsResponse{Stations: stations})
+}
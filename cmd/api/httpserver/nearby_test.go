@@ -0,0 +1,126 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/scardozos/rottenbikes/cmd/api/email"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+func TestHandleNearbyBikes(t *testing.T) {
+	var got domain.ListBikesQuery
+	mockService := &MockService{
+		ListBikesFunc: func(ctx context.Context, q domain.ListBikesQuery) ([]domain.Bike, string, error) {
+			got = q
+			dist := 120.5
+			return []domain.Bike{{NumericalID: "0101", DistanceM: &dist}}, "", nil
+		},
+	}
+
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	get := func(url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		before := time.Now().Add(-defaultNearbyMaxAge)
+		w := get("/bikes/nearby?lat=41.3874&lon=2.1686&is_electric=true")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if got.Near == nil || got.Near.Lat != 41.3874 || got.Near.Lon != 2.1686 || got.Near.RadiusM != defaultNearbyRadiusM {
+			t.Errorf("unexpected location %+v", got.Near)
+		}
+		if got.SeenAfter == nil || got.SeenAfter.Before(before) {
+			t.Errorf("expected sightings from the last day, got %v", got.SeenAfter)
+		}
+		if got.IsElectric == nil || !*got.IsElectric {
+			t.Error("expected is_electric filter to be passed on")
+		}
+
+		var resp listBikesResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Bikes) != 1 || resp.Bikes[0].DistanceM == nil {
+			t.Errorf("unexpected response %+v", resp)
+		}
+	})
+
+	t.Run("explicit_radius_and_age", func(t *testing.T) {
+		w := get("/bikes/nearby?lat=41.3874&lon=2.1686&radius=2000&seen_after=2025-03-01T00:00:00Z")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		if got.Near.RadiusM != 2000 || !got.SeenAfter.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected query %+v", got)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, url := range []string{
+			"/bikes/nearby",
+			"/bikes/nearby?lat=41.38",
+			"/bikes/nearby?lat=abc&lon=2.16",
+			"/bikes/nearby?lat=41.38&lon=2.16&radius=far",
+		} {
+			if w := get(url); w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", url, w.Code)
+			}
+		}
+
+		mockService.ListBikesFunc = func(ctx context.Context, q domain.ListBikesQuery) ([]domain.Bike, string, error) {
+			return nil, "", domain.ErrInvalidLocation
+		}
+		if w := get("/bikes/nearby?lat=41.38&lon=2.16&radius=50000"); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 for an out of range radius, got %d", w.Code)
+		}
+	})
+}
+
+func TestHandleNearbyStations(t *testing.T) {
+	var gotNear domain.GeoQuery
+	var gotLimit int
+	mockService := &MockService{
+		ListStationsNearFunc: func(ctx context.Context, near domain.GeoQuery, limit int) ([]domain.NearbyStation, error) {
+			gotNear, gotLimit = near, limit
+			return []domain.NearbyStation{{Station: domain.Station{StationID: "1"}, DistanceM: 80, BikeCount: 2}}, nil
+		},
+	}
+
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/stations/nearby?lat=41.3874&lon=2.1686&radius=300&limit=5", nil)
+	w := httptest.NewRecorder()
+	srv.server.Handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if gotNear.RadiusM != 300 || gotLimit != 5 {
+		t.Errorf("unexpected call: %+v, limit %d", gotNear, gotLimit)
+	}
+
+	var resp nearbyStationsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Stations) != 1 || resp.Stations[0].BikeCount != 2 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
@@ -0,0 +1,31 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+var errInvalidLimit = errors.New("limit must be a positive integer")
+
+// parsePageParams reads the keyset pagination parameters (?cursor=&limit=).
+// A missing limit is returned as 0 and left for the store to default.
+func parsePageParams(r *http.Request) (cursor string, limit int, err error) {
+	q := r.URL.Query()
+	cursor = q.Get("cursor")
+	if v := q.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return "", 0, errInvalidLimit
+		}
+	}
+	return cursor, limit, nil
+}
+
+// nextCursor turns the store's "no more pages" empty cursor into a JSON null.
+func nextCursor(c string) *string {
+	if c == "" {
+		return nil
+	}
+	return &c
+}
@@ -0,0 +1,223 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/scardozos/rottenbikes/cmd/api/blob"
+	"github.com/scardozos/rottenbikes/cmd/api/imaging"
+	"github.com/scardozos/rottenbikes/cmd/api/validate"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+// maxPhotoBytes caps a single uploaded photo. The request body may be a bit
+// larger to make room for the multipart framing.
+const maxPhotoBytes = 10 << 20
+
+// POST /bikes/{id}/reviews/{rid}/images → add a photo to one's own review
+//
+// Takes multipart/form-data with the JPEG or PNG in the "image" field and an
+// optional "caption" field before it. The photo is re-encoded without
+// metadata, a thumbnail is stored next to it, and it goes after the review's
+// other images.
+func (s *HTTPServer) handleUploadReviewPhoto(w http.ResponseWriter, r *http.Request, bikeID string, reviewID int64) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.blobs == nil {
+		s.sendError(w, "photo uploads are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if !validate.Numeric(bikeID) {
+		s.sendError(w, "invalid bike id", http.StatusBadRequest)
+		return
+	}
+
+	posterID, ok := posterIDFromContext(r.Context())
+	if !ok {
+		s.sendError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxPhotoBytes+64<<10)
+	data, caption, err := readPhotoUpload(r)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge), errors.Is(err, errPhotoTooLarge):
+			s.sendError(w, "image must be at most 10 MB", http.StatusRequestEntityTooLarge)
+		default:
+			s.sendError(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	photo, err := imaging.Process(data)
+	if err != nil {
+		switch {
+		case errors.Is(err, imaging.ErrUnsupportedType):
+			s.sendError(w, err.Error(), http.StatusUnsupportedMediaType)
+		case errors.Is(err, imaging.ErrTooManyPixels):
+			s.sendError(w, err.Error(), http.StatusRequestEntityTooLarge)
+		case errors.Is(err, imaging.ErrInvalidImage):
+			s.sendError(w, err.Error(), http.StatusBadRequest)
+		default:
+			s.sendInternalServerError(w, r, err)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	id, err := randomBlobID()
+	if err != nil {
+		s.sendInternalServerError(w, r, err)
+		return
+	}
+	imageKey := id + "." + photo.Ext
+	thumbKey := id + "_thumb." + photo.Ext
+
+	if err := s.blobs.Put(ctx, imageKey, bytes.NewReader(photo.Full)); err != nil {
+		s.sendInternalServerError(w, r, err)
+		return
+	}
+	if err := s.blobs.Put(ctx, thumbKey, bytes.NewReader(photo.Thumb)); err != nil {
+		s.deleteBlobs(r, imageKey)
+		s.sendInternalServerError(w, r, err)
+		return
+	}
+
+	img, err := s.service.AddReviewPhoto(ctx, bikeID, reviewID, posterID, imageKey, thumbKey, caption)
+	if err != nil {
+		s.deleteBlobs(r, imageKey, thumbKey)
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	zerolog.Ctx(r.Context()).Info().
+		Int64("review_id", reviewID).
+		Str("image_key", imageKey).
+		Int("bytes", len(photo.Full)).
+		Msg("review photo uploaded")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(img)
+}
+
+var errPhotoTooLarge = errors.New("image too large")
+
+// readPhotoUpload returns the "image" part and the optional "caption" part
+// sent before it, streaming the body instead of spooling it to temporary
+// files.
+func readPhotoUpload(r *http.Request) ([]byte, *string, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, nil, errors.New("expected multipart/form-data")
+	}
+	var caption *string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, nil, errors.New("image is required")
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		switch part.FormName() {
+		case "caption":
+			b, err := io.ReadAll(io.LimitReader(part, 4<<10))
+			if err != nil {
+				return nil, nil, err
+			}
+			if c := strings.TrimSpace(string(b)); c != "" {
+				caption = &c
+			}
+		case "image":
+			data, err := io.ReadAll(io.LimitReader(part, maxPhotoBytes+1))
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(data) > maxPhotoBytes {
+				return nil, nil, errPhotoTooLarge
+			}
+			return data, caption, nil
+		}
+	}
+}
+
+// deleteBlobs removes blobs on a best-effort basis; a failure only leaves an
+// orphaned file behind, so it is logged rather than returned.
+func (s *HTTPServer) deleteBlobs(r *http.Request, keys ...string) {
+	for _, key := range keys {
+		if key == "" || s.blobs == nil {
+			continue
+		}
+		if err := s.blobs.Delete(context.WithoutCancel(r.Context()), key); err != nil {
+			zerolog.Ctx(r.Context()).Warn().Err(err).Str("key", key).Msg("delete blob error")
+		}
+	}
+}
+
+func randomBlobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+var imageContentTypes = map[string]string{
+	".jpg": "image/jpeg",
+	".png": "image/png",
+}
+
+// GET /images/{key} → a stored review photo or thumbnail
+//
+// Keys are never reused, so responses can be cached indefinitely.
+func (s *HTTPServer) handleGetImage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, domain.ImagePathPrefix)
+	ct, ok := imageContentTypes[path.Ext(key)]
+	if s.blobs == nil || !ok || !blob.ValidKey(key) {
+		s.sendError(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	rc, err := s.blobs.Get(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			s.sendError(w, "not found", http.StatusNotFound)
+			return
+		}
+		s.sendInternalServerError(w, r, err)
+		return
+	}
+	defer rc.Close()
+
+	w.Header().Set("Content-Type", ct)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	if _, err := io.Copy(w, rc); err != nil {
+		zerolog.Ctx(r.Context()).Warn().Err(err).Str("key", key).Msg("serve image error")
+	}
+}
@@ -0,0 +1,175 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/scardozos/rottenbikes/cmd/api/blob"
+	"github.com/scardozos/rottenbikes/cmd/api/email"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+func multipartImage(t *testing.T, data []byte, caption string) (*bytes.Buffer, string) {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if caption != "" {
+		_ = mw.WriteField("caption", caption)
+	}
+	fw, err := mw.CreateFormFile("image", "photo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = fw.Write(data)
+	_ = mw.Close()
+	return &body, mw.FormDataContentType()
+}
+
+func TestHandleUploadReviewPhoto(t *testing.T) {
+	dir := t.TempDir()
+	store, err := blob.NewLocalStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockService := &MockService{
+		GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
+			return &domain.AuthPoster{PosterID: 1, Role: domain.RoleUser}, nil
+		},
+	}
+	srv, err := New(mockService, &email.NoopSender{}, store, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+
+	upload := func(data []byte, caption string) *httptest.ResponseRecorder {
+		body, ct := multipartImage(t, data, caption)
+		req := httptest.NewRequest(http.MethodPost, "/bikes/0101/reviews/7/images", body)
+		req.Header.Set("Content-Type", ct)
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		var gotImage, gotThumb string
+		var gotCaption *string
+		mockService.AddReviewPhotoFunc = func(ctx context.Context, bikeID string, reviewID, posterID int64, imageKey, thumbKey string, caption *string) (*domain.ReviewImage, error) {
+			if bikeID != "0101" || reviewID != 7 || posterID != 1 {
+				t.Errorf("unexpected call: bike %s, review %d, poster %d", bikeID, reviewID, posterID)
+			}
+			gotImage, gotThumb, gotCaption = imageKey, thumbKey, caption
+			thumb := "/images/" + thumbKey
+			return &domain.ReviewImage{ImageID: 5, Position: 1, URL: "/images/" + imageKey, ThumbURL: &thumb, Caption: caption}, nil
+		}
+
+		w := upload(pngData.Bytes(), "torn seat")
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		if gotCaption == nil || *gotCaption != "torn seat" {
+			t.Errorf("expected caption to be passed on, got %v", gotCaption)
+		}
+		if !strings.HasSuffix(gotImage, ".png") || !strings.HasSuffix(gotThumb, "_thumb.png") {
+			t.Errorf("unexpected keys %q, %q", gotImage, gotThumb)
+		}
+
+		var resp domain.ReviewImage
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.ImageID != 5 || resp.ThumbURL == nil {
+			t.Fatalf("unexpected response %+v", resp)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, *resp.ThumbURL, nil)
+		w = httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200 serving thumbnail, got %d", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+			t.Errorf("expected image/png, got %s", ct)
+		}
+	})
+
+	t.Run("too_many_images", func(t *testing.T) {
+		mockService.AddReviewPhotoFunc = func(ctx context.Context, bikeID string, reviewID, posterID int64, imageKey, thumbKey string, caption *string) (*domain.ReviewImage, error) {
+			return nil, domain.ErrTooManyReviewImages
+		}
+		if w := upload(pngData.Bytes(), ""); w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("expected status 422, got %d", w.Code)
+		}
+	})
+
+	t.Run("unsupported_type", func(t *testing.T) {
+		if w := upload([]byte("GIF89a...."), ""); w.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("expected status 415, got %d", w.Code)
+		}
+	})
+
+	t.Run("too_large", func(t *testing.T) {
+		if w := upload(make([]byte, maxPhotoBytes+1), ""); w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("expected status 413, got %d", w.Code)
+		}
+	})
+
+	t.Run("not_found_cleans_up", func(t *testing.T) {
+		before, _ := os.ReadDir(dir)
+		mockService.AddReviewPhotoFunc = func(ctx context.Context, bikeID string, reviewID, posterID int64, imageKey, thumbKey string, caption *string) (*domain.ReviewImage, error) {
+			return nil, sql.ErrNoRows
+		}
+
+		if w := upload(pngData.Bytes(), ""); w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+		if after, _ := os.ReadDir(dir); len(after) != len(before) {
+			t.Errorf("expected uploaded blobs to be removed, had %d files, now %d", len(before), len(after))
+		}
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/bikes/0101/reviews/7/images", nil)
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", w.Code)
+		}
+	})
+}
+
+func TestHandleGetImage(t *testing.T) {
+	store, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := New(&MockService{}, &email.NoopSender{}, store, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	for _, path := range []string{"/images/missing.jpg", "/images/..%2fsecret.jpg", "/images/notes.txt"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404, got %d", path, w.Code)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// /posters/{username}...
+func (s *HTTPServer) handlePosterSubroutes(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/posters/")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	if parts[0] == "" {
+		s.sendError(w, "not found", http.StatusNotFound)
+		return
+	}
+	username := parts[0]
+
+	switch {
+	case len(parts) == 1:
+		s.handleGetPosterProfile(w, r, username)
+	case len(parts) == 2 && parts[1] == "reviews":
+		s.handleListPosterReviews(w, r, username)
+	default:
+		s.sendError(w, "not found", http.StatusNotFound)
+	}
+}
+
+// GET /posters/{username} → public profile and stats
+func (s *HTTPServer) handleGetPosterProfile(w http.ResponseWriter, r *http.Request, username string) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	profile, err := s.service.GetPosterProfile(ctx, username)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(profile)
+}
+
+// GET /posters/{username}/reviews → paginated reviews written by the poster
+//
+// Accepts the same query parameters as GET /bikes/{id}/reviews.
+func (s *HTTPServer) handleListPosterReviews(w http.ResponseWriter, r *http.Request, username string) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q, err := parseListReviewsQuery(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	reviews, cursor, err := s.service.ListReviewsWithRatingsByPoster(ctx, username, q)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	s.sendReviewPage(w, r, reviews, cursor)
+}
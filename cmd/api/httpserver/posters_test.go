@@ -0,0 +1,129 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/scardozos/rottenbikes/cmd/api/email"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+func TestHandleGetPosterProfile(t *testing.T) {
+	mockService := &MockService{
+		GetPosterProfileFunc: func(ctx context.Context, username string) (*domain.PosterProfile, error) {
+			if username == "alice" {
+				return &domain.PosterProfile{PosterID: 1, Username: "alice", ReviewCount: 3}, nil
+			}
+			return nil, domain.ErrUserNotFound
+		},
+	}
+
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/posters/alice", nil)
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+
+		var resp map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp["review_count"] != float64(3) {
+			t.Errorf("expected review_count 3, got %v", resp["review_count"])
+		}
+		if _, ok := resp["email"]; ok {
+			t.Error("public profile must not expose email")
+		}
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/posters/nobody", nil)
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("method_not_allowed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/posters/alice", nil)
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("expected status 405, got %d", w.Code)
+		}
+	})
+}
+
+func TestHandleListPosterReviews(t *testing.T) {
+	mockService := &MockService{
+		ListReviewsWithRatingsByPosterFunc: func(ctx context.Context, username string, q domain.ListReviewsQuery) ([]domain.ReviewWithRatings, string, error) {
+			if username != "alice" {
+				return nil, "", domain.ErrUserNotFound
+			}
+			return []domain.ReviewWithRatings{{ReviewID: 1, PosterUsername: "alice"}}, "more", nil
+		},
+	}
+
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/posters/alice/reviews?sort=oldest", nil)
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+
+		var resp listReviewsResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(resp.Reviews) != 1 || resp.NextCursor == nil || *resp.NextCursor != "more" {
+			t.Errorf("unexpected page %+v", resp)
+		}
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/posters/nobody/reviews", nil)
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("unknown_subroute", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/posters/alice/bikes", nil)
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package httpserver
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+
+	"github.com/scardozos/rottenbikes/cmd/api/ratelimit"
+)
+
+var httpRateLimitedTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "http_rate_limited_total",
+		Help: "Requests rejected by a rate limit policy",
+	},
+	[]string{"policy"},
+)
+
+// Option configures optional parts of the server in New.
+type Option func(*HTTPServer)
+
+// WithRateLimits applies policies using limiter. Without it nothing is
+// rate limited. With trustProxy set, the client IP is taken from the last
+// X-Forwarded-For entry, which only the proxy in front of the API can set.
+func WithRateLimits(limiter ratelimit.RateLimiter, policies ratelimit.Policies, trustProxy bool) Option {
+	return func(s *HTTPServer) {
+		s.limiter = limiter
+		s.policies = policies
+		s.trustProxy = trustProxy
+	}
+}
+
+// rateLimit takes a token from the named policy's bucket for the caller:
+// the poster when the request is authenticated, the client IP otherwise.
+func (s *HTTPServer) rateLimit(policy string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := s.policies[policy]
+		if s.limiter == nil || !ok || !p.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		d, err := s.limiter.Allow(r.Context(), policy+":"+s.rateLimitSubject(r), p)
+		if err != nil {
+			// Fail open: a broken limiter shouldn't take the API down with it.
+			zerolog.Ctx(r.Context()).Error().Err(err).Str("policy", policy).Msg("rate limiter failed")
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(d.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+		h.Set("RateLimit-Reset", ceilSeconds(d.Reset))
+		h.Set("RateLimit-Policy", strconv.Itoa(p.Limit)+";w="+ceilSeconds(p.Window))
+		if !d.Allowed {
+			httpRateLimitedTotal.WithLabelValues(policy).Inc()
+			h.Set("Retry-After", ceilSeconds(d.RetryAfter))
+			s.sendError(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitGlobal applies the global policy to everything but health
+// checks, which come from the platform rather than clients.
+func (s *HTTPServer) rateLimitGlobal(next http.Handler) http.Handler {
+	limited := s.rateLimit(ratelimit.PolicyGlobal, next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		limited.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitWrites applies the write policy to requests that can change
+// data. It must run inside middlewareAuth.
+func (s *HTTPServer) rateLimitWrites(next http.Handler) http.Handler {
+	limited := s.rateLimit(ratelimit.PolicyWrite, next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+		default:
+			limited.ServeHTTP(w, r)
+		}
+	})
+}
+
+func (s *HTTPServer) rateLimitSubject(r *http.Request) string {
+	if posterID, ok := posterIDFromContext(r.Context()); ok {
+		return "poster:" + strconv.FormatInt(posterID, 10)
+	}
+	return "ip:" + s.clientIP(r)
+}
+
+func (s *HTTPServer) clientIP(r *http.Request) string {
+	if s.trustProxy {
+		if fwd := r.Header.Values("X-Forwarded-For"); len(fwd) > 0 {
+			hops := strings.Split(fwd[len(fwd)-1], ",")
+			if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,134 @@
+package httpserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/scardozos/rottenbikes/cmd/api/email"
+	"github.com/scardozos/rottenbikes/cmd/api/ratelimit"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(ctx context.Context, key string, p ratelimit.Policy) (ratelimit.Decision, error) {
+	return ratelimit.Decision{}, errors.New("limiter down")
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	mockService := &MockService{
+		GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
+			if token == "other" {
+				return &domain.AuthPoster{PosterID: 2, Username: "other"}, nil
+			}
+			return &domain.AuthPoster{PosterID: 1, Username: "me"}, nil
+		},
+		GetStationFunc: func(ctx context.Context, stationID string) (*domain.Station, error) {
+			return nil, sql.ErrNoRows
+		},
+		RevokeSessionFunc: func(ctx context.Context, posterID, sessionID int64) error {
+			return nil
+		},
+	}
+	policies := ratelimit.Policies{
+		ratelimit.PolicyGlobal: {Limit: 3, Window: time.Hour},
+		ratelimit.PolicyWrite:  {Limit: 1, Window: time.Hour},
+	}
+
+	newServer := func(t *testing.T, limiter ratelimit.RateLimiter, trustProxy bool) *HTTPServer {
+		srv, err := New(mockService, &email.NoopSender{}, nil, ":8080", WithRateLimits(limiter, policies, trustProxy))
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		return srv
+	}
+	do := func(srv *HTTPServer, method, path, remoteAddr string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = remoteAddr
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("global_per_ip", func(t *testing.T) {
+		srv := newServer(t, ratelimit.NewMemoryLimiter(), false)
+
+		for i := 0; i < 3; i++ {
+			w := do(srv, http.MethodGet, "/stations/x", "10.0.0.1:1234", nil)
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("request %d: expected status 404, got %d", i, w.Code)
+			}
+			if got := w.Header().Get("RateLimit-Remaining"); got != strconv.Itoa(2-i) {
+				t.Errorf("request %d: expected RateLimit-Remaining %d, got %q", i, 2-i, got)
+			}
+		}
+		if got := do(srv, http.MethodGet, "/stations/x", "10.0.0.1:1234", nil).Header().Get("RateLimit-Policy"); got != "3;w=3600" {
+			t.Errorf("expected RateLimit-Policy 3;w=3600, got %q", got)
+		}
+
+		w := do(srv, http.MethodGet, "/stations/x", "10.0.0.1:5678", nil)
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected status 429, got %d", w.Code)
+		}
+		if got := w.Header().Get("Retry-After"); got != "1200" {
+			t.Errorf("expected Retry-After 1200, got %q", got)
+		}
+
+		if w := do(srv, http.MethodGet, "/stations/x", "10.0.0.2:1234", nil); w.Code != http.StatusNotFound {
+			t.Errorf("other IP: expected status 404, got %d", w.Code)
+		}
+		if w := do(srv, http.MethodGet, "/healthz", "10.0.0.1:1234", nil); w.Code != http.StatusOK {
+			t.Errorf("healthz: expected status 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("forwarded_for", func(t *testing.T) {
+		srv := newServer(t, ratelimit.NewMemoryLimiter(), true)
+		for i := 0; i < 3; i++ {
+			do(srv, http.MethodGet, "/stations/x", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "1.1.1.1, 2.2.2.2"})
+		}
+		if w := do(srv, http.MethodGet, "/stations/x", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "9.9.9.9, 2.2.2.2"}); w.Code != http.StatusTooManyRequests {
+			t.Errorf("spoofed first hop: expected status 429, got %d", w.Code)
+		}
+		if w := do(srv, http.MethodGet, "/stations/x", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "3.3.3.3"}); w.Code != http.StatusNotFound {
+			t.Errorf("other client: expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("writes_per_poster", func(t *testing.T) {
+		srv := newServer(t, ratelimit.NewMemoryLimiter(), false)
+		auth := func(token string) map[string]string {
+			return map[string]string{"Authorization": "Bearer " + token}
+		}
+
+		if w := do(srv, http.MethodDelete, "/me/sessions/1", "10.0.0.1:1", auth("me")); w.Code != http.StatusNoContent {
+			t.Fatalf("expected status 204, got %d", w.Code)
+		}
+		if w := do(srv, http.MethodDelete, "/me/sessions/1", "10.0.0.2:1", auth("me")); w.Code != http.StatusTooManyRequests {
+			t.Errorf("same poster: expected status 429, got %d", w.Code)
+		}
+		if w := do(srv, http.MethodDelete, "/me/sessions/1", "10.0.0.1:1", auth("other")); w.Code != http.StatusNoContent {
+			t.Errorf("other poster: expected status 204, got %d", w.Code)
+		}
+	})
+
+	t.Run("fails_open", func(t *testing.T) {
+		srv := newServer(t, failingLimiter{}, false)
+		w := do(srv, http.MethodGet, "/stations/x", "10.0.0.1:1234", nil)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+		if w.Header().Get("RateLimit-Limit") != "" {
+			t.Error("expected no RateLimit headers when the limiter fails")
+		}
+	})
+}
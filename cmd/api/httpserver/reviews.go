@@ -2,21 +2,26 @@ package httpserver
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/rs/zerolog"
 
+	"github.com/scardozos/rottenbikes/cmd/api/validate"
 	"github.com/scardozos/rottenbikes/internal/domain"
 )
 
 type createReviewRequest struct {
-	PosterID int64   `json:"poster_id"` // ignored/overridden by auth
-	Comment  *string `json:"comment"`
-	BikeImg  *string `json:"bike_img"`
+	PosterID int64                `json:"poster_id"` // ignored/overridden by auth
+	Comment  *string              `json:"comment"`
+	Images   []reviewImageRequest `json:"images"`
+	BikeImg  *string              `json:"bike_img"` // deprecated, same as a single linked image
+	// StationID records where the bike was found; create only.
+	StationID *string `json:"station_id"`
 
 	Overall    *int16 `json:"overall"`
 	Breaks     *int16 `json:"breaks"`
@@ -26,6 +31,78 @@ type createReviewRequest struct {
 	Pedals     *int16 `json:"pedals"`
 }
 
+type reviewImageRequest struct {
+	ImageID *int64  `json:"image_id"`
+	URL     string  `json:"url"`
+	Caption *string `json:"caption"`
+}
+
+// validate checks a new review, or an edit of one when create is false.
+// Whether an image_id belongs to the review is up to the store.
+func (req createReviewRequest) validate(create bool) error {
+	var v validate.Validator
+	v.Check(req.Comment == nil || validate.MaxLen(*req.Comment, domain.MaxCommentLen),
+		"comment", fmt.Sprintf("must be at most %d characters", domain.MaxCommentLen))
+	if req.Images != nil {
+		v.Check(len(req.Images) <= domain.MaxReviewImages,
+			"images", fmt.Sprintf("must have at most %d images", domain.MaxReviewImages))
+		for i, img := range req.Images {
+			field := fmt.Sprintf("images[%d]", i)
+			v.Check(img.Caption == nil || validate.MaxLen(*img.Caption, domain.MaxImageCaptionLen),
+				field+".caption", fmt.Sprintf("must be at most %d characters", domain.MaxImageCaptionLen))
+			switch {
+			case img.ImageID != nil && create:
+				v.Check(false, field+".image_id", "can only be set when editing a review")
+			case img.ImageID != nil:
+				v.Check(img.URL == "", field, "must have an image_id or a url, not both")
+			default:
+				v.Check(domain.ValidImageURL(img.URL), field+".url", "must be an http(s) URL")
+			}
+		}
+	} else if req.BikeImg != nil {
+		v.Check(domain.ValidImageURL(*req.BikeImg), "bike_img", "must be an http(s) URL")
+	}
+	if create {
+		v.Check(req.StationID == nil || validStationID(*req.StationID), "station_id", stationIDRule)
+	}
+	scores := []struct {
+		sub   domain.RatingSubcategory
+		score *int16
+	}{
+		{domain.RatingSubcategoryOverall, req.Overall},
+		{domain.RatingSubcategoryBreaks, req.Breaks},
+		{domain.RatingSubcategorySeat, req.Seat},
+		{domain.RatingSubcategorySturdiness, req.Sturdiness},
+		{domain.RatingSubcategoryPower, req.Power},
+		{domain.RatingSubcategoryPedals, req.Pedals},
+	}
+	for _, sc := range scores {
+		v.Check(validate.Between(sc.score, domain.MinScore, domain.MaxScore),
+			string(sc.sub), fmt.Sprintf("must be between %d and %d", domain.MinScore, domain.MaxScore))
+	}
+	return v.Err()
+}
+
+// images returns the image list of the request, or nil if it has none. On
+// updates nil leaves the review's images alone and an empty list clears them.
+func (req createReviewRequest) images() []domain.ReviewImageInput {
+	if req.Images == nil {
+		if req.BikeImg == nil {
+			return nil
+		}
+		return []domain.ReviewImageInput{{URL: *req.BikeImg}}
+	}
+	out := make([]domain.ReviewImageInput, 0, len(req.Images))
+	for _, img := range req.Images {
+		out = append(out, domain.ReviewImageInput{
+			ImageID: img.ImageID,
+			URL:     img.URL,
+			Caption: img.Caption,
+		})
+	}
+	return out
+}
+
 // POST /bikes/{id}/reviews → create a review with optional subcategory ratings
 func (s *HTTPServer) handleCreateBikeReview(w http.ResponseWriter, r *http.Request, bikeID string) {
 	if r.Method != http.MethodPost {
@@ -33,11 +110,20 @@ func (s *HTTPServer) handleCreateBikeReview(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if !validate.Numeric(bikeID) {
+		s.sendError(w, "invalid bike id", http.StatusBadRequest)
+		return
+	}
+
 	var req createReviewRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		s.sendError(w, "invalid JSON", http.StatusBadRequest)
 		return
 	}
+	if err := req.validate(true); err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
 
 	posterID, ok := posterIDFromContext(r.Context())
 	if !ok {
@@ -52,7 +138,8 @@ func (s *HTTPServer) handleCreateBikeReview(w http.ResponseWriter, r *http.Reque
 		PosterID:   posterID,
 		BikeID:     bikeID,
 		Comment:    req.Comment,
-		BikeImg:    req.BikeImg,
+		Images:     req.images(),
+		StationID:  req.StationID,
 		Overall:    req.Overall,
 		Breaks:     req.Breaks,
 		Seat:       req.Seat,
@@ -61,17 +148,7 @@ func (s *HTTPServer) handleCreateBikeReview(w http.ResponseWriter, r *http.Reque
 		Pedals:     req.Pedals,
 	})
 	if err != nil {
-		if errors.Is(err, domain.ErrTooFrequentReview) {
-			s.sendError(w, "you can only review this bike every 10 minutes", http.StatusTooManyRequests)
-			return
-		}
-		if errors.Is(err, domain.ErrHourlyRateLimitExceeded) {
-			s.sendError(w, "you have reached the hourly limit of 5 reviews", http.StatusTooManyRequests)
-			return
-		}
-
-		zerolog.Ctx(r.Context()).Error().Err(err).Str("bike_id", bikeID).Msg("create review error")
-		s.sendError(w, "internal server error", http.StatusInternalServerError)
+		s.sendDomainError(w, r, err)
 		return
 	}
 
@@ -81,6 +158,88 @@ func (s *HTTPServer) handleCreateBikeReview(w http.ResponseWriter, r *http.Reque
 	})
 }
 
+type listReviewsResponse struct {
+	Reviews    []domain.ReviewWithRatings `json:"reviews"`
+	NextCursor *string                    `json:"next_cursor"`
+}
+
+// GET /bikes/{id}/reviews → paginated reviews of a bike
+//
+// Query parameters: cursor, limit, sort (newest, oldest, highest, lowest;
+// defaults to newest) and has_comment (true, false).
+func (s *HTTPServer) handleListBikeReviews(w http.ResponseWriter, r *http.Request, bikeID string) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !validate.Numeric(bikeID) {
+		s.sendError(w, "invalid bike id", http.StatusBadRequest)
+		return
+	}
+
+	q, err := parseListReviewsQuery(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	reviews, cursor, err := s.service.ListReviewsWithRatingsByBike(ctx, bikeID, q)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	s.sendReviewPage(w, r, reviews, cursor)
+}
+
+func (s *HTTPServer) sendReviewPage(w http.ResponseWriter, r *http.Request, reviews []domain.ReviewWithRatings, cursor string) {
+	if reviews == nil {
+		reviews = []domain.ReviewWithRatings{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(listReviewsResponse{
+		Reviews:    reviews,
+		NextCursor: nextCursor(cursor),
+	}); err != nil {
+		zerolog.Ctx(r.Context()).Error().Err(err).Msg("encode reviews error")
+	}
+}
+
+func parseListReviewsQuery(r *http.Request) (domain.ListReviewsQuery, error) {
+	var q domain.ListReviewsQuery
+	var err error
+
+	q.Cursor, q.Limit, err = parsePageParams(r)
+	if err != nil {
+		return q, err
+	}
+
+	params := r.URL.Query()
+
+	q.Sort = domain.ReviewSortNewest
+	if v := params.Get("sort"); v != "" {
+		q.Sort = domain.ReviewSort(v)
+		if !domain.ValidReviewSort(q.Sort) {
+			return q, errors.New("sort must be one of newest, oldest, highest, lowest")
+		}
+	}
+
+	if v := params.Get("has_comment"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return q, errors.New("has_comment must be true or false")
+		}
+		q.HasComment = &b
+	}
+
+	return q, nil
+}
+
 // PUT /reviews/{id}
 func (s *HTTPServer) handleUpdateReview(w http.ResponseWriter, r *http.Request, reviewID int64) {
 	if r.Method != http.MethodPut {
@@ -93,6 +252,10 @@ func (s *HTTPServer) handleUpdateReview(w http.ResponseWriter, r *http.Request,
 		s.sendError(w, "invalid JSON", http.StatusBadRequest)
 		return
 	}
+	if err := req.validate(false); err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
 
 	posterID, ok := posterIDFromContext(r.Context())
 	if !ok {
@@ -103,32 +266,33 @@ func (s *HTTPServer) handleUpdateReview(w http.ResponseWriter, r *http.Request,
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	err := s.service.UpdateReviewWithRatings(ctx, domain.UpdateReviewInput{
+	in := domain.UpdateReviewInput{
 		ReviewID:   reviewID,
 		PosterID:   posterID,
 		Comment:    req.Comment,
-		BikeImg:    req.BikeImg,
 		Overall:    req.Overall,
 		Breaks:     req.Breaks,
 		Seat:       req.Seat,
 		Sturdiness: req.Sturdiness,
 		Power:      req.Power,
 		Pedals:     req.Pedals,
-	})
+	}
+	if images := req.images(); images != nil {
+		in.Images = &images
+	}
+
+	removedKeys, err := s.service.UpdateReviewWithRatings(ctx, in)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			s.sendError(w, "review not found", http.StatusNotFound)
-			return
-		}
-		zerolog.Ctx(r.Context()).Error().Err(err).Int64("review_id", reviewID).Msg("update review error")
-		s.sendError(w, "internal server error", http.StatusInternalServerError)
+		s.sendDomainError(w, r, err)
 		return
 	}
+	s.deleteBlobs(r, removedKeys...)
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// GET /reviews/{id} → single review with ratings
+// GET /reviews/{id} → single review with ratings. Hidden reviews are only
+// shown to moderators.
 func (s *HTTPServer) handleGetReview(w http.ResponseWriter, r *http.Request, reviewID int64) {
 	if r.Method != http.MethodGet {
 		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -138,14 +302,10 @@ func (s *HTTPServer) handleGetReview(w http.ResponseWriter, r *http.Request, rev
 	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
 	defer cancel()
 
-	review, err := s.service.GetReviewWithRatingsByID(ctx, reviewID)
+	includeHidden := roleFromContext(r.Context()).AtLeast(domain.RoleModerator)
+	review, err := s.service.GetReviewWithRatingsByID(ctx, reviewID, includeHidden)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			s.sendError(w, "review not found", http.StatusNotFound)
-			return
-		}
-		zerolog.Ctx(r.Context()).Error().Err(err).Int64("review_id", reviewID).Msg("get review error")
-		s.sendError(w, "internal server error", http.StatusInternalServerError)
+		s.sendDomainError(w, r, err)
 		return
 	}
 
@@ -180,12 +340,7 @@ func (s *HTTPServer) handleDeleteReview(w http.ResponseWriter, r *http.Request,
 	defer cancel()
 
 	if err := s.service.DeleteReview(ctx, reviewID, posterID); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			s.sendError(w, "review not found", http.StatusNotFound)
-			return
-		}
-		zerolog.Ctx(r.Context()).Error().Err(err).Int64("review_id", reviewID).Msg("delete review error")
-		s.sendError(w, "internal server error", http.StatusInternalServerError)
+		s.sendDomainError(w, r, err)
 		return
 	}
 
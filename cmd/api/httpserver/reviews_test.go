@@ -8,6 +8,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -25,7 +26,7 @@ func TestHandleCreateBikeReview(t *testing.T) {
 		},
 	}
 
-	srv, err := New(mockService, &email.NoopSender{}, ":8080")
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
 	if err != nil {
 		t.Fatalf("failed to create server: %v", err)
 	}
@@ -69,6 +70,123 @@ func TestHandleCreateBikeReview(t *testing.T) {
 		}
 	})
 
+	t.Run("images", func(t *testing.T) {
+		var got []domain.ReviewImageInput
+		mockService.CreateReviewWithRatingsFunc = func(ctx context.Context, in domain.CreateReviewInput) (int64, error) {
+			got = in.Images
+			return 1, nil
+		}
+
+		body := `{"images":[{"url":"https://example.com/brake.jpg","caption":"brake"},{"url":"https://example.com/seat.jpg"}]}`
+		req := httptest.NewRequest(http.MethodPost, "/bikes/1/reviews", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d", w.Code)
+		}
+		if len(got) != 2 || got[0].URL != "https://example.com/brake.jpg" || got[0].Caption == nil || *got[0].Caption != "brake" {
+			t.Errorf("unexpected images %+v", got)
+		}
+	})
+
+	t.Run("legacy_bike_img", func(t *testing.T) {
+		var got []domain.ReviewImageInput
+		mockService.CreateReviewWithRatingsFunc = func(ctx context.Context, in domain.CreateReviewInput) (int64, error) {
+			got = in.Images
+			return 1, nil
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/bikes/1/reviews", bytes.NewBufferString(`{"bike_img":"https://example.com/a.jpg"}`))
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if len(got) != 1 || got[0].URL != "https://example.com/a.jpg" {
+			t.Errorf("expected bike_img as the only image, got %+v", got)
+		}
+	})
+
+	t.Run("invalid_images", func(t *testing.T) {
+		mockService.CreateReviewWithRatingsFunc = func(ctx context.Context, in domain.CreateReviewInput) (int64, error) {
+			t.Error("expected the review to be rejected before reaching the service")
+			return 0, nil
+		}
+
+		images := []map[string]any{
+			{"url": "ftp://x"},
+			{"url": "https://example.com/a.jpg", "caption": strings.Repeat("ñ", domain.MaxImageCaptionLen+1)},
+			{"image_id": 3},
+		}
+		for len(images) <= domain.MaxReviewImages {
+			images = append(images, map[string]any{"url": "https://example.com/b.jpg"})
+		}
+		reqBody, _ := json.Marshal(map[string]any{"images": images, "station_id": " 42"})
+		req := httptest.NewRequest(http.MethodPost, "/bikes/1/reviews", bytes.NewReader(reqBody))
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		want := "images,images[0].url,images[1].caption,images[2].image_id,station_id"
+		if got := strings.Join(invalidFields(t, w), ","); got != want {
+			t.Errorf("expected %s to be reported, got %s", want, got)
+		}
+	})
+
+	t.Run("invalid_bike_id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/bikes/abc/reviews", bytes.NewBufferString(`{"overall":4}`))
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("invalid_fields", func(t *testing.T) {
+		called := false
+		mockService.CreateReviewWithRatingsFunc = func(ctx context.Context, in domain.CreateReviewInput) (int64, error) {
+			called = true
+			return 1, nil
+		}
+
+		reqBody, _ := json.Marshal(map[string]interface{}{
+			"comment": strings.Repeat("ñ", domain.MaxCommentLen+1),
+			"overall": 6,
+			"seat":    0,
+			"pedals":  5,
+		})
+		req := httptest.NewRequest(http.MethodPost, "/bikes/1/reviews", bytes.NewReader(reqBody))
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("expected status 422, got %d", w.Code)
+		}
+		if called {
+			t.Error("expected the review to be rejected before reaching the service")
+		}
+		var resp errorResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		var fields []string
+		for _, f := range resp.Error.Fields {
+			fields = append(fields, f.Field)
+		}
+		if strings.Join(fields, ",") != "comment,overall,seat" {
+			t.Errorf("expected comment, overall and seat to be reported, got %v", fields)
+		}
+	})
+
 	t.Run("bad_request_invalid_json", func(t *testing.T) {
 		token := "valid_token"
 		req := httptest.NewRequest(http.MethodPost, "/bikes/1/reviews", bytes.NewReader([]byte("invalid")))
@@ -88,18 +206,18 @@ func TestHandleUpdateReview(t *testing.T) {
 		GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
 			return &domain.AuthPoster{PosterID: 1}, nil
 		},
-		UpdateReviewWithRatingsFunc: func(ctx context.Context, in domain.UpdateReviewInput) error {
+		UpdateReviewWithRatingsFunc: func(ctx context.Context, in domain.UpdateReviewInput) ([]string, error) {
 			if in.ReviewID == 404 {
-				return sql.ErrNoRows
+				return nil, sql.ErrNoRows
 			}
 			if in.ReviewID == 500 {
-				return errors.New("db error")
+				return nil, errors.New("db error")
 			}
-			return nil
+			return nil, nil
 		},
 	}
 
-	srv, err := New(mockService, &email.NoopSender{}, ":8080")
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
 	if err != nil {
 		t.Fatalf("failed to create server: %v", err)
 	}
@@ -123,6 +241,50 @@ func TestHandleUpdateReview(t *testing.T) {
 		}
 	})
 
+	t.Run("images", func(t *testing.T) {
+		update := mockService.UpdateReviewWithRatingsFunc
+		defer func() { mockService.UpdateReviewWithRatingsFunc = update }()
+
+		var got *[]domain.ReviewImageInput
+		mockService.UpdateReviewWithRatingsFunc = func(ctx context.Context, in domain.UpdateReviewInput) ([]string, error) {
+			got = in.Images
+			return nil, nil
+		}
+
+		cases := map[string]int{
+			`{"comment":"x"}`:             -1, // images untouched
+			`{"images":[]}`:               0,  // all removed
+			`{"images":[{"image_id":3}]}`: 1,
+		}
+		for body, want := range cases {
+			req := httptest.NewRequest(http.MethodPut, "/reviews/1", bytes.NewBufferString(body))
+			req.Header.Set("Authorization", "Bearer valid_token")
+			w := httptest.NewRecorder()
+			srv.server.Handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusNoContent {
+				t.Fatalf("%s: expected status 204, got %d", body, w.Code)
+			}
+			switch {
+			case want == -1 && got != nil:
+				t.Errorf("%s: expected no image change, got %+v", body, *got)
+			case want >= 0 && (got == nil || len(*got) != want):
+				t.Errorf("%s: expected %d images, got %v", body, want, got)
+			}
+		}
+	})
+
+	t.Run("image_id_and_url", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/reviews/1", bytes.NewBufferString(`{"images":[{"image_id":3,"url":"https://example.com/a.jpg"}]}`))
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if fields := invalidFields(t, w); len(fields) != 1 || fields[0] != "images[0]" {
+			t.Errorf("expected images[0] to be reported, got %v", fields)
+		}
+	})
+
 	t.Run("not_found", func(t *testing.T) {
 		token := "valid_token"
 		reqBody, _ := json.Marshal(map[string]interface{}{"comment": "update"})
@@ -154,10 +316,10 @@ func TestHandleUpdateReview(t *testing.T) {
 
 func TestHandleGetReview(t *testing.T) {
 	mockService := &MockService{
-		GetReviewWithRatingsByIDFunc: func(ctx context.Context, reviewID int64) (*domain.ReviewWithRatings, error) {
+		GetReviewWithRatingsByIDFunc: func(ctx context.Context, reviewID int64, includeHidden bool) (*domain.ReviewWithRatings, error) {
 			if reviewID == 1 {
 				comment := "comment"
-				bikeImg := "img.jpg"
+				bikeImg := "https://example.com/img.jpg"
 				return &domain.ReviewWithRatings{
 					ReviewID:        1,
 					PosterID:        1,
@@ -168,7 +330,7 @@ func TestHandleGetReview(t *testing.T) {
 					Ratings: map[domain.RatingSubcategory]int16{
 						"overall": 5,
 					},
-					BikeImg: &bikeImg,
+					Images: []domain.ReviewImage{{ImageID: 1, URL: bikeImg}},
 				}, nil
 			} else if reviewID == 404 {
 				return nil, sql.ErrNoRows
@@ -180,7 +342,7 @@ func TestHandleGetReview(t *testing.T) {
 		},
 	}
 
-	srv, err := New(mockService, &email.NoopSender{}, ":8080")
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
 	if err != nil {
 		t.Fatalf("failed to create server: %v", err)
 	}
@@ -208,6 +370,43 @@ func TestHandleGetReview(t *testing.T) {
 			t.Errorf("expected status 404, got %d", w.Code)
 		}
 	})
+
+	t.Run("hidden_only_for_moderators", func(t *testing.T) {
+		var gotIncludeHidden []bool
+		mockService := &MockService{
+			GetReviewWithRatingsByIDFunc: func(ctx context.Context, reviewID int64, includeHidden bool) (*domain.ReviewWithRatings, error) {
+				gotIncludeHidden = append(gotIncludeHidden, includeHidden)
+				return &domain.ReviewWithRatings{ReviewID: reviewID}, nil
+			},
+			GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
+				if token == "moderator_token" {
+					return &domain.AuthPoster{PosterID: 2, Role: domain.RoleModerator}, nil
+				}
+				return &domain.AuthPoster{PosterID: 1, Role: domain.RoleUser}, nil
+			},
+		}
+		srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+
+		for _, auth := range []string{"", "Bearer valid_token", "Bearer moderator_token"} {
+			req := httptest.NewRequest(http.MethodGet, "/reviews/1", nil)
+			if auth != "" {
+				req.Header.Set("Authorization", auth)
+			}
+			w := httptest.NewRecorder()
+
+			srv.server.Handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("%q: expected status 200, got %d", auth, w.Code)
+			}
+		}
+		if len(gotIncludeHidden) != 3 || gotIncludeHidden[0] || gotIncludeHidden[1] || !gotIncludeHidden[2] {
+			t.Errorf("expected only the moderator to see hidden reviews, got %v", gotIncludeHidden)
+		}
+	})
 }
 
 func TestHandleDeleteReview(t *testing.T) {
@@ -226,7 +425,7 @@ func TestHandleDeleteReview(t *testing.T) {
 		},
 	}
 
-	srv, err := New(mockService, &email.NoopSender{}, ":8080")
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
 	if err != nil {
 		t.Fatalf("failed to create server: %v", err)
 	}
@@ -271,3 +470,86 @@ func TestHandleDeleteReview(t *testing.T) {
 		}
 	})
 }
+
+func TestHandleListBikeReviews(t *testing.T) {
+	var gotBikeID string
+	var gotQuery domain.ListReviewsQuery
+	mockService := &MockService{
+		ListReviewsWithRatingsByBikeFunc: func(ctx context.Context, bikeID string, q domain.ListReviewsQuery) ([]domain.ReviewWithRatings, string, error) {
+			gotBikeID, gotQuery = bikeID, q
+			return []domain.ReviewWithRatings{{ReviewID: 2}, {ReviewID: 1}}, "", nil
+		},
+	}
+
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/bikes/1234/reviews?sort=lowest&has_comment=false&limit=5", nil)
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		if gotBikeID != "1234" || gotQuery.Sort != domain.ReviewSortLowest || gotQuery.Limit != 5 {
+			t.Errorf("unexpected query for bike %s: %+v", gotBikeID, gotQuery)
+		}
+		if gotQuery.HasComment == nil || *gotQuery.HasComment {
+			t.Errorf("expected has_comment=false filter")
+		}
+
+		var resp listReviewsResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(resp.Reviews) != 2 || resp.Reviews[0].ReviewID != 2 {
+			t.Errorf("expected reviews in service order, got %+v", resp.Reviews)
+		}
+		if resp.NextCursor != nil {
+			t.Errorf("expected null next_cursor, got %v", *resp.NextCursor)
+		}
+	})
+
+	t.Run("defaults_to_newest", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/bikes/1234/reviews", nil)
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if gotQuery.Sort != domain.ReviewSortNewest {
+			t.Errorf("expected newest, got %s", gotQuery.Sort)
+		}
+	})
+
+	t.Run("bad_request", func(t *testing.T) {
+		for _, url := range []string{"/bikes/abc/reviews", "/bikes/1234/reviews?sort=random", "/bikes/1234/reviews?has_comment=x", "/bikes/1234/reviews?limit=-1"} {
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			w := httptest.NewRecorder()
+
+			srv.server.Handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", url, w.Code)
+			}
+		}
+	})
+
+	t.Run("internal_error", func(t *testing.T) {
+		mockService.ListReviewsWithRatingsByBikeFunc = func(ctx context.Context, bikeID string, q domain.ListReviewsQuery) ([]domain.ReviewWithRatings, string, error) {
+			return nil, "", errors.New("db error")
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/bikes/1234/reviews", nil)
+		w := httptest.NewRecorder()
+
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("expected status 500, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+// /me/sessions...
+func (s *HTTPServer) handleSessionSubroutes(w http.ResponseWriter, r *http.Request, parts []string) {
+	switch len(parts) {
+	case 0:
+		switch r.Method {
+		case http.MethodGet:
+			s.handleListSessions(w, r)
+		case http.MethodDelete:
+			s.handleRevokeAllSessions(w, r)
+		default:
+			s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	case 1:
+		if r.Method != http.MethodDelete {
+			s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		sessionID, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			s.sendError(w, "invalid session id", http.StatusBadRequest)
+			return
+		}
+		s.handleRevokeSession(w, r, sessionID)
+	default:
+		s.sendError(w, "not found", http.StatusNotFound)
+	}
+}
+
+// GET /me/sessions → the caller's logged-in devices
+func (s *HTTPServer) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	posterID, ok := posterIDFromContext(r.Context())
+	if !ok {
+		s.sendError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	currentID, _ := sessionIDFromContext(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	sessions, err := s.service.ListSessions(ctx, posterID)
+	if err != nil {
+		s.sendInternalServerError(w, r, err)
+		return
+	}
+
+	if sessions == nil {
+		sessions = []domain.Session{}
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].SessionID == currentID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(sessions)
+}
+
+// DELETE /me/sessions/{id} → log out one device
+func (s *HTTPServer) handleRevokeSession(w http.ResponseWriter, r *http.Request, sessionID int64) {
+	posterID, ok := posterIDFromContext(r.Context())
+	if !ok {
+		s.sendError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := s.service.RevokeSession(ctx, posterID, sessionID); err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DELETE /me/sessions → log out everywhere, including this device
+func (s *HTTPServer) handleRevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	posterID, ok := posterIDFromContext(r.Context())
+	if !ok {
+		s.sendError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	n, err := s.service.RevokeAllSessions(ctx, posterID)
+	if err != nil {
+		s.sendInternalServerError(w, r, err)
+		return
+	}
+
+	zerolog.Ctx(r.Context()).Info().Int64("poster_id", posterID).Int64("sessions", n).Msg("logged out everywhere")
+
+	w.WriteHeader(http.StatusNoContent)
+}
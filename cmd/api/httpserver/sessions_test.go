@@ -0,0 +1,90 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/scardozos/rottenbikes/cmd/api/email"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+func TestHandleSessions(t *testing.T) {
+	var revoked []int64
+	mockService := &MockService{
+		GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
+			return &domain.AuthPoster{PosterID: 1, Username: "me", SessionID: 2}, nil
+		},
+		ListSessionsFunc: func(ctx context.Context, posterID int64) ([]domain.Session, error) {
+			return []domain.Session{
+				{SessionID: 2, CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)},
+				{SessionID: 1, CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)},
+			}, nil
+		},
+		RevokeSessionFunc: func(ctx context.Context, posterID, sessionID int64) error {
+			if sessionID != 1 {
+				return domain.ErrSessionNotFound
+			}
+			revoked = append(revoked, sessionID)
+			return nil
+		},
+		RevokeAllSessionsFunc: func(ctx context.Context, posterID int64) (int64, error) {
+			return 2, nil
+		},
+	}
+
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("list_marks_current", func(t *testing.T) {
+		w := do(http.MethodGet, "/me/sessions")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+
+		var sessions []domain.Session
+		if err := json.NewDecoder(w.Body).Decode(&sessions); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(sessions) != 2 || !sessions[0].Current || sessions[1].Current {
+			t.Errorf("expected only session 2 to be current, got %+v", sessions)
+		}
+	})
+
+	t.Run("revoke_one", func(t *testing.T) {
+		if w := do(http.MethodDelete, "/me/sessions/1"); w.Code != http.StatusNoContent {
+			t.Errorf("expected status 204, got %d", w.Code)
+		}
+		if len(revoked) != 1 || revoked[0] != 1 {
+			t.Errorf("expected session 1 revoked, got %v", revoked)
+		}
+	})
+
+	t.Run("revoke_unknown", func(t *testing.T) {
+		if w := do(http.MethodDelete, "/me/sessions/99"); w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+		if w := do(http.MethodDelete, "/me/sessions/abc"); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("log_out_everywhere", func(t *testing.T) {
+		if w := do(http.MethodDelete, "/me/sessions"); w.Code != http.StatusNoContent {
+			t.Errorf("expected status 204, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,222 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/scardozos/rottenbikes/cmd/api/validate"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+// /stations/nearby, /stations/{id}, /stations/{id}/bikes
+func (s *HTTPServer) handleStationSubroutes(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/stations/"), "/")
+	parts := strings.Split(path, "/")
+
+	if r.Method != http.MethodGet {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch {
+	case path == "nearby":
+		s.handleNearbyStations(w, r)
+	case len(parts) == 1 && parts[0] != "":
+		s.handleGetStation(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "bikes":
+		s.handleListStationBikes(w, r, parts[0])
+	default:
+		s.sendError(w, "not found", http.StatusNotFound)
+	}
+}
+
+// GET /stations/{id}
+func (s *HTTPServer) handleGetStation(w http.ResponseWriter, r *http.Request, stationID string) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	st, err := s.service.GetStation(ctx, stationID)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(st)
+}
+
+type listStationBikesResponse struct {
+	Station    *domain.Station `json:"station"`
+	Bikes      []domain.Bike   `json:"bikes"`
+	NextCursor *string         `json:"next_cursor"`
+}
+
+// GET /stations/{id}/bikes → bikes last seen at a station, most recent first
+//
+// Takes the same parameters as GET /bikes.
+func (s *HTTPServer) handleListStationBikes(w http.ResponseWriter, r *http.Request, stationID string) {
+	q, err := parseListBikesQuery(r)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Get("sort") == "" {
+		q.Sort = domain.BikeSortLastSeen
+		q.Descending = r.URL.Query().Get("order") != "asc"
+	}
+	q.StationID = &stationID
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	st, err := s.service.GetStation(ctx, stationID)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	bikes, cursor, err := s.service.ListBikes(ctx, q)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	if bikes == nil {
+		bikes = []domain.Bike{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(listStationBikesResponse{
+		Station:    st,
+		Bikes:      bikes,
+		NextCursor: nextCursor(cursor),
+	})
+}
+
+type importStationsRequest struct {
+	Stations []domain.Station `json:"stations"`
+}
+
+func (req importStationsRequest) validate() error {
+	var v validate.Validator
+	v.Check(len(req.Stations) > 0, "stations", "is required")
+	for i, st := range req.Stations {
+		field := fmt.Sprintf("stations[%d].", i)
+		v.Check(validStationID(st.StationID), field+"station_id", stationIDRule)
+		name := strings.TrimSpace(st.Name)
+		v.Check(name != "" && validate.MaxLen(name, domain.MaxStationNameLen), field+"name",
+			fmt.Sprintf("must be between 1 and %d characters", domain.MaxStationNameLen))
+		v.Check(st.Lat >= -90 && st.Lat <= 90, field+"lat", "must be between -90 and 90")
+		v.Check(st.Lon >= -180 && st.Lon <= 180, field+"lon", "must be between -180 and 180")
+		v.Check(st.Capacity == nil || *st.Capacity >= 0, field+"capacity", "must not be negative")
+	}
+	return v.Err()
+}
+
+// maxStationImportBytes bounds an import body; a city's worth of stations is
+// a few hundred kilobytes.
+const maxStationImportBytes = 8 << 20
+
+// POST /admin/stations/import → create or update stations by id
+func (s *HTTPServer) handleImportStations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req importStationsRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxStationImportBytes)).Decode(&req); err != nil {
+		s.sendError(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(); err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	res, err := s.service.ImportStations(ctx, req.Stations)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	zerolog.Ctx(r.Context()).Info().
+		Int("created", res.Created).
+		Int("updated", res.Updated).
+		Msg("stations imported")
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+type recordSightingRequest struct {
+	StationID string `json:"station_id"`
+}
+
+func (req recordSightingRequest) validate() error {
+	var v validate.Validator
+	v.Check(validStationID(req.StationID), "station_id", stationIDRule)
+	return v.Err()
+}
+
+// stationIDRule is what a request is told about a bad station_id.
+var stationIDRule = fmt.Sprintf("must be 1 to %d bytes without surrounding spaces", domain.MaxStationIDLen)
+
+// validStationID mirrors the store's check, so a bad id is reported as a
+// field of the request.
+func validStationID(id string) bool {
+	return id != "" && len(id) <= domain.MaxStationIDLen && strings.TrimSpace(id) == id
+}
+
+// POST /bikes/{id}/sightings → note that a bike is at a station right now
+func (s *HTTPServer) handleRecordSighting(w http.ResponseWriter, r *http.Request, bikeID string) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !validate.Numeric(bikeID) {
+		s.sendError(w, "invalid bike id", http.StatusBadRequest)
+		return
+	}
+
+	posterID, ok := posterIDFromContext(r.Context())
+	if !ok {
+		s.sendError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req recordSightingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(); err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	sightingID, err := s.service.RecordSighting(ctx, bikeID, req.StationID, posterID)
+	if err != nil {
+		s.sendDomainError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"sighting_id": sightingID,
+	})
+}
@@ -0,0 +1,248 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scardozos/rottenbikes/cmd/api/email"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+func TestHandleListStationBikes(t *testing.T) {
+	var got domain.ListBikesQuery
+	mockService := &MockService{
+		GetStationFunc: func(ctx context.Context, stationID string) (*domain.Station, error) {
+			if stationID != "42" {
+				return nil, sql.ErrNoRows
+			}
+			return &domain.Station{StationID: "42", Name: "Gran Via"}, nil
+		},
+		ListBikesFunc: func(ctx context.Context, q domain.ListBikesQuery) ([]domain.Bike, string, error) {
+			got = q
+			rating := 4.5
+			return []domain.Bike{{NumericalID: "0101", AverageRating: &rating}}, "", nil
+		},
+	}
+
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/stations/42/bikes?is_electric=true", nil)
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if got.StationID == nil || *got.StationID != "42" || got.Sort != domain.BikeSortLastSeen || !got.Descending {
+			t.Errorf("unexpected query %+v", got)
+		}
+		if got.IsElectric == nil || !*got.IsElectric {
+			t.Errorf("expected is_electric filter to be passed on")
+		}
+
+		var resp listStationBikesResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Station == nil || resp.Station.Name != "Gran Via" || len(resp.Bikes) != 1 || resp.NextCursor != nil {
+			t.Errorf("unexpected response %+v", resp)
+		}
+	})
+
+	t.Run("explicit_sort", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/stations/42/bikes?sort=average_rating", nil)
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		if got.Sort != domain.BikeSortAverageRating {
+			t.Errorf("expected average_rating sort, got %s", got.Sort)
+		}
+	})
+
+	t.Run("station_not_found", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/stations/7/bikes", nil)
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", w.Code)
+		}
+	})
+}
+
+func TestHandleImportStations(t *testing.T) {
+	mockService := &MockService{
+		GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
+			if token == "admin_token" {
+				return &domain.AuthPoster{PosterID: 1, Role: domain.RoleAdmin}, nil
+			}
+			return &domain.AuthPoster{PosterID: 2, Role: domain.RoleUser}, nil
+		},
+	}
+
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	importAs := func(token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/stations/import", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		var got []domain.Station
+		mockService.ImportStationsFunc = func(ctx context.Context, stations []domain.Station) (*domain.StationImportResult, error) {
+			got = stations
+			return &domain.StationImportResult{Created: 1}, nil
+		}
+
+		w := importAs("admin_token", `{"stations":[{"station_id":"42","name":"Gran Via","lat":41.39,"lon":2.18,"capacity":27}]}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if len(got) != 1 || got[0].StationID != "42" || got[0].Capacity == nil || *got[0].Capacity != 27 {
+			t.Errorf("unexpected stations %+v", got)
+		}
+	})
+
+	t.Run("invalid_station", func(t *testing.T) {
+		mockService.ImportStationsFunc = func(ctx context.Context, stations []domain.Station) (*domain.StationImportResult, error) {
+			return nil, domain.ErrInvalidStation
+		}
+		if w := importAs("admin_token", `{"stations":[{"station_id":"42","name":"Gran Via","lat":41.39,"lon":2.18}]}`); w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("expected status 422, got %d", w.Code)
+		}
+	})
+
+	t.Run("invalid_fields", func(t *testing.T) {
+		called := false
+		mockService.ImportStationsFunc = func(ctx context.Context, stations []domain.Station) (*domain.StationImportResult, error) {
+			called = true
+			return &domain.StationImportResult{}, nil
+		}
+
+		w := importAs("admin_token", `{"stations":[{"station_id":"42","name":"Gran Via","lat":41.39,"lon":2.18},{"station_id":" 43","name":"","lat":91,"lon":2.18,"capacity":-1}]}`)
+		fields := invalidFields(t, w)
+		if got := strings.Join(fields, ","); got != "stations[1].station_id,stations[1].name,stations[1].lat,stations[1].capacity" {
+			t.Errorf("unexpected fields %s", got)
+		}
+		if called {
+			t.Error("expected the import to be rejected before reaching the service")
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		if fields := invalidFields(t, importAs("admin_token", `{"stations":[]}`)); len(fields) != 1 || fields[0] != "stations" {
+			t.Errorf("expected stations to be reported, got %v", fields)
+		}
+	})
+
+	t.Run("forbidden_for_users", func(t *testing.T) {
+		if w := importAs("user_token", `{"stations":[]}`); w.Code != http.StatusForbidden {
+			t.Errorf("expected status 403, got %d", w.Code)
+		}
+	})
+}
+
+func TestHandleImportStationsOutlivesAuthTimeout(t *testing.T) {
+	t.Parallel()
+
+	mockService := adminMockService()
+	mockService.ImportStationsFunc = func(ctx context.Context, stations []domain.Station) (*domain.StationImportResult, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(authLookupTimeout + 500*time.Millisecond):
+		}
+		return &domain.StationImportResult{Created: len(stations)}, nil
+	}
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/stations/import", bytes.NewBufferString(`{"stations":[{"station_id":"42","name":"Gran Via","lat":41.39,"lon":2.18}]}`))
+	req.Header.Set("Authorization", "Bearer admin_token")
+	w := httptest.NewRecorder()
+	srv.server.Handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleRecordSighting(t *testing.T) {
+	mockService := &MockService{
+		GetPosterByAPITokenFunc: func(ctx context.Context, token string) (*domain.AuthPoster, error) {
+			return &domain.AuthPoster{PosterID: 1, Role: domain.RoleUser}, nil
+		},
+	}
+
+	srv, err := New(mockService, &email.NoopSender{}, nil, ":8080")
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	sight := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/bikes/0101/sightings", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer valid_token")
+		w := httptest.NewRecorder()
+		srv.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockService.RecordSightingFunc = func(ctx context.Context, bikeID, stationID string, posterID int64) (int64, error) {
+			if bikeID != "0101" || stationID != "42" || posterID != 1 {
+				t.Errorf("unexpected call: bike %s, station %s, poster %d", bikeID, stationID, posterID)
+			}
+			return 3, nil
+		}
+		if w := sight(`{"station_id":"42"}`); w.Code != http.StatusCreated {
+			t.Errorf("expected status 201, got %d", w.Code)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		cases := map[error]int{
+			domain.ErrUnknownStation: http.StatusUnprocessableEntity,
+			sql.ErrNoRows:            http.StatusNotFound,
+		}
+		for serr, status := range cases {
+			mockService.RecordSightingFunc = func(ctx context.Context, bikeID, stationID string, posterID int64) (int64, error) {
+				return 0, serr
+			}
+			if w := sight(`{"station_id":"42"}`); w.Code != status {
+				t.Errorf("%v: expected status %d, got %d", serr, status, w.Code)
+			}
+		}
+	})
+
+	t.Run("invalid_station_id", func(t *testing.T) {
+		mockService.RecordSightingFunc = func(ctx context.Context, bikeID, stationID string, posterID int64) (int64, error) {
+			t.Error("expected the sighting to be rejected before reaching the service")
+			return 0, nil
+		}
+		for _, body := range []string{`{}`, `{"station_id":" 42"}`, `{"station_id":"` + strings.Repeat("4", domain.MaxStationIDLen+1) + `"}`} {
+			if fields := invalidFields(t, sight(body)); len(fields) != 1 || fields[0] != "station_id" {
+				t.Errorf("%s: expected station_id to be reported, got %v", body, fields)
+			}
+		}
+	})
+}
@@ -0,0 +1,231 @@
+// Package imaging prepares uploaded photos for storage: it checks the format,
+// drops all metadata (EXIF, including GPS position) by re-encoding the pixels,
+// and produces a thumbnail.
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+var (
+	ErrUnsupportedType = errors.New("image must be a JPEG or PNG")
+	ErrInvalidImage    = errors.New("image could not be decoded")
+	ErrTooManyPixels   = errors.New("image dimensions are too large")
+)
+
+const (
+	// FullSize and ThumbSize bound the longest side of the stored photo and
+	// of its thumbnail. Smaller images are never upscaled.
+	FullSize  = 2048
+	ThumbSize = 320
+
+	// maxPixels rejects images that would take too much memory to decode,
+	// whatever their compressed size. 24 MP covers phone cameras and keeps a
+	// decoded photo under about 100 MB.
+	maxPixels = 24_000_000
+
+	jpegQuality = 85
+)
+
+// Processed is a cleaned-up photo and its thumbnail, both in the format of
+// the upload.
+type Processed struct {
+	ContentType string
+	Ext         string
+	Full        []byte
+	Thumb       []byte
+}
+
+// Process validates and re-encodes an uploaded JPEG or PNG. The type is
+// sniffed from the content, not taken from the client.
+func Process(data []byte) (*Processed, error) {
+	ct := http.DetectContentType(data)
+	var ext string
+	switch ct {
+	case "image/jpeg":
+		ext = "jpg"
+	case "image/png":
+		ext = "png"
+	default:
+		return nil, ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	// The orientation tag goes away with the rest of the EXIF data, so
+	// apply it to the pixels or phone photos end up sideways. Scaling first
+	// leaves fewer pixels to turn, and the thumbnail is cut from the result.
+	img = fit(img, FullSize)
+	if ct == "image/jpeg" {
+		img = orient(img, exifOrientation(data))
+	}
+
+	full, err := encode(img, ct)
+	if err != nil {
+		return nil, err
+	}
+	thumb, err := encode(fit(img, ThumbSize), ct)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Processed{ContentType: ct, Ext: ext, Full: full, Thumb: thumb}, nil
+}
+
+func encode(img image.Image, ct string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if ct == "image/png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// fit scales img down so its longest side is at most size.
+func fit(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+
+	tw, th := size, size
+	if w >= h {
+		th = max(1, h*size/w)
+	} else {
+		tw = max(1, w*size/h)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	draw.BiLinear.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// orient turns img upright according to an EXIF orientation value (1-8).
+func orient(img image.Image, o int) image.Image {
+	if o < 2 || o > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := float64(b.Dx()), float64(b.Dy())
+	dw, dh := b.Dx(), b.Dy()
+	if o >= 5 {
+		dw, dh = dh, dw
+	}
+
+	// Each maps a source point, relative to b.Min, to the upright image.
+	var m f64.Aff3
+	switch o {
+	case 2: // mirrored
+		m = f64.Aff3{-1, 0, w, 0, 1, 0}
+	case 3: // rotated 180
+		m = f64.Aff3{-1, 0, w, 0, -1, h}
+	case 4: // flipped
+		m = f64.Aff3{1, 0, 0, 0, -1, h}
+	case 5: // transposed
+		m = f64.Aff3{0, 1, 0, 1, 0, 0}
+	case 6: // needs 90 clockwise
+		m = f64.Aff3{0, -1, h, 1, 0, 0}
+	case 7: // transversed
+		m = f64.Aff3{0, -1, h, -1, 0, w}
+	case 8: // needs 90 counter-clockwise
+		m = f64.Aff3{0, 1, 0, -1, 0, w}
+	}
+	minX, minY := float64(b.Min.X), float64(b.Min.Y)
+	m[2] -= m[0]*minX + m[1]*minY
+	m[5] -= m[3]*minX + m[4]*minY
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	draw.NearestNeighbor.Transform(dst, m, img, b, draw.Src, nil)
+	return dst
+}
+
+// exifOrientation reads the orientation tag from a JPEG's EXIF segment. It
+// returns 1 (upright) when there is none or the data doesn't parse.
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 { // image data starts, no EXIF seen
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return 1
+		}
+		seg := data[i+4 : i+2+size]
+		if marker == 0xE1 && len(seg) > 6 && string(seg[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(seg[6:])
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+func tiffOrientation(t []byte) int {
+	if len(t) < 8 {
+		return 1
+	}
+
+	var bo binary.ByteOrder
+	switch string(t[:2]) {
+	case "II":
+		bo = binary.LittleEndian
+	case "MM":
+		bo = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(bo.Uint32(t[4:]))
+	if ifd < 8 || ifd+2 > len(t) {
+		return 1
+	}
+	n := int(bo.Uint16(t[ifd:]))
+	for k := 0; k < n; k++ {
+		e := ifd + 2 + 12*k
+		if e+12 > len(t) {
+			return 1
+		}
+		if bo.Uint16(t[e:]) == 0x0112 {
+			if v := int(bo.Uint16(t[e+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			return 1
+		}
+	}
+	return 1
+}
@@ -0,0 +1,161 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+// withExif splices an APP1 segment carrying the orientation tag and a fake
+// GPS IFD pointer right after the JPEG's SOI marker.
+func withExif(t *testing.T, jpg []byte, orientation uint16) []byte {
+	t.Helper()
+
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	_ = binary.Write(&tiff, binary.BigEndian, uint16(42))
+	_ = binary.Write(&tiff, binary.BigEndian, uint32(8))
+	_ = binary.Write(&tiff, binary.BigEndian, uint16(2)) // entries
+	// orientation, SHORT, count 1
+	_ = binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3})
+	_ = binary.Write(&tiff, binary.BigEndian, uint32(1))
+	_ = binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0})
+	// GPSInfo IFD pointer, LONG, count 1
+	_ = binary.Write(&tiff, binary.BigEndian, []uint16{0x8825, 4})
+	_ = binary.Write(&tiff, binary.BigEndian, []uint32{1, 0})
+	_ = binary.Write(&tiff, binary.BigEndian, uint32(0)) // next IFD
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	seg := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	seg = append(seg, payload...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, seg...)
+	return append(out, jpg[2:]...)
+}
+
+func TestProcess(t *testing.T) {
+	t.Run("jpeg_strips_exif_and_applies_orientation", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, testImage(40, 20), nil); err != nil {
+			t.Fatal(err)
+		}
+		data := withExif(t, buf.Bytes(), 6)
+		if got := exifOrientation(data); got != 6 {
+			t.Fatalf("expected orientation 6, got %d", got)
+		}
+
+		p, err := Process(data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if p.ContentType != "image/jpeg" || p.Ext != "jpg" {
+			t.Errorf("unexpected type %s/%s", p.ContentType, p.Ext)
+		}
+		if bytes.Contains(p.Full, []byte("Exif")) || bytes.Contains(p.Thumb, []byte("Exif")) {
+			t.Error("expected EXIF to be stripped")
+		}
+
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(p.Full))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Width != 20 || cfg.Height != 40 {
+			t.Errorf("expected rotated 20x40, got %dx%d", cfg.Width, cfg.Height)
+		}
+	})
+
+	t.Run("png_thumbnail", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, testImage(ThumbSize*2, ThumbSize)); err != nil {
+			t.Fatal(err)
+		}
+
+		p, err := Process(buf.Bytes())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if p.ContentType != "image/png" {
+			t.Errorf("expected image/png, got %s", p.ContentType)
+		}
+
+		cfg, err := png.DecodeConfig(bytes.NewReader(p.Thumb))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Width != ThumbSize || cfg.Height != ThumbSize/2 {
+			t.Errorf("expected thumbnail %dx%d, got %dx%d", ThumbSize, ThumbSize/2, cfg.Width, cfg.Height)
+		}
+	})
+
+	t.Run("rejects_too_many_pixels", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, testImage(1, 1)); err != nil {
+			t.Fatal(err)
+		}
+		// Claim 6000x4001 in the IHDR chunk; only the header is read.
+		data := buf.Bytes()
+		binary.BigEndian.PutUint32(data[16:], 6000)
+		binary.BigEndian.PutUint32(data[20:], 4001)
+		binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+		if _, err := Process(data); !errors.Is(err, ErrTooManyPixels) {
+			t.Errorf("expected ErrTooManyPixels, got %v", err)
+		}
+	})
+
+	t.Run("rejects_other_types", func(t *testing.T) {
+		if _, err := Process([]byte("GIF89a not really")); !errors.Is(err, ErrUnsupportedType) {
+			t.Errorf("expected ErrUnsupportedType, got %v", err)
+		}
+	})
+
+	t.Run("rejects_corrupt_data", func(t *testing.T) {
+		data := []byte("\x89PNG\r\n\x1a\n truncated")
+		if _, err := Process(data); !errors.Is(err, ErrInvalidImage) {
+			t.Errorf("expected ErrInvalidImage, got %v", err)
+		}
+	})
+}
+
+func TestOrient(t *testing.T) {
+	src := testImage(3, 2)
+	// Where the source pixel (x, y) lands for each orientation.
+	moves := map[int]func(x, y int) (int, int){
+		2: func(x, y int) (int, int) { return 2 - x, y },
+		3: func(x, y int) (int, int) { return 2 - x, 1 - y },
+		4: func(x, y int) (int, int) { return x, 1 - y },
+		5: func(x, y int) (int, int) { return y, x },
+		6: func(x, y int) (int, int) { return 1 - y, x },
+		7: func(x, y int) (int, int) { return 1 - y, 2 - x },
+		8: func(x, y int) (int, int) { return y, 2 - x },
+	}
+	for o, move := range moves {
+		dst := orient(src, o)
+		for y := 0; y < 2; y++ {
+			for x := 0; x < 3; x++ {
+				dx, dy := move(x, y)
+				if got, want := dst.At(dx, dy), src.At(x, y); got != want {
+					t.Errorf("orientation %d: pixel (%d,%d) moved to (%d,%d) is %v, want %v", o, x, y, dx, dy, got, want)
+				}
+			}
+		}
+	}
+}
@@ -15,8 +15,10 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"github.com/scardozos/rottenbikes/cmd/api/blob"
 	"github.com/scardozos/rottenbikes/cmd/api/email"
 	"github.com/scardozos/rottenbikes/cmd/api/httpserver"
+	"github.com/scardozos/rottenbikes/cmd/api/ratelimit"
 	"github.com/scardozos/rottenbikes/internal/domain"
 )
 
@@ -39,6 +41,19 @@ type Config struct {
 	MailtrapTokenSet bool   `json:"MAILTRAP_TOKEN_SET"`
 	EmailFromAddress string `json:"EMAIL_FROM_ADDRESS"`
 	EmailFromName    string `json:"EMAIL_FROM_NAME"`
+	DeleteRetention  string `json:"SOFT_DELETE_RETENTION"`
+	PurgeInterval    string `json:"PURGE_INTERVAL"`
+	LinkRetention    string `json:"MAGIC_LINK_RETENTION"`
+	CleanupInterval  string `json:"CLEANUP_INTERVAL"`
+	BlobDir          string `json:"BLOB_DIR"`
+	GBFSInfo         string `json:"GBFS_STATION_INFORMATION"`
+	GBFSStatus       string `json:"GBFS_STATION_STATUS"`
+	GBFSInterval     string `json:"GBFS_INTERVAL"`
+	AvailRetention   string `json:"STATION_AVAILABILITY_RETENTION"`
+	RateLimitStore   string `json:"RATE_LIMIT_BACKEND"`
+	RateLimits       string `json:"RATE_LIMITS"`
+	RateLimitFile    string `json:"RATE_LIMIT_CONFIG"`
+	TrustProxy       bool   `json:"RATE_LIMIT_TRUST_PROXY"`
 }
 
 func main() {
@@ -62,6 +77,19 @@ func main() {
 		EmailSenderType:  "noop",
 		EmailFromAddress: getEnv("EMAIL_FROM_ADDRESS", "hello@rottenbik.es"),
 		EmailFromName:    getEnv("EMAIL_FROM_NAME", "RottenBikes"),
+		DeleteRetention:  getEnv("SOFT_DELETE_RETENTION", "720h"),
+		PurgeInterval:    getEnv("PURGE_INTERVAL", "1h"),
+		LinkRetention:    getEnv("MAGIC_LINK_RETENTION", "720h"),
+		CleanupInterval:  getEnv("CLEANUP_INTERVAL", "1h"),
+		BlobDir:          getEnv("BLOB_DIR", "./data/images"),
+		GBFSInfo:         getEnv("GBFS_STATION_INFORMATION", ""),
+		GBFSStatus:       getEnv("GBFS_STATION_STATUS", ""),
+		GBFSInterval:     getEnv("GBFS_INTERVAL", "5m"),
+		AvailRetention:   getEnv("STATION_AVAILABILITY_RETENTION", "720h"),
+		RateLimitStore:   getEnv("RATE_LIMIT_BACKEND", "memory"),
+		RateLimits:       getEnv("RATE_LIMITS", ""),
+		RateLimitFile:    getEnv("RATE_LIMIT_CONFIG", ""),
+		TrustProxy:       getEnv("RATE_LIMIT_TRUST_PROXY", "false") == "true",
 	}
 	if os.Getenv("API_PORT") != "" {
 		cfg.APIPort = os.Getenv("API_PORT")
@@ -89,7 +117,6 @@ func main() {
 		log.Fatal().Err(err).Msg("failed to ping db")
 	}
 
-	store := domain.NewStore(db)
 	port := cfg.APIPort
 
 	// Initialize Email Sender
@@ -108,10 +135,77 @@ func main() {
 		cfg.MailtrapTokenSet = false
 	}
 
+	retention, err := time.ParseDuration(cfg.DeleteRetention)
+	if err != nil || retention <= 0 {
+		log.Fatal().Err(err).Msg("invalid SOFT_DELETE_RETENTION")
+	}
+	purgeInterval, err := time.ParseDuration(cfg.PurgeInterval)
+	if err != nil || purgeInterval <= 0 {
+		log.Fatal().Err(err).Msg("invalid PURGE_INTERVAL")
+	}
+
+	linkRetention, err := time.ParseDuration(cfg.LinkRetention)
+	if err != nil || linkRetention < domain.MinMagicLinkRetention {
+		log.Fatal().Err(err).Msg("invalid MAGIC_LINK_RETENTION, it must be at least 24h")
+	}
+	cleanupInterval, err := time.ParseDuration(cfg.CleanupInterval)
+	if err != nil || cleanupInterval <= 0 {
+		log.Fatal().Err(err).Msg("invalid CLEANUP_INTERVAL")
+	}
+	gbfsInterval, err := time.ParseDuration(cfg.GBFSInterval)
+	if err != nil || gbfsInterval <= 0 {
+		log.Fatal().Err(err).Msg("invalid GBFS_INTERVAL")
+	}
+	availabilityRetention, err := time.ParseDuration(cfg.AvailRetention)
+	if err != nil || availabilityRetention <= 0 {
+		log.Fatal().Err(err).Msg("invalid STATION_AVAILABILITY_RETENTION")
+	}
+
+	// Policies come from the defaults, then the config file, then RATE_LIMITS.
+	policies := ratelimit.DefaultPolicies()
+	if cfg.RateLimitFile != "" {
+		if err := policies.Load(cfg.RateLimitFile); err != nil {
+			log.Fatal().Err(err).Msg("invalid RATE_LIMIT_CONFIG")
+		}
+	}
+	if err := policies.Set(cfg.RateLimits); err != nil {
+		log.Fatal().Err(err).Msg("invalid RATE_LIMITS")
+	}
+	store := domain.NewStore(db, domain.WithQuotas(domain.Quotas{
+		MagicLinks:  domain.Quota(policies[ratelimit.QuotaMagicLinks]),
+		Reviews:     domain.Quota(policies[ratelimit.QuotaReviews]),
+		BikeReviews: domain.Quota(policies[ratelimit.QuotaBikeReviews]),
+	}))
+
+	var limiter ratelimit.RateLimiter
+	var pgLimiter *ratelimit.PostgresLimiter
+	switch cfg.RateLimitStore {
+	case "memory":
+		limiter = ratelimit.NewMemoryLimiter()
+	case "postgres":
+		pgLimiter = ratelimit.NewPostgresLimiter(db)
+		limiter = pgLimiter
+	default:
+		log.Fatal().Str("backend", cfg.RateLimitStore).Msg("invalid RATE_LIMIT_BACKEND, want memory or postgres")
+	}
+
+	blobs, err := blob.NewLocalStore(cfg.BlobDir)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to open blob store")
+	}
+
 	// Log Startup Config
 	log.Info().Interface("config", cfg).Msg("starting service")
 
-	srv, err := httpserver.New(store, sender, ":"+port)
+	// Background jobs, and exports built off the request, stop when jobsCtx
+	// is cancelled on shutdown.
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	jobs := newScheduler(jobsCtx)
+
+	srv, err := httpserver.New(store, sender, blobs, ":"+port,
+		httpserver.WithRateLimits(limiter, policies, cfg.TrustProxy),
+		httpserver.WithBackground(jobs))
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to create server")
 	}
@@ -131,6 +225,15 @@ func main() {
 		}
 	}()
 
+	jobs.every("purge_deleted", purgeInterval, time.Minute, purgeDeletedJob(store, blobs, retention))
+	jobs.every("cleanup", cleanupInterval, time.Minute, cleanupJob(store, blobs, linkRetention, availabilityRetention))
+	if pgLimiter != nil {
+		jobs.every("rate_limit_prune", cleanupInterval, time.Minute, pruneRateLimitsJob(pgLimiter))
+	}
+	if cfg.GBFSInfo != "" {
+		jobs.every("gbfs_import", gbfsInterval, time.Minute, gbfsImportJob(store, cfg.GBFSInfo, cfg.GBFSStatus))
+	}
+
 	go func() {
 		log.Info().Msgf("Metrics server listening on %s", metricsSrv.Addr)
 		if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -142,6 +245,7 @@ func main() {
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 	<-stop
+	stopJobs()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -152,4 +256,5 @@ func main() {
 	if err := metricsSrv.Shutdown(ctx); err != nil {
 		log.Error().Err(err).Msg("metrics server shutdown failed")
 	}
+	jobs.wait()
 }
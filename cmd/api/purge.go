@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/scardozos/rottenbikes/cmd/api/blob"
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+// purgeDeletedJob hard-deletes bikes and reviews whose soft delete is older
+// than retention, and the photo files of the purged reviews.
+func purgeDeletedJob(store *domain.Store, blobs blob.BlobStore, retention time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		bikes, reviews, keys, err := store.PurgeDeleted(ctx, retention)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			// The rows are gone either way; a failure only leaves a stray file.
+			if err := blobs.Delete(ctx, key); err != nil {
+				log.Warn().Err(err).Str("key", key).Msg("delete purged review photo")
+			}
+		}
+		cleanupRowsTotal.WithLabelValues("bikes").Add(float64(bikes))
+		cleanupRowsTotal.WithLabelValues("reviews").Add(float64(reviews))
+		if bikes > 0 || reviews > 0 {
+			log.Info().Int64("bikes", bikes).Int64("reviews", reviews).Msg("purged deleted rows")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,120 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy names the API applies.
+const (
+	// PolicyGlobal covers every request, per client IP.
+	PolicyGlobal = "global"
+	// PolicyAuth covers the unauthenticated endpoints that send email or
+	// issue credentials, per client IP.
+	PolicyAuth = "auth"
+	// PolicyWrite covers authenticated requests that change data, per
+	// poster.
+	PolicyWrite = "write"
+	// PolicyExport covers account data exports, per poster.
+	PolicyExport = "export"
+)
+
+// Quota names. The store enforces these per poster itself, counting the
+// rows it has already written rather than keeping buckets, so they are
+// sliding windows without a burst.
+const (
+	// QuotaMagicLinks covers sign-in and email change links.
+	QuotaMagicLinks = "magic_links"
+	// QuotaReviews covers every review a poster writes.
+	QuotaReviews = "reviews"
+	// QuotaBikeReviews covers a poster's reviews of any one bike.
+	QuotaBikeReviews = "bike_reviews"
+)
+
+// Policies maps a policy name to its limit.
+type Policies map[string]Policy
+
+// DefaultPolicies are the limits used unless configured otherwise.
+func DefaultPolicies() Policies {
+	return Policies{
+		PolicyGlobal: {Limit: 300, Window: time.Minute},
+		PolicyAuth:   {Limit: 20, Window: time.Hour},
+		PolicyWrite:  {Limit: 60, Window: time.Minute},
+		PolicyExport: {Limit: 5, Window: time.Hour},
+
+		QuotaMagicLinks:  {Limit: 2, Window: 24 * time.Hour},
+		QuotaReviews:     {Limit: 5, Window: time.Hour},
+		QuotaBikeReviews: {Limit: 1, Window: 10 * time.Minute},
+	}
+}
+
+// ParsePolicy reads "<limit>/<window>", e.g. "300/1m". A limit of 0
+// disables the policy.
+func ParsePolicy(s string) (Policy, error) {
+	limit, window, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("rate limit %q: want <limit>/<window>", s)
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 0 {
+		return Policy{}, fmt.Errorf("rate limit %q: invalid limit", s)
+	}
+	w, err := time.ParseDuration(window)
+	if err != nil || w <= 0 {
+		return Policy{}, fmt.Errorf("rate limit %q: invalid window", s)
+	}
+	return Policy{Limit: n, Window: w}, nil
+}
+
+// Set overrides policies from a comma-separated list of
+// "<name>=<limit>/<window>" entries, as in RATE_LIMITS.
+func (ps Policies) Set(spec string) error {
+	for _, entry := range strings.Split(spec, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("rate limit %q: want <name>=<limit>/<window>", entry)
+		}
+		if err := ps.set(strings.TrimSpace(name), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load overrides policies from a JSON file mapping names to
+// "<limit>/<window>" strings.
+func (ps Policies) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read rate limit config: %w", err)
+	}
+	var entries map[string]string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("parse rate limit config: %w", err)
+	}
+	for name, value := range entries {
+		if err := ps.set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ps Policies) set(name, value string) error {
+	if _, ok := ps[name]; !ok {
+		return fmt.Errorf("unknown rate limit policy %q", name)
+	}
+	p, err := ParsePolicy(value)
+	if err != nil {
+		return err
+	}
+	ps[name] = p
+	return nil
+}
@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how often the memory limiter drops buckets that have
+// refilled, which behave the same as missing ones.
+const sweepEvery = time.Minute
+
+type memoryEntry struct {
+	bucket
+	full time.Time
+}
+
+// MemoryLimiter keeps buckets in process. Each replica counts on its own,
+// so with N replicas a client gets up to N times the limit.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{buckets: make(map[string]*memoryEntry), now: time.Now}
+}
+
+func (m *MemoryLimiter) Allow(ctx context.Context, key string, p Policy) (Decision, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if now.Sub(m.lastSweep) >= sweepEvery {
+		for k, e := range m.buckets {
+			if !now.Before(e.full) {
+				delete(m.buckets, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	e, ok := m.buckets[key]
+	if !ok {
+		e = &memoryEntry{bucket: bucket{tokens: float64(p.Limit), updated: now}}
+		m.buckets[key] = e
+	}
+	d := e.take(p, now)
+	e.full = e.fullAt(p)
+	return d, nil
+}
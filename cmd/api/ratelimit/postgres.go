@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// PostgresLimiter keeps buckets in the rate_limit_buckets table, so every
+// replica shares them. Each call is one short transaction that locks the
+// bucket's row.
+type PostgresLimiter struct {
+	db *sql.DB
+}
+
+func NewPostgresLimiter(db *sql.DB) *PostgresLimiter {
+	return &PostgresLimiter{db: db}
+}
+
+func (l *PostgresLimiter) Allow(ctx context.Context, key string, p Policy) (Decision, error) {
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Decision{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The no-op update makes the upsert lock and return an existing row,
+	// or create a full bucket. Time comes from the database so replicas
+	// with skewed clocks agree.
+	var b bucket
+	var now time.Time
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO rate_limit_buckets (bucket_key, tokens, updated_ts, full_ts)
+		VALUES ($1, $2, NOW(), NOW())
+		ON CONFLICT (bucket_key) DO UPDATE SET bucket_key = EXCLUDED.bucket_key
+		RETURNING tokens, updated_ts, NOW()
+	`, key, p.Limit).Scan(&b.tokens, &b.updated, &now); err != nil {
+		return Decision{}, fmt.Errorf("load bucket: %w", err)
+	}
+
+	d := b.take(p, now)
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE rate_limit_buckets
+		SET tokens = $2, updated_ts = $3, full_ts = $4
+		WHERE bucket_key = $1
+	`, key, b.tokens, b.updated, b.fullAt(p)); err != nil {
+		return Decision{}, fmt.Errorf("save bucket: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Decision{}, fmt.Errorf("commit tx: %w", err)
+	}
+	return d, nil
+}
+
+// Prune deletes buckets that have refilled; a missing bucket counts as
+// full. It returns how many were deleted.
+func (l *PostgresLimiter) Prune(ctx context.Context) (int64, error) {
+	res, err := l.db.ExecContext(ctx, `DELETE FROM rate_limit_buckets WHERE full_ts < NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("prune rate limit buckets: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,79 @@
+// Package ratelimit throttles requests with token buckets kept in memory or
+// in Postgres.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Policy allows Limit requests per Window. Buckets start full and refill
+// steadily, so up to Limit requests can arrive in a burst.
+type Policy struct {
+	Limit  int
+	Window time.Duration
+}
+
+// Enabled reports whether the policy limits anything; a zero limit turns it
+// off.
+func (p Policy) Enabled() bool {
+	return p.Limit > 0 && p.Window > 0
+}
+
+// rate is how many tokens the bucket regains per second.
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Window.Seconds()
+}
+
+// Decision is the outcome of taking a token from a bucket.
+type Decision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next request would be allowed; zero
+	// when this one was.
+	RetryAfter time.Duration
+}
+
+// RateLimiter takes one token from the bucket under key, creating it full
+// if it doesn't exist. Implementations must be safe for concurrent use.
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, p Policy) (Decision, error)
+}
+
+// bucket is a token bucket as both limiters store it.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take refills b up to now and, if a whole token is left, spends it.
+func (b *bucket) take(p Policy, now time.Time) Decision {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(p.Limit), b.tokens+elapsed*p.rate())
+	}
+	b.updated = now
+
+	d := Decision{Limit: p.Limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		d.Allowed = true
+	} else {
+		d.RetryAfter = seconds((1 - b.tokens) / p.rate())
+	}
+	d.Remaining = int(b.tokens)
+	d.Reset = seconds((float64(p.Limit) - b.tokens) / p.rate())
+	return d
+}
+
+// fullAt is when b will have refilled completely.
+func (b *bucket) fullAt(p Policy) time.Time {
+	return b.updated.Add(seconds((float64(p.Limit) - b.tokens) / p.rate()))
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
@@ -0,0 +1,120 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := NewMemoryLimiter()
+	l.now = func() time.Time { return now }
+	p := Policy{Limit: 2, Window: time.Minute}
+	ctx := context.Background()
+
+	for i, want := range []Decision{
+		{Allowed: true, Limit: 2, Remaining: 1, Reset: 30 * time.Second},
+		{Allowed: true, Limit: 2, Remaining: 0, Reset: time.Minute},
+		{Allowed: false, Limit: 2, Remaining: 0, Reset: time.Minute, RetryAfter: 30 * time.Second},
+	} {
+		got, _ := l.Allow(ctx, "k", p)
+		if got != want {
+			t.Errorf("request %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+
+	if d, _ := l.Allow(ctx, "other", p); !d.Allowed {
+		t.Error("expected a separate bucket per key")
+	}
+
+	now = now.Add(30 * time.Second)
+	if d, _ := l.Allow(ctx, "k", p); !d.Allowed || d.Remaining != 0 {
+		t.Errorf("expected one refilled token, got %+v", d)
+	}
+
+	now = now.Add(time.Hour)
+	if d, _ := l.Allow(ctx, "k", p); d.Remaining != 1 {
+		t.Errorf("expected refill to stop at the limit, got %+v", d)
+	}
+	if _, ok := l.buckets["other"]; ok {
+		t.Error("expected refilled buckets to be swept")
+	}
+}
+
+func TestPostgresLimiter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	l := NewPostgresLimiter(db)
+	p := Policy{Limit: 10, Window: 10 * time.Second}
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("refills_and_takes", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO rate_limit_buckets").
+			WithArgs("auth:ip:1.2.3.4", 10).
+			WillReturnRows(sqlmock.NewRows([]string{"tokens", "updated_ts", "now"}).
+				AddRow(0.5, now.Add(-time.Second), now))
+		mock.ExpectExec("UPDATE rate_limit_buckets").
+			WithArgs("auth:ip:1.2.3.4", 0.5, now, now.Add(9500*time.Millisecond)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		d, err := l.Allow(context.Background(), "auth:ip:1.2.3.4", p)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !d.Allowed || d.Remaining != 0 {
+			t.Errorf("unexpected decision %+v", d)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO rate_limit_buckets").
+			WillReturnRows(sqlmock.NewRows([]string{"tokens", "updated_ts", "now"}).
+				AddRow(0.0, now, now))
+		mock.ExpectExec("UPDATE rate_limit_buckets").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		d, err := l.Allow(context.Background(), "auth:ip:1.2.3.4", p)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if d.Allowed || d.RetryAfter != time.Second {
+			t.Errorf("unexpected decision %+v", d)
+		}
+	})
+}
+
+func TestPolicies(t *testing.T) {
+	ps := DefaultPolicies()
+	if err := ps.Set("auth=5/15m, write=0/1m, reviews=10/1h"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ps[PolicyAuth] != (Policy{Limit: 5, Window: 15 * time.Minute}) {
+		t.Errorf("unexpected auth policy %+v", ps[PolicyAuth])
+	}
+	if ps[PolicyWrite].Enabled() {
+		t.Error("expected a zero limit to disable the policy")
+	}
+	if ps[QuotaReviews] != (Policy{Limit: 10, Window: time.Hour}) {
+		t.Errorf("unexpected reviews quota %+v", ps[QuotaReviews])
+	}
+
+	for _, spec := range []string{"auth", "auth=5", "auth=x/1m", "auth=5/0s", "auth=-1/1m", "nope=1/1m"} {
+		if err := DefaultPolicies().Set(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	jobRunsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "background_job_runs_total",
+			Help: "Total number of background job runs",
+		},
+		[]string{"job", "result"},
+	)
+
+	cleanupRowsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cleanup_rows_total",
+			Help: "Rows deleted or scrubbed by background cleanup jobs",
+		},
+		[]string{"kind"},
+	)
+)
+
+// scheduler runs periodic background jobs, and one-off ones handed to Go,
+// until its context ends. Shutdown cancels the context and then waits for
+// runs still in progress.
+type scheduler struct {
+	ctx context.Context
+	wg  sync.WaitGroup
+
+	mu      sync.Mutex
+	stopped bool
+}
+
+func newScheduler(ctx context.Context) *scheduler {
+	return &scheduler{ctx: ctx}
+}
+
+// every runs fn once right away and then every interval. Each run gets a
+// context bounded by timeout and cancelled with the scheduler's. A run that
+// overlaps the next tick delays it rather than running twice.
+func (s *scheduler) every(name string, interval, timeout time.Duration, fn func(ctx context.Context) error) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			s.run(name, timeout, fn)
+
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Go runs fn once in the background, with a context bounded by timeout and
+// cancelled with the scheduler's. It reports false, without running fn, once
+// the scheduler is stopping.
+func (s *scheduler) Go(name string, timeout time.Duration, fn func(ctx context.Context) error) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped || s.ctx.Err() != nil {
+		return false
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run(name, timeout, fn)
+	}()
+	return true
+}
+
+func (s *scheduler) run(name string, timeout time.Duration, fn func(ctx context.Context) error) {
+	runCtx, cancel := context.WithTimeout(s.ctx, timeout)
+	defer cancel()
+
+	err := fn(runCtx)
+	switch {
+	case err == nil:
+		jobRunsTotal.WithLabelValues(name, "ok").Inc()
+	case s.ctx.Err() != nil:
+		// shutting down; the run was cut short on purpose
+	default:
+		jobRunsTotal.WithLabelValues(name, "error").Inc()
+		log.Error().Err(err).Str("job", name).Msg("background job failed")
+	}
+}
+
+// wait blocks until every job has returned. Go accepts no more work once
+// wait is called.
+func (s *scheduler) wait() {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+	s.wg.Wait()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRunsUntilCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := newScheduler(ctx)
+
+	var runs atomic.Int32
+	s.every("test", 5*time.Millisecond, time.Second, func(ctx context.Context) error {
+		if runs.Add(1) == 2 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runs.Load() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected at least 3 runs, got %d", runs.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		s.wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("scheduler did not stop after cancel")
+	}
+
+	stopped := runs.Load()
+	time.Sleep(20 * time.Millisecond)
+	if runs.Load() != stopped {
+		t.Fatal("job ran after the scheduler stopped")
+	}
+}
+
+func TestSchedulerCancelsRunningJob(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := newScheduler(ctx)
+
+	started := make(chan struct{})
+	s.every("slow", time.Hour, time.Hour, func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	<-started
+	cancel()
+	s.wait()
+}
+
+func TestSchedulerGo(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := newScheduler(ctx)
+
+	started := make(chan struct{})
+	var finished atomic.Bool
+	if !s.Go("once", time.Hour, func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		finished.Store(true)
+		return ctx.Err()
+	}) {
+		t.Fatal("expected the scheduler to accept the run")
+	}
+
+	<-started
+	cancel()
+	s.wait()
+	if !finished.Load() {
+		t.Fatal("wait returned before the run finished")
+	}
+
+	if s.Go("late", time.Hour, func(ctx context.Context) error { return nil }) {
+		t.Fatal("expected the stopped scheduler to refuse the run")
+	}
+}
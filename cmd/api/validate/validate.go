@@ -0,0 +1,90 @@
+// Package validate checks request bodies before they reach the store. A
+// Validator keeps going after the first failed check, so a client learns
+// about every bad field at once, each under its path in the body
+// ("images[2].caption").
+package validate
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+// Errors lists the invalid fields of a request.
+type Errors []domain.FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, f := range e {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Validator collects failed checks. The zero value is ready to use.
+type Validator struct {
+	errs Errors
+}
+
+// Check records message against field unless ok.
+func (v *Validator) Check(ok bool, field, message string) {
+	if !ok {
+		v.errs = append(v.errs, domain.FieldError{Field: field, Message: message})
+	}
+}
+
+// Err returns the failed checks as Errors, or nil if there were none.
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// Numeric reports whether s is one or more ASCII digits.
+func Numeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Alphanumeric reports whether s is one or more ASCII letters and digits.
+func Alphanumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Digits reports whether s is between min and max ASCII digits long.
+func Digits(s string, min, max int) bool {
+	return Numeric(s) && len(s) >= min && len(s) <= max
+}
+
+// MaxLen reports whether s is at most n characters long. Like Postgres'
+// VARCHAR(n), it counts characters rather than bytes.
+func MaxLen(s string, n int) bool {
+	return utf8.RuneCountInString(s) <= n
+}
+
+// Between reports whether n is nil or within [min, max].
+func Between[T ~int16 | ~int32 | ~int64 | ~int](n *T, min, max T) bool {
+	return n == nil || (*n >= min && *n <= max)
+}
+
+// Email reports whether s is a bare email address the database accepts.
+func Email(s string) bool {
+	return domain.ValidEmail(s)
+}
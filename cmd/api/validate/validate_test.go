@@ -0,0 +1,68 @@
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+func TestValidator(t *testing.T) {
+	var v Validator
+	if v.Err() != nil {
+		t.Fatal("expected no error from an unused validator")
+	}
+
+	v.Check(true, "ok", "never recorded")
+	v.Check(false, "numerical_id", "must be 4-5 digits")
+	v.Check(false, "images[1].caption", "too long")
+
+	var errs Errors
+	if !errors.As(v.Err(), &errs) {
+		t.Fatalf("expected Errors, got %T", v.Err())
+	}
+	want := Errors{
+		{Field: "numerical_id", Message: "must be 4-5 digits"},
+		{Field: "images[1].caption", Message: "too long"},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("expected %v, got %v", want, errs)
+	}
+	if got := errs.Error(); got != "numerical_id: must be 4-5 digits; images[1].caption: too long" {
+		t.Errorf("unexpected message %q", got)
+	}
+}
+
+func TestRules(t *testing.T) {
+	five, six := int16(5), int16(6)
+
+	tests := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{"numeric", Numeric("0123"), true},
+		{"numeric_empty", Numeric(""), false},
+		{"numeric_sign", Numeric("-1"), false},
+		{"alphanumeric", Alphanumeric("aB3"), true},
+		{"alphanumeric_empty", Alphanumeric(""), false},
+		{"alphanumeric_space", Alphanumeric("a b"), false},
+		{"digits_short", Digits("123", 4, 5), false},
+		{"digits", Digits("01234", 4, 5), true},
+		{"digits_long", Digits("012345", 4, 5), false},
+		{"max_len_counts_characters", MaxLen("ñññ", 3), true},
+		{"max_len_over", MaxLen("abcd", 3), false},
+		{"between_nil", Between[int16](nil, domain.MinScore, domain.MaxScore), true},
+		{"between", Between(&five, domain.MinScore, domain.MaxScore), true},
+		{"between_over", Between(&six, domain.MinScore, domain.MaxScore), false},
+		{"email", Email("rider@example.com"), true},
+		{"email_invalid", Email("rider"), false},
+		{"email_display_name", Email("Rider <rider@example.com>"), false},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, tt.got)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Command import-gbfs upserts stations from a GBFS station_information feed
+// and records availability from its station_status feed. Feeds are read from
+// a URL or, for offline runs, a file:
+//
+//	import-gbfs -info station_information.json -status station_status.json
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/scardozos/rottenbikes/internal/domain"
+	"github.com/scardozos/rottenbikes/internal/gbfs"
+)
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+func main() {
+	info := flag.String("info", "", "station_information feed, URL or file path (required)")
+	status := flag.String("status", "", "station_status feed, URL or file path")
+	timeout := flag.Duration("timeout", time.Minute, "give up after this long")
+	flag.Parse()
+
+	if *info == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		dsn = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
+			getEnv("DB_USER", "rottenbikes"),
+			getEnv("DB_PASSWORD", "rottenbikes"),
+			getEnv("DB_HOST", "localhost"),
+			getEnv("DB_PORT", "5432"),
+			getEnv("DB_NAME", "rottenbikes"),
+			getEnv("DB_SSLMODE", "disable"))
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to open db")
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	feed, err := gbfs.Load(ctx, &http.Client{Timeout: 30 * time.Second}, *info, *status)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load feed")
+	}
+
+	res, err := domain.NewStore(db).ImportStationFeed(ctx, feed.Stations, feed.Availability)
+	if err != nil {
+		log.Fatal().Err(err).Msg("import failed")
+	}
+
+	log.Info().
+		Int("created", res.Created).
+		Int("updated", res.Updated).
+		Int("snapshots", res.Snapshots).
+		Msg("gbfs feed imported")
+}
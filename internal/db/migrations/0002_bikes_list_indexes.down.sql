@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_bikes_electric;
+DROP INDEX IF EXISTS idx_bikes_created;
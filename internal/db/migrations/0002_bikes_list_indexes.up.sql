@@ -0,0 +1,3 @@
+-- Keyset pagination over GET /bikes sorted by creation time
+CREATE INDEX idx_bikes_created ON bikes (created_ts, numerical_id);
+CREATE INDEX idx_bikes_electric ON bikes (is_electric);
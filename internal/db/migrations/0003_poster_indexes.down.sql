@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_bikes_creator;
+DROP INDEX IF EXISTS idx_reviews_poster_created;
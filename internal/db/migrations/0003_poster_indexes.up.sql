@@ -0,0 +1,3 @@
+-- Poster profiles: review history and bikes created per poster
+CREATE INDEX idx_reviews_poster_created ON reviews (poster_id, created_ts);
+CREATE INDEX idx_bikes_creator ON bikes (creator_id);
@@ -0,0 +1,2 @@
+ALTER TABLE magic_links DROP CONSTRAINT IF EXISTS new_email_valid;
+ALTER TABLE magic_links DROP COLUMN IF EXISTS new_email;
@@ -0,0 +1,3 @@
+-- Email change: the pending address rides on the magic link until confirmed
+ALTER TABLE magic_links ADD COLUMN new_email TEXT;
+ALTER TABLE magic_links ADD CONSTRAINT new_email_valid CHECK (new_email ~* '^[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}$');
@@ -0,0 +1,16 @@
+ALTER TABLE magic_links DROP COLUMN IF EXISTS device_label;
+
+ALTER TABLE posters ADD COLUMN api_token TEXT;
+ALTER TABLE posters ADD COLUMN api_token_expires_ts TIMESTAMPTZ;
+
+-- Fall back to the longest-lived session of each poster
+UPDATE posters p
+SET api_token = s.token, api_token_expires_ts = s.expires_ts
+FROM (
+    SELECT DISTINCT ON (poster_id) poster_id, token, expires_ts
+    FROM sessions
+    ORDER BY poster_id, expires_ts DESC
+) s
+WHERE s.poster_id = p.poster_id;
+
+DROP TABLE IF EXISTS sessions;
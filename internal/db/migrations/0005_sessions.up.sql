@@ -0,0 +1,24 @@
+-- One row per logged-in device, replacing the single posters.api_token
+CREATE TABLE sessions (
+    session_id   BIGSERIAL PRIMARY KEY,
+    poster_id    BIGINT      NOT NULL REFERENCES posters(poster_id) ON DELETE CASCADE,
+    token        TEXT        NOT NULL UNIQUE,
+    device_label TEXT,
+    created_ts   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_ts TIMESTAMPTZ,
+    expires_ts   TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_sessions_poster ON sessions (poster_id);
+
+-- Keep existing logins working as an unnamed session each
+INSERT INTO sessions (poster_id, token, expires_ts)
+SELECT poster_id, api_token, api_token_expires_ts
+FROM posters
+WHERE api_token IS NOT NULL AND api_token_expires_ts > NOW();
+
+ALTER TABLE posters DROP COLUMN api_token;
+ALTER TABLE posters DROP COLUMN api_token_expires_ts;
+
+-- Label for the session a magic link will create once confirmed
+ALTER TABLE magic_links ADD COLUMN device_label TEXT;
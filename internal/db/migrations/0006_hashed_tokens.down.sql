@@ -0,0 +1,9 @@
+-- Hashes can't be reversed: every session and magic link is invalidated.
+DELETE FROM sessions;
+DELETE FROM magic_links;
+
+ALTER TABLE magic_links DROP COLUMN IF EXISTS sealed_api_token;
+ALTER TABLE magic_links ADD COLUMN api_token TEXT;
+ALTER TABLE magic_links RENAME COLUMN token_hash TO token;
+
+ALTER TABLE sessions RENAME COLUMN token_hash TO token;
@@ -0,0 +1,12 @@
+-- Tokens are stored as the hex SHA-256 of their value. Re-hashing in place
+-- keeps existing sessions and pending magic links working.
+ALTER TABLE sessions RENAME COLUMN token TO token_hash;
+UPDATE sessions SET token_hash = encode(sha256(convert_to(token_hash, 'UTF8')), 'hex');
+
+ALTER TABLE magic_links RENAME COLUMN token TO token_hash;
+UPDATE magic_links SET token_hash = encode(sha256(convert_to(token_hash, 'UTF8')), 'hex');
+
+-- Plaintext api tokens kept for polling are dropped; confirmed links now
+-- hold the token sealed with the magic token until it is polled once.
+ALTER TABLE magic_links DROP COLUMN api_token;
+ALTER TABLE magic_links ADD COLUMN sealed_api_token TEXT;
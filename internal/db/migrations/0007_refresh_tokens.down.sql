@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS refresh_tokens;
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS access_expires_ts;
+ALTER TABLE sessions RENAME COLUMN access_token_hash TO token_hash;
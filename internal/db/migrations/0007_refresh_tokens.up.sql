@@ -0,0 +1,19 @@
+-- Sessions carry a short-lived access token; expires_ts is now the sliding
+-- end of the session, pushed forward on every refresh.
+ALTER TABLE sessions RENAME COLUMN token_hash TO access_token_hash;
+ALTER TABLE sessions ADD COLUMN access_expires_ts TIMESTAMPTZ;
+-- Existing tokens have no refresh token, so they get the same 15 minute
+-- access window as new ones (capped by the session) and then log in again.
+UPDATE sessions SET access_expires_ts = LEAST(expires_ts, NOW() + INTERVAL '15 minutes');
+ALTER TABLE sessions ALTER COLUMN access_expires_ts SET NOT NULL;
+
+-- Every refresh token ever issued for a session (its family). Rotated ones
+-- are kept so that a replay can be detected and the session revoked.
+CREATE TABLE refresh_tokens (
+    token_hash TEXT        PRIMARY KEY,
+    session_id BIGINT      NOT NULL REFERENCES sessions(session_id) ON DELETE CASCADE,
+    created_ts TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    rotated_ts TIMESTAMPTZ
+);
+
+CREATE INDEX idx_refresh_tokens_session ON refresh_tokens (session_id);
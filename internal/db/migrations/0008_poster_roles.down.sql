@@ -0,0 +1,2 @@
+ALTER TABLE posters DROP CONSTRAINT IF EXISTS role_valid;
+ALTER TABLE posters DROP COLUMN IF EXISTS role;
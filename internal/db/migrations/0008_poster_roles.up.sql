@@ -0,0 +1,3 @@
+-- Privilege level; promote with UPDATE posters SET role = 'admin' WHERE ...
+ALTER TABLE posters ADD COLUMN role TEXT NOT NULL DEFAULT 'user';
+ALTER TABLE posters ADD CONSTRAINT role_valid CHECK (role IN ('user', 'moderator', 'admin'));
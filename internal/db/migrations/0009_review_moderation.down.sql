@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS moderation_actions;
+DROP TABLE IF EXISTS review_reports;
+ALTER TABLE reviews DROP COLUMN IF EXISTS hidden_by;
+ALTER TABLE reviews DROP COLUMN IF EXISTS hidden_ts;
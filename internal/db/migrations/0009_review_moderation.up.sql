@@ -0,0 +1,32 @@
+-- Hidden reviews stay in the table but drop out of listings and aggregates.
+ALTER TABLE reviews ADD COLUMN hidden_ts TIMESTAMPTZ;
+ALTER TABLE reviews ADD COLUMN hidden_by BIGINT REFERENCES posters(poster_id) ON DELETE SET NULL;
+
+-- One report per review per reporter; resolved when a moderator acts on it.
+CREATE TABLE review_reports (
+    report_id   BIGSERIAL PRIMARY KEY,
+    review_id   BIGINT      NOT NULL REFERENCES reviews(review_id) ON DELETE CASCADE,
+    reporter_id BIGINT      REFERENCES posters(poster_id) ON DELETE SET NULL,
+    reason      TEXT        NOT NULL,
+    created_ts  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_ts TIMESTAMPTZ,
+    resolved_by BIGINT      REFERENCES posters(poster_id) ON DELETE SET NULL,
+
+    CONSTRAINT review_reports_once UNIQUE (review_id, reporter_id)
+);
+
+CREATE INDEX idx_review_reports_open ON review_reports (report_id) WHERE resolved_ts IS NULL;
+
+-- Log of moderator actions. review_id has no FK so the record of a delete
+-- outlives the review it deleted.
+CREATE TABLE moderation_actions (
+    action_id    BIGSERIAL PRIMARY KEY,
+    review_id    BIGINT      NOT NULL,
+    moderator_id BIGINT      REFERENCES posters(poster_id) ON DELETE SET NULL,
+    action       TEXT        NOT NULL,
+    created_ts   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT moderation_action_valid CHECK (action IN ('hide', 'unhide', 'delete'))
+);
+
+CREATE INDEX idx_moderation_actions_review ON moderation_actions (review_id);
@@ -0,0 +1,26 @@
+-- Append-only record of mutations. actor_id has no FK so events survive the
+-- deletion of the poster who caused them.
+CREATE TABLE audit_events (
+    event_id    BIGSERIAL PRIMARY KEY,
+    actor_id    BIGINT,
+    action      TEXT        NOT NULL,
+    entity_type TEXT        NOT NULL,
+    entity_id   TEXT        NOT NULL,
+    before      JSONB,
+    after       JSONB,
+    request_id  TEXT,
+    created_ts  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_events_entity ON audit_events (entity_type, entity_id, event_id DESC);
+CREATE INDEX idx_audit_events_actor ON audit_events (actor_id, event_id DESC);
+
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_reviews_deleted;
+DROP INDEX IF EXISTS idx_bikes_deleted;
+ALTER TABLE reviews DROP COLUMN IF EXISTS deleted_ts;
+ALTER TABLE bikes DROP COLUMN IF EXISTS deleted_ts;
@@ -0,0 +1,7 @@
+-- Deleted bikes and reviews keep their rows (and a bike its reviews) until
+-- the purge job removes them after the retention period.
+ALTER TABLE bikes ADD COLUMN deleted_ts TIMESTAMPTZ;
+ALTER TABLE reviews ADD COLUMN deleted_ts TIMESTAMPTZ;
+
+CREATE INDEX idx_bikes_deleted ON bikes (deleted_ts) WHERE deleted_ts IS NOT NULL;
+CREATE INDEX idx_reviews_deleted ON reviews (deleted_ts) WHERE deleted_ts IS NOT NULL;
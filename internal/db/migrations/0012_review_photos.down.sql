@@ -0,0 +1,2 @@
+ALTER TABLE reviews DROP COLUMN IF EXISTS thumb_key;
+ALTER TABLE reviews DROP COLUMN IF EXISTS image_key;
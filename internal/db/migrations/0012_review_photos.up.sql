@@ -0,0 +1,4 @@
+-- Blob store keys of an uploaded review photo and its thumbnail. Both are set
+-- together; the legacy bike_img URL column is left as it was.
+ALTER TABLE reviews ADD COLUMN image_key TEXT;
+ALTER TABLE reviews ADD COLUMN thumb_key TEXT;
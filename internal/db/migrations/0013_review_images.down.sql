@@ -0,0 +1,26 @@
+-- Only the first link and the first upload of each review survive.
+ALTER TABLE reviews ADD COLUMN bike_img TEXT;
+ALTER TABLE reviews ADD COLUMN image_key TEXT;
+ALTER TABLE reviews ADD COLUMN thumb_key TEXT;
+
+UPDATE reviews r
+SET bike_img = ri.url
+FROM (
+    SELECT DISTINCT ON (review_id) review_id, url
+    FROM review_images
+    WHERE url IS NOT NULL
+    ORDER BY review_id, position
+) ri
+WHERE ri.review_id = r.review_id;
+
+UPDATE reviews r
+SET image_key = ri.image_key, thumb_key = ri.thumb_key
+FROM (
+    SELECT DISTINCT ON (review_id) review_id, image_key, thumb_key
+    FROM review_images
+    WHERE image_key IS NOT NULL
+    ORDER BY review_id, position
+) ri
+WHERE ri.review_id = r.review_id;
+
+DROP TABLE IF EXISTS review_images;
@@ -0,0 +1,32 @@
+-- Photos attached to a review, in display order. An image is either a link
+-- to an external URL or a pair of blob keys from an upload.
+CREATE TABLE review_images (
+    image_id   BIGSERIAL PRIMARY KEY,
+    review_id  BIGINT      NOT NULL REFERENCES reviews(review_id) ON DELETE CASCADE,
+    position   SMALLINT    NOT NULL,
+    url        TEXT,
+    image_key  TEXT,
+    thumb_key  TEXT,
+    caption    TEXT,
+    created_ts TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- deferred so a reorder can swap positions inside one transaction
+    CONSTRAINT review_images_position UNIQUE (review_id, position) DEFERRABLE INITIALLY DEFERRED,
+    CONSTRAINT review_images_source CHECK ((url IS NULL) <> (image_key IS NULL))
+);
+
+-- Carry over the single image a review could have until now: the bike_img
+-- link first, then an uploaded photo.
+INSERT INTO review_images (review_id, position, url, created_ts)
+SELECT review_id, 0, bike_img, created_ts
+FROM reviews
+WHERE bike_img IS NOT NULL AND bike_img <> '';
+
+INSERT INTO review_images (review_id, position, image_key, thumb_key, created_ts)
+SELECT review_id, CASE WHEN bike_img IS NOT NULL AND bike_img <> '' THEN 1 ELSE 0 END, image_key, thumb_key, created_ts
+FROM reviews
+WHERE image_key IS NOT NULL;
+
+ALTER TABLE reviews DROP COLUMN bike_img;
+ALTER TABLE reviews DROP COLUMN image_key;
+ALTER TABLE reviews DROP COLUMN thumb_key;
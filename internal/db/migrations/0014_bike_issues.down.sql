@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS issue_confirmations;
+DROP TABLE IF EXISTS bike_issues;
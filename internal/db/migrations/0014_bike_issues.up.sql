@@ -0,0 +1,29 @@
+-- Problems riders report on a bike. A bike has at most one open issue of each
+-- type; once resolved, the same problem can be reported again.
+CREATE TABLE bike_issues (
+    issue_id          BIGSERIAL PRIMARY KEY,
+    bike_numerical_id TEXT        NOT NULL REFERENCES bikes(numerical_id) ON DELETE CASCADE,
+    issue_type        TEXT        NOT NULL,
+    reporter_id       BIGINT      REFERENCES posters(poster_id) ON DELETE SET NULL,
+    note              TEXT,
+    created_ts        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_ts       TIMESTAMPTZ,
+    resolved_by       BIGINT      REFERENCES posters(poster_id) ON DELETE SET NULL,
+
+    CONSTRAINT bike_issue_type_valid CHECK (issue_type IN ('flat_tyre', 'broken_brake', 'missing_pedal', 'battery_dead'))
+);
+
+CREATE UNIQUE INDEX idx_bike_issues_open ON bike_issues (bike_numerical_id, issue_type) WHERE resolved_ts IS NULL;
+CREATE INDEX idx_bike_issues_bike ON bike_issues (bike_numerical_id, issue_id DESC);
+
+-- Other riders saying whether an issue is still there. A poster has one
+-- confirmation per issue and can change their mind.
+CREATE TABLE issue_confirmations (
+    issue_id   BIGINT      NOT NULL REFERENCES bike_issues(issue_id) ON DELETE CASCADE,
+    poster_id  BIGINT      NOT NULL REFERENCES posters(poster_id) ON DELETE CASCADE,
+    status     TEXT        NOT NULL,
+    created_ts TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (issue_id, poster_id),
+    CONSTRAINT issue_confirmation_status_valid CHECK (status IN ('still_broken', 'fixed'))
+);
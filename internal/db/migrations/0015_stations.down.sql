@@ -0,0 +1,4 @@
+ALTER TABLE bikes DROP COLUMN IF EXISTS last_seen_ts;
+ALTER TABLE bikes DROP COLUMN IF EXISTS last_station_id;
+DROP TABLE IF EXISTS bike_sightings;
+DROP TABLE IF EXISTS stations;
@@ -0,0 +1,36 @@
+-- Docking stations. station_id is the operator's id (GBFS station_id), so it
+-- is text and imports can upsert by it.
+CREATE TABLE stations (
+    station_id TEXT             PRIMARY KEY,
+    name       TEXT             NOT NULL,
+    lat        DOUBLE PRECISION NOT NULL,
+    lon        DOUBLE PRECISION NOT NULL,
+    capacity   INTEGER,
+    created_ts TIMESTAMPTZ      NOT NULL DEFAULT NOW(),
+    updated_ts TIMESTAMPTZ      NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT station_lat_valid CHECK (lat BETWEEN -90 AND 90),
+    CONSTRAINT station_lon_valid CHECK (lon BETWEEN -180 AND 180),
+    CONSTRAINT station_capacity_valid CHECK (capacity >= 0)
+);
+
+-- Where and when a poster saw a bike, either on its own or while reviewing
+-- it.
+CREATE TABLE bike_sightings (
+    sighting_id       BIGSERIAL PRIMARY KEY,
+    bike_numerical_id TEXT        NOT NULL REFERENCES bikes(numerical_id) ON DELETE CASCADE,
+    station_id        TEXT        NOT NULL REFERENCES stations(station_id) ON DELETE CASCADE,
+    poster_id         BIGINT      REFERENCES posters(poster_id) ON DELETE SET NULL,
+    review_id         BIGINT      REFERENCES reviews(review_id) ON DELETE SET NULL,
+    seen_ts           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_bike_sightings_bike ON bike_sightings (bike_numerical_id, seen_ts DESC);
+CREATE INDEX idx_bike_sightings_station ON bike_sightings (station_id, seen_ts DESC);
+
+-- The latest sighting is copied onto the bike so listings can filter and sort
+-- on it without scanning the history.
+ALTER TABLE bikes ADD COLUMN last_station_id TEXT REFERENCES stations(station_id) ON DELETE SET NULL;
+ALTER TABLE bikes ADD COLUMN last_seen_ts TIMESTAMPTZ;
+
+CREATE INDEX idx_bikes_last_station ON bikes (last_station_id, last_seen_ts DESC) WHERE last_station_id IS NOT NULL;
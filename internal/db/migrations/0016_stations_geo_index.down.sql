@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_stations_lat_lon;
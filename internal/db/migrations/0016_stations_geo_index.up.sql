@@ -0,0 +1,3 @@
+-- Nearby searches prefilter stations with a lat/lon bounding box before
+-- computing exact distances; this index answers the box.
+CREATE INDEX idx_stations_lat_lon ON stations (lat, lon);
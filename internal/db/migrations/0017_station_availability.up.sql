@@ -0,0 +1,18 @@
+-- Bike and dock counts per station, as the operator's GBFS station_status
+-- feed reported them. A station that hasn't reported since the previous
+-- import keeps its reported_ts, so the unique key turns the repeat into a
+-- no-op.
+CREATE TABLE station_availability (
+    snapshot_id      BIGSERIAL   PRIMARY KEY,
+    station_id       TEXT        NOT NULL REFERENCES stations(station_id) ON DELETE CASCADE,
+    bikes_available  INTEGER     NOT NULL,
+    ebikes_available INTEGER,
+    docks_available  INTEGER,
+    is_renting       BOOLEAN     NOT NULL,
+    is_returning     BOOLEAN     NOT NULL,
+    reported_ts      TIMESTAMPTZ NOT NULL,
+    recorded_ts      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT station_availability_counts_valid CHECK (bikes_available >= 0 AND ebikes_available >= 0 AND docks_available >= 0),
+    CONSTRAINT station_availability_reported_unique UNIQUE (station_id, reported_ts)
+);
@@ -0,0 +1,17 @@
+-- Account exports too large to download inline are built in the background
+-- and stored as a blob; the poster is emailed a link carrying a token whose
+-- hash is kept here.
+CREATE TABLE data_exports (
+    export_id  BIGSERIAL   PRIMARY KEY,
+    poster_id  BIGINT      NOT NULL REFERENCES posters(poster_id) ON DELETE CASCADE,
+    token_hash TEXT        NOT NULL UNIQUE,
+    blob_key   TEXT        NOT NULL,
+    format     TEXT        NOT NULL,
+    size_bytes BIGINT      NOT NULL,
+    created_ts TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_ts TIMESTAMPTZ NOT NULL,
+
+    CONSTRAINT data_export_format_valid CHECK (format IN ('json', 'zip'))
+);
+
+CREATE INDEX idx_data_exports_expires ON data_exports (expires_ts);
@@ -0,0 +1,11 @@
+-- Token buckets for the Postgres rate limiter, shared by every API replica.
+-- A bucket's row can be dropped once it has refilled (full_ts), as a
+-- missing bucket counts as full.
+CREATE TABLE rate_limit_buckets (
+    bucket_key TEXT             PRIMARY KEY,
+    tokens     DOUBLE PRECISION NOT NULL,
+    updated_ts TIMESTAMPTZ      NOT NULL,
+    full_ts    TIMESTAMPTZ      NOT NULL
+);
+
+CREATE INDEX idx_rate_limit_buckets_full ON rate_limit_buckets (full_ts);
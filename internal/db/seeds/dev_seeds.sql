@@ -20,11 +20,10 @@ ON CONFLICT (numerical_id) DO NOTHING;
 
 -- Sample reviews
 -- Alice reviews bike 1
-INSERT INTO reviews (poster_id, bike_numerical_id, bike_img, comment)
+INSERT INTO reviews (poster_id, bike_numerical_id, comment)
 SELECT
     (SELECT poster_id FROM posters WHERE username = 'alice' LIMIT 1),
     '1001',
-    'https://example.com/bike1_alice.jpg',
     'Solid bike, comfy seat'
 WHERE NOT EXISTS (
     SELECT 1 FROM reviews
@@ -33,11 +32,10 @@ WHERE NOT EXISTS (
 );
 
 -- Bob reviews bike 1
-INSERT INTO reviews (poster_id, bike_numerical_id, bike_img, comment)
+INSERT INTO reviews (poster_id, bike_numerical_id, comment)
 SELECT
     (SELECT poster_id FROM posters WHERE username = 'bob' LIMIT 1),
     '1001',
-    'https://example.com/bike1_bob.jpg',
     'Good power but weak breaks'
 WHERE NOT EXISTS (
     SELECT 1 FROM reviews
@@ -46,11 +44,10 @@ WHERE NOT EXISTS (
 );
 
 -- Carol reviews bike 2
-INSERT INTO reviews (poster_id, bike_numerical_id, bike_img, comment)
+INSERT INTO reviews (poster_id, bike_numerical_id, comment)
 SELECT
     (SELECT poster_id FROM posters WHERE username = 'carol' LIMIT 1),
     '1002',
-    'https://example.com/bike2_carol.jpg',
     'Great for commuting'
 WHERE NOT EXISTS (
     SELECT 1 FROM reviews
@@ -58,6 +55,62 @@ WHERE NOT EXISTS (
       AND bike_numerical_id = '1002'
 );
 
+-- Sample review_images
+INSERT INTO review_images (review_id, position, url, caption)
+SELECT r.review_id, v.position, v.url, v.caption
+FROM reviews r
+JOIN posters p ON r.poster_id = p.poster_id
+JOIN (VALUES
+    ('alice', '1001', 0, 'https://example.com/bike1_alice.jpg', NULL),
+    ('bob',   '1001', 0, 'https://example.com/bike1_bob.jpg', 'Front brake'),
+    ('bob',   '1001', 1, 'https://example.com/bike1_bob_seat.jpg', 'Torn seat'),
+    ('carol', '1002', 0, 'https://example.com/bike2_carol.jpg', NULL)
+) AS v (username, bike, position, url, caption)
+    ON v.username = p.username AND v.bike = r.bike_numerical_id
+WHERE NOT EXISTS (SELECT 1 FROM review_images ri WHERE ri.review_id = r.review_id);
+
+-- Sample stations and sightings
+INSERT INTO stations (station_id, name, lat, lon, capacity)
+VALUES
+    ('1', 'C/ Gran Via Corts Catalanes, 760', 41.3979, 2.1801, 46),
+    ('2', 'C/ Roger de Flor, 126',            41.3955, 2.1771, 27)
+ON CONFLICT (station_id) DO NOTHING;
+
+INSERT INTO bike_sightings (bike_numerical_id, station_id, poster_id)
+SELECT v.bike, v.station, p.poster_id
+FROM (VALUES
+    ('bob',   '1001', '1'),
+    ('carol', '1002', '2')
+) AS v (username, bike, station)
+JOIN posters p ON p.username = v.username
+WHERE NOT EXISTS (SELECT 1 FROM bike_sightings s WHERE s.bike_numerical_id = v.bike);
+
+UPDATE bikes b
+SET last_station_id = s.station_id, last_seen_ts = s.seen_ts
+FROM (
+    SELECT DISTINCT ON (bike_numerical_id) bike_numerical_id, station_id, seen_ts
+    FROM bike_sightings
+    ORDER BY bike_numerical_id, seen_ts DESC
+) s
+WHERE s.bike_numerical_id = b.numerical_id;
+
+-- Sample bike_issues and confirmations
+INSERT INTO bike_issues (bike_numerical_id, issue_type, reporter_id, note)
+SELECT v.bike, v.issue_type, p.poster_id, v.note
+FROM (VALUES
+    ('bob',   '1001', 'broken_brake', 'Front brake barely grips'),
+    ('carol', '1002', 'battery_dead', NULL)
+) AS v (username, bike, issue_type, note)
+JOIN posters p ON p.username = v.username
+ON CONFLICT DO NOTHING;
+
+INSERT INTO issue_confirmations (issue_id, poster_id, status)
+SELECT i.issue_id, p.poster_id, 'still_broken'
+FROM bike_issues i
+JOIN posters p ON p.username = 'alice'
+WHERE i.bike_numerical_id = '1001' AND i.issue_type = 'broken_brake' AND i.resolved_ts IS NULL
+ON CONFLICT (issue_id, poster_id) DO NOTHING;
+
 -- Sample review_ratings (per subcategory)
 INSERT INTO review_ratings (review_id, subcategory, score)
 SELECT r.review_id, 'overall', 4
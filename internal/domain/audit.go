@@ -0,0 +1,227 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Audited actions, named <entity>.<verb>.
+const (
+	AuditBikeCreate        = "bike.create"
+	AuditBikeImport        = "bike.import"
+	AuditBikeUpdate        = "bike.update"
+	AuditBikeDelete        = "bike.delete"
+	AuditBikeRestore       = "bike.restore"
+	AuditBikePurge         = "bike.purge"
+	AuditPosterRename      = "poster.rename"
+	AuditPosterEmailChange = "poster.email_change"
+	AuditPosterDelete      = "poster.delete"
+	AuditReviewCreate      = "review.create"
+	AuditReviewUpdate      = "review.update"
+	AuditReviewDelete      = "review.delete"
+	AuditReviewHide        = "review.hide"
+	AuditReviewUnhide      = "review.unhide"
+	AuditReviewRestore     = "review.restore"
+	AuditReviewPurge       = "review.purge"
+	AuditIssueReport       = "issue.report"
+	AuditIssueConfirm      = "issue.confirm"
+)
+
+// auditSystem is the actor of changes made by background jobs rather than a
+// poster; it is stored as a null actor_id.
+const auditSystem int64 = 0
+
+// AuditEvent is one row of the append-only audit log. Before and After are
+// JSON images of the entity; either is null when it didn't exist on that side
+// of the change.
+type AuditEvent struct {
+	EventID    int64           `json:"event_id"`
+	ActorID    *int64          `json:"actor_id"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	RequestID  *string         `json:"request_id"`
+	CreatedAt  time.Time       `json:"created_ts"`
+}
+
+type ListAuditEventsQuery struct {
+	Cursor     string
+	Limit      int
+	ActorID    *int64
+	Action     string
+	EntityType string
+	EntityID   string
+	Since      *time.Time
+	Until      *time.Time
+}
+
+const auditCursorSort = "audit"
+
+type requestIDKey struct{}
+
+// WithRequestID tags ctx with the id of the HTTP request it serves, so audit
+// events can be matched with access logs.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Snapshot queries used for before/after images. Each takes the entity id as
+// $1 and locks the row so the image matches what the mutation changes.
+const (
+	bikeSnapshotSQL = `
+		SELECT to_jsonb(b)
+		FROM bikes b
+		WHERE b.numerical_id = $1
+		FOR UPDATE`
+
+	reviewSnapshotSQL = `
+		SELECT to_jsonb(r) || jsonb_build_object(
+			'ratings', COALESCE(
+				(SELECT jsonb_object_agg(rr.subcategory, rr.score)
+				 FROM review_ratings rr
+				 WHERE rr.review_id = r.review_id), '{}'::jsonb),
+			'images', ` + reviewImagesAggSQL + `)
+		FROM reviews r
+		WHERE r.review_id = $1
+		FOR UPDATE OF r`
+
+	// The email is left out so the log doesn't keep it after an account is gone.
+	posterSnapshotSQL = `
+		SELECT to_jsonb(p) - 'email'
+		FROM posters p
+		WHERE p.poster_id = $1
+		FOR UPDATE`
+
+	issueSnapshotSQL = `
+		SELECT to_jsonb(i) || jsonb_build_object(
+			'confirmations', COALESCE(
+				(SELECT jsonb_object_agg(c.poster_id, c.status)
+				 FROM issue_confirmations c
+				 WHERE c.issue_id = i.issue_id), '{}'::jsonb))
+		FROM bike_issues i
+		WHERE i.issue_id = $1
+		FOR UPDATE OF i`
+)
+
+// snapshot runs one of the snapshot queries inside tx. It returns
+// sql.ErrNoRows when the entity doesn't exist.
+func snapshot(ctx context.Context, tx *sql.Tx, query string, id any) (json.RawMessage, error) {
+	var img []byte
+	if err := tx.QueryRowContext(ctx, query, id).Scan(&img); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// recordAudit appends an event to the audit log inside tx, so it commits or
+// rolls back together with the mutation it describes. actorID is auditSystem
+// for changes no poster asked for.
+func recordAudit(ctx context.Context, tx *sql.Tx, actorID int64, action, entityType, entityID string, before, after json.RawMessage) error {
+	actor := sql.NullInt64{Int64: actorID, Valid: actorID != auditSystem}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO audit_events (actor_id, action, entity_type, entity_id, before, after, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, actor, action, entityType, entityID, jsonOrNull(before), jsonOrNull(after), nullIfEmpty(requestIDFromContext(ctx))); err != nil {
+		return fmt.Errorf("record audit event: %w", err)
+	}
+	return nil
+}
+
+func jsonOrNull(b json.RawMessage) any {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
+
+// ListAuditEvents pages over the audit log, newest first.
+func (s *Store) ListAuditEvents(ctx context.Context, q ListAuditEventsQuery) ([]AuditEvent, string, error) {
+	limit := clampLimit(q.Limit)
+
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := []string{"TRUE"}
+	if q.ActorID != nil {
+		where = append(where, "actor_id = "+arg(*q.ActorID))
+	}
+	if q.Action != "" {
+		where = append(where, "action = "+arg(q.Action))
+	}
+	if q.EntityType != "" {
+		where = append(where, "entity_type = "+arg(q.EntityType))
+	}
+	if q.EntityID != "" {
+		where = append(where, "entity_id = "+arg(q.EntityID))
+	}
+	if q.Since != nil {
+		where = append(where, "created_ts >= "+arg(*q.Since))
+	}
+	if q.Until != nil {
+		where = append(where, "created_ts < "+arg(*q.Until))
+	}
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor, auditCursorSort, "", "bigint")
+		if err != nil {
+			return nil, "", err
+		}
+		where = append(where, "event_id < "+arg(c.ID)+"::bigint")
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT event_id, actor_id, action, entity_type, entity_id, before, after, request_id, created_ts
+		FROM audit_events
+		WHERE %s
+		ORDER BY event_id DESC
+		LIMIT %s
+	`, strings.Join(where, " AND "), arg(limit+1)), args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var out []AuditEvent
+	for rows.Next() {
+		var e AuditEvent
+		var actorID sql.NullInt64
+		var requestID sql.NullString
+		if err := rows.Scan(&e.EventID, &actorID, &e.Action, &e.EntityType, &e.EntityID,
+			(*[]byte)(&e.Before), (*[]byte)(&e.After), &requestID, &e.CreatedAt); err != nil {
+			return nil, "", err
+		}
+		if actorID.Valid {
+			e.ActorID = &actorID.Int64
+		}
+		if requestID.Valid {
+			e.RequestID = &requestID.String
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if len(out) <= limit {
+		return out, "", nil
+	}
+	out = out[:limit]
+	return out, encodeCursor(pageCursor{
+		Sort: auditCursorSort,
+		ID:   strconv.FormatInt(out[limit-1].EventID, 10),
+	}), nil
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestListAuditEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	store := NewStore(db)
+	columns := []string{"event_id", "actor_id", "action", "entity_type", "entity_id", "before", "after", "request_id", "created_ts"}
+	actorID := int64(3)
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM audit_events WHERE TRUE AND actor_id = \\$1 AND entity_type = \\$2 AND entity_id = \\$3 AND created_ts >= \\$4 ORDER BY event_id DESC LIMIT \\$5").
+		WithArgs(actorID, "bike", "1234", since, 2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(9, 3, AuditBikeDelete, "bike", "1234", []byte(`{"numerical_id":"1234"}`), nil, "req-9", time.Now()).
+			AddRow(5, 3, AuditBikeUpdate, "bike", "1234", []byte(`{}`), []byte(`{}`), nil, time.Now()))
+
+	events, cursor, err := store.ListAuditEvents(ctx, ListAuditEventsQuery{
+		Limit:      1,
+		ActorID:    &actorID,
+		EntityType: "bike",
+		EntityID:   "1234",
+		Since:      &since,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 1 || events[0].Action != AuditBikeDelete || events[0].After != nil {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	if string(events[0].Before) != `{"numerical_id":"1234"}` || *events[0].RequestID != "req-9" {
+		t.Errorf("unexpected images: before %s, request %v", events[0].Before, events[0].RequestID)
+	}
+	if cursor == "" {
+		t.Fatal("expected a next cursor")
+	}
+
+	mock.ExpectQuery("WHERE TRUE AND event_id < \\$1::bigint ORDER BY event_id DESC LIMIT \\$2").
+		WithArgs("9", 2).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	if _, cursor, err = store.ListAuditEvents(ctx, ListAuditEventsQuery{Cursor: cursor, Limit: 1}); err != nil || cursor != "" {
+		t.Errorf("expected empty last page, got cursor %q, err %v", cursor, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -5,19 +5,45 @@ import (
 	"crypto/rand"
 	"database/sql"
 	"encoding/hex"
-	"errors"
+	"encoding/json"
 	"fmt"
-	"net/mail"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
 
 var (
-	ErrRateLimitExceeded = errors.New("daily magic link limit reached")
-	ErrUserNotFound      = errors.New("user not found")
+	ErrRateLimitExceeded = &RateLimitedError{Code: "magic_link_limit", Message: "magic link limit reached"}
+	ErrUserNotFound      = &NotFoundError{Code: "user_not_found", Message: "user not found"}
+	ErrInvalidEmail      = &ValidationError{Code: "invalid_email", Message: "invalid email format"}
+	ErrInvalidUsername   = &ValidationError{Code: "invalid_username", Message: "username can only contain letters, numbers and dots"}
+	ErrEmailExists       = &ConflictError{Code: "email_exists", Message: "email already exists"}
+	ErrUsernameExists    = &ConflictError{Code: "username_exists", Message: "username already exists"}
+	ErrEmailUnchanged    = &ValidationError{Code: "email_unchanged", Message: "new email matches the current one"}
+	ErrInvalidToken      = &UnauthorizedError{Code: "invalid_token", Message: "invalid token"}
+	ErrTokenExpired      = &UnauthorizedError{Code: "token_expired", Message: "token expired or already used"}
+	ErrEmailNotVerified  = &UnauthorizedError{Code: "email_not_verified", Message: "email not verified"}
 )
 
+// usernamePattern allows alphanumerics and dots only.
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9.]+$`)
+
+// ValidUsername reports whether name is allowed as a username.
+func ValidUsername(name string) bool {
+	return usernamePattern.MatchString(name)
+}
+
+// emailPattern is the email_valid constraint on posters.email, which is
+// case-insensitive.
+var emailPattern = regexp.MustCompile(`(?i)^[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}$`)
+
+// ValidEmail reports whether email is a bare address the database accepts.
+// Display names ("Name <a@b.com>") are not.
+func ValidEmail(email string) bool {
+	return emailPattern.MatchString(email)
+}
+
 func randomToken(nBytes int) (string, error) {
 	b := make([]byte, nBytes)
 	if _, err := rand.Read(b); err != nil {
@@ -27,17 +53,15 @@ func randomToken(nBytes int) (string, error) {
 }
 
 type Poster struct {
-	PosterID          int64
-	Email             string
-	Username          string
-	APIToken          *string
-	APITokenExpiresAt *time.Time
-	EmailVerified     bool
+	PosterID      int64
+	Email         string
+	Username      string
+	EmailVerified bool
 }
 
-// Create or load poster by email or username, ensure a long-lived api_token exists,
-// and issue a single-use magic link token.
-func (s *Store) CreateMagicLink(ctx context.Context, identifier string) (magicToken string, email string, err error) {
+// Load poster by email or username and issue a single-use magic link token.
+// The session it logs into is only created once the link is confirmed.
+func (s *Store) CreateMagicLink(ctx context.Context, identifier, deviceLabel string) (magicToken string, email string, err error) {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return "", "", fmt.Errorf("begin tx: %w", err)
@@ -45,16 +69,14 @@ func (s *Store) CreateMagicLink(ctx context.Context, identifier string) (magicTo
 	defer tx.Rollback()
 
 	var posterID int64
-	var apiToken *string
-	var apiTokenExpires sql.NullTime
 	var userEmail string
 
 	// SELECT poster strictly by email OR username
 	err = tx.QueryRowContext(ctx, `
-		SELECT poster_id, api_token, api_token_expires_ts, email
+		SELECT poster_id, email
 		FROM posters
 		WHERE email = $1 OR username = $1
-	`, identifier).Scan(&posterID, &apiToken, &apiTokenExpires, &userEmail)
+	`, identifier).Scan(&posterID, &userEmail)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return "", "", ErrUserNotFound
@@ -62,20 +84,11 @@ func (s *Store) CreateMagicLink(ctx context.Context, identifier string) (magicTo
 		return "", "", fmt.Errorf("query poster: %w", err)
 	}
 
-	// Rate limit: max 2 links per user per 24 hours
-	var count int
-	err = tx.QueryRowContext(ctx, `
-		SELECT COUNT(*) FROM magic_links
-		WHERE poster_id = $1 AND created_ts > NOW() - INTERVAL '24 hours'
-	`, posterID).Scan(&count)
-	if err != nil {
-		return "", "", fmt.Errorf("check rate limit: %w", err)
-	}
-	if count >= 2 {
-		return "", "", ErrRateLimitExceeded
+	if err := s.checkMagicLinkQuota(ctx, tx, posterID); err != nil {
+		return "", "", err
 	}
 
-	magicToken, err = s.issueMagicLink(ctx, tx, posterID, apiToken, apiTokenExpires)
+	magicToken, err = s.issueMagicLink(ctx, tx, posterID, deviceLabel, nil)
 	if err != nil {
 		return "", "", err
 	}
@@ -87,17 +100,86 @@ func (s *Store) CreateMagicLink(ctx context.Context, identifier string) (magicTo
 	return magicToken, userEmail, nil
 }
 
-func (s *Store) Register(ctx context.Context, username, email string) (string, error) {
-	// Validate email format
-	_, err := mail.ParseAddress(email)
+// checkMagicLinkQuota holds the poster's magic link quota lock for the rest
+// of tx and fails once the quota is used up.
+func (s *Store) checkMagicLinkQuota(ctx context.Context, tx *sql.Tx, posterID int64) error {
+	if err := lockPoster(ctx, tx, lockMagicLinkQuota, posterID); err != nil {
+		return err
+	}
+	return checkQuota(ctx, tx, s.quotas.MagicLinks, ErrRateLimitExceeded, `
+		SELECT COUNT(*), MIN(created_ts) FROM magic_links
+		WHERE poster_id = $1 AND created_ts > $2
+	`, posterID)
+}
+
+// RequestEmailChange issues a magic link carrying newEmail as the pending
+// address. posters.email and email_verified stay untouched until the link is
+// confirmed through ConfirmMagicLink.
+func (s *Store) RequestEmailChange(ctx context.Context, posterID int64, newEmail string) (string, error) {
+	if !ValidEmail(newEmail) {
+		return "", ErrInvalidEmail.At("email")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var currentEmail string
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT email
+		FROM posters
+		WHERE poster_id = $1
+	`, posterID).Scan(&currentEmail)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrUserNotFound
+		}
+		return "", fmt.Errorf("query poster: %w", err)
+	}
+
+	if strings.EqualFold(currentEmail, newEmail) {
+		return "", ErrEmailUnchanged
+	}
+
+	var taken bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM posters WHERE email = $1)
+	`, newEmail).Scan(&taken); err != nil {
+		return "", fmt.Errorf("check email: %w", err)
+	}
+	if taken {
+		return "", ErrEmailExists
+	}
+
+	// Shares the magic link quota with sign-in links.
+	if err := s.checkMagicLinkQuota(ctx, tx, posterID); err != nil {
+		return "", err
+	}
+
+	magicToken, err := s.issueMagicLink(ctx, tx, posterID, "", &newEmail)
 	if err != nil {
-		return "", fmt.Errorf("invalid email format")
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("commit tx: %w", err)
+	}
+
+	return magicToken, nil
+}
+
+func (s *Store) Register(ctx context.Context, username, email, deviceLabel string) (string, error) {
+	// Validate email format
+	if !ValidEmail(email) {
+		return "", ErrInvalidEmail.At("email")
 	}
 
 	// Validate username format (alphanumeric and dots only)
-	validUsername := regexp.MustCompile(`^[a-zA-Z0-9.]+$`)
-	if !validUsername.MatchString(username) {
-		return "", fmt.Errorf("username can only contain letters, numbers and dots")
+	if !ValidUsername(username) {
+		return "", ErrInvalidUsername.At("username")
 	}
 
 	tx, err := s.db.BeginTx(ctx, nil)
@@ -107,26 +189,24 @@ func (s *Store) Register(ctx context.Context, username, email string) (string, e
 	defer tx.Rollback()
 
 	var posterID int64
-	var apiToken *string
-	var apiTokenExpires sql.NullTime
 
 	// Create poster
 	err = tx.QueryRowContext(ctx, `
 		INSERT INTO posters (email, username)
 		VALUES ($1, $2)
-		RETURNING poster_id, api_token, api_token_expires_ts
-	`, email, username).Scan(&posterID, &apiToken, &apiTokenExpires)
+		RETURNING poster_id
+	`, email, username).Scan(&posterID)
 	if err != nil {
 		if strings.Contains(err.Error(), "posters_email_key") {
-			return "", fmt.Errorf("email already exists")
+			return "", ErrEmailExists
 		}
 		if strings.Contains(err.Error(), "posters_username_key") {
-			return "", fmt.Errorf("username already exists")
+			return "", ErrUsernameExists
 		}
 		return "", fmt.Errorf("insert poster: %w", err)
 	}
 
-	magicToken, err := s.issueMagicLink(ctx, tx, posterID, apiToken, apiTokenExpires)
+	magicToken, err := s.issueMagicLink(ctx, tx, posterID, deviceLabel, nil)
 	if err != nil {
 		return "", err
 	}
@@ -138,52 +218,20 @@ func (s *Store) Register(ctx context.Context, username, email string) (string, e
 	return magicToken, nil
 }
 
-func (s *Store) issueMagicLink(ctx context.Context, tx *sql.Tx, posterID int64, apiToken *string, apiTokenExpires sql.NullTime) (string, error) {
-
-	now := time.Now()
-	needNewToken := true
-	if apiToken != nil && apiTokenExpires.Valid && apiTokenExpires.Time.After(now) {
-		// existing token still valid; keep it but refresh expiry
-		needNewToken = false
-	}
-
-	if needNewToken {
-		tok, err := randomToken(32)
-		if err != nil {
-			return "", fmt.Errorf("generate api token: %w", err)
-		}
-		exp := now.AddDate(0, 2, 0) // +2 months
-		if _, err := tx.ExecContext(ctx, `
-			UPDATE posters
-			SET api_token = $1, api_token_expires_ts = $2
-			WHERE poster_id = $3
-		`, tok, exp, posterID); err != nil {
-			return "", fmt.Errorf("set api token: %w", err)
-		}
-		apiToken = &tok
-	} else {
-		// refresh expiry on existing token
-		exp := now.AddDate(0, 2, 0)
-		if _, err := tx.ExecContext(ctx, `
-			UPDATE posters
-			SET api_token_expires_ts = $1
-			WHERE poster_id = $2
-		`, exp, posterID); err != nil {
-			return "", fmt.Errorf("refresh api token expiry: %w", err)
-		}
-	}
-
-	// issue one-time magic token
+// issueMagicLink inserts a new one-time magic link. deviceLabel names the
+// session created on confirmation. A non-nil newEmail makes it an email change
+// link: the address is only written to posters when the link is confirmed.
+func (s *Store) issueMagicLink(ctx context.Context, tx *sql.Tx, posterID int64, deviceLabel string, newEmail *string) (string, error) {
 	magicToken, err := randomToken(32)
 	if err != nil {
 		return "", fmt.Errorf("generate magic token: %w", err)
 	}
 
-	expires := now.Add(30 * time.Minute)
+	expires := time.Now().Add(30 * time.Minute)
 	if _, err := tx.ExecContext(ctx, `
-		INSERT INTO magic_links (poster_id, token, expires_ts)
-		VALUES ($1, $2, $3)
-	`, posterID, magicToken, expires); err != nil {
+		INSERT INTO magic_links (poster_id, token_hash, expires_ts, new_email, device_label)
+		VALUES ($1, $2, $3, $4, $5)
+	`, posterID, hashToken(magicToken), expires, newEmail, nullIfEmpty(deviceLabel)); err != nil {
 		return "", fmt.Errorf("insert magic link: %w", err)
 	}
 
@@ -192,9 +240,13 @@ func (s *Store) issueMagicLink(ctx context.Context, tx *sql.Tx, posterID int64,
 
 // Consume magic link, verify, and return api_token.
 type ConfirmResult struct {
-	APIToken          string
-	Email             string
-	APITokenExpiresAt time.Time
+	APIToken              string
+	Email                 string
+	APITokenExpiresAt     time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+	// PreviousEmail is set when the link confirmed an email change.
+	PreviousEmail string
 }
 
 // Consume magic link, verify, and return api_token.
@@ -208,151 +260,218 @@ func (s *Store) ConfirmMagicLink(ctx context.Context, token string) (*ConfirmRes
 	var posterID int64
 	var expires time.Time
 	var consumed sql.NullTime
+	var newEmail sql.NullString
+	var deviceLabel sql.NullString
 
 	err = tx.QueryRowContext(ctx, `
-		SELECT poster_id, expires_ts, consumed_ts
+		SELECT poster_id, expires_ts, consumed_ts, new_email, device_label
 		FROM magic_links
-		WHERE token = $1
+		WHERE token_hash = $1
 		FOR UPDATE
-	`, token).Scan(&posterID, &expires, &consumed)
+	`, hashToken(token)).Scan(&posterID, &expires, &consumed, &newEmail, &deviceLabel)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("invalid token")
+			return nil, ErrInvalidToken
 		}
 		return nil, fmt.Errorf("load magic link: %w", err)
 	}
 
 	if (consumed.Valid && !consumed.Time.IsZero()) || time.Now().After(expires) {
-		return nil, fmt.Errorf("token expired or already used")
+		return nil, ErrTokenExpired
 	}
 
-	// We will update the magic_links table with the api_token AFTER we retrieve/generate it.
-	// This happens after line 210 in the original file.
+	// Email change: the new address is proven by this link, swap it in.
+	var previousEmail string
+	var before json.RawMessage
+	if newEmail.Valid {
+		if before, err = snapshot(ctx, tx, posterSnapshotSQL, posterID); err != nil {
+			return nil, fmt.Errorf("snapshot poster: %w", err)
+		}
+		if err := tx.QueryRowContext(ctx, `
+			SELECT email
+			FROM posters
+			WHERE poster_id = $1
+			FOR UPDATE
+		`, posterID).Scan(&previousEmail); err != nil {
+			return nil, fmt.Errorf("load poster email: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE posters
+			SET email = $1
+			WHERE poster_id = $2
+		`, newEmail.String, posterID); err != nil {
+			if strings.Contains(err.Error(), "posters_email_key") {
+				return nil, ErrEmailExists
+			}
+			return nil, fmt.Errorf("update poster email: %w", err)
+		}
+	}
 
-	// Get current token info
-	var apiToken string
 	var email string
-	var apiTokenExpires sql.NullTime
-
-	err = tx.QueryRowContext(ctx, `
-		SELECT api_token, email, api_token_expires_ts
-		FROM posters
+	if err := tx.QueryRowContext(ctx, `
+		UPDATE posters
+		SET email_verified = TRUE
 		WHERE poster_id = $1
-		FOR UPDATE
-	`, posterID).Scan(&apiToken, &email, &apiTokenExpires)
-	if err != nil {
-		return nil, fmt.Errorf("load poster: %w", err)
+		RETURNING email
+	`, posterID).Scan(&email); err != nil {
+		return nil, fmt.Errorf("update poster verified: %w", err)
 	}
 
-	now := time.Now()
-	if apiToken == "" || !apiTokenExpires.Valid || apiTokenExpires.Time.Before(now) {
-		// issue a new token valid for 2 months
-		tok, err := randomToken(32)
+	if newEmail.Valid {
+		// The images leave the addresses out; the event only says it changed.
+		after, err := snapshot(ctx, tx, posterSnapshotSQL, posterID)
 		if err != nil {
-			return nil, fmt.Errorf("generate api token: %w", err)
-		}
-		exp := now.AddDate(0, 2, 0)
-		if err := tx.QueryRowContext(ctx, `
-			UPDATE posters
-			SET api_token = $1, api_token_expires_ts = $2, email_verified = TRUE
-			WHERE poster_id = $3
-			RETURNING api_token, api_token_expires_ts, email
-		`, tok, exp, posterID).Scan(&apiToken, &apiTokenExpires.Time, &email); err != nil {
-			return nil, fmt.Errorf("update poster with new token: %w", err)
+			return nil, fmt.Errorf("snapshot poster: %w", err)
 		}
-		apiTokenExpires.Valid = true
-	} else {
-		// token exists and is valid; ensure email_verified is set
-		if err := tx.QueryRowContext(ctx, `
-			UPDATE posters
-			SET email_verified = TRUE
-			WHERE poster_id = $1
-			RETURNING api_token_expires_ts, email
-		`, posterID).Scan(&apiTokenExpires.Time, &email); err != nil {
-			return nil, fmt.Errorf("update poster verified: %w", err)
+		if err := recordAudit(ctx, tx, posterID, AuditPosterEmailChange, "poster", strconv.FormatInt(posterID, 10), before, after); err != nil {
+			return nil, err
 		}
-		apiTokenExpires.Valid = true
 	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("commit tx: %w", err)
+	// The confirming client and the one polling for the link each get a
+	// session of their own: sharing one refresh token would make whichever
+	// refreshes second look like a replay and revoke both.
+	pair, err := createSession(ctx, tx, posterID, deviceLabel.String)
+	if err != nil {
+		return nil, err
+	}
+	polled, err := createSession(ctx, tx, posterID, deviceLabel.String)
+	if err != nil {
+		return nil, err
 	}
 
-	// Update magic_links table to store the api_token AND mark as consumed
-	// This makes it available for the polling endpoint.
-	if _, err := s.db.ExecContext(ctx, `
+	// Mark the link consumed and leave the poller's tokens for the polling
+	// endpoint, sealed with the magic token so the stored row alone can't
+	// reveal them.
+	handover, err := json.Marshal(polled)
+	if err != nil {
+		return nil, fmt.Errorf("encode tokens: %w", err)
+	}
+	sealed, err := sealHandover(token, string(handover))
+	if err != nil {
+		return nil, fmt.Errorf("seal api token: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
 		UPDATE magic_links
-		SET consumed_ts = NOW(), api_token = $1
-		WHERE token = $2
-	`, apiToken, token); err != nil {
+		SET consumed_ts = NOW(), sealed_api_token = $1
+		WHERE token_hash = $2
+	`, sealed, hashToken(token)); err != nil {
+		return nil, fmt.Errorf("consume magic link: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
 	}
 
 	return &ConfirmResult{
-		APIToken:          apiToken,
-		Email:             email,
-		APITokenExpiresAt: apiTokenExpires.Time,
+		APIToken:              pair.APIToken,
+		Email:                 email,
+		APITokenExpiresAt:     pair.APITokenExpiresAt,
+		RefreshToken:          pair.RefreshToken,
+		RefreshTokenExpiresAt: pair.RefreshTokenExpiresAt,
+		PreviousEmail:         previousEmail,
 	}, nil
 }
 
 type AuthPoster struct {
-	PosterID int64
-	Email    string
-	Username string
+	PosterID  int64
+	Email     string
+	Username  string
+	Role      Role
+	SessionID int64
 }
 
-// GetPosterByAPIToken returns the poster for a valid, non-expired token.
+// GetPosterByAPIToken returns the poster for a valid, non-expired access
+// token and records its session as used. Refresh tokens are never accepted.
 func (s *Store) GetPosterByAPIToken(ctx context.Context, token string) (*AuthPoster, error) {
 	var p AuthPoster
 	var expires sql.NullTime
 	var emailVerified bool
 
 	err := s.db.QueryRowContext(ctx, `
-		SELECT poster_id, email, username, api_token_expires_ts, email_verified
-		FROM posters
-		WHERE api_token = $1
-	`, token).Scan(&p.PosterID, &p.Email, &p.Username, &expires, &emailVerified)
+		SELECT s.session_id, p.poster_id, p.email, p.username, p.role, s.access_expires_ts, p.email_verified
+		FROM sessions s
+		JOIN posters p ON p.poster_id = s.poster_id
+		WHERE s.access_token_hash = $1
+	`, hashToken(token)).Scan(&p.SessionID, &p.PosterID, &p.Email, &p.Username, &p.Role, &expires, &emailVerified)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("invalid token")
+			return nil, ErrInvalidToken
 		}
 		return nil, fmt.Errorf("load poster by token: %w", err)
 	}
 
 	if !emailVerified {
-		return nil, fmt.Errorf("email not verified")
+		return nil, ErrEmailNotVerified
 	}
 
 	if !expires.Valid || time.Now().After(expires.Time) {
-		return nil, fmt.Errorf("token expired")
+		return nil, ErrTokenExpired
+	}
+
+	if err := touchSession(ctx, s.db, p.SessionID); err != nil {
+		return nil, err
 	}
 
 	return &p, nil
 }
 
-// CheckMagicLinkStatus returns the api_token if the link was confirmed, otherwise empty.
-func (s *Store) CheckMagicLinkStatus(ctx context.Context, token string) (string, error) {
-	var apiToken sql.NullString
+// CheckMagicLinkStatus returns the session tokens if the link was confirmed,
+// otherwise nil. They are handed over exactly once: cleared as they are read.
+func (s *Store) CheckMagicLinkStatus(ctx context.Context, token string) (*TokenPair, error) {
+	var sealed string
 	err := s.db.QueryRowContext(ctx, `
-		SELECT api_token
-		FROM magic_links
-		WHERE token = $1 AND consumed_ts IS NOT NULL
-	`, token).Scan(&apiToken)
+		WITH pending AS (
+			SELECT id, sealed_api_token
+			FROM magic_links
+			WHERE token_hash = $1 AND consumed_ts IS NOT NULL AND sealed_api_token IS NOT NULL
+			FOR UPDATE
+		)
+		UPDATE magic_links m
+		SET sealed_api_token = NULL
+		FROM pending
+		WHERE m.id = pending.id
+		RETURNING pending.sealed_api_token
+	`, hashToken(token)).Scan(&sealed)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return "", nil
+			return nil, nil
 		}
-		return "", err
+		return nil, err
+	}
+
+	handover, err := openHandover(token, sealed)
+	if err != nil {
+		return nil, err
 	}
-	return apiToken.String, nil
+	var pair TokenPair
+	if err := json.Unmarshal([]byte(handover), &pair); err != nil {
+		return nil, fmt.Errorf("decode tokens: %w", err)
+	}
+	return &pair, nil
 }
 
-func (s *Store) DeletePoster(ctx context.Context, posterID int64, deleteContent bool) error {
+// DeletePoster erases an account, and with deleteContent its reviews and the
+// bikes it created. It returns the blob keys of the photos and data exports
+// that went with it, for the caller to delete.
+func (s *Store) DeletePoster(ctx context.Context, posterID int64, deleteContent bool) ([]string, error) {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("begin tx: %w", err)
+		return nil, fmt.Errorf("begin tx: %w", err)
 	}
 	defer tx.Rollback()
 
+	// Blob keys of the photos and exports deleted with the account
+	var blobKeys []string
+
+	before, err := snapshot(ctx, tx, posterSnapshotSQL, posterID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("load poster: %w", err)
+	}
+
 	// 1. Identify bikes that will need aggregate recomputation
 	// (Only if we are deleting content OR if we want to be safe, but actually
 	// if we orphan reviews, aggregates don't strictly change unless we remove ratings.
@@ -366,25 +485,38 @@ func (s *Store) DeletePoster(ctx context.Context, posterID int64, deleteContent
 			WHERE poster_id = $1
 		`, posterID)
 		if err != nil {
-			return fmt.Errorf("list user reviews: %w", err)
+			return nil, fmt.Errorf("list user reviews: %w", err)
 		}
 		var bikeIDs []string
 		for rows.Next() {
 			var bid string
 			if err := rows.Scan(&bid); err != nil {
 				rows.Close()
-				return err
+				return nil, err
 			}
 			bikeIDs = append(bikeIDs, bid)
 		}
 		rows.Close()
 
+		// Photos of the reviews going away, including other posters'
+		// reviews of bikes this poster created
+		keys, err := deleteReviewImages(ctx, tx, `
+			SELECT review_id
+			FROM reviews
+			WHERE poster_id = $1
+				OR bike_numerical_id IN (SELECT numerical_id FROM bikes WHERE creator_id = $1)
+		`, posterID)
+		if err != nil {
+			return nil, err
+		}
+		blobKeys = append(blobKeys, keys...)
+
 		// 2. Delete review ratings
 		if _, err := tx.ExecContext(ctx, `
 			DELETE FROM review_ratings
 			WHERE review_id IN (SELECT review_id FROM reviews WHERE poster_id = $1)
 		`, posterID); err != nil {
-			return fmt.Errorf("delete user ratings: %w", err)
+			return nil, fmt.Errorf("delete user ratings: %w", err)
 		}
 
 		// 3. Delete reviews
@@ -392,13 +524,13 @@ func (s *Store) DeletePoster(ctx context.Context, posterID int64, deleteContent
 			DELETE FROM reviews
 			WHERE poster_id = $1
 		`, posterID); err != nil {
-			return fmt.Errorf("delete user reviews: %w", err)
+			return nil, fmt.Errorf("delete user reviews: %w", err)
 		}
 
 		// 4. Recompute aggregates for affected bikes
 		for _, bid := range bikeIDs {
 			if err := RecomputeAggregatesForBike(ctx, tx, bid); err != nil {
-				return fmt.Errorf("recompute aggregates for bike %s: %w", bid, err)
+				return nil, fmt.Errorf("recompute aggregates for bike %s: %w", bid, err)
 			}
 		}
 
@@ -407,7 +539,7 @@ func (s *Store) DeletePoster(ctx context.Context, posterID int64, deleteContent
 			DELETE FROM bikes
 			WHERE creator_id = $1
 		`, posterID); err != nil {
-			return fmt.Errorf("delete user bikes: %w", err)
+			return nil, fmt.Errorf("delete user bikes: %w", err)
 		}
 
 	} else {
@@ -417,7 +549,7 @@ func (s *Store) DeletePoster(ctx context.Context, posterID int64, deleteContent
 			SET creator_id = NULL
 			WHERE creator_id = $1
 		`, posterID); err != nil {
-			return fmt.Errorf("orphan bikes: %w", err)
+			return nil, fmt.Errorf("orphan bikes: %w", err)
 		}
 
 		if _, err := tx.ExecContext(ctx, `
@@ -425,16 +557,46 @@ func (s *Store) DeletePoster(ctx context.Context, posterID int64, deleteContent
 			SET poster_id = NULL
 			WHERE poster_id = $1
 		`, posterID); err != nil {
-			return fmt.Errorf("orphan reviews: %w", err)
+			return nil, fmt.Errorf("orphan reviews: %w", err)
 		}
 	}
 
+	// Always delete data exports, files included
+	rows, err := tx.QueryContext(ctx, `
+		DELETE FROM data_exports
+		WHERE poster_id = $1
+		RETURNING blob_key
+	`, posterID)
+	if err != nil {
+		return nil, fmt.Errorf("delete data exports: %w", err)
+	}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		blobKeys = append(blobKeys, key)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Always delete sessions
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM sessions
+		WHERE poster_id = $1
+	`, posterID); err != nil {
+		return nil, fmt.Errorf("delete sessions: %w", err)
+	}
+
 	// Always delete magic links
 	if _, err := tx.ExecContext(ctx, `
 		DELETE FROM magic_links
 		WHERE poster_id = $1
 	`, posterID); err != nil {
-		return fmt.Errorf("delete magic links: %w", err)
+		return nil, fmt.Errorf("delete magic links: %w", err)
 	}
 
 	// Always delete poster
@@ -442,12 +604,16 @@ func (s *Store) DeletePoster(ctx context.Context, posterID int64, deleteContent
 		DELETE FROM posters
 		WHERE poster_id = $1
 	`, posterID); err != nil {
-		return fmt.Errorf("delete poster: %w", err)
+		return nil, fmt.Errorf("delete poster: %w", err)
+	}
+
+	if err := recordAudit(ctx, tx, posterID, AuditPosterDelete, "poster", strconv.FormatInt(posterID, 10), before, nil); err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("commit tx: %w", err)
+		return nil, fmt.Errorf("commit tx: %w", err)
 	}
 
-	return nil
+	return blobKeys, nil
 }
@@ -3,8 +3,10 @@ package domain
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
-
+	"slices"
 	"testing"
 	"time"
 
@@ -21,39 +23,30 @@ func TestCreateMagicLink(t *testing.T) {
 	ctx := context.Background()
 	email := "test@example.com"
 
-	t.Run("success_existing_valid_token", func(t *testing.T) {
-		validToken := "existing_token"
-		validExpires := time.Now().Add(24 * time.Hour)
-
+	t.Run("success", func(t *testing.T) {
 		mock.ExpectBegin()
-		mock.ExpectQuery("SELECT poster_id, api_token, api_token_expires_ts, email FROM posters").
+		mock.ExpectQuery("SELECT poster_id, email FROM posters").
 			WithArgs(email).
-			WillReturnRows(sqlmock.NewRows([]string{"poster_id", "api_token", "api_token_expires_ts", "email"}).
-				AddRow(1, validToken, validExpires, email))
-
-		// Rate limit check
-		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM magic_links").
-			WithArgs(1).
-			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
-
-		// issueMagicLink logic starts here
-		// Invalidate old links - wait, I removed this in my refactor but I should probably keep it if it was intended.
-		// Actually, I removed it in my multi_replace_file_content call. Let's see.
+			WillReturnRows(sqlmock.NewRows([]string{"poster_id", "email"}).
+				AddRow(1, email))
 
-		// Refresh expiry (part of issueMagicLink if token exists)
-		mock.ExpectExec("UPDATE posters").
-			WithArgs(sqlmock.AnyArg(), 1).
-			WillReturnResult(sqlmock.NewResult(1, 1))
+		// Rate limit check, under a per-poster lock
+		mock.ExpectExec("SELECT pg_advisory_xact_lock").
+			WithArgs(lockMagicLinkQuota, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\), MIN\\(created_ts\\) FROM magic_links").
+			WithArgs(1, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"count", "min"}).AddRow(0, nil))
 
-		// Insert magic link
+		// Insert magic link; no session or token expiry is touched until confirmation
 		mock.ExpectExec("INSERT INTO magic_links").
-			WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "Pixel 8").
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		mock.ExpectCommit()
 
 		store := NewStore(db)
-		token, _, err := store.CreateMagicLink(ctx, email)
+		token, _, err := store.CreateMagicLink(ctx, email, "Pixel 8")
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -68,13 +61,13 @@ func TestCreateMagicLink(t *testing.T) {
 
 	t.Run("user_not_found", func(t *testing.T) {
 		mock.ExpectBegin()
-		mock.ExpectQuery("SELECT poster_id, api_token, api_token_expires_ts, email FROM posters").
+		mock.ExpectQuery("SELECT poster_id, email FROM posters").
 			WithArgs(email).
 			WillReturnError(sql.ErrNoRows)
 		mock.ExpectRollback()
 
 		store := NewStore(db)
-		_, _, err := store.CreateMagicLink(ctx, email)
+		_, _, err := store.CreateMagicLink(ctx, email, "")
 		if err == nil {
 			t.Error("expected error user not found")
 		}
@@ -82,19 +75,22 @@ func TestCreateMagicLink(t *testing.T) {
 
 	t.Run("rate_limit_exceeded", func(t *testing.T) {
 		mock.ExpectBegin()
-		mock.ExpectQuery("SELECT poster_id, api_token, api_token_expires_ts, email FROM posters").
+		mock.ExpectQuery("SELECT poster_id, email FROM posters").
 			WithArgs(email).
-			WillReturnRows(sqlmock.NewRows([]string{"poster_id", "api_token", "api_token_expires_ts", "email"}).
-				AddRow(1, "some_token", time.Now().Add(time.Hour), email))
+			WillReturnRows(sqlmock.NewRows([]string{"poster_id", "email"}).
+				AddRow(1, email))
 
-		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM magic_links").
-			WithArgs(1).
-			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectExec("SELECT pg_advisory_xact_lock").
+			WithArgs(lockMagicLinkQuota, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\), MIN\\(created_ts\\) FROM magic_links").
+			WithArgs(1, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"count", "min"}).AddRow(2, time.Now().Add(-time.Hour)))
 
 		mock.ExpectRollback()
 
 		store := NewStore(db)
-		_, _, err := store.CreateMagicLink(ctx, email)
+		_, _, err := store.CreateMagicLink(ctx, email, "")
 		if err == nil || !errors.Is(err, ErrRateLimitExceeded) {
 			t.Errorf("expected ErrRateLimitExceeded, got %v", err)
 		}
@@ -116,24 +112,17 @@ func TestRegister(t *testing.T) {
 		mock.ExpectBegin()
 		mock.ExpectQuery("INSERT INTO posters").
 			WithArgs(email, username).
-			WillReturnRows(sqlmock.NewRows([]string{"poster_id", "api_token", "api_token_expires_ts"}).
-				AddRow(1, nil, nil))
-
-		// issueMagicLink
-		// Create new token
-		mock.ExpectExec("UPDATE posters").
-			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 1).
-			WillReturnResult(sqlmock.NewResult(1, 1))
+			WillReturnRows(sqlmock.NewRows([]string{"poster_id"}).AddRow(1))
 
 		// Insert magic link
 		mock.ExpectExec("INSERT INTO magic_links").
-			WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, nil).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		mock.ExpectCommit()
 
 		store := NewStore(db)
-		token, err := store.Register(ctx, username, email)
+		token, err := store.Register(ctx, username, email, "")
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -151,12 +140,12 @@ func TestRegister(t *testing.T) {
 		email := "invalid-email" // Missing @ and domain
 
 		store := NewStore(db)
-		_, err := store.Register(ctx, username, email)
+		_, err := store.Register(ctx, username, email, "")
 		if err == nil {
 			t.Error("expected error for invalid email, got nil")
 		}
-		if err != nil && err.Error() != "invalid email format" {
-			t.Errorf("expected 'invalid email format' error, got: %v", err)
+		if !errors.Is(err, ErrInvalidEmail) {
+			t.Errorf("expected ErrInvalidEmail, got: %v", err)
 		}
 	})
 
@@ -165,16 +154,125 @@ func TestRegister(t *testing.T) {
 		email := "test@example.com"
 
 		store := NewStore(db)
-		_, err := store.Register(ctx, username, email)
+		_, err := store.Register(ctx, username, email, "")
 		if err == nil {
 			t.Error("expected error for invalid username, got nil")
 		}
-		if err != nil && err.Error() != "username can only contain letters, numbers and dots" {
-			t.Errorf("expected 'username can only contain letters, numbers and dots' error, got: %v", err)
+		if !errors.Is(err, ErrInvalidUsername) {
+			t.Errorf("expected ErrInvalidUsername, got: %v", err)
 		}
 	})
 }
 
+func TestRequestEmailChange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	posterID := int64(1)
+	newEmail := "new@example.com"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT email FROM posters").
+			WithArgs(posterID).
+			WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("old@example.com"))
+		mock.ExpectQuery("SELECT EXISTS").
+			WithArgs(newEmail).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectExec("SELECT pg_advisory_xact_lock").
+			WithArgs(lockMagicLinkQuota, posterID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\), MIN\\(created_ts\\) FROM magic_links").
+			WithArgs(posterID, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"count", "min"}).AddRow(0, nil))
+		// The pending address travels with the link, posters.email is untouched
+		mock.ExpectExec("INSERT INTO magic_links").
+			WithArgs(posterID, sqlmock.AnyArg(), sqlmock.AnyArg(), newEmail, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		store := NewStore(db)
+		token, err := store.RequestEmailChange(ctx, posterID, newEmail)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if token == "" {
+			t.Error("expected token to be generated")
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("invalid_email", func(t *testing.T) {
+		store := NewStore(db)
+		for _, email := range []string{"not-an-email", "New Me <new@example.com>"} {
+			_, err := store.RequestEmailChange(ctx, posterID, email)
+			if !errors.Is(err, ErrInvalidEmail) {
+				t.Errorf("%s: expected ErrInvalidEmail, got %v", email, err)
+			}
+		}
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT email FROM posters").
+			WithArgs(posterID).
+			WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow(newEmail))
+		mock.ExpectRollback()
+
+		store := NewStore(db)
+		_, err := store.RequestEmailChange(ctx, posterID, newEmail)
+		if !errors.Is(err, ErrEmailUnchanged) {
+			t.Errorf("expected ErrEmailUnchanged, got %v", err)
+		}
+	})
+
+	t.Run("email_taken", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT email FROM posters").
+			WithArgs(posterID).
+			WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("old@example.com"))
+		mock.ExpectQuery("SELECT EXISTS").
+			WithArgs(newEmail).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectRollback()
+
+		store := NewStore(db)
+		_, err := store.RequestEmailChange(ctx, posterID, newEmail)
+		if !errors.Is(err, ErrEmailExists) {
+			t.Errorf("expected ErrEmailExists, got %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
+
+// sealedPair matches a sealed handover and keeps the token pair inside it.
+type sealedPair struct {
+	magicToken string
+	pair       TokenPair
+}
+
+func (m *sealedPair) Match(v driver.Value) bool {
+	sealed, ok := v.(string)
+	if !ok {
+		return false
+	}
+	opened, err := openHandover(m.magicToken, sealed)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal([]byte(opened), &m.pair) == nil
+}
+
 func TestConfirmMagicLink(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -184,42 +282,127 @@ func TestConfirmMagicLink(t *testing.T) {
 
 	ctx := context.Background()
 	token := "magic_token"
+	linkColumns := []string{"poster_id", "expires_ts", "consumed_ts", "new_email", "device_label"}
 
 	t.Run("success_valid_token", func(t *testing.T) {
 		mock.ExpectBegin()
 
 		// Load magic link
-		mock.ExpectQuery("SELECT poster_id, expires_ts, consumed_ts FROM magic_links").
-			WithArgs(token).
-			WillReturnRows(sqlmock.NewRows([]string{"poster_id", "expires_ts", "consumed_ts"}).
-				AddRow(1, time.Now().Add(time.Hour), nil))
-
-		// Load poster
-		mock.ExpectQuery("SELECT api_token, email, api_token_expires_ts FROM posters").
-			WithArgs(1).
-			WillReturnRows(sqlmock.NewRows([]string{"api_token", "email", "api_token_expires_ts"}).
-				AddRow("api_token", "test@example.com", time.Now().Add(time.Hour)))
+		mock.ExpectQuery("SELECT poster_id, expires_ts, consumed_ts, new_email, device_label FROM magic_links").
+			WithArgs(hashToken(token)).
+			WillReturnRows(sqlmock.NewRows(linkColumns).
+				AddRow(1, time.Now().Add(time.Hour), nil, nil, "Pixel 8"))
 
 		// Update poster verified
-		mock.ExpectQuery("UPDATE posters").
+		mock.ExpectQuery("UPDATE posters SET email_verified = TRUE").
 			WithArgs(1).
-			WillReturnRows(sqlmock.NewRows([]string{"api_token_expires_ts", "email"}).
-				AddRow(time.Now().Add(time.Hour), "test@example.com"))
+			WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("test@example.com"))
+
+		// One session for the confirming client, one for the poller
+		for _, sessionID := range []int64{7, 8} {
+			mock.ExpectQuery("INSERT INTO sessions").
+				WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg(), "Pixel 8", sqlmock.AnyArg()).
+				WillReturnRows(sqlmock.NewRows([]string{"session_id"}).AddRow(sessionID))
+			mock.ExpectExec("INSERT INTO refresh_tokens").
+				WithArgs(sqlmock.AnyArg(), sessionID).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+		}
+
+		// Hand the poller's tokens to the polling endpoint
+		handover := &sealedPair{magicToken: token}
+		mock.ExpectExec("UPDATE magic_links SET consumed_ts = NOW\\(\\), sealed_api_token = \\$1").
+			WithArgs(handover, hashToken(token)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		mock.ExpectCommit()
 
-		// New: External update for polling
+		store := NewStore(db)
+		res, err := store.ConfirmMagicLink(ctx, token)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if res.APIToken == "" || res.RefreshToken == "" {
+			t.Error("expected access and refresh tokens")
+		}
+		if !res.APITokenExpiresAt.Before(res.RefreshTokenExpiresAt) {
+			t.Errorf("expected access token to expire before refresh token, got %v / %v", res.APITokenExpiresAt, res.RefreshTokenExpiresAt)
+		}
+		if handover.pair.RefreshToken == "" || handover.pair.RefreshToken == res.RefreshToken || handover.pair.APIToken == res.APIToken {
+			t.Errorf("expected the poller to get its own tokens, got %+v", handover.pair)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("email_change", func(t *testing.T) {
+		mock.ExpectBegin()
+
+		mock.ExpectQuery("SELECT poster_id, expires_ts, consumed_ts, new_email, device_label FROM magic_links").
+			WithArgs(hashToken(token)).
+			WillReturnRows(sqlmock.NewRows(linkColumns).
+				AddRow(1, time.Now().Add(time.Hour), nil, "new@example.com", nil))
+
+		// Swap the pending address in
+		mock.ExpectQuery("SELECT to_jsonb\\(p\\) - 'email' FROM posters p").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"image"}).AddRow(`{"poster_id":1}`))
+		mock.ExpectQuery("SELECT email FROM posters").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("old@example.com"))
+		mock.ExpectExec("UPDATE posters SET email = \\$1").
+			WithArgs("new@example.com", 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		mock.ExpectQuery("UPDATE posters SET email_verified = TRUE").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("new@example.com"))
+		mock.ExpectQuery("SELECT to_jsonb\\(p\\) - 'email' FROM posters p").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"image"}).AddRow(`{"poster_id":1}`))
+		mock.ExpectExec("INSERT INTO audit_events").
+			WithArgs(1, AuditPosterEmailChange, "poster", "1", `{"poster_id":1}`, `{"poster_id":1}`, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		for _, sessionID := range []int64{8, 9} {
+			mock.ExpectQuery("INSERT INTO sessions").
+				WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+				WillReturnRows(sqlmock.NewRows([]string{"session_id"}).AddRow(sessionID))
+			mock.ExpectExec("INSERT INTO refresh_tokens").
+				WithArgs(sqlmock.AnyArg(), sessionID).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+		}
 		mock.ExpectExec("UPDATE magic_links").
-			WithArgs("api_token", token).
+			WithArgs(sqlmock.AnyArg(), hashToken(token)).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
+		mock.ExpectCommit()
+
 		store := NewStore(db)
 		res, err := store.ConfirmMagicLink(ctx, token)
 		if err != nil {
-			t.Errorf("unexpected error: %v", err)
+			t.Fatalf("unexpected error: %v", err)
 		}
-		if res == nil {
-			t.Error("expected result")
+		if res.Email != "new@example.com" || res.PreviousEmail != "old@example.com" {
+			t.Errorf("expected email change old@example.com -> new@example.com, got %q -> %q", res.PreviousEmail, res.Email)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("already_consumed", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT poster_id, expires_ts, consumed_ts, new_email, device_label FROM magic_links").
+			WithArgs(hashToken(token)).
+			WillReturnRows(sqlmock.NewRows(linkColumns).
+				AddRow(1, time.Now().Add(time.Hour), time.Now(), nil, nil))
+		mock.ExpectRollback()
+
+		store := NewStore(db)
+		if _, err := store.ConfirmMagicLink(ctx, token); err == nil {
+			t.Error("expected error for consumed link")
 		}
 
 		if err := mock.ExpectationsWereMet(); err != nil {
@@ -229,8 +412,8 @@ func TestConfirmMagicLink(t *testing.T) {
 
 	t.Run("invalid_token", func(t *testing.T) {
 		mock.ExpectBegin()
-		mock.ExpectQuery("SELECT poster_id, expires_ts, consumed_ts FROM magic_links").
-			WithArgs(token).
+		mock.ExpectQuery("SELECT poster_id, expires_ts, consumed_ts, new_email, device_label FROM magic_links").
+			WithArgs(hashToken(token)).
 			WillReturnError(sql.ErrNoRows)
 		mock.ExpectRollback()
 
@@ -251,12 +434,16 @@ func TestGetPosterByAPIToken(t *testing.T) {
 
 	ctx := context.Background()
 	token := "api_token"
+	sessionColumns := []string{"session_id", "poster_id", "email", "username", "role", "access_expires_ts", "email_verified"}
 
 	t.Run("success", func(t *testing.T) {
-		mock.ExpectQuery("SELECT poster_id, email, username, api_token_expires_ts, email_verified FROM posters").
-			WithArgs(token).
-			WillReturnRows(sqlmock.NewRows([]string{"poster_id", "email", "username", "api_token_expires_ts", "email_verified"}).
-				AddRow(1, "test@example.com", "testuser", time.Now().Add(time.Hour), true))
+		mock.ExpectQuery("SELECT s.session_id, p.poster_id, p.email, p.username, p.role, s.access_expires_ts, p.email_verified FROM sessions s JOIN posters p ON p.poster_id = s.poster_id WHERE s.access_token_hash = \\$1").
+			WithArgs(hashToken(token)).
+			WillReturnRows(sqlmock.NewRows(sessionColumns).
+				AddRow(7, 1, "test@example.com", "testuser", "moderator", time.Now().Add(time.Hour), true))
+		mock.ExpectExec("UPDATE sessions SET last_used_ts = NOW\\(\\)").
+			WithArgs(7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
 
 		store := NewStore(db)
 		poster, err := store.GetPosterByAPIToken(ctx, token)
@@ -269,13 +456,23 @@ func TestGetPosterByAPIToken(t *testing.T) {
 		if poster.Email != "test@example.com" {
 			t.Errorf("expected email test@example.com, got %s", poster.Email)
 		}
+		if poster.SessionID != 7 {
+			t.Errorf("expected session 7, got %d", poster.SessionID)
+		}
+		if poster.Role != RoleModerator {
+			t.Errorf("expected role moderator, got %s", poster.Role)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
 	})
 
 	t.Run("expired_token", func(t *testing.T) {
-		mock.ExpectQuery("SELECT poster_id, email, username, api_token_expires_ts, email_verified FROM posters").
-			WithArgs(token).
-			WillReturnRows(sqlmock.NewRows([]string{"poster_id", "email", "username", "api_token_expires_ts", "email_verified"}).
-				AddRow(1, "test@example.com", "testuser", time.Now().Add(-time.Hour), true))
+		mock.ExpectQuery("FROM sessions s JOIN posters p").
+			WithArgs(hashToken(token)).
+			WillReturnRows(sqlmock.NewRows(sessionColumns).
+				AddRow(7, 1, "test@example.com", "testuser", "user", time.Now().Add(-time.Hour), true))
 
 		store := NewStore(db)
 		_, err := store.GetPosterByAPIToken(ctx, token)
@@ -285,10 +482,10 @@ func TestGetPosterByAPIToken(t *testing.T) {
 	})
 
 	t.Run("unverified_email", func(t *testing.T) {
-		mock.ExpectQuery("SELECT poster_id, email, username, api_token_expires_ts, email_verified FROM posters").
-			WithArgs(token).
-			WillReturnRows(sqlmock.NewRows([]string{"poster_id", "email", "username", "api_token_expires_ts", "email_verified"}).
-				AddRow(1, "test@example.com", "testuser", time.Now().Add(time.Hour), false))
+		mock.ExpectQuery("FROM sessions s JOIN posters p").
+			WithArgs(hashToken(token)).
+			WillReturnRows(sqlmock.NewRows(sessionColumns).
+				AddRow(7, 1, "test@example.com", "testuser", "user", time.Now().Add(time.Hour), false))
 
 		store := NewStore(db)
 		_, err := store.GetPosterByAPIToken(ctx, token)
@@ -298,6 +495,50 @@ func TestGetPosterByAPIToken(t *testing.T) {
 	})
 }
 
+func TestCheckMagicLinkStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	token := "magic_token"
+
+	t.Run("hands_over_once", func(t *testing.T) {
+		sealed, err := sealHandover(token, `{"api_token":"api_token","refresh_token":"refresh_token"}`)
+		if err != nil {
+			t.Fatalf("seal: %v", err)
+		}
+
+		mock.ExpectQuery("UPDATE magic_links m SET sealed_api_token = NULL FROM pending").
+			WithArgs(hashToken(token)).
+			WillReturnRows(sqlmock.NewRows([]string{"sealed_api_token"}).AddRow(sealed))
+		// Second poll: already cleared
+		mock.ExpectQuery("UPDATE magic_links m SET sealed_api_token = NULL FROM pending").
+			WithArgs(hashToken(token)).
+			WillReturnError(sql.ErrNoRows)
+
+		store := NewStore(db)
+		tokens, err := store.CheckMagicLinkStatus(ctx, token)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if tokens == nil || tokens.APIToken != "api_token" || tokens.RefreshToken != "refresh_token" {
+			t.Errorf("expected both tokens, got %+v", tokens)
+		}
+
+		tokens, err = store.CheckMagicLinkStatus(ctx, token)
+		if err != nil || tokens != nil {
+			t.Errorf("expected nothing on second poll, got %+v, %v", tokens, err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
+
 func TestDeletePoster(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -310,12 +551,22 @@ func TestDeletePoster(t *testing.T) {
 
 	t.Run("success_delete_content", func(t *testing.T) {
 		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT to_jsonb\\(p\\) - 'email' FROM posters p WHERE p.poster_id = \\$1 FOR UPDATE").
+			WithArgs(posterID).
+			WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{"poster_id":1}`))
 
 		// 1. List user reviews (returns 2 bikes)
 		mock.ExpectQuery("SELECT DISTINCT bike_numerical_id FROM reviews").
 			WithArgs(posterID).
 			WillReturnRows(sqlmock.NewRows([]string{"bike_numerical_id"}).AddRow("101").AddRow("102"))
 
+		// Photos of those reviews and of reviews of the poster's bikes
+		mock.ExpectQuery("DELETE FROM review_images WHERE review_id IN \\( SELECT review_id FROM reviews WHERE poster_id = \\$1 OR bike_numerical_id IN \\(SELECT numerical_id FROM bikes WHERE creator_id = \\$1\\) \\) RETURNING image_key, thumb_key").
+			WithArgs(posterID).
+			WillReturnRows(sqlmock.NewRows([]string{"image_key", "thumb_key"}).
+				AddRow("a.jpg", "a_thumb.jpg").
+				AddRow(nil, nil))
+
 		// 2. Delete ratings
 		mock.ExpectExec("DELETE FROM review_ratings").
 			WithArgs(posterID).
@@ -347,7 +598,13 @@ func TestDeletePoster(t *testing.T) {
 			WithArgs(posterID).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 
-		// 6. Delete magic links
+		// 6. Delete data exports, sessions and magic links
+		mock.ExpectQuery("DELETE FROM data_exports WHERE poster_id = \\$1 RETURNING blob_key").
+			WithArgs(posterID).
+			WillReturnRows(sqlmock.NewRows([]string{"blob_key"}).AddRow("export.zip"))
+		mock.ExpectExec("DELETE FROM sessions").
+			WithArgs(posterID).
+			WillReturnResult(sqlmock.NewResult(0, 2))
 		mock.ExpectExec("DELETE FROM magic_links").
 			WithArgs(posterID).
 			WillReturnResult(sqlmock.NewResult(0, 1))
@@ -356,15 +613,21 @@ func TestDeletePoster(t *testing.T) {
 		mock.ExpectExec("DELETE FROM posters").
 			WithArgs(posterID).
 			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO audit_events").
+			WithArgs(posterID, AuditPosterDelete, "poster", "1", `{"poster_id":1}`, nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		mock.ExpectCommit()
 
 		store := NewStore(db)
 		// deleteContent = true
-		err := store.DeletePoster(ctx, posterID, true)
+		keys, err := store.DeletePoster(ctx, posterID, true)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
+		if want := []string{"a.jpg", "a_thumb.jpg", "export.zip"}; !slices.Equal(keys, want) {
+			t.Errorf("expected blob keys %v, got %v", want, keys)
+		}
 
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("there were unfulfilled expectations: %s", err)
@@ -373,6 +636,9 @@ func TestDeletePoster(t *testing.T) {
 
 	t.Run("success_orphan_content", func(t *testing.T) {
 		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT to_jsonb\\(p\\) - 'email' FROM posters p WHERE p.poster_id = \\$1 FOR UPDATE").
+			WithArgs(posterID).
+			WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{"poster_id":1}`))
 
 		// 1. Orphan bikes
 		mock.ExpectExec("UPDATE bikes SET creator_id = NULL").
@@ -384,7 +650,13 @@ func TestDeletePoster(t *testing.T) {
 			WithArgs(posterID).
 			WillReturnResult(sqlmock.NewResult(0, 2))
 
-		// 3. Delete magic links
+		// 3. Delete data exports, sessions and magic links
+		mock.ExpectQuery("DELETE FROM data_exports").
+			WithArgs(posterID).
+			WillReturnRows(sqlmock.NewRows([]string{"blob_key"}))
+		mock.ExpectExec("DELETE FROM sessions").
+			WithArgs(posterID).
+			WillReturnResult(sqlmock.NewResult(0, 2))
 		mock.ExpectExec("DELETE FROM magic_links").
 			WithArgs(posterID).
 			WillReturnResult(sqlmock.NewResult(0, 1))
@@ -393,15 +665,21 @@ func TestDeletePoster(t *testing.T) {
 		mock.ExpectExec("DELETE FROM posters").
 			WithArgs(posterID).
 			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO audit_events").
+			WithArgs(posterID, AuditPosterDelete, "poster", "1", `{"poster_id":1}`, nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		mock.ExpectCommit()
 
 		store := NewStore(db)
 		// deleteContent = false
-		err := store.DeletePoster(ctx, posterID, false)
+		keys, err := store.DeletePoster(ctx, posterID, false)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
+		if len(keys) != 0 {
+			t.Errorf("expected orphaned reviews to keep their photos, got %v", keys)
+		}
 
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("there were unfulfilled expectations: %s", err)
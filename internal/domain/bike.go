@@ -4,41 +4,231 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
+var (
+	ErrBikeNotFound = &NotFoundError{Code: "bike_not_found", Message: "bike not found"}
+	ErrBikeExists   = &ConflictError{Code: "bike_exists", Message: "bike with this numerical_id already exists"}
+	ErrHashIDExists = &ConflictError{Code: "hash_id_exists", Message: "bike with this hash_id already exists"}
+)
+
 type Bike struct {
-	NumericalID   string    `db:"numerical_id" json:"numerical_id"` // PK
-	HashID        *string   `db:"hash_id" json:"hash_id"`
-	IsElectric    bool      `db:"is_electric" json:"is_electric"`
-	AverageRating *float64  `db:"average_rating" json:"average_rating"`
-	CreatedAt     time.Time `db:"created_ts" json:"created_ts"`
-	UpdatedAt     time.Time `db:"updated_ts" json:"updated_ts"`
+	NumericalID   string   `db:"numerical_id" json:"numerical_id"` // PK
+	HashID        *string  `db:"hash_id" json:"hash_id"`
+	IsElectric    bool     `db:"is_electric" json:"is_electric"`
+	AverageRating *float64 `db:"average_rating" json:"average_rating"`
+	ReviewCount   int64    `db:"review_count" json:"review_count"`
+	// OpenIssueCount is how many reported problems are still unresolved.
+	OpenIssueCount int64  `db:"open_issue_count" json:"open_issue_count"`
+	CreatorID      *int64 `db:"creator_id" json:"creator_id"`
+	// LastStationID and LastSeenAt come from the bike's latest sighting.
+	LastStationID *string    `db:"last_station_id" json:"last_station_id"`
+	LastSeenAt    *time.Time `db:"last_seen_ts" json:"last_seen_ts"`
+	// DistanceM is only set by nearby searches: metres to the last sighting.
+	DistanceM *float64  `db:"distance_m" json:"distance_m,omitempty"`
+	CreatedAt time.Time `db:"created_ts" json:"created_ts"`
+	UpdatedAt time.Time `db:"updated_ts" json:"updated_ts"`
 }
 
 type BikeDetails struct {
 	Bike
 	Ratings []RatingAggregate   `json:"ratings"`
-	Reviews []ReviewWithRatings `json:"reviews"`
+	Reviews []ReviewWithRatings `json:"reviews"` // first page, newest first
+	// ReviewsNextCursor continues Reviews through GET /bikes/{id}/reviews.
+	ReviewsNextCursor *string `json:"reviews_next_cursor"`
 }
 
-func (s *Store) ListBikes(ctx context.Context) ([]Bike, error) {
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT 
-			b.numerical_id, 
-			b.hash_id, 
-			b.is_electric, 
-			b.created_ts, 
-			b.updated_ts,
-			ra.average_rating
-		FROM bikes b
-		LEFT JOIN rating_aggregates ra 
-			ON b.numerical_id = ra.bike_numerical_id 
-			AND ra.subcategory = 'overall'
-		ORDER BY b.numerical_id
-	`)
+type BikeSort string
+
+const (
+	BikeSortNumericalID   BikeSort = "numerical_id"
+	BikeSortAverageRating BikeSort = "average_rating"
+	BikeSortCreatedAt     BikeSort = "created_ts"
+	BikeSortReviewCount   BikeSort = "review_count"
+	BikeSortLastSeen      BikeSort = "last_seen_ts"
+)
+
+// bikeSortKeys maps each sort to the expression it orders by and the type its
+// cursor value is cast back to.
+var bikeSortKeys = map[BikeSort]struct {
+	expr string
+	cast string
+}{
+	BikeSortNumericalID:   {"numerical_id", "text"},
+	BikeSortAverageRating: {"COALESCE(average_rating, 0)", "numeric"},
+	BikeSortCreatedAt:     {"created_ts", "timestamptz"},
+	BikeSortReviewCount:   {"review_count", "bigint"},
+	BikeSortLastSeen:      {"COALESCE(last_seen_ts, 'epoch')", "timestamptz"},
+}
+
+func ValidBikeSort(s BikeSort) bool {
+	_, ok := bikeSortKeys[s]
+	return ok
+}
+
+type ListBikesQuery struct {
+	Cursor string
+	Limit  int
+
+	// HashID keeps the bike with that hash_id, as read from its QR code.
+	HashID       *string
+	IsElectric   *bool
+	MinRating    *float64
+	CreatedAfter *time.Time
+	CreatorID    *int64
+	// StationID keeps bikes whose last sighting was at that station.
+	StationID *string
+	// SeenAfter keeps bikes whose last sighting is more recent.
+	SeenAfter *time.Time
+	// Near keeps bikes last seen at a station within the circle and ranks
+	// them by distance, then overall rating, ignoring Sort.
+	Near *GeoQuery
+
+	Sort       BikeSort
+	Descending bool
+}
+
+// nearbyCursorSort tags cursors of distance-ranked pages. Their Value holds
+// the distance and the rating of the last bike, separated by a space.
+const nearbyCursorSort = "distance"
+
+// ListBikes returns one page of bikes and the cursor for the next page, which
+// is empty when there are no more bikes.
+func (s *Store) ListBikes(ctx context.Context, q ListBikesQuery) ([]Bike, string, error) {
+	if q.Sort == "" {
+		q.Sort = BikeSortNumericalID
+	}
+	key, ok := bikeSortKeys[q.Sort]
+	if !ok && q.Near == nil {
+		return nil, "", fmt.Errorf("unknown bike sort %q", q.Sort)
+	}
+	limit := clampLimit(q.Limit)
+
+	var where []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// Nearby searches join the station of the last sighting, narrowed by a
+	// bounding box the stations index can use, and compute the exact
+	// distance for filtering and ranking.
+	nearJoin, nearColumn, nearSelect := "", "", ""
+	if q.Near != nil {
+		if err := q.Near.validate(); err != nil {
+			return nil, "", err
+		}
+		lat, lon := arg(q.Near.Lat), arg(q.Near.Lon)
+		box := q.Near.box()
+		on := []string{
+			"st.station_id = b.last_station_id",
+			fmt.Sprintf("st.lat BETWEEN %s AND %s", arg(box.minLat), arg(box.maxLat)),
+		}
+		if !box.allLon {
+			on = append(on, fmt.Sprintf("st.lon BETWEEN %s AND %s", arg(box.minLon), arg(box.maxLon)))
+		}
+		nearJoin = "JOIN stations st ON " + strings.Join(on, " AND ")
+		nearColumn = ",\n\t\t\t\t" + haversineSQL("st.lat", "st.lon", lat, lon) + " AS distance_m"
+		nearSelect = ", distance_m"
+		where = append(where, "distance_m <= "+arg(q.Near.RadiusM))
+	}
+
+	if q.HashID != nil {
+		where = append(where, "hash_id = "+arg(*q.HashID))
+	}
+	if q.IsElectric != nil {
+		where = append(where, "is_electric = "+arg(*q.IsElectric))
+	}
+	if q.MinRating != nil {
+		where = append(where, "average_rating >= "+arg(*q.MinRating))
+	}
+	if q.CreatedAfter != nil {
+		where = append(where, "created_ts > "+arg(*q.CreatedAfter))
+	}
+	if q.CreatorID != nil {
+		where = append(where, "creator_id = "+arg(*q.CreatorID))
+	}
+	if q.StationID != nil {
+		where = append(where, "last_station_id = "+arg(*q.StationID))
+	}
+	if q.SeenAfter != nil {
+		where = append(where, "last_seen_ts > "+arg(*q.SeenAfter))
+	}
+
+	sortTag := string(q.Sort)
+	dir, cmp := "ASC", ">"
+	if q.Descending {
+		dir, cmp = "DESC", "<"
+	}
+	orderBy := fmt.Sprintf("%s %s, numerical_id %s", key.expr, dir, dir)
+	if q.Near != nil {
+		sortTag = nearbyCursorSort
+		orderBy = "distance_m ASC, COALESCE(average_rating, 0) DESC, numerical_id ASC"
+	}
+
+	if q.Cursor != "" {
+		valueCast := key.cast
+		if q.Near != nil {
+			valueCast = ""
+		}
+		c, err := decodeCursor(q.Cursor, sortTag, valueCast, "")
+		if err != nil {
+			return nil, "", err
+		}
+		if q.Near != nil {
+			dist, rating, ok := strings.Cut(c.Value, " ")
+			if !ok || !parsesAs(dist, "float8") || !parsesAs(rating, "numeric") {
+				return nil, "", ErrInvalidCursor
+			}
+			d, r, id := arg(dist), arg(rating), arg(c.ID)
+			where = append(where, fmt.Sprintf(
+				"(distance_m > %[1]s::float8 OR (distance_m = %[1]s::float8 AND (COALESCE(average_rating, 0) < %[2]s::numeric OR (COALESCE(average_rating, 0) = %[2]s::numeric AND numerical_id > %[3]s))))",
+				d, r, id))
+		} else {
+			where = append(where, fmt.Sprintf("(%s, numerical_id) %s (%s::%s, %s)",
+				key.expr, cmp, arg(c.Value), key.cast, arg(c.ID)))
+		}
+	}
+
+	whereClause := ""
+	if len(where) > 0 {
+		whereClause = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT numerical_id, hash_id, is_electric, created_ts, updated_ts, average_rating, review_count, creator_id, open_issue_count, last_station_id, last_seen_ts%s
+		FROM (
+			SELECT 
+				b.numerical_id, 
+				b.hash_id, 
+				b.is_electric, 
+				b.creator_id,
+				b.created_ts, 
+				b.updated_ts,
+				b.last_station_id,
+				b.last_seen_ts,
+				ra.average_rating,
+				(SELECT COUNT(*) FROM reviews r WHERE r.bike_numerical_id = b.numerical_id AND r.hidden_ts IS NULL AND r.deleted_ts IS NULL) AS review_count,
+				(SELECT COUNT(*) FROM bike_issues i WHERE i.bike_numerical_id = b.numerical_id AND i.resolved_ts IS NULL) AS open_issue_count%s
+			FROM bikes b
+			%s
+			LEFT JOIN rating_aggregates ra 
+				ON b.numerical_id = ra.bike_numerical_id 
+				AND ra.subcategory = 'overall'
+			WHERE b.deleted_ts IS NULL
+		) bk
+		%s
+		ORDER BY %s
+		LIMIT %s
+	`, nearSelect, nearColumn, nearJoin, whereClause, orderBy, arg(limit+1))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer rows.Close()
 
@@ -46,36 +236,118 @@ func (s *Store) ListBikes(ctx context.Context) ([]Bike, error) {
 	for rows.Next() {
 		var b Bike
 		var avgRating sql.NullFloat64
-		if err := rows.Scan(&b.NumericalID, &b.HashID, &b.IsElectric, &b.CreatedAt, &b.UpdatedAt, &avgRating); err != nil {
-			return nil, err
+		dest := []any{&b.NumericalID, &b.HashID, &b.IsElectric, &b.CreatedAt, &b.UpdatedAt, &avgRating, &b.ReviewCount, &b.CreatorID, &b.OpenIssueCount, &b.LastStationID, &b.LastSeenAt}
+		if q.Near != nil {
+			dest = append(dest, &b.DistanceM)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, "", err
 		}
 		if avgRating.Valid {
 			b.AverageRating = &avgRating.Float64
 		}
 		bikes = append(bikes, b)
 	}
-	return bikes, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if len(bikes) <= limit {
+		return bikes, "", nil
+	}
+	bikes = bikes[:limit]
+	last := bikes[limit-1]
+	value := bikeSortValue(last, q.Sort)
+	if q.Near != nil {
+		value = nearbyCursorValue(last)
+	}
+	return bikes, encodeCursor(pageCursor{
+		Sort:  sortTag,
+		Value: value,
+		ID:    last.NumericalID,
+	}), nil
+}
+
+func nearbyCursorValue(b Bike) string {
+	var dist float64
+	if b.DistanceM != nil {
+		dist = *b.DistanceM
+	}
+	return strconv.FormatFloat(dist, 'g', -1, 64) + " " + bikeSortValue(b, BikeSortAverageRating)
+}
+
+func bikeSortValue(b Bike, sort BikeSort) string {
+	switch sort {
+	case BikeSortAverageRating:
+		if b.AverageRating == nil {
+			return "0"
+		}
+		return strconv.FormatFloat(*b.AverageRating, 'f', -1, 64)
+	case BikeSortCreatedAt:
+		return b.CreatedAt.Format(time.RFC3339Nano)
+	case BikeSortReviewCount:
+		return strconv.FormatInt(b.ReviewCount, 10)
+	case BikeSortLastSeen:
+		if b.LastSeenAt == nil {
+			return time.Unix(0, 0).UTC().Format(time.RFC3339Nano)
+		}
+		return b.LastSeenAt.Format(time.RFC3339Nano)
+	default:
+		return b.NumericalID
+	}
 }
 
 func (s *Store) CreateBike(ctx context.Context, numericalID string, hashID *string, isElectric bool, creatorID int64) (*Bike, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
 	var b Bike
-	err := s.db.QueryRowContext(ctx, `
+	var after []byte
+	err = tx.QueryRowContext(ctx, `
 		INSERT INTO bikes (numerical_id, hash_id, is_electric, creator_id)
 		VALUES ($1, $2, $3, $4)
-		RETURNING numerical_id, hash_id, is_electric, created_ts, updated_ts
+		RETURNING numerical_id, hash_id, is_electric, created_ts, updated_ts, to_jsonb(bikes)
 	`, numericalID, hashID, isElectric, creatorID).Scan(
 		&b.NumericalID,
 		&b.HashID,
 		&b.IsElectric,
 		&b.CreatedAt,
 		&b.UpdatedAt,
+		&after,
 	)
+	b.CreatorID = &creatorID
 	if err != nil {
+		if err := bikeConflict(err); err != nil {
+			return nil, err
+		}
 		return nil, fmt.Errorf("insert bike: %w", err)
 	}
+
+	if err := recordAudit(ctx, tx, creatorID, AuditBikeCreate, "bike", b.NumericalID, nil, after); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
 	return &b, nil
 }
 
+// bikeConflict maps a unique violation on bikes to the matching error, or
+// returns nil for any other error.
+func bikeConflict(err error) error {
+	switch {
+	case strings.Contains(err.Error(), "bikes_pkey"):
+		return ErrBikeExists
+	case strings.Contains(err.Error(), "bikes_hash_id_key"):
+		return ErrHashIDExists
+	}
+	return nil
+}
+
 func (s *Store) GetBike(ctx context.Context, id string) (*Bike, error) {
 	var b Bike
 	var avgRating sql.NullFloat64
@@ -86,14 +358,22 @@ func (s *Store) GetBike(ctx context.Context, id string) (*Bike, error) {
 			b.is_electric, 
 			b.created_ts, 
 			b.updated_ts,
-			ra.average_rating
+			ra.average_rating,
+			(SELECT COUNT(*) FROM reviews r WHERE r.bike_numerical_id = b.numerical_id AND r.hidden_ts IS NULL AND r.deleted_ts IS NULL) AS review_count,
+			b.creator_id,
+			(SELECT COUNT(*) FROM bike_issues i WHERE i.bike_numerical_id = b.numerical_id AND i.resolved_ts IS NULL) AS open_issue_count,
+			b.last_station_id,
+			b.last_seen_ts
 		FROM bikes b
 		LEFT JOIN rating_aggregates ra 
 			ON b.numerical_id = ra.bike_numerical_id 
 			AND ra.subcategory = 'overall'
-		WHERE b.numerical_id = $1
-	`, id).Scan(&b.NumericalID, &b.HashID, &b.IsElectric, &b.CreatedAt, &b.UpdatedAt, &avgRating)
+		WHERE b.numerical_id = $1 AND b.deleted_ts IS NULL
+	`, id).Scan(&b.NumericalID, &b.HashID, &b.IsElectric, &b.CreatedAt, &b.UpdatedAt, &avgRating, &b.ReviewCount, &b.CreatorID, &b.OpenIssueCount, &b.LastStationID, &b.LastSeenAt)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrBikeNotFound
+		}
 		return nil, err
 	}
 	if avgRating.Valid {
@@ -113,34 +393,105 @@ func (s *Store) GetBikeDetails(ctx context.Context, id string) (*BikeDetails, er
 		return nil, fmt.Errorf("failed to fetch ratings: %w", err)
 	}
 
-	reviews, err := s.ListReviewsWithRatingsByBike(ctx, id)
+	reviews, cursor, err := s.ListReviewsWithRatingsByBike(ctx, id, ListReviewsQuery{Sort: ReviewSortNewest})
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch reviews: %w", err)
 	}
 
-	return &BikeDetails{
+	details := &BikeDetails{
 		Bike:    *b,
 		Ratings: ratings,
 		Reviews: reviews,
-	}, nil
+	}
+	if cursor != "" {
+		details.ReviewsNextCursor = &cursor
+	}
+	return details, nil
 }
 
-func (s *Store) UpdateBike(ctx context.Context, id string, hashID *string, isElectric *bool) error {
-	_, err := s.db.ExecContext(ctx, `
+func (s *Store) UpdateBike(ctx context.Context, id string, hashID *string, isElectric *bool, actorID int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := snapshot(ctx, tx, bikeSnapshotSQL, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrBikeNotFound
+		}
+		return fmt.Errorf("load bike: %w", err)
+	}
+
+	var after []byte
+	if err := tx.QueryRowContext(ctx, `
 		UPDATE bikes
 		SET
 			hash_id     = COALESCE($1, hash_id),
 			is_electric = COALESCE($2, is_electric),
 			updated_ts  = NOW()
-		WHERE numerical_id = $3
-	`, hashID, isElectric, id)
-	return err
+		WHERE numerical_id = $3 AND deleted_ts IS NULL
+		RETURNING to_jsonb(bikes)
+	`, hashID, isElectric, id).Scan(&after); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrBikeNotFound
+		}
+		if err := bikeConflict(err); err != nil {
+			return err
+		}
+		return fmt.Errorf("update bike: %w", err)
+	}
+
+	if err := recordAudit(ctx, tx, actorID, AuditBikeUpdate, "bike", id, before, after); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
 }
 
-func (s *Store) DeleteBike(ctx context.Context, id string) error {
-	_, err := s.db.ExecContext(ctx, `
-		DELETE FROM bikes
-		WHERE numerical_id = $1
+func (s *Store) DeleteBike(ctx context.Context, id string, actorID int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := snapshot(ctx, tx, bikeSnapshotSQL, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrBikeNotFound
+		}
+		return fmt.Errorf("load bike: %w", err)
+	}
+
+	// Soft delete: the bike and its reviews disappear from every query but
+	// stay restorable until PurgeDeleted removes them.
+	res, err := tx.ExecContext(ctx, `
+		UPDATE bikes
+		SET deleted_ts = NOW()
+		WHERE numerical_id = $1 AND deleted_ts IS NULL
 	`, id)
-	return err
+	if err != nil {
+		return fmt.Errorf("delete bike: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrBikeNotFound
+	}
+
+	if err := recordAudit(ctx, tx, actorID, AuditBikeDelete, "bike", id, before, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
 }
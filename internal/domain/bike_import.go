@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// BikeImportRow is one bike of a bulk import. Line is where it sits in the
+// uploaded file, so errors can point at it.
+type BikeImportRow struct {
+	Line        int
+	NumericalID string
+	HashID      *string
+	IsElectric  bool
+}
+
+var ErrBikeImportConflict = &ConflictError{Code: "bike_import_conflict", Message: "some bikes already exist"}
+
+// BikeImportField is the path import errors use for field of the row on
+// line, or for the whole row when field is empty, e.g. "rows[12].hash_id".
+func BikeImportField(line int, field string) string {
+	if field == "" {
+		return fmt.Sprintf("rows[%d]", line)
+	}
+	return fmt.Sprintf("rows[%d].%s", line, field)
+}
+
+// BikeImportResult is what an import did, or on a dry run would have done.
+type BikeImportResult struct {
+	Created int  `json:"created"`
+	DryRun  bool `json:"dry_run"`
+}
+
+// ImportBikes creates bikes in one transaction. If any row clashes with an
+// existing bike or an earlier row, nothing is written and the error is
+// ErrBikeImportConflict listing every such row. A dry run checks the same
+// way but always rolls back.
+func (s *Store) ImportBikes(ctx context.Context, rows []BikeImportRow, creatorID int64, dryRun bool) (*BikeImportResult, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	res := BikeImportResult{DryRun: dryRun}
+	// Images of the created bikes, audited once the import is known to go through.
+	var created []json.RawMessage
+	var clashes []FieldError
+	for _, row := range rows {
+		var id string
+		var after []byte
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO bikes (numerical_id, hash_id, is_electric, creator_id)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT DO NOTHING
+			RETURNING numerical_id, to_jsonb(bikes)
+		`, row.NumericalID, row.HashID, row.IsElectric, creatorID).Scan(&id, &after)
+		if err == nil {
+			res.Created++
+			created = append(created, after)
+			continue
+		}
+		if err != sql.ErrNoRows {
+			return nil, fmt.Errorf("insert bike %s: %w", row.NumericalID, err)
+		}
+
+		// Deleted bikes still hold their ids until they are purged.
+		var sameID bool
+		if err := tx.QueryRowContext(ctx, `
+			SELECT numerical_id = $1
+			FROM bikes
+			WHERE numerical_id = $1 OR hash_id = $2
+			ORDER BY numerical_id = $1 DESC
+			LIMIT 1
+		`, row.NumericalID, row.HashID).Scan(&sameID); err != nil {
+			return nil, fmt.Errorf("find conflicting bike: %w", err)
+		}
+		clash := FieldError{Field: BikeImportField(row.Line, "hash_id"), Message: "bike with this hash_id already exists"}
+		if sameID {
+			clash = FieldError{Field: BikeImportField(row.Line, "numerical_id"), Message: "bike with this numerical_id already exists"}
+		}
+		clashes = append(clashes, clash)
+	}
+
+	if len(clashes) > 0 {
+		conflict := *ErrBikeImportConflict
+		conflict.Fields = clashes
+		return nil, &conflict
+	}
+	if dryRun {
+		return &res, nil
+	}
+
+	for i, row := range rows {
+		if err := recordAudit(ctx, tx, creatorID, AuditBikeImport, "bike", row.NumericalID, nil, created[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return &res, nil
+}
+
+// BikeExport is a bike with every rating average it has.
+type BikeExport struct {
+	Bike
+	Ratings map[RatingSubcategory]float64 `json:"ratings"`
+}
+
+// ExportBikes calls fn for every bike that isn't deleted, in numerical_id
+// order, reading them as it goes rather than all at once. An error from fn
+// stops the export and is returned.
+func (s *Store) ExportBikes(ctx context.Context, fn func(BikeExport) error) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+			b.numerical_id,
+			b.hash_id,
+			b.is_electric,
+			b.created_ts,
+			b.updated_ts,
+			(SELECT COUNT(*) FROM reviews r WHERE r.bike_numerical_id = b.numerical_id AND r.hidden_ts IS NULL AND r.deleted_ts IS NULL) AS review_count,
+			b.creator_id,
+			(SELECT COUNT(*) FROM bike_issues i WHERE i.bike_numerical_id = b.numerical_id AND i.resolved_ts IS NULL) AS open_issue_count,
+			b.last_station_id,
+			b.last_seen_ts,
+			(SELECT json_object_agg(ra.subcategory, ra.average_rating) FROM rating_aggregates ra WHERE ra.bike_numerical_id = b.numerical_id) AS ratings
+		FROM bikes b
+		WHERE b.deleted_ts IS NULL
+		ORDER BY b.numerical_id ASC
+	`)
+	if err != nil {
+		return fmt.Errorf("export bikes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b BikeExport
+		var ratings []byte
+		if err := rows.Scan(&b.NumericalID, &b.HashID, &b.IsElectric, &b.CreatedAt, &b.UpdatedAt, &b.ReviewCount,
+			&b.CreatorID, &b.OpenIssueCount, &b.LastStationID, &b.LastSeenAt, &ratings); err != nil {
+			return err
+		}
+		b.Ratings = map[RatingSubcategory]float64{}
+		if ratings != nil {
+			if err := json.Unmarshal(ratings, &b.Ratings); err != nil {
+				return fmt.Errorf("decode ratings of bike %s: %w", b.NumericalID, err)
+			}
+		}
+		if avg, ok := b.Ratings[RatingSubcategoryOverall]; ok {
+			b.AverageRating = &avg
+		}
+		if err := fn(b); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestImportBikes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	creatorID := int64(1)
+	hash := "abc123"
+	rows := []BikeImportRow{
+		{Line: 2, NumericalID: "01234", HashID: &hash, IsElectric: true},
+		{Line: 3, NumericalID: "5678"},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO bikes \\(numerical_id, hash_id, is_electric, creator_id\\) VALUES \\(\\$1, \\$2, \\$3, \\$4\\) ON CONFLICT DO NOTHING RETURNING numerical_id, to_jsonb\\(bikes\\)").
+			WithArgs("01234", hash, true, creatorID).
+			WillReturnRows(sqlmock.NewRows([]string{"numerical_id", "image"}).AddRow("01234", `{"numerical_id":"01234"}`))
+		mock.ExpectQuery("INSERT INTO bikes").
+			WithArgs("5678", nil, false, creatorID).
+			WillReturnRows(sqlmock.NewRows([]string{"numerical_id", "image"}).AddRow("5678", `{"numerical_id":"5678"}`))
+		mock.ExpectExec("INSERT INTO audit_events").
+			WithArgs(creatorID, AuditBikeImport, "bike", "01234", nil, `{"numerical_id":"01234"}`, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO audit_events").
+			WithArgs(creatorID, AuditBikeImport, "bike", "5678", nil, `{"numerical_id":"5678"}`, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		store := NewStore(db)
+		res, err := store.ImportBikes(ctx, rows, creatorID, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if res.Created != 2 || res.DryRun {
+			t.Errorf("unexpected result %+v", res)
+		}
+	})
+
+	t.Run("dry_run_rolls_back", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO bikes").
+			WithArgs("01234", hash, true, creatorID).
+			WillReturnRows(sqlmock.NewRows([]string{"numerical_id", "image"}).AddRow("01234", `{"numerical_id":"01234"}`))
+		mock.ExpectQuery("INSERT INTO bikes").
+			WithArgs("5678", nil, false, creatorID).
+			WillReturnRows(sqlmock.NewRows([]string{"numerical_id", "image"}).AddRow("5678", `{"numerical_id":"5678"}`))
+		mock.ExpectRollback()
+
+		store := NewStore(db)
+		res, err := store.ImportBikes(ctx, rows, creatorID, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if res.Created != 2 || !res.DryRun {
+			t.Errorf("unexpected result %+v", res)
+		}
+	})
+
+	t.Run("conflicts_write_nothing", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO bikes").
+			WithArgs("01234", hash, true, creatorID).
+			WillReturnRows(sqlmock.NewRows([]string{"numerical_id", "image"}))
+		mock.ExpectQuery("SELECT numerical_id = \\$1 FROM bikes WHERE numerical_id = \\$1 OR hash_id = \\$2").
+			WithArgs("01234", hash).
+			WillReturnRows(sqlmock.NewRows([]string{"same_id"}).AddRow(false))
+		mock.ExpectQuery("INSERT INTO bikes").
+			WithArgs("5678", nil, false, creatorID).
+			WillReturnRows(sqlmock.NewRows([]string{"numerical_id", "image"}))
+		mock.ExpectQuery("SELECT numerical_id = \\$1 FROM bikes").
+			WithArgs("5678", nil).
+			WillReturnRows(sqlmock.NewRows([]string{"same_id"}).AddRow(true))
+		mock.ExpectRollback()
+
+		store := NewStore(db)
+		_, err := store.ImportBikes(ctx, rows, creatorID, false)
+		var conflict *ConflictError
+		if !errors.As(err, &conflict) || !errors.Is(err, ErrBikeImportConflict) {
+			t.Fatalf("expected error %v, got %v", ErrBikeImportConflict, err)
+		}
+		want := []FieldError{
+			{Field: "rows[2].hash_id", Message: "bike with this hash_id already exists"},
+			{Field: "rows[3].numerical_id", Message: "bike with this numerical_id already exists"},
+		}
+		if !slices.Equal(conflict.Fields, want) {
+			t.Errorf("unexpected fields %+v", conflict.Fields)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestExportBikes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT .* json_object_agg\\(ra.subcategory, ra.average_rating\\) .* FROM bikes b WHERE b.deleted_ts IS NULL ORDER BY b.numerical_id ASC").
+		WillReturnRows(sqlmock.NewRows([]string{"numerical_id", "hash_id", "is_electric", "created_ts", "updated_ts", "review_count", "creator_id", "open_issue_count", "last_station_id", "last_seen_ts", "ratings"}).
+			AddRow("01234", "abc123", true, now, now, 2, 1, 0, nil, nil, []byte(`{"overall": 4.50, "seat": 3.00}`)).
+			AddRow("5678", nil, false, now, now, 0, nil, 1, "42", now, nil))
+
+	var got []BikeExport
+	store := NewStore(db)
+	if err := store.ExportBikes(context.Background(), func(b BikeExport) error {
+		got = append(got, b)
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 bikes, got %d", len(got))
+	}
+	if got[0].AverageRating == nil || *got[0].AverageRating != 4.5 || got[0].Ratings[RatingSubcategorySeat] != 3 {
+		t.Errorf("unexpected ratings %+v", got[0])
+	}
+	if got[1].AverageRating != nil || len(got[1].Ratings) != 0 || got[1].LastStationID == nil {
+		t.Errorf("unexpected bike %+v", got[1])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -3,6 +3,7 @@ package domain
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"testing"
 	"time"
 
@@ -17,23 +18,230 @@ func TestListBikes(t *testing.T) {
 	defer db.Close()
 
 	ctx := context.Background()
+	columns := []string{"numerical_id", "hash_id", "is_electric", "created_ts", "updated_ts", "average_rating", "review_count", "creator_id", "open_issue_count", "last_station_id", "last_seen_ts"}
 
 	t.Run("success", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"numerical_id", "hash_id", "is_electric", "created_ts", "updated_ts", "average_rating"}).
-			AddRow("01", "hash1", true, time.Now(), time.Now(), 4.5).
-			AddRow("02", "hash2", false, time.Now(), time.Now(), nil)
+		rows := sqlmock.NewRows(columns).
+			AddRow("01", "hash1", true, time.Now(), time.Now(), 4.5, 3, 1, 0, nil, nil).
+			AddRow("02", "hash2", false, time.Now(), time.Now(), nil, 0, nil, 0, nil, nil)
 
-		mock.ExpectQuery("SELECT b.numerical_id, b.hash_id, b.is_electric, b.created_ts, b.updated_ts, ra.average_rating FROM bikes b LEFT JOIN rating_aggregates ra ON b.numerical_id = ra.bike_numerical_id AND ra.subcategory = 'overall' ORDER BY b.numerical_id").
+		mock.ExpectQuery("SELECT numerical_id, hash_id, is_electric, created_ts, updated_ts, average_rating, review_count, creator_id, open_issue_count, last_station_id, last_seen_ts FROM \\(.*\\) bk ORDER BY numerical_id ASC, numerical_id ASC LIMIT \\$1").
+			WithArgs(DefaultPageLimit + 1).
 			WillReturnRows(rows)
 
 		store := NewStore(db)
-		bikes, err := store.ListBikes(ctx)
+		bikes, cursor, err := store.ListBikes(ctx, ListBikesQuery{})
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
 		if len(bikes) != 2 {
 			t.Errorf("expected 2 bikes, got %d", len(bikes))
 		}
+		if cursor != "" {
+			t.Errorf("expected no next cursor, got %q", cursor)
+		}
+		if bikes[0].ReviewCount != 3 {
+			t.Errorf("expected review count 3, got %d", bikes[0].ReviewCount)
+		}
+		if bikes[0].CreatorID == nil || *bikes[0].CreatorID != 1 || bikes[1].CreatorID != nil {
+			t.Errorf("expected creator 1 then none, got %v, %v", bikes[0].CreatorID, bikes[1].CreatorID)
+		}
+	})
+
+	t.Run("filters_and_next_page", func(t *testing.T) {
+		electric := true
+		minRating := 3.5
+		after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		rows := sqlmock.NewRows(columns).
+			AddRow("01", "hash1", true, time.Now(), time.Now(), 4.5, 3, 1, 0, nil, nil).
+			AddRow("02", "hash2", true, time.Now(), time.Now(), 4.0, 1, 1, 0, nil, nil)
+
+		mock.ExpectQuery("WHERE is_electric = \\$1 AND average_rating >= \\$2 AND created_ts > \\$3 ORDER BY COALESCE\\(average_rating, 0\\) DESC, numerical_id DESC LIMIT \\$4").
+			WithArgs(electric, minRating, after, 2).
+			WillReturnRows(rows)
+
+		store := NewStore(db)
+		bikes, cursor, err := store.ListBikes(ctx, ListBikesQuery{
+			Limit:        1,
+			IsElectric:   &electric,
+			MinRating:    &minRating,
+			CreatedAfter: &after,
+			Sort:         BikeSortAverageRating,
+			Descending:   true,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(bikes) != 1 {
+			t.Fatalf("expected 1 bike, got %d", len(bikes))
+		}
+		if cursor == "" {
+			t.Fatal("expected a next cursor")
+		}
+
+		// Following the cursor resumes after the last bike of the page.
+		mock.ExpectQuery("WHERE \\(COALESCE\\(average_rating, 0\\), numerical_id\\) < \\(\\$1::numeric, \\$2\\) ORDER BY").
+			WithArgs("4.5", "01", 2).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("02", "hash2", true, time.Now(), time.Now(), 4.0, 1, 1, 0, nil, nil))
+
+		bikes, cursor, err = store.ListBikes(ctx, ListBikesQuery{
+			Cursor:     cursor,
+			Limit:      1,
+			Sort:       BikeSortAverageRating,
+			Descending: true,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(bikes) != 1 || bikes[0].NumericalID != "02" {
+			t.Errorf("expected bike 02, got %+v", bikes)
+		}
+		if cursor != "" {
+			t.Errorf("expected no next cursor, got %q", cursor)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("by_hash_id", func(t *testing.T) {
+		hashID := "hash2"
+
+		mock.ExpectQuery("WHERE hash_id = \\$1 ORDER BY numerical_id ASC, numerical_id ASC LIMIT \\$2").
+			WithArgs(hashID, 2).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("02", hashID, false, time.Now(), time.Now(), nil, 0, nil, 0, nil, nil))
+
+		store := NewStore(db)
+		bikes, _, err := store.ListBikes(ctx, ListBikesQuery{Limit: 1, HashID: &hashID})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(bikes) != 1 || bikes[0].NumericalID != "02" {
+			t.Errorf("expected bike 02, got %+v", bikes)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("station_by_last_seen", func(t *testing.T) {
+		station := "42"
+		seen := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+
+		mock.ExpectQuery("WHERE last_station_id = \\$1 ORDER BY COALESCE\\(last_seen_ts, 'epoch'\\) DESC, numerical_id DESC LIMIT \\$2").
+			WithArgs(station, 2).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("01", "hash1", true, time.Now(), time.Now(), 4.5, 3, 1, 0, station, seen).
+				AddRow("02", "hash2", true, time.Now(), time.Now(), 4.0, 1, 1, 0, station, seen))
+
+		store := NewStore(db)
+		bikes, cursor, err := store.ListBikes(ctx, ListBikesQuery{
+			Limit:      1,
+			StationID:  &station,
+			Sort:       BikeSortLastSeen,
+			Descending: true,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(bikes) != 1 || bikes[0].LastStationID == nil || *bikes[0].LastStationID != station || bikes[0].LastSeenAt == nil {
+			t.Fatalf("unexpected bikes %+v", bikes)
+		}
+
+		c, err := decodeCursor(cursor, string(BikeSortLastSeen), "timestamptz", "")
+		if err != nil {
+			t.Fatalf("unexpected cursor error: %v", err)
+		}
+		if c.Value != seen.Format(time.RFC3339Nano) || c.ID != "01" {
+			t.Errorf("unexpected cursor %+v", c)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("nearby_ranked_by_distance_then_rating", func(t *testing.T) {
+		near := GeoQuery{Lat: 41.3874, Lon: 2.1686, RadiusM: 500}
+		since := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+		box := near.box()
+		nearColumns := append(append([]string{}, columns...), "distance_m")
+
+		mock.ExpectQuery("SELECT .*, last_seen_ts, distance_m FROM \\( SELECT .* AS distance_m FROM bikes b JOIN stations st ON st.station_id = b.last_station_id AND st.lat BETWEEN \\$3 AND \\$4 AND st.lon BETWEEN \\$5 AND \\$6 LEFT JOIN rating_aggregates .* \\) bk WHERE distance_m <= \\$7 AND last_seen_ts > \\$8 ORDER BY distance_m ASC, COALESCE\\(average_rating, 0\\) DESC, numerical_id ASC LIMIT \\$9").
+			WithArgs(near.Lat, near.Lon, box.minLat, box.maxLat, box.minLon, box.maxLon, near.RadiusM, since, 2).
+			WillReturnRows(sqlmock.NewRows(nearColumns).
+				AddRow("01", "hash1", true, time.Now(), time.Now(), 4.5, 3, 1, 0, "42", time.Now(), 120.25).
+				AddRow("02", "hash2", true, time.Now(), time.Now(), 4.0, 1, 1, 0, "42", time.Now(), 120.25))
+
+		store := NewStore(db)
+		bikes, cursor, err := store.ListBikes(ctx, ListBikesQuery{Limit: 1, Near: &near, SeenAfter: &since})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(bikes) != 1 || bikes[0].DistanceM == nil || *bikes[0].DistanceM != 120.25 {
+			t.Fatalf("unexpected bikes %+v", bikes)
+		}
+
+		// The next page continues after (distance, rating, id) of bike 01.
+		mock.ExpectQuery("WHERE distance_m <= \\$7 AND \\(distance_m > \\$8::float8 OR \\(distance_m = \\$8::float8 AND \\(COALESCE\\(average_rating, 0\\) < \\$9::numeric OR \\(COALESCE\\(average_rating, 0\\) = \\$9::numeric AND numerical_id > \\$10\\)\\)\\)\\) ORDER BY").
+			WithArgs(near.Lat, near.Lon, box.minLat, box.maxLat, box.minLon, box.maxLon, near.RadiusM, "120.25", "4.5", "01", 2).
+			WillReturnRows(sqlmock.NewRows(nearColumns).
+				AddRow("02", "hash2", true, time.Now(), time.Now(), 4.0, 1, 1, 0, "42", time.Now(), 120.25))
+
+		bikes, cursor, err = store.ListBikes(ctx, ListBikesQuery{Limit: 1, Near: &near, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(bikes) != 1 || bikes[0].NumericalID != "02" || cursor != "" {
+			t.Errorf("unexpected second page %+v, %q", bikes, cursor)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("nearby_invalid_location", func(t *testing.T) {
+		store := NewStore(db)
+		_, _, err := store.ListBikes(ctx, ListBikesQuery{Near: &GeoQuery{Lat: 41.38, Lon: 2.17, RadiusM: 50000}})
+		if err != ErrInvalidLocation {
+			t.Errorf("expected ErrInvalidLocation, got %v", err)
+		}
+	})
+
+	t.Run("cursor_with_unparseable_value", func(t *testing.T) {
+		store := NewStore(db)
+		for sort, value := range map[BikeSort]string{
+			BikeSortCreatedAt:     "yesterday",
+			BikeSortReviewCount:   "many",
+			BikeSortAverageRating: "NaN",
+		} {
+			c := encodeCursor(pageCursor{Sort: string(sort), Value: value, ID: "01"})
+			_, _, err := store.ListBikes(ctx, ListBikesQuery{Cursor: c, Sort: sort})
+			if err != ErrInvalidCursor {
+				t.Errorf("%s: expected ErrInvalidCursor, got %v", sort, err)
+			}
+		}
+
+		near := GeoQuery{Lat: 41.3874, Lon: 2.1686, RadiusM: 500}
+		c := encodeCursor(pageCursor{Sort: nearbyCursorSort, Value: "far 4.5", ID: "01"})
+		_, _, err := store.ListBikes(ctx, ListBikesQuery{Cursor: c, Near: &near})
+		if err != ErrInvalidCursor {
+			t.Errorf("nearby: expected ErrInvalidCursor, got %v", err)
+		}
+	})
+
+	t.Run("cursor_from_other_sort", func(t *testing.T) {
+		c := encodeCursor(pageCursor{Sort: string(BikeSortCreatedAt), Value: "x", ID: "01"})
+
+		store := NewStore(db)
+		_, _, err := store.ListBikes(ctx, ListBikesQuery{Cursor: c, Sort: BikeSortReviewCount})
+		if err != ErrInvalidCursor {
+			t.Errorf("expected ErrInvalidCursor, got %v", err)
+		}
 	})
 }
 
@@ -51,21 +259,30 @@ func TestCreateBike(t *testing.T) {
 	creatorID := int64(1)
 
 	t.Run("success", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"numerical_id", "hash_id", "is_electric", "created_ts", "updated_ts"}).
-			AddRow(numericalID, hashID, isElectric, time.Now(), time.Now())
+		rows := sqlmock.NewRows([]string{"numerical_id", "hash_id", "is_electric", "created_ts", "updated_ts", "to_jsonb"}).
+			AddRow(numericalID, hashID, isElectric, time.Now(), time.Now(), `{"numerical_id":"0123"}`)
 
+		mock.ExpectBegin()
 		mock.ExpectQuery("INSERT INTO bikes").
 			WithArgs(numericalID, &hashID, isElectric, creatorID).
 			WillReturnRows(rows)
+		mock.ExpectExec("INSERT INTO audit_events").
+			WithArgs(creatorID, AuditBikeCreate, "bike", numericalID, nil, `{"numerical_id":"0123"}`, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 
 		store := NewStore(db)
 		bike, err := store.CreateBike(ctx, numericalID, &hashID, isElectric, creatorID)
 		if err != nil {
-			t.Errorf("unexpected error: %v", err)
+			t.Fatalf("unexpected error: %v", err)
 		}
 		if bike.NumericalID != numericalID {
 			t.Errorf("expected numericalID %s, got %s", numericalID, bike.NumericalID)
 		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
 	})
 }
 
@@ -80,10 +297,10 @@ func TestGetBike(t *testing.T) {
 	id := "01"
 
 	t.Run("success", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"numerical_id", "hash_id", "is_electric", "created_ts", "updated_ts", "average_rating"}).
-			AddRow(id, "hash1", true, time.Now(), time.Now(), 4.5)
+		rows := sqlmock.NewRows([]string{"numerical_id", "hash_id", "is_electric", "created_ts", "updated_ts", "average_rating", "review_count", "creator_id", "open_issue_count", "last_station_id", "last_seen_ts"}).
+			AddRow(id, "hash1", true, time.Now(), time.Now(), 4.5, 2, 1, 2, nil, nil)
 
-		mock.ExpectQuery("SELECT b.numerical_id, b.hash_id, b.is_electric, b.created_ts, b.updated_ts, ra.average_rating FROM bikes b LEFT JOIN rating_aggregates ra ON b.numerical_id = ra.bike_numerical_id AND ra.subcategory = 'overall' WHERE b.numerical_id = \\$1").
+		mock.ExpectQuery("SELECT b.numerical_id, b.hash_id, b.is_electric, b.created_ts, b.updated_ts, ra.average_rating, .* FROM bikes b LEFT JOIN rating_aggregates ra ON b.numerical_id = ra.bike_numerical_id AND ra.subcategory = 'overall' WHERE b.numerical_id = \\$1 AND b.deleted_ts IS NULL").
 			WithArgs(id).
 			WillReturnRows(rows)
 
@@ -95,17 +312,20 @@ func TestGetBike(t *testing.T) {
 		if bike.NumericalID != id {
 			t.Errorf("expected id %s, got %s", id, bike.NumericalID)
 		}
+		if bike.OpenIssueCount != 2 {
+			t.Errorf("expected 2 open issues, got %d", bike.OpenIssueCount)
+		}
 	})
 
 	t.Run("not_found", func(t *testing.T) {
-		mock.ExpectQuery("SELECT b.numerical_id, b.hash_id, b.is_electric, b.created_ts, b.updated_ts, ra.average_rating FROM bikes b LEFT JOIN rating_aggregates ra ON b.numerical_id = ra.bike_numerical_id AND ra.subcategory = 'overall' WHERE b.numerical_id = \\$1").
+		mock.ExpectQuery("SELECT b.numerical_id, b.hash_id, b.is_electric, b.created_ts, b.updated_ts, ra.average_rating, .* FROM bikes b LEFT JOIN rating_aggregates ra ON b.numerical_id = ra.bike_numerical_id AND ra.subcategory = 'overall' WHERE b.numerical_id = \\$1 AND b.deleted_ts IS NULL").
 			WithArgs(id).
 			WillReturnError(sql.ErrNoRows)
 
 		store := NewStore(db)
 		_, err := store.GetBike(ctx, id)
-		if err != sql.ErrNoRows {
-			t.Errorf("expected sql.ErrNoRows, got %v", err)
+		if !errors.Is(err, ErrBikeNotFound) {
+			t.Errorf("expected ErrBikeNotFound, got %v", err)
 		}
 	})
 }
@@ -121,18 +341,45 @@ func TestUpdateBike(t *testing.T) {
 	id := "01"
 	hashID := "new_hash"
 	isElectric := false
+	actorID := int64(3)
 
 	t.Run("success", func(t *testing.T) {
-		mock.ExpectExec("UPDATE bikes").
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT to_jsonb\\(b\\) FROM bikes b WHERE b.numerical_id = \\$1 FOR UPDATE").
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{"hash_id":"old"}`))
+		mock.ExpectQuery("UPDATE bikes .* RETURNING to_jsonb\\(bikes\\)").
 			WithArgs(&hashID, &isElectric, id).
-			WillReturnResult(sqlmock.NewResult(0, 1))
+			WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{"hash_id":"new_hash"}`))
+		mock.ExpectExec("INSERT INTO audit_events \\(actor_id, action, entity_type, entity_id, before, after, request_id\\)").
+			WithArgs(actorID, AuditBikeUpdate, "bike", id, `{"hash_id":"old"}`, `{"hash_id":"new_hash"}`, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 
 		store := NewStore(db)
-		err := store.UpdateBike(ctx, id, &hashID, &isElectric)
+		err := store.UpdateBike(ctx, id, &hashID, &isElectric, actorID)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
 	})
+
+	t.Run("not_found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT to_jsonb\\(b\\) FROM bikes b").
+			WithArgs(id).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		store := NewStore(db)
+		err := store.UpdateBike(ctx, id, &hashID, &isElectric, actorID)
+		if !errors.Is(err, ErrBikeNotFound) {
+			t.Errorf("expected ErrBikeNotFound, got %v", err)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
 }
 
 func TestDeleteBike(t *testing.T) {
@@ -144,16 +391,45 @@ func TestDeleteBike(t *testing.T) {
 
 	ctx := context.Background()
 	id := "01"
+	actorID := int64(3)
 
 	t.Run("success", func(t *testing.T) {
-		mock.ExpectExec("DELETE FROM bikes").
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT to_jsonb\\(b\\) FROM bikes b").
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{"numerical_id":"01"}`))
+		mock.ExpectExec("UPDATE bikes SET deleted_ts = NOW\\(\\) WHERE numerical_id = \\$1 AND deleted_ts IS NULL").
 			WithArgs(id).
 			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO audit_events").
+			WithArgs(actorID, AuditBikeDelete, "bike", id, `{"numerical_id":"01"}`, nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 
 		store := NewStore(db)
-		err := store.DeleteBike(ctx, id)
+		err := store.DeleteBike(ctx, id, actorID)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
 	})
+
+	t.Run("already_deleted", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT to_jsonb\\(b\\) FROM bikes b").
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{"numerical_id":"01"}`))
+		mock.ExpectExec("UPDATE bikes SET deleted_ts").
+			WithArgs(id).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		store := NewStore(db)
+		if err := store.DeleteBike(ctx, id, actorID); !errors.Is(err, ErrBikeNotFound) {
+			t.Errorf("expected ErrBikeNotFound, got %v", err)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
 }
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// MinMagicLinkRetention keeps magic links at least as long as the default
+// magic link quota looks back.
+const MinMagicLinkRetention = 24 * time.Hour
+
+// AuthCleanupResult counts what CleanupAuth removed.
+type AuthCleanupResult struct {
+	// MagicLinks is how many consumed or expired links were deleted.
+	MagicLinks int64
+	// SealedTokens is how many session handovers nobody polled for were
+	// cleared from expired links.
+	SealedTokens int64
+	// Sessions is how many expired sessions were deleted, refresh tokens
+	// and all.
+	Sessions int64
+}
+
+// CleanupAuth deletes credentials that can no longer be used: magic links
+// consumed or expired more than retention ago, unclaimed tokens sealed on
+// expired links, and expired sessions. retention is raised to
+// MinMagicLinkRetention, or the window of the magic link quota, if shorter.
+func (s *Store) CleanupAuth(ctx context.Context, retention time.Duration) (*AuthCleanupResult, error) {
+	retention = max(retention, MinMagicLinkRetention, s.quotas.MagicLinks.Window)
+	cutoff := time.Now().Add(-retention)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var res AuthCleanupResult
+	steps := []struct {
+		n     *int64
+		what  string
+		query string
+		args  []any
+	}{
+		{&res.SealedTokens, "clear sealed tokens", `
+			UPDATE magic_links
+			SET sealed_api_token = NULL
+			WHERE sealed_api_token IS NOT NULL AND expires_ts < NOW()
+		`, nil},
+		{&res.MagicLinks, "purge magic links", `
+			DELETE FROM magic_links
+			WHERE (consumed_ts IS NOT NULL OR expires_ts < NOW()) AND created_ts < $1
+		`, []any{cutoff}},
+		{&res.Sessions, "purge sessions", `
+			DELETE FROM sessions
+			WHERE expires_ts < NOW()
+		`, nil},
+	}
+	for _, step := range steps {
+		r, err := tx.ExecContext(ctx, step.query, step.args...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", step.what, err)
+		}
+		if *step.n, err = r.RowsAffected(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return &res, nil
+}
+
+// PurgeExpiredDataExports deletes the records of expired data exports and
+// returns their blob keys, for the caller to delete the files.
+func (s *Store) PurgeExpiredDataExports(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		DELETE FROM data_exports
+		WHERE expires_ts < NOW()
+		RETURNING blob_key
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("purge data exports: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// PruneStationAvailability deletes availability snapshots recorded more
+// than retention ago and returns how many it removed.
+func (s *Store) PruneStationAvailability(ctx context.Context, retention time.Duration) (int64, error) {
+	r, err := s.db.ExecContext(ctx, `
+		DELETE FROM station_availability
+		WHERE recorded_ts < $1
+	`, time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("prune station availability: %w", err)
+	}
+	return r.RowsAffected()
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCleanupAuth(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	store := NewStore(db)
+
+	expect := func(retention time.Duration) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE magic_links SET sealed_api_token = NULL WHERE sealed_api_token IS NOT NULL AND expires_ts < NOW\\(\\)").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec("DELETE FROM magic_links WHERE \\(consumed_ts IS NOT NULL OR expires_ts < NOW\\(\\)\\) AND created_ts < \\$1").
+			WithArgs(cutoffNear{time.Now().Add(-retention)}).
+			WillReturnResult(sqlmock.NewResult(0, 7))
+		mock.ExpectExec("DELETE FROM sessions WHERE expires_ts < NOW\\(\\)").
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectCommit()
+	}
+
+	t.Run("purges", func(t *testing.T) {
+		expect(30 * 24 * time.Hour)
+		res, err := store.CleanupAuth(ctx, 30*24*time.Hour)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if res.SealedTokens != 2 || res.MagicLinks != 7 || res.Sessions != 3 {
+			t.Errorf("unexpected result %+v", res)
+		}
+	})
+
+	t.Run("keeps_links_the_daily_limit_counts", func(t *testing.T) {
+		expect(MinMagicLinkRetention)
+		if _, err := store.CleanupAuth(ctx, time.Hour); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPurgeExpiredDataExports(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("DELETE FROM data_exports WHERE expires_ts < NOW\\(\\) RETURNING blob_key").
+		WillReturnRows(sqlmock.NewRows([]string{"blob_key"}).AddRow("export_a.zip").AddRow("export_b.json"))
+
+	store := NewStore(db)
+	keys, err := store.PurgeExpiredDataExports(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keys) != 2 || keys[0] != "export_a.zip" {
+		t.Errorf("unexpected keys %v", keys)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPruneStationAvailability(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM station_availability WHERE recorded_ts < \\$1").
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 42))
+
+	store := NewStore(db)
+	n, err := store.PruneStationAvailability(context.Background(), 720*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 42 {
+		t.Errorf("expected 42 pruned snapshots, got %d", n)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+)
+
+var ErrInvalidCursor = &ValidationError{Code: "invalid_cursor", Message: "invalid cursor"}
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 100
+)
+
+// pageCursor is the keyset position of the last row of a page: the value of
+// the sort key plus the row id used as tie-breaker. Sort is kept so a cursor
+// issued for one ordering can't be replayed against another.
+type pageCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+func encodeCursor(c pageCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor parses a cursor issued for sort. Its value and id must parse as
+// the SQL types they are cast to (valueCast, idCast; empty skips the check),
+// so a tampered cursor is rejected here instead of failing the query.
+func decodeCursor(s, sort, valueCast, idCast string) (*pageCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c pageCursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.Sort != sort || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	if !parsesAs(c.Value, valueCast) || !parsesAs(c.ID, idCast) {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// parsesAs reports whether Postgres will accept v cast to the given type.
+func parsesAs(v, cast string) bool {
+	switch cast {
+	case "", "text":
+		return true
+	case "bigint":
+		_, err := strconv.ParseInt(v, 10, 64)
+		return err == nil
+	case "smallint":
+		_, err := strconv.ParseInt(v, 10, 16)
+		return err == nil
+	case "numeric", "float8":
+		f, err := strconv.ParseFloat(v, 64)
+		return err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+	case "timestamptz":
+		_, err := time.Parse(time.RFC3339Nano, v)
+		return err == nil
+	}
+	return false
+}
+
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return limit
+}
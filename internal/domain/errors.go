@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// The store reports expected failures with the error types below, so callers
+// can tell them apart with errors.As instead of matching messages. Each
+// carries a Code, stable across releases, that API clients can switch on,
+// and a Message fit to show to users.
+//
+// Sentinels like ErrBikeNotFound are values of these types. errors.Is
+// matches errors of the same type and code, so a copy made to carry details
+// (a retry-after, the fields at fault) still matches its sentinel.
+
+// NotFoundError means the thing asked for doesn't exist, or isn't visible to
+// the caller.
+type NotFoundError struct {
+	Code    string
+	Message string
+}
+
+func (e *NotFoundError) Error() string { return e.Message }
+
+// Is also matches sql.ErrNoRows, which the store returned for missing rows
+// before it had these types.
+func (e *NotFoundError) Is(target error) bool {
+	if target == sql.ErrNoRows {
+		return true
+	}
+	t, ok := target.(*NotFoundError)
+	return ok && t.Code == e.Code
+}
+
+// ConflictError means the change clashes with the current state, e.g. a
+// username that is already taken. Fields, when set, points at the input
+// that clashes.
+type ConflictError struct {
+	Code    string
+	Message string
+	Fields  []FieldError
+}
+
+func (e *ConflictError) Error() string { return e.Message }
+
+func (e *ConflictError) Is(target error) bool {
+	t, ok := target.(*ConflictError)
+	return ok && t.Code == e.Code
+}
+
+// FieldError is one invalid input field. Field is a path into the input,
+// e.g. "stations[3].lat".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError means the input is malformed or out of range.
+type ValidationError struct {
+	Code    string
+	Message string
+	Fields  []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Fields) == 1 {
+		return fmt.Sprintf("%s: %s", e.Fields[0].Field, e.Message)
+	}
+	return e.Message
+}
+
+func (e *ValidationError) Is(target error) bool {
+	t, ok := target.(*ValidationError)
+	return ok && t.Code == e.Code
+}
+
+// At returns a copy of e blaming the given fields.
+func (e *ValidationError) At(fields ...string) *ValidationError {
+	c := *e
+	c.Fields = make([]FieldError, len(fields))
+	for i, f := range fields {
+		c.Fields[i] = FieldError{Field: f, Message: e.Message}
+	}
+	return &c
+}
+
+// RateLimitedError means the caller has used up a quota.
+type RateLimitedError struct {
+	Code    string
+	Message string
+	// RetryAfter is how long until the quota allows another try; zero when
+	// unknown.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string { return e.Message }
+
+func (e *RateLimitedError) Is(target error) bool {
+	t, ok := target.(*RateLimitedError)
+	return ok && t.Code == e.Code
+}
+
+// After returns a copy of e that can be retried after d.
+func (e *RateLimitedError) After(d time.Duration) *RateLimitedError {
+	c := *e
+	c.RetryAfter = max(d, 0)
+	return &c
+}
+
+// ForbiddenError means the caller may not do this.
+type ForbiddenError struct {
+	Code    string
+	Message string
+}
+
+func (e *ForbiddenError) Error() string { return e.Message }
+
+func (e *ForbiddenError) Is(target error) bool {
+	t, ok := target.(*ForbiddenError)
+	return ok && t.Code == e.Code
+}
+
+// UnauthorizedError means a credential (token, link) is missing, unknown or
+// no longer valid.
+type UnauthorizedError struct {
+	Code    string
+	Message string
+}
+
+func (e *UnauthorizedError) Error() string { return e.Message }
+
+func (e *UnauthorizedError) Is(target error) bool {
+	t, ok := target.(*UnauthorizedError)
+	return ok && t.Code == e.Code
+}
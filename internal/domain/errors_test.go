@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestErrorMatching(t *testing.T) {
+	t.Run("copies_match_their_sentinel", func(t *testing.T) {
+		limited := ErrTooFrequentReview.After(time.Minute)
+		if !errors.Is(fmt.Errorf("create review: %w", limited), ErrTooFrequentReview) {
+			t.Error("expected a RetryAfter copy to match ErrTooFrequentReview")
+		}
+		if errors.Is(limited, ErrHourlyRateLimitExceeded) {
+			t.Error("expected codes to tell rate limits apart")
+		}
+		if ErrTooFrequentReview.RetryAfter != 0 {
+			t.Error("After must not modify the sentinel")
+		}
+
+		invalid := ErrInvalidEmail.At("email")
+		if !errors.Is(invalid, ErrInvalidEmail) || len(ErrInvalidEmail.Fields) != 0 {
+			t.Error("expected At to copy the sentinel")
+		}
+		if invalid.Error() != "email: invalid email format" {
+			t.Errorf("unexpected message %q", invalid.Error())
+		}
+	})
+
+	t.Run("not_found_matches_no_rows", func(t *testing.T) {
+		if !errors.Is(ErrBikeNotFound, sql.ErrNoRows) {
+			t.Error("expected ErrBikeNotFound to match sql.ErrNoRows")
+		}
+		if errors.Is(ErrBikeNotFound, ErrReviewNotFound) {
+			t.Error("expected different not-found codes not to match")
+		}
+	})
+
+	t.Run("retry_after_never_negative", func(t *testing.T) {
+		if d := ErrRateLimitExceeded.After(-time.Second).RetryAfter; d != 0 {
+			t.Errorf("expected 0, got %v", d)
+		}
+	})
+}
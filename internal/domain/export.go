@@ -0,0 +1,214 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// DataExportTTL is how long an emailed export link keeps working.
+const DataExportTTL = 7 * 24 * time.Hour
+
+var ErrExportNotFound = &NotFoundError{Code: "export_not_found", Message: "export not found or expired"}
+
+// PosterExport is everything stored about a poster, for GET /me/export.
+type PosterExport struct {
+	ExportedAt time.Time         `json:"exported_ts"`
+	Account    Account           `json:"account"`
+	Reviews    []ExportedReview  `json:"reviews"`
+	Bikes      []Bike            `json:"bikes"`
+	MagicLinks []MagicLinkRecord `json:"magic_links"`
+}
+
+// ExportedReview is one of the poster's reviews, including hidden ones and
+// ones deleted but not yet purged.
+type ExportedReview struct {
+	ReviewWithRatings
+	HiddenAt  *time.Time `json:"hidden_ts"`
+	DeletedAt *time.Time `json:"deleted_ts"`
+}
+
+// MagicLinkRecord is one sign-in or email change link sent to the poster.
+// Token hashes are left out.
+type MagicLinkRecord struct {
+	CreatedAt   time.Time  `json:"created_ts"`
+	ExpiresAt   time.Time  `json:"expires_ts"`
+	ConsumedAt  *time.Time `json:"consumed_ts"`
+	DeviceLabel *string    `json:"device_label"`
+	// NewEmail is set on email change links.
+	NewEmail *string `json:"new_email"`
+}
+
+// Records counts the rows in an export, to tell how large it is.
+func (e *PosterExport) Records() int {
+	return 1 + len(e.Reviews) + len(e.Bikes) + len(e.MagicLinks)
+}
+
+// ExportPosterData gathers everything stored about a poster. The whole
+// export is held in memory, so callers encode it once it is complete.
+func (s *Store) ExportPosterData(ctx context.Context, posterID int64) (*PosterExport, error) {
+	account, err := s.GetAccount(ctx, posterID, 0)
+	if err != nil {
+		return nil, err
+	}
+	export := PosterExport{ExportedAt: time.Now().UTC(), Account: *account}
+
+	if export.Reviews, err = s.exportReviews(ctx, posterID); err != nil {
+		return nil, err
+	}
+
+	// Bikes the poster created, every page of GET /me/bikes.
+	q := ListBikesQuery{CreatorID: &posterID, Limit: MaxPageLimit}
+	for {
+		bikes, cursor, err := s.ListBikes(ctx, q)
+		if err != nil {
+			return nil, fmt.Errorf("export bikes: %w", err)
+		}
+		export.Bikes = append(export.Bikes, bikes...)
+		if cursor == "" {
+			break
+		}
+		q.Cursor = cursor
+	}
+
+	if export.MagicLinks, err = s.exportMagicLinks(ctx, posterID); err != nil {
+		return nil, err
+	}
+
+	if export.Reviews == nil {
+		export.Reviews = []ExportedReview{}
+	}
+	if export.Bikes == nil {
+		export.Bikes = []Bike{}
+	}
+	if export.MagicLinks == nil {
+		export.MagicLinks = []MagicLinkRecord{}
+	}
+	return &export, nil
+}
+
+func (s *Store) exportReviews(ctx context.Context, posterID int64) ([]ExportedReview, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT
+			r.review_id,
+			r.poster_id,
+			COALESCE(po.username, ''),
+			r.bike_numerical_id,
+			r.comment,
+			r.created_ts,
+			rr.subcategory,
+			rr.score,
+			%s AS images
+		FROM reviews r
+		LEFT JOIN posters po        ON po.poster_id = r.poster_id
+		LEFT JOIN review_ratings rr ON rr.review_id = r.review_id
+		WHERE r.poster_id = $1
+		ORDER BY r.created_ts ASC, r.review_id ASC, rr.subcategory
+	`, reviewImagesAggSQL), posterID)
+	if err != nil {
+		return nil, fmt.Errorf("export reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews, err := buildReviewWithRatingsFromRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ExportedReview, 0, len(reviews))
+	index := make(map[int64]int, len(reviews))
+	for i, r := range reviews {
+		out = append(out, ExportedReview{ReviewWithRatings: r})
+		index[r.ReviewID] = i
+	}
+
+	stateRows, err := s.db.QueryContext(ctx, `
+		SELECT review_id, hidden_ts, deleted_ts
+		FROM reviews
+		WHERE poster_id = $1 AND (hidden_ts IS NOT NULL OR deleted_ts IS NOT NULL)
+	`, posterID)
+	if err != nil {
+		return nil, fmt.Errorf("export review states: %w", err)
+	}
+	defer stateRows.Close()
+
+	for stateRows.Next() {
+		var id int64
+		var hidden, deleted *time.Time
+		if err := stateRows.Scan(&id, &hidden, &deleted); err != nil {
+			return nil, err
+		}
+		if i, ok := index[id]; ok {
+			out[i].HiddenAt, out[i].DeletedAt = hidden, deleted
+		}
+	}
+	return out, stateRows.Err()
+}
+
+func (s *Store) exportMagicLinks(ctx context.Context, posterID int64) ([]MagicLinkRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT created_ts, expires_ts, consumed_ts, device_label, new_email
+		FROM magic_links
+		WHERE poster_id = $1
+		ORDER BY created_ts ASC, id ASC
+	`, posterID)
+	if err != nil {
+		return nil, fmt.Errorf("export magic links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []MagicLinkRecord
+	for rows.Next() {
+		var l MagicLinkRecord
+		if err := rows.Scan(&l.CreatedAt, &l.ExpiresAt, &l.ConsumedAt, &l.DeviceLabel, &l.NewEmail); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// DataExport is a finished export waiting to be downloaded.
+type DataExport struct {
+	PosterID  int64
+	BlobKey   string
+	Format    string
+	SizeBytes int64
+	ExpiresAt time.Time
+}
+
+// CreateDataExport records an export stored under blobKey and returns the
+// token its download link carries. The link stops working after
+// DataExportTTL.
+func (s *Store) CreateDataExport(ctx context.Context, posterID int64, blobKey, format string, sizeBytes int64) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO data_exports (poster_id, token_hash, blob_key, format, size_bytes, expires_ts)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, posterID, hashToken(token), blobKey, format, sizeBytes, time.Now().Add(DataExportTTL)); err != nil {
+		return "", fmt.Errorf("insert data export: %w", err)
+	}
+	return token, nil
+}
+
+// GetDataExport looks up an export by the token of its link. Unknown and
+// expired tokens yield ErrExportNotFound.
+func (s *Store) GetDataExport(ctx context.Context, token string) (*DataExport, error) {
+	var e DataExport
+	err := s.db.QueryRowContext(ctx, `
+		SELECT poster_id, blob_key, format, size_bytes, expires_ts
+		FROM data_exports
+		WHERE token_hash = $1 AND expires_ts > NOW()
+	`, hashToken(token)).Scan(&e.PosterID, &e.BlobKey, &e.Format, &e.SizeBytes, &e.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrExportNotFound
+		}
+		return nil, fmt.Errorf("get data export: %w", err)
+	}
+	return &e, nil
+}
@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestExportPosterData(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	posterID := int64(1)
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT p.poster_id, p.email, p.username").
+		WithArgs(posterID, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"poster_id", "email", "username", "email_verified", "role", "created_ts", "active_sessions", "access_expires_ts", "expires_ts"}).
+			AddRow(posterID, "me@example.com", "me", true, "user", now, 1, nil, nil))
+	mock.ExpectQuery("FROM reviews r LEFT JOIN posters po ON po.poster_id = r.poster_id LEFT JOIN review_ratings rr ON rr.review_id = r.review_id WHERE r.poster_id = \\$1").
+		WithArgs(posterID).
+		WillReturnRows(sqlmock.NewRows([]string{"review_id", "poster_id", "username", "bike_numerical_id", "comment", "created_ts", "subcategory", "score", "images"}).
+			AddRow(10, posterID, "me", "01234", "fine", now, "overall", 4, []byte("[]")).
+			AddRow(10, posterID, "me", "01234", "fine", now, "seat", 3, []byte("[]")).
+			AddRow(11, posterID, "me", "5678", nil, now, nil, nil, []byte("[]")))
+	mock.ExpectQuery("SELECT review_id, hidden_ts, deleted_ts FROM reviews WHERE poster_id = \\$1").
+		WithArgs(posterID).
+		WillReturnRows(sqlmock.NewRows([]string{"review_id", "hidden_ts", "deleted_ts"}).AddRow(11, now, nil))
+	mock.ExpectQuery("FROM bikes b").
+		WithArgs(posterID, MaxPageLimit+1).
+		WillReturnRows(sqlmock.NewRows([]string{"numerical_id", "hash_id", "is_electric", "created_ts", "updated_ts", "average_rating", "review_count", "creator_id", "open_issue_count", "last_station_id", "last_seen_ts"}).
+			AddRow("01234", nil, false, now, now, nil, 1, posterID, 0, nil, nil))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT created_ts, expires_ts, consumed_ts, device_label, new_email FROM magic_links WHERE poster_id = $1")).
+		WithArgs(posterID).
+		WillReturnRows(sqlmock.NewRows([]string{"created_ts", "expires_ts", "consumed_ts", "device_label", "new_email"}).
+			AddRow(now, now, now, "phone", nil).
+			AddRow(now, now, nil, nil, "new@example.com"))
+
+	store := NewStore(db)
+	export, err := store.ExportPosterData(ctx, posterID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if export.Account.Email != "me@example.com" {
+		t.Errorf("unexpected account %+v", export.Account)
+	}
+	if len(export.Reviews) != 2 || export.Reviews[0].Ratings[RatingSubcategorySeat] != 3 ||
+		export.Reviews[0].HiddenAt != nil || export.Reviews[1].HiddenAt == nil {
+		t.Errorf("unexpected reviews %+v", export.Reviews)
+	}
+	if len(export.Bikes) != 1 || len(export.MagicLinks) != 2 || export.MagicLinks[1].NewEmail == nil {
+		t.Errorf("unexpected bikes %+v or links %+v", export.Bikes, export.MagicLinks)
+	}
+	if export.Records() != 6 {
+		t.Errorf("expected 6 records, got %d", export.Records())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDataExportLinks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	store := NewStore(db)
+
+	mock.ExpectExec("INSERT INTO data_exports \\(poster_id, token_hash, blob_key, format, size_bytes, expires_ts\\)").
+		WithArgs(int64(1), sqlmock.AnyArg(), "export_ab.zip", "zip", int64(2048), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	token, err := store.CreateDataExport(ctx, 1, "export_ab.zip", "zip", 2048)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expires := time.Now().Add(DataExportTTL)
+	mock.ExpectQuery("SELECT poster_id, blob_key, format, size_bytes, expires_ts FROM data_exports WHERE token_hash = \\$1 AND expires_ts > NOW\\(\\)").
+		WithArgs(hashToken(token)).
+		WillReturnRows(sqlmock.NewRows([]string{"poster_id", "blob_key", "format", "size_bytes", "expires_ts"}).
+			AddRow(1, "export_ab.zip", "zip", 2048, expires))
+	mock.ExpectQuery("FROM data_exports").
+		WithArgs(hashToken("expired")).
+		WillReturnError(sql.ErrNoRows)
+
+	export, err := store.GetDataExport(ctx, token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if export.BlobKey != "export_ab.zip" || export.SizeBytes != 2048 {
+		t.Errorf("unexpected export %+v", export)
+	}
+	if _, err := store.GetDataExport(ctx, "expired"); !errors.Is(err, ErrExportNotFound) {
+		t.Errorf("expected error %v, got %v", ErrExportNotFound, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+)
+
+var ErrInvalidLocation = &ValidationError{Code: "invalid_location", Message: "lat must be between -90 and 90, lon between -180 and 180 and radius between 1 and 10000 metres"}
+
+const (
+	earthRadiusM = 6371008.8
+	// MaxNearbyRadiusM caps nearby searches so the bounding box stays small
+	// enough for the stations index to be selective.
+	MaxNearbyRadiusM = 10000
+)
+
+// GeoQuery is a circle around a point, radius in metres.
+type GeoQuery struct {
+	Lat     float64
+	Lon     float64
+	RadiusM float64
+}
+
+func (g GeoQuery) validate() error {
+	switch {
+	case math.IsNaN(g.Lat) || g.Lat < -90 || g.Lat > 90,
+		math.IsNaN(g.Lon) || g.Lon < -180 || g.Lon > 180,
+		math.IsNaN(g.RadiusM) || g.RadiusM < 1 || g.RadiusM > MaxNearbyRadiusM:
+		return ErrInvalidLocation
+	}
+	return nil
+}
+
+// geoBox is a lat/lon rectangle that contains a GeoQuery's circle. It is only
+// a prefilter the stations (lat, lon) index can answer; the exact distance is
+// checked afterwards.
+type geoBox struct {
+	minLat, maxLat float64
+	minLon, maxLon float64
+	// allLon is set when the circle reaches a pole or crosses the
+	// antimeridian, where a single longitude range doesn't cover it.
+	allLon bool
+}
+
+func (g GeoQuery) box() geoBox {
+	angular := g.RadiusM / earthRadiusM
+	dLat := angular * 180 / math.Pi
+	b := geoBox{minLat: g.Lat - dLat, maxLat: g.Lat + dLat}
+	if b.minLat <= -90 || b.maxLat >= 90 {
+		b.minLat, b.maxLat = math.Max(b.minLat, -90), math.Min(b.maxLat, 90)
+		b.allLon = true
+		return b
+	}
+
+	dLon := math.Asin(math.Sin(angular)/math.Cos(g.Lat*math.Pi/180)) * 180 / math.Pi
+	b.minLon, b.maxLon = g.Lon-dLon, g.Lon+dLon
+	if b.minLon < -180 || b.maxLon > 180 {
+		b.allLon = true
+	}
+	return b
+}
+
+// haversineSQL is the great-circle distance in metres between the point in
+// the latCol/lonCol columns and the one bound to the latArg/lonArg
+// placeholders. The LEAST guards asin against rounding just past 1.
+func haversineSQL(latCol, lonCol, latArg, lonArg string) string {
+	return fmt.Sprintf(`(2 * %[5].1f * asin(LEAST(1, sqrt(
+		power(sin(radians(%[1]s - %[3]s::float8) / 2), 2) +
+		cos(radians(%[3]s::float8)) * cos(radians(%[1]s)) * power(sin(radians(%[2]s - %[4]s::float8) / 2), 2)))))`,
+		latCol, lonCol, latArg, lonArg, earthRadiusM)
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"math"
+	"testing"
+)
+
+func haversineM(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * earthRadiusM * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func TestGeoQueryBox(t *testing.T) {
+	t.Run("contains_circle", func(t *testing.T) {
+		g := GeoQuery{Lat: 41.3874, Lon: 2.1686, RadiusM: 1000}
+		b := g.box()
+		if b.allLon {
+			t.Fatal("did not expect a full longitude range in Barcelona")
+		}
+
+		// Points on the circle, every 10 degrees of bearing, must be inside
+		// the box.
+		for bearing := 0.0; bearing < 360; bearing += 10 {
+			rad := bearing * math.Pi / 180
+			dLat := g.RadiusM / earthRadiusM * math.Cos(rad) * 180 / math.Pi
+			dLon := g.RadiusM / earthRadiusM * math.Sin(rad) / math.Cos(g.Lat*math.Pi/180) * 180 / math.Pi
+			lat, lon := g.Lat+dLat*0.999, g.Lon+dLon*0.999
+			if d := haversineM(g.Lat, g.Lon, lat, lon); d > g.RadiusM {
+				t.Fatalf("test point at bearing %v is %vm away", bearing, d)
+			}
+			if lat < b.minLat || lat > b.maxLat || lon < b.minLon || lon > b.maxLon {
+				t.Errorf("point at bearing %v (%v, %v) outside box %+v", bearing, lat, lon, b)
+			}
+		}
+
+		// and the box is tight: just outside it is beyond the radius
+		if d := haversineM(g.Lat, g.Lon, b.maxLat+1e-6, g.Lon); d <= g.RadiusM {
+			t.Errorf("expected north edge at the radius, got %vm", d)
+		}
+	})
+
+	t.Run("antimeridian", func(t *testing.T) {
+		b := GeoQuery{Lat: 0, Lon: 179.999, RadiusM: 1000}.box()
+		if !b.allLon {
+			t.Error("expected the full longitude range across the antimeridian")
+		}
+	})
+
+	t.Run("pole", func(t *testing.T) {
+		b := GeoQuery{Lat: 89.999, Lon: 0, RadiusM: 1000}.box()
+		if !b.allLon || b.maxLat != 90 {
+			t.Errorf("expected a polar cap, got %+v", b)
+		}
+	})
+}
+
+func TestGeoQueryValidate(t *testing.T) {
+	cases := []struct {
+		name string
+		g    GeoQuery
+		want error
+	}{
+		{"valid", GeoQuery{Lat: 41.38, Lon: 2.17, RadiusM: 500}, nil},
+		{"lat", GeoQuery{Lat: 91, Lon: 2.17, RadiusM: 500}, ErrInvalidLocation},
+		{"lon", GeoQuery{Lat: 41.38, Lon: -181, RadiusM: 500}, ErrInvalidLocation},
+		{"radius_zero", GeoQuery{Lat: 41.38, Lon: 2.17}, ErrInvalidLocation},
+		{"radius_too_large", GeoQuery{Lat: 41.38, Lon: 2.17, RadiusM: MaxNearbyRadiusM + 1}, ErrInvalidLocation},
+		{"nan", GeoQuery{Lat: math.NaN(), Lon: 2.17, RadiusM: 500}, ErrInvalidLocation},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.g.validate(); err != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
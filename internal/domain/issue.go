@@ -0,0 +1,349 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrIssueNotFound       = &NotFoundError{Code: "issue_not_found", Message: "issue not found"}
+	ErrInvalidIssueType    = &ValidationError{Code: "invalid_issue_type", Message: "issue type must be one of flat_tyre, broken_brake, missing_pedal, battery_dead"}
+	ErrIssueNotApplicable  = &ValidationError{Code: "issue_not_applicable", Message: "battery issues can only be reported on electric bikes"}
+	ErrInvalidIssueNote    = &ValidationError{Code: "invalid_issue_note", Message: "issue notes must be at most 500 characters"}
+	ErrIssueAlreadyOpen    = &ConflictError{Code: "issue_already_open", Message: "this issue is already open for the bike"}
+	ErrIssueResolved       = &ConflictError{Code: "issue_resolved", Message: "issue is already resolved"}
+	ErrInvalidConfirmation = &ValidationError{Code: "invalid_confirmation", Message: "confirmation status must be still_broken or fixed"}
+	ErrInvalidIssueStatus  = &ValidationError{Code: "invalid_issue_status", Message: "issue status must be open or resolved"}
+)
+
+// MaxIssueNoteLen is how long an issue note can be, in characters.
+const MaxIssueNoteLen = 500
+
+// IssueType is a kind of problem a rider can report on a bike.
+type IssueType string
+
+const (
+	IssueFlatTyre     IssueType = "flat_tyre"
+	IssueBrokenBrake  IssueType = "broken_brake"
+	IssueMissingPedal IssueType = "missing_pedal"
+	IssueBatteryDead  IssueType = "battery_dead"
+)
+
+// Valid reports whether t is one of the issue types above.
+func (t IssueType) Valid() bool {
+	switch t {
+	case IssueFlatTyre, IssueBrokenBrake, IssueMissingPedal, IssueBatteryDead:
+		return true
+	}
+	return false
+}
+
+type IssueStatus string
+
+const (
+	IssueOpen     IssueStatus = "open"
+	IssueResolved IssueStatus = "resolved"
+)
+
+// ParseIssueStatus validates a status filter taken from a request.
+func ParseIssueStatus(s string) (IssueStatus, error) {
+	switch st := IssueStatus(s); st {
+	case IssueOpen, IssueResolved:
+		return st, nil
+	}
+	return "", ErrInvalidIssueStatus
+}
+
+// ConfirmationStatus is what another rider found when they checked a reported
+// issue.
+type ConfirmationStatus string
+
+const (
+	ConfirmStillBroken ConfirmationStatus = "still_broken"
+	ConfirmFixed       ConfirmationStatus = "fixed"
+)
+
+// issueFixedQuorum is how many "fixed" confirmations from riders other than
+// the reporter close an issue, provided they outnumber "still broken" ones.
+const issueFixedQuorum = 2
+
+// BikeIssue is a reported problem together with the tally of confirmations.
+type BikeIssue struct {
+	IssueID          int64       `json:"issue_id"`
+	BikeNumericalID  string      `json:"bike_numerical_id"`
+	Type             IssueType   `json:"type"`
+	Status           IssueStatus `json:"status"`
+	Note             *string     `json:"note"`
+	ReporterUsername *string     `json:"reporter_username"`
+	CreatedAt        time.Time   `json:"created_ts"`
+	ResolvedAt       *time.Time  `json:"resolved_ts"`
+	StillBrokenCount int64       `json:"still_broken_count"`
+	FixedCount       int64       `json:"fixed_count"`
+	LastConfirmedAt  *time.Time  `json:"last_confirmed_ts"`
+}
+
+type ListIssuesQuery struct {
+	Cursor string
+	Limit  int
+	// Status keeps only open or only resolved issues when set.
+	Status *IssueStatus
+}
+
+const issueCursorSort = "issues"
+
+// ReportBikeIssue opens an issue on a bike. Battery issues only apply to
+// electric bikes, and a problem that is already open can't be reported twice;
+// riders confirm the open one instead.
+func (s *Store) ReportBikeIssue(ctx context.Context, bikeID string, reporterID int64, issueType IssueType, note *string) (int64, error) {
+	if !issueType.Valid() {
+		return 0, ErrInvalidIssueType
+	}
+	if note != nil {
+		trimmed := strings.TrimSpace(*note)
+		if len([]rune(trimmed)) > MaxIssueNoteLen {
+			return 0, ErrInvalidIssueNote
+		}
+		note = &trimmed
+		if trimmed == "" {
+			note = nil
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var isElectric bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT is_electric
+		FROM bikes
+		WHERE numerical_id = $1 AND deleted_ts IS NULL
+	`, bikeID).Scan(&isElectric); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrBikeNotFound
+		}
+		return 0, fmt.Errorf("load bike: %w", err)
+	}
+	if issueType == IssueBatteryDead && !isElectric {
+		return 0, ErrIssueNotApplicable
+	}
+
+	var issueID int64
+	var after []byte
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO bike_issues (bike_numerical_id, issue_type, reporter_id, note)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (bike_numerical_id, issue_type) WHERE resolved_ts IS NULL DO NOTHING
+		RETURNING issue_id, to_jsonb(bike_issues)
+	`, bikeID, string(issueType), reporterID, note).Scan(&issueID, &after)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrIssueAlreadyOpen
+		}
+		return 0, fmt.Errorf("insert issue: %w", err)
+	}
+
+	if err := recordAudit(ctx, tx, reporterID, AuditIssueReport, "issue", strconv.FormatInt(issueID, 10), nil, after); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit tx: %w", err)
+	}
+	return issueID, nil
+}
+
+// ListBikeIssues pages over a bike's issues, newest first. A missing or
+// deleted bike yields ErrBikeNotFound rather than an empty page.
+func (s *Store) ListBikeIssues(ctx context.Context, bikeID string, q ListIssuesQuery) ([]BikeIssue, string, error) {
+	limit := clampLimit(q.Limit)
+
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM bikes WHERE numerical_id = $1 AND deleted_ts IS NULL)
+	`, bikeID).Scan(&exists); err != nil {
+		return nil, "", fmt.Errorf("check bike: %w", err)
+	}
+	if !exists {
+		return nil, "", ErrBikeNotFound
+	}
+
+	args := []any{bikeID, limit + 1}
+	var where []string
+	if q.Status != nil {
+		switch *q.Status {
+		case IssueOpen:
+			where = append(where, "AND i.resolved_ts IS NULL")
+		case IssueResolved:
+			where = append(where, "AND i.resolved_ts IS NOT NULL")
+		default:
+			return nil, "", ErrInvalidIssueStatus
+		}
+	}
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor, issueCursorSort, "", "bigint")
+		if err != nil {
+			return nil, "", err
+		}
+		args = append(args, c.ID)
+		where = append(where, fmt.Sprintf("AND i.issue_id < $%d::bigint", len(args)))
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT
+			i.issue_id,
+			i.bike_numerical_id,
+			i.issue_type,
+			i.note,
+			reporter.username,
+			i.created_ts,
+			i.resolved_ts,
+			COUNT(c.poster_id) FILTER (WHERE c.status = 'still_broken') AS still_broken_count,
+			COUNT(c.poster_id) FILTER (WHERE c.status = 'fixed') AS fixed_count,
+			MAX(c.created_ts) AS last_confirmed_ts
+		FROM bike_issues i
+		LEFT JOIN posters reporter          ON reporter.poster_id = i.reporter_id
+		LEFT JOIN issue_confirmations c     ON c.issue_id = i.issue_id
+		WHERE i.bike_numerical_id = $1 %s
+		GROUP BY i.issue_id, reporter.username
+		ORDER BY i.issue_id DESC
+		LIMIT $2
+	`, strings.Join(where, " ")), args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("list issues: %w", err)
+	}
+	defer rows.Close()
+
+	var out []BikeIssue
+	for rows.Next() {
+		var is BikeIssue
+		var issueType string
+		var reporter sql.NullString
+		var resolvedAt, lastConfirmedAt sql.NullTime
+		if err := rows.Scan(&is.IssueID, &is.BikeNumericalID, &issueType, &is.Note, &reporter,
+			&is.CreatedAt, &resolvedAt, &is.StillBrokenCount, &is.FixedCount, &lastConfirmedAt); err != nil {
+			return nil, "", err
+		}
+		is.Type = IssueType(issueType)
+		is.Status = IssueOpen
+		if resolvedAt.Valid {
+			is.Status = IssueResolved
+			is.ResolvedAt = &resolvedAt.Time
+		}
+		if reporter.Valid {
+			is.ReporterUsername = &reporter.String
+		}
+		if lastConfirmedAt.Valid {
+			is.LastConfirmedAt = &lastConfirmedAt.Time
+		}
+		out = append(out, is)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if len(out) <= limit {
+		return out, "", nil
+	}
+	out = out[:limit]
+	return out, encodeCursor(pageCursor{
+		Sort: issueCursorSort,
+		ID:   strconv.FormatInt(out[limit-1].IssueID, 10),
+	}), nil
+}
+
+// ConfirmBikeIssue records whether posterID found an open issue still there,
+// replacing any earlier confirmation of theirs, and returns the issue's status
+// afterwards. The reporter saying it's fixed closes the issue; otherwise it
+// closes once enough other riders say so and they outnumber those who say it
+// is still broken.
+func (s *Store) ConfirmBikeIssue(ctx context.Context, issueID, posterID int64, status ConfirmationStatus) (IssueStatus, error) {
+	if status != ConfirmStillBroken && status != ConfirmFixed {
+		return "", ErrInvalidConfirmation
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var reporterID sql.NullInt64
+	var resolved bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT i.reporter_id, i.resolved_ts IS NOT NULL
+		FROM bike_issues i
+		JOIN bikes b ON b.numerical_id = i.bike_numerical_id
+		WHERE i.issue_id = $1 AND b.deleted_ts IS NULL
+		FOR UPDATE OF i
+	`, issueID).Scan(&reporterID, &resolved); err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrIssueNotFound
+		}
+		return "", fmt.Errorf("load issue: %w", err)
+	}
+	if resolved {
+		return "", ErrIssueResolved
+	}
+
+	before, err := snapshot(ctx, tx, issueSnapshotSQL, issueID)
+	if err != nil {
+		return "", fmt.Errorf("snapshot issue: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO issue_confirmations (issue_id, poster_id, status)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (issue_id, poster_id) DO UPDATE
+		SET status = EXCLUDED.status, created_ts = NOW()
+	`, issueID, posterID, string(status)); err != nil {
+		return "", fmt.Errorf("record confirmation: %w", err)
+	}
+
+	outcome := IssueResolved
+	byReporter := reporterID.Valid && reporterID.Int64 == posterID
+	if !byReporter || status != ConfirmFixed {
+		var fixed, stillBroken int
+		if err := tx.QueryRowContext(ctx, `
+			SELECT
+				COUNT(*) FILTER (WHERE status = 'fixed'),
+				COUNT(*) FILTER (WHERE status = 'still_broken')
+			FROM issue_confirmations
+			WHERE issue_id = $1 AND poster_id IS DISTINCT FROM $2
+		`, issueID, reporterID).Scan(&fixed, &stillBroken); err != nil {
+			return "", fmt.Errorf("count confirmations: %w", err)
+		}
+		if fixed < issueFixedQuorum || fixed <= stillBroken {
+			outcome = IssueOpen
+		}
+	}
+
+	if outcome == IssueResolved {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE bike_issues
+			SET resolved_ts = NOW(), resolved_by = $2
+			WHERE issue_id = $1
+		`, issueID, posterID); err != nil {
+			return "", fmt.Errorf("resolve issue: %w", err)
+		}
+	}
+
+	after, err := snapshot(ctx, tx, issueSnapshotSQL, issueID)
+	if err != nil {
+		return "", fmt.Errorf("snapshot issue: %w", err)
+	}
+	if err := recordAudit(ctx, tx, posterID, AuditIssueConfirm, "issue", strconv.FormatInt(issueID, 10), before, after); err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("commit tx: %w", err)
+	}
+	return outcome, nil
+}
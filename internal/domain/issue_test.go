@@ -0,0 +1,302 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestReportBikeIssue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	bikeID := "0101"
+	posterID := int64(1)
+
+	t.Run("success", func(t *testing.T) {
+		note := "  rear brake  "
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT is_electric FROM bikes WHERE numerical_id = \\$1 AND deleted_ts IS NULL").
+			WithArgs(bikeID).
+			WillReturnRows(sqlmock.NewRows([]string{"is_electric"}).AddRow(false))
+		mock.ExpectQuery("INSERT INTO bike_issues \\(bike_numerical_id, issue_type, reporter_id, note\\) .* ON CONFLICT \\(bike_numerical_id, issue_type\\) WHERE resolved_ts IS NULL DO NOTHING RETURNING issue_id, to_jsonb\\(bike_issues\\)").
+			WithArgs(bikeID, "broken_brake", posterID, "rear brake").
+			WillReturnRows(sqlmock.NewRows([]string{"issue_id", "image"}).AddRow(5, `{"issue_id":5}`))
+		mock.ExpectExec("INSERT INTO audit_events").
+			WithArgs(posterID, AuditIssueReport, "issue", "5", nil, `{"issue_id":5}`, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		store := NewStore(db)
+		id, err := store.ReportBikeIssue(ctx, bikeID, posterID, IssueBrokenBrake, &note)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if id != 5 {
+			t.Errorf("expected issue 5, got %d", id)
+		}
+	})
+
+	t.Run("battery_on_mechanical_bike", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT is_electric FROM bikes").
+			WithArgs(bikeID).
+			WillReturnRows(sqlmock.NewRows([]string{"is_electric"}).AddRow(false))
+		mock.ExpectRollback()
+
+		store := NewStore(db)
+		if _, err := store.ReportBikeIssue(ctx, bikeID, posterID, IssueBatteryDead, nil); !errors.Is(err, ErrIssueNotApplicable) {
+			t.Errorf("expected ErrIssueNotApplicable, got %v", err)
+		}
+	})
+
+	t.Run("already_open", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT is_electric FROM bikes").
+			WithArgs(bikeID).
+			WillReturnRows(sqlmock.NewRows([]string{"is_electric"}).AddRow(true))
+		mock.ExpectQuery("INSERT INTO bike_issues").
+			WithArgs(bikeID, "battery_dead", posterID, nil).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		store := NewStore(db)
+		if _, err := store.ReportBikeIssue(ctx, bikeID, posterID, IssueBatteryDead, nil); !errors.Is(err, ErrIssueAlreadyOpen) {
+			t.Errorf("expected ErrIssueAlreadyOpen, got %v", err)
+		}
+	})
+
+	t.Run("bike_not_found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT is_electric FROM bikes").
+			WithArgs(bikeID).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		store := NewStore(db)
+		if _, err := store.ReportBikeIssue(ctx, bikeID, posterID, IssueFlatTyre, nil); !errors.Is(err, ErrBikeNotFound) {
+			t.Errorf("expected error %v, got %v", ErrBikeNotFound, err)
+		}
+	})
+
+	t.Run("invalid_type", func(t *testing.T) {
+		store := NewStore(db)
+		if _, err := store.ReportBikeIssue(ctx, bikeID, posterID, IssueType("wobbly"), nil); !errors.Is(err, ErrInvalidIssueType) {
+			t.Errorf("expected ErrInvalidIssueType, got %v", err)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestListBikeIssues(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	bikeID := "0101"
+	columns := []string{"issue_id", "bike_numerical_id", "issue_type", "note", "username", "created_ts", "resolved_ts", "still_broken_count", "fixed_count", "last_confirmed_ts"}
+
+	t.Run("open_with_next_page", func(t *testing.T) {
+		open := IssueOpen
+		mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM bikes").
+			WithArgs(bikeID).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery("FROM bike_issues i .* WHERE i.bike_numerical_id = \\$1 AND i.resolved_ts IS NULL GROUP BY .* ORDER BY i.issue_id DESC LIMIT \\$2").
+			WithArgs(bikeID, 2).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(8, bikeID, "flat_tyre", nil, "alice", time.Now(), nil, 2, 1, time.Now()).
+				AddRow(6, bikeID, "broken_brake", "rear", nil, time.Now(), nil, 0, 0, nil))
+
+		store := NewStore(db)
+		issues, next, err := store.ListBikeIssues(ctx, bikeID, ListIssuesQuery{Limit: 1, Status: &open})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(issues) != 1 || next == "" {
+			t.Fatalf("expected one issue and a cursor, got %d, %q", len(issues), next)
+		}
+		is := issues[0]
+		if is.Type != IssueFlatTyre || is.Status != IssueOpen || is.StillBrokenCount != 2 || is.FixedCount != 1 ||
+			is.ReporterUsername == nil || is.LastConfirmedAt == nil {
+			t.Errorf("unexpected issue %+v", is)
+		}
+
+		mock.ExpectQuery("SELECT EXISTS").
+			WithArgs(bikeID).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery("FROM bike_issues i .* AND i.issue_id < \\$3::bigint").
+			WithArgs(bikeID, 2, "8").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(6, bikeID, "broken_brake", "rear", nil, time.Now(), time.Now(), 0, 0, nil))
+
+		issues, next, err = store.ListBikeIssues(ctx, bikeID, ListIssuesQuery{Limit: 1, Cursor: next})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(issues) != 1 || next != "" || issues[0].Status != IssueResolved {
+			t.Errorf("unexpected second page %+v, %q", issues, next)
+		}
+	})
+
+	t.Run("bike_not_found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT EXISTS").
+			WithArgs(bikeID).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		store := NewStore(db)
+		if _, _, err := store.ListBikeIssues(ctx, bikeID, ListIssuesQuery{}); !errors.Is(err, ErrBikeNotFound) {
+			t.Errorf("expected error %v, got %v", ErrBikeNotFound, err)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestConfirmBikeIssue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	issueID := int64(4)
+	reporterID := int64(1)
+	posterID := int64(2)
+
+	expectLoad := func(resolved bool) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT i.reporter_id, i.resolved_ts IS NOT NULL FROM bike_issues i .* FOR UPDATE OF i").
+			WithArgs(issueID).
+			WillReturnRows(sqlmock.NewRows([]string{"reporter_id", "resolved"}).AddRow(reporterID, resolved))
+		if !resolved {
+			mock.ExpectQuery("SELECT to_jsonb\\(i\\) .* FROM bike_issues i WHERE i.issue_id = \\$1 FOR UPDATE OF i").
+				WithArgs(issueID).
+				WillReturnRows(sqlmock.NewRows([]string{"image"}).AddRow(`{"issue_id":4}`))
+		}
+	}
+	expectUpsert := func(poster int64, status string) {
+		mock.ExpectExec("INSERT INTO issue_confirmations \\(issue_id, poster_id, status\\) .* ON CONFLICT \\(issue_id, poster_id\\) DO UPDATE").
+			WithArgs(issueID, poster, status).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	expectAudit := func(poster int64) {
+		mock.ExpectQuery("SELECT to_jsonb\\(i\\)").
+			WithArgs(issueID).
+			WillReturnRows(sqlmock.NewRows([]string{"image"}).AddRow(`{"issue_id":4,"confirmations":{}}`))
+		mock.ExpectExec("INSERT INTO audit_events").
+			WithArgs(poster, AuditIssueConfirm, "issue", "4", `{"issue_id":4}`, `{"issue_id":4,"confirmations":{}}`, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	expectCounts := func(fixed, stillBroken int) {
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FILTER \\(WHERE status = 'fixed'\\), COUNT\\(\\*\\) FILTER \\(WHERE status = 'still_broken'\\) FROM issue_confirmations").
+			WithArgs(issueID, reporterID).
+			WillReturnRows(sqlmock.NewRows([]string{"fixed", "still_broken"}).AddRow(fixed, stillBroken))
+	}
+
+	t.Run("stays_open", func(t *testing.T) {
+		expectLoad(false)
+		expectUpsert(posterID, "fixed")
+		expectCounts(1, 0)
+		expectAudit(posterID)
+		mock.ExpectCommit()
+
+		store := NewStore(db)
+		status, err := store.ConfirmBikeIssue(ctx, issueID, posterID, ConfirmFixed)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if status != IssueOpen {
+			t.Errorf("expected open, got %s", status)
+		}
+	})
+
+	t.Run("quorum_resolves", func(t *testing.T) {
+		expectLoad(false)
+		expectUpsert(posterID, "fixed")
+		expectCounts(2, 1)
+		mock.ExpectExec("UPDATE bike_issues SET resolved_ts = NOW\\(\\), resolved_by = \\$2 WHERE issue_id = \\$1").
+			WithArgs(issueID, posterID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectAudit(posterID)
+		mock.ExpectCommit()
+
+		store := NewStore(db)
+		status, err := store.ConfirmBikeIssue(ctx, issueID, posterID, ConfirmFixed)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if status != IssueResolved {
+			t.Errorf("expected resolved, got %s", status)
+		}
+	})
+
+	t.Run("reporter_fixed_resolves", func(t *testing.T) {
+		expectLoad(false)
+		expectUpsert(reporterID, "fixed")
+		mock.ExpectExec("UPDATE bike_issues SET resolved_ts").
+			WithArgs(issueID, reporterID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectAudit(reporterID)
+		mock.ExpectCommit()
+
+		store := NewStore(db)
+		status, err := store.ConfirmBikeIssue(ctx, issueID, reporterID, ConfirmFixed)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if status != IssueResolved {
+			t.Errorf("expected resolved, got %s", status)
+		}
+	})
+
+	t.Run("already_resolved", func(t *testing.T) {
+		expectLoad(true)
+		mock.ExpectRollback()
+
+		store := NewStore(db)
+		if _, err := store.ConfirmBikeIssue(ctx, issueID, posterID, ConfirmStillBroken); !errors.Is(err, ErrIssueResolved) {
+			t.Errorf("expected ErrIssueResolved, got %v", err)
+		}
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT i.reporter_id").
+			WithArgs(issueID).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		store := NewStore(db)
+		if _, err := store.ConfirmBikeIssue(ctx, issueID, posterID, ConfirmStillBroken); !errors.Is(err, ErrIssueNotFound) {
+			t.Errorf("expected error %v, got %v", ErrIssueNotFound, err)
+		}
+	})
+
+	t.Run("invalid_status", func(t *testing.T) {
+		store := NewStore(db)
+		if _, err := store.ConfirmBikeIssue(ctx, issueID, posterID, ConfirmationStatus("maybe")); !errors.Is(err, ErrInvalidConfirmation) {
+			t.Errorf("expected ErrInvalidConfirmation, got %v", err)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Advisory lock namespaces, the first key of every lock taken by lockPoster.
+const (
+	lockReviewQuota int32 = iota + 1
+	lockMagicLinkQuota
+)
+
+// lockPoster takes a transaction-scoped advisory lock on posterID within
+// namespace, so a quota check and the insert it guards can't interleave with
+// another transaction doing the same for that poster. Postgres releases it
+// on commit or rollback.
+//
+// The second key is only 32 bits; ids that collide merely wait for each
+// other.
+func lockPoster(ctx context.Context, tx *sql.Tx, namespace int32, posterID int64) error {
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, $2)`, namespace, int32(posterID)); err != nil {
+		return fmt.Errorf("lock poster: %w", err)
+	}
+	return nil
+}
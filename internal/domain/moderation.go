@@ -0,0 +1,263 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidReportReason = &ValidationError{Code: "invalid_report_reason", Message: "report reason must be between 1 and 500 characters"}
+	ErrAlreadyReported     = &ConflictError{Code: "already_reported", Message: "review already reported by this poster"}
+	ErrInvalidModeration   = &ValidationError{Code: "invalid_moderation", Message: "unknown moderation action"}
+)
+
+// MaxReportReasonLen is how long a report reason can be, in characters.
+const MaxReportReasonLen = 500
+
+// ModerationAction is something a moderator did to a review. Every action is
+// kept in moderation_actions together with who did it and when.
+type ModerationAction string
+
+const (
+	ModerationHide   ModerationAction = "hide"
+	ModerationUnhide ModerationAction = "unhide"
+	ModerationDelete ModerationAction = "delete"
+)
+
+var moderationAuditActions = map[ModerationAction]string{
+	ModerationHide:   AuditReviewHide,
+	ModerationUnhide: AuditReviewUnhide,
+	ModerationDelete: AuditReviewDelete,
+}
+
+// ReviewReport is an open report in the moderation queue, together with the
+// review it points at so moderators can act without a second lookup.
+type ReviewReport struct {
+	ReportID         int64     `json:"report_id"`
+	ReviewID         int64     `json:"review_id"`
+	ReporterUsername *string   `json:"reporter_username"`
+	Reason           string    `json:"reason"`
+	CreatedAt        time.Time `json:"created_ts"`
+
+	BikeNumericalID string  `json:"bike_numerical_id"`
+	PosterUsername  *string `json:"poster_username"`
+	Comment         *string `json:"comment"`
+	Hidden          bool    `json:"hidden"`
+}
+
+type ListReportsQuery struct {
+	Cursor string
+	Limit  int
+}
+
+// reportCursorSort tags queue cursors so they can't be replayed elsewhere.
+const reportCursorSort = "reports"
+
+// ReportReview flags a review for moderators. A poster can report a given
+// review once.
+func (s *Store) ReportReview(ctx context.Context, reviewID, reporterID int64, reason string) (int64, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len([]rune(reason)) > MaxReportReasonLen {
+		return 0, ErrInvalidReportReason
+	}
+
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM reviews r
+			JOIN bikes b ON b.numerical_id = r.bike_numerical_id AND b.deleted_ts IS NULL
+			WHERE r.review_id = $1 AND r.deleted_ts IS NULL
+		)
+	`, reviewID).Scan(&exists); err != nil {
+		return 0, fmt.Errorf("check review: %w", err)
+	}
+	if !exists {
+		return 0, ErrReviewNotFound
+	}
+
+	var reportID int64
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO review_reports (review_id, reporter_id, reason)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (review_id, reporter_id) DO NOTHING
+		RETURNING report_id
+	`, reviewID, reporterID, reason).Scan(&reportID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrAlreadyReported
+		}
+		return 0, fmt.Errorf("insert report: %w", err)
+	}
+	return reportID, nil
+}
+
+// ListOpenReports pages over unresolved reports, oldest first.
+func (s *Store) ListOpenReports(ctx context.Context, q ListReportsQuery) ([]ReviewReport, string, error) {
+	limit := clampLimit(q.Limit)
+
+	args := []any{limit + 1}
+	after := ""
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor, reportCursorSort, "", "bigint")
+		if err != nil {
+			return nil, "", err
+		}
+		args = append(args, c.ID)
+		after = "AND rp.report_id > $2::bigint"
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT
+			rp.report_id,
+			rp.review_id,
+			reporter.username,
+			rp.reason,
+			rp.created_ts,
+			r.bike_numerical_id,
+			author.username,
+			r.comment,
+			r.hidden_ts IS NOT NULL
+		FROM review_reports rp
+		JOIN reviews r              ON r.review_id = rp.review_id
+		LEFT JOIN posters reporter  ON reporter.poster_id = rp.reporter_id
+		LEFT JOIN posters author    ON author.poster_id = r.poster_id
+		WHERE rp.resolved_ts IS NULL AND r.deleted_ts IS NULL %s
+		ORDER BY rp.report_id ASC
+		LIMIT $1
+	`, after), args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("list reports: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ReviewReport
+	for rows.Next() {
+		var rp ReviewReport
+		var reporter, author sql.NullString
+		if err := rows.Scan(&rp.ReportID, &rp.ReviewID, &reporter, &rp.Reason, &rp.CreatedAt,
+			&rp.BikeNumericalID, &author, &rp.Comment, &rp.Hidden); err != nil {
+			return nil, "", err
+		}
+		if reporter.Valid {
+			rp.ReporterUsername = &reporter.String
+		}
+		if author.Valid {
+			rp.PosterUsername = &author.String
+		}
+		out = append(out, rp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if len(out) <= limit {
+		return out, "", nil
+	}
+	out = out[:limit]
+	return out, encodeCursor(pageCursor{
+		Sort: reportCursorSort,
+		ID:   strconv.FormatInt(out[limit-1].ReportID, 10),
+	}), nil
+}
+
+// ModerateReview applies a moderator action to a review, records it and
+// refreshes the bike's aggregates, all in one transaction. Any action settles
+// the review's open reports; unhiding a review that was never hidden is how a
+// moderator dismisses reports against it.
+func (s *Store) ModerateReview(ctx context.Context, reviewID, moderatorID int64, action ModerationAction) error {
+	auditAction, ok := moderationAuditActions[action]
+	if !ok {
+		return ErrInvalidModeration
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var bikeID string
+	if err := tx.QueryRowContext(ctx, `
+		SELECT bike_numerical_id
+		FROM reviews
+		WHERE review_id = $1 AND deleted_ts IS NULL
+		FOR UPDATE
+	`, reviewID).Scan(&bikeID); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrReviewNotFound
+		}
+		return fmt.Errorf("load review: %w", err)
+	}
+
+	before, err := snapshot(ctx, tx, reviewSnapshotSQL, reviewID)
+	if err != nil {
+		return fmt.Errorf("snapshot review: %w", err)
+	}
+
+	switch action {
+	case ModerationHide:
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE reviews
+			SET hidden_ts = NOW(), hidden_by = $2
+			WHERE review_id = $1
+		`, reviewID, moderatorID); err != nil {
+			return fmt.Errorf("hide review: %w", err)
+		}
+	case ModerationUnhide:
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE reviews
+			SET hidden_ts = NULL, hidden_by = NULL
+			WHERE review_id = $1
+		`, reviewID); err != nil {
+			return fmt.Errorf("unhide review: %w", err)
+		}
+	case ModerationDelete:
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE reviews
+			SET deleted_ts = NOW()
+			WHERE review_id = $1
+		`, reviewID); err != nil {
+			return fmt.Errorf("delete review: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE review_reports
+		SET resolved_ts = NOW(), resolved_by = $2
+		WHERE review_id = $1 AND resolved_ts IS NULL
+	`, reviewID, moderatorID); err != nil {
+		return fmt.Errorf("resolve reports: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO moderation_actions (review_id, moderator_id, action)
+		VALUES ($1, $2, $3)
+	`, reviewID, moderatorID, string(action)); err != nil {
+		return fmt.Errorf("record moderation action: %w", err)
+	}
+
+	var after json.RawMessage
+	if action != ModerationDelete {
+		if after, err = snapshot(ctx, tx, reviewSnapshotSQL, reviewID); err != nil {
+			return fmt.Errorf("snapshot review: %w", err)
+		}
+	}
+	if err := recordAudit(ctx, tx, moderatorID, auditAction, "review", strconv.FormatInt(reviewID, 10), before, after); err != nil {
+		return err
+	}
+
+	if err := RecomputeAggregatesForBike(ctx, tx, bikeID); err != nil {
+		return fmt.Errorf("recompute aggregates: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
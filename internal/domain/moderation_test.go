@@ -0,0 +1,224 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestReportReview(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	store := NewStore(db)
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT EXISTS \\( SELECT 1 FROM reviews r JOIN bikes b ON b.numerical_id = r.bike_numerical_id AND b.deleted_ts IS NULL WHERE r.review_id = \\$1 AND r.deleted_ts IS NULL \\)").
+			WithArgs(int64(7)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery("INSERT INTO review_reports \\(review_id, reporter_id, reason\\) .* ON CONFLICT \\(review_id, reporter_id\\) DO NOTHING RETURNING report_id").
+			WithArgs(int64(7), int64(1), "spam").
+			WillReturnRows(sqlmock.NewRows([]string{"report_id"}).AddRow(11))
+
+		id, err := store.ReportReview(ctx, 7, 1, "  spam ")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if id != 11 {
+			t.Errorf("expected report 11, got %d", id)
+		}
+	})
+
+	t.Run("already_reported", func(t *testing.T) {
+		mock.ExpectQuery("SELECT EXISTS").
+			WithArgs(int64(7)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery("INSERT INTO review_reports").
+			WithArgs(int64(7), int64(1), "spam").
+			WillReturnRows(sqlmock.NewRows([]string{"report_id"}))
+
+		if _, err := store.ReportReview(ctx, 7, 1, "spam"); err != ErrAlreadyReported {
+			t.Errorf("expected ErrAlreadyReported, got %v", err)
+		}
+	})
+
+	t.Run("review_not_found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT EXISTS").
+			WithArgs(int64(8)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		if _, err := store.ReportReview(ctx, 8, 1, "spam"); !errors.Is(err, ErrReviewNotFound) {
+			t.Errorf("expected ErrReviewNotFound, got %v", err)
+		}
+	})
+
+	t.Run("empty_reason", func(t *testing.T) {
+		if _, err := store.ReportReview(ctx, 7, 1, "   "); err != ErrInvalidReportReason {
+			t.Errorf("expected ErrInvalidReportReason, got %v", err)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestListOpenReports(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	store := NewStore(db)
+	columns := []string{"report_id", "review_id", "reporter", "reason", "created_ts", "bike_numerical_id", "author", "comment", "hidden"}
+
+	mock.ExpectQuery("FROM review_reports rp .* WHERE rp.resolved_ts IS NULL AND r.deleted_ts IS NULL ORDER BY rp.report_id ASC LIMIT \\$1").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, 7, "alice", "spam", time.Now(), "0101", "bob", "buy now", false).
+			AddRow(2, 8, nil, "rude", time.Now(), "0102", nil, nil, true))
+
+	reports, cursor, err := store.ListOpenReports(ctx, ListReportsQuery{Limit: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reports) != 1 || reports[0].ReportID != 1 || *reports[0].ReporterUsername != "alice" {
+		t.Fatalf("unexpected reports: %+v", reports)
+	}
+	if cursor == "" {
+		t.Fatal("expected a next cursor")
+	}
+
+	mock.ExpectQuery("WHERE rp.resolved_ts IS NULL AND r.deleted_ts IS NULL AND rp.report_id > \\$2::bigint").
+		WithArgs(2, "1").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(2, 8, nil, "rude", time.Now(), "0102", nil, nil, true))
+
+	reports, cursor, err = store.ListOpenReports(ctx, ListReportsQuery{Cursor: cursor, Limit: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reports) != 1 || reports[0].ReporterUsername != nil || !reports[0].Hidden || cursor != "" {
+		t.Errorf("unexpected last page: %+v, cursor %q", reports, cursor)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestModerateReview(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	store := NewStore(db)
+	reviewID, moderatorID, bikeID := int64(7), int64(3), "0101"
+
+	expectLoad := func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT bike_numerical_id FROM reviews WHERE review_id = \\$1 AND deleted_ts IS NULL FOR UPDATE").
+			WithArgs(reviewID).
+			WillReturnRows(sqlmock.NewRows([]string{"bike_numerical_id"}).AddRow(bikeID))
+		mock.ExpectQuery("SELECT to_jsonb\\(r\\)").
+			WithArgs(reviewID).
+			WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{"hidden_ts":null}`))
+	}
+	expectRecordAndRecompute := func(action ModerationAction) {
+		mock.ExpectExec("INSERT INTO moderation_actions \\(review_id, moderator_id, action\\)").
+			WithArgs(reviewID, moderatorID, string(action)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		var after any
+		if action != ModerationDelete {
+			after = `{"hidden_ts":"now"}`
+			mock.ExpectQuery("SELECT to_jsonb\\(r\\)").
+				WithArgs(reviewID).
+				WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(after))
+		}
+		mock.ExpectExec("INSERT INTO audit_events").
+			WithArgs(moderatorID, moderationAuditActions[action], "review", "7", `{"hidden_ts":null}`, after, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("DELETE FROM rating_aggregates").
+			WithArgs(bikeID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO rating_aggregates").
+			WithArgs(bikeID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+
+	t.Run("hide", func(t *testing.T) {
+		expectLoad()
+		mock.ExpectExec("UPDATE reviews SET hidden_ts = NOW\\(\\), hidden_by = \\$2 WHERE review_id = \\$1").
+			WithArgs(reviewID, moderatorID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE review_reports SET resolved_ts = NOW\\(\\), resolved_by = \\$2 WHERE review_id = \\$1 AND resolved_ts IS NULL").
+			WithArgs(reviewID, moderatorID).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		expectRecordAndRecompute(ModerationHide)
+
+		if err := store.ModerateReview(ctx, reviewID, moderatorID, ModerationHide); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("unhide", func(t *testing.T) {
+		expectLoad()
+		mock.ExpectExec("UPDATE reviews SET hidden_ts = NULL, hidden_by = NULL WHERE review_id = \\$1").
+			WithArgs(reviewID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE review_reports").
+			WithArgs(reviewID, moderatorID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		expectRecordAndRecompute(ModerationUnhide)
+
+		if err := store.ModerateReview(ctx, reviewID, moderatorID, ModerationUnhide); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		expectLoad()
+		// Soft delete: the row and its ratings stay until purged
+		mock.ExpectExec("UPDATE reviews SET deleted_ts = NOW\\(\\) WHERE review_id = \\$1").
+			WithArgs(reviewID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE review_reports").
+			WithArgs(reviewID, moderatorID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectRecordAndRecompute(ModerationDelete)
+
+		if err := store.ModerateReview(ctx, reviewID, moderatorID, ModerationDelete); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT bike_numerical_id FROM reviews").
+			WithArgs(reviewID).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		if err := store.ModerateReview(ctx, reviewID, moderatorID, ModerationHide); !errors.Is(err, ErrReviewNotFound) {
+			t.Errorf("expected ErrReviewNotFound, got %v", err)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PosterProfile is the public view of a poster: no email, no tokens.
+type PosterProfile struct {
+	PosterID          int64     `json:"poster_id"`
+	Username          string    `json:"username"`
+	JoinedAt          time.Time `json:"joined_ts"`
+	ReviewCount       int64     `json:"review_count"`
+	BikesCreated      int64     `json:"bikes_created"`
+	AverageScoreGiven *float64  `json:"average_score_given"` // mean overall score across their reviews
+}
+
+func (s *Store) GetPosterProfile(ctx context.Context, username string) (*PosterProfile, error) {
+	var p PosterProfile
+	var avgScore sql.NullFloat64
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT
+			p.poster_id,
+			p.username,
+			p.created_ts,
+			(
+				SELECT COUNT(*)
+				FROM reviews r
+				JOIN bikes b ON b.numerical_id = r.bike_numerical_id AND b.deleted_ts IS NULL
+				WHERE r.poster_id = p.poster_id AND r.hidden_ts IS NULL AND r.deleted_ts IS NULL
+			),
+			(SELECT COUNT(*) FROM bikes b WHERE b.creator_id = p.poster_id AND b.deleted_ts IS NULL),
+			(
+				SELECT ROUND(AVG(rr.score)::numeric, 2)
+				FROM review_ratings rr
+				JOIN reviews r ON r.review_id = rr.review_id
+				JOIN bikes b ON b.numerical_id = r.bike_numerical_id AND b.deleted_ts IS NULL
+				WHERE r.poster_id = p.poster_id AND r.hidden_ts IS NULL AND r.deleted_ts IS NULL AND rr.subcategory = 'overall'
+			)
+		FROM posters p
+		WHERE p.username = $1
+	`, username).Scan(&p.PosterID, &p.Username, &p.JoinedAt, &p.ReviewCount, &p.BikesCreated, &avgScore)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("load poster profile: %w", err)
+	}
+	if avgScore.Valid {
+		p.AverageScoreGiven = &avgScore.Float64
+	}
+	return &p, nil
+}
+
+// ListReviewsWithRatingsByPoster pages over every review written by username,
+// using the same ordering and cursors as ListReviewsWithRatingsByBike.
+func (s *Store) ListReviewsWithRatingsByPoster(ctx context.Context, username string, q ListReviewsQuery) ([]ReviewWithRatings, string, error) {
+	var posterID int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT poster_id
+		FROM posters
+		WHERE username = $1
+	`, username).Scan(&posterID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, "", ErrUserNotFound
+		}
+		return nil, "", fmt.Errorf("load poster: %w", err)
+	}
+
+	return s.listReviewsWithRatings(ctx, "r.poster_id", posterID, q)
+}
+
+// Account is the private view of a poster, only ever returned to its owner.
+// The token expiries are those of the session asking, if any.
+type Account struct {
+	PosterID              int64      `json:"poster_id"`
+	Email                 string     `json:"email"`
+	Username              string     `json:"username"`
+	EmailVerified         bool       `json:"email_verified"`
+	Role                  Role       `json:"role"`
+	CreatedAt             time.Time  `json:"created_ts"`
+	ActiveSessions        int64      `json:"active_sessions"`
+	APITokenExpiresAt     *time.Time `json:"api_token_expires_at,omitempty"`
+	RefreshTokenExpiresAt *time.Time `json:"refresh_token_expires_at,omitempty"`
+}
+
+// GetAccount loads the poster's account as seen from sessionID, which may
+// be 0 when no session is asking.
+func (s *Store) GetAccount(ctx context.Context, posterID, sessionID int64) (*Account, error) {
+	var a Account
+	var accessExpires, refreshExpires sql.NullTime
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT p.poster_id, p.email, p.username, p.email_verified, p.role, p.created_ts,
+		       (SELECT COUNT(*) FROM sessions s WHERE s.poster_id = p.poster_id AND s.expires_ts > NOW()) AS active_sessions,
+		       cs.access_expires_ts, cs.expires_ts
+		FROM posters p
+		LEFT JOIN sessions cs ON cs.session_id = $2 AND cs.poster_id = p.poster_id
+		WHERE p.poster_id = $1
+	`, posterID, sessionID).Scan(&a.PosterID, &a.Email, &a.Username, &a.EmailVerified, &a.Role, &a.CreatedAt, &a.ActiveSessions,
+		&accessExpires, &refreshExpires)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("load account: %w", err)
+	}
+	if accessExpires.Valid {
+		a.APITokenExpiresAt = &accessExpires.Time
+	}
+	if refreshExpires.Valid {
+		a.RefreshTokenExpiresAt = &refreshExpires.Time
+	}
+	return &a, nil
+}
+
+// UpdateUsername renames a poster, applying the same rules as Register.
+func (s *Store) UpdateUsername(ctx context.Context, posterID int64, username string) error {
+	if !ValidUsername(username) {
+		return ErrInvalidUsername.At("username")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := snapshot(ctx, tx, posterSnapshotSQL, posterID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("load poster: %w", err)
+	}
+
+	var after []byte
+	if err := tx.QueryRowContext(ctx, `
+		UPDATE posters
+		SET username = $1
+		WHERE poster_id = $2
+		RETURNING to_jsonb(posters) - 'email'
+	`, username, posterID).Scan(&after); err != nil {
+		if strings.Contains(err.Error(), "posters_username_key") {
+			return ErrUsernameExists
+		}
+		return fmt.Errorf("update username: %w", err)
+	}
+
+	if err := recordAudit(ctx, tx, posterID, AuditPosterRename, "poster", strconv.FormatInt(posterID, 10), before, after); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
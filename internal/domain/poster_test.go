@@ -0,0 +1,232 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetPosterProfile(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	username := "alice"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT p.poster_id, p.username, p.created_ts, \\( SELECT COUNT\\(\\*\\) FROM reviews r JOIN bikes b ON b.numerical_id = r.bike_numerical_id AND b.deleted_ts IS NULL .* JOIN bikes b ON b.numerical_id = r.bike_numerical_id AND b.deleted_ts IS NULL .* FROM posters p WHERE p.username = \\$1").
+			WithArgs(username).
+			WillReturnRows(sqlmock.NewRows([]string{"poster_id", "username", "created_ts", "review_count", "bikes_created", "avg"}).
+				AddRow(1, username, time.Now(), 4, 2, 3.75))
+
+		store := NewStore(db)
+		profile, err := store.GetPosterProfile(ctx, username)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if profile.ReviewCount != 4 || profile.BikesCreated != 2 {
+			t.Errorf("unexpected counts %+v", profile)
+		}
+		if profile.AverageScoreGiven == nil || *profile.AverageScoreGiven != 3.75 {
+			t.Errorf("expected average 3.75, got %v", profile.AverageScoreGiven)
+		}
+	})
+
+	t.Run("no_reviews", func(t *testing.T) {
+		mock.ExpectQuery("FROM posters p WHERE p.username = \\$1").
+			WithArgs(username).
+			WillReturnRows(sqlmock.NewRows([]string{"poster_id", "username", "created_ts", "review_count", "bikes_created", "avg"}).
+				AddRow(1, username, time.Now(), 0, 0, nil))
+
+		store := NewStore(db)
+		profile, err := store.GetPosterProfile(ctx, username)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if profile.AverageScoreGiven != nil {
+			t.Errorf("expected nil average, got %v", *profile.AverageScoreGiven)
+		}
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		mock.ExpectQuery("FROM posters p WHERE p.username = \\$1").
+			WithArgs(username).
+			WillReturnError(sql.ErrNoRows)
+
+		store := NewStore(db)
+		_, err := store.GetPosterProfile(ctx, username)
+		if err != ErrUserNotFound {
+			t.Errorf("expected ErrUserNotFound, got %v", err)
+		}
+	})
+}
+
+func TestListReviewsWithRatingsByPoster(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	username := "alice"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT poster_id FROM posters WHERE username = \\$1").
+			WithArgs(username).
+			WillReturnRows(sqlmock.NewRows([]string{"poster_id"}).AddRow(7))
+
+		mock.ExpectQuery("WITH p AS \\(.* WHERE r.poster_id = \\$1 AND r.hidden_ts IS NULL AND r.deleted_ts IS NULL ORDER BY r.created_ts DESC").
+			WithArgs(int64(7), DefaultPageLimit+1).
+			WillReturnRows(sqlmock.NewRows([]string{"review_id", "poster_id", "username", "bike_numerical_id", "comment", "created_ts", "subcategory", "score", "images"}).
+				AddRow(1, 7, username, "1001", "ok", time.Now(), "overall", 3, `[]`))
+
+		store := NewStore(db)
+		reviews, _, err := store.ListReviewsWithRatingsByPoster(ctx, username, ListReviewsQuery{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(reviews) != 1 || reviews[0].PosterID != 7 {
+			t.Errorf("unexpected reviews %+v", reviews)
+		}
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT poster_id FROM posters WHERE username = \\$1").
+			WithArgs(username).
+			WillReturnError(sql.ErrNoRows)
+
+		store := NewStore(db)
+		_, _, err := store.ListReviewsWithRatingsByPoster(ctx, username, ListReviewsQuery{})
+		if err != ErrUserNotFound {
+			t.Errorf("expected ErrUserNotFound, got %v", err)
+		}
+	})
+}
+
+func TestGetAccount(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	posterID := int64(1)
+
+	t.Run("success", func(t *testing.T) {
+		accessExpires := time.Now().Add(15 * time.Minute).UTC().Truncate(time.Second)
+		refreshExpires := time.Now().Add(60 * 24 * time.Hour).UTC().Truncate(time.Second)
+		mock.ExpectQuery("SELECT p.poster_id, p.email, p.username, p.email_verified, p.role, p.created_ts, .* AS active_sessions, cs.access_expires_ts, cs.expires_ts FROM posters p LEFT JOIN sessions cs ON cs.session_id = \\$2").
+			WithArgs(posterID, 7).
+			WillReturnRows(sqlmock.NewRows([]string{"poster_id", "email", "username", "email_verified", "role", "created_ts", "active_sessions", "access_expires_ts", "expires_ts"}).
+				AddRow(posterID, "test@example.com", "testuser", true, "user", time.Now(), 2, accessExpires, refreshExpires))
+
+		store := NewStore(db)
+		account, err := store.GetAccount(ctx, posterID, 7)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if account.Email != "test@example.com" || !account.EmailVerified {
+			t.Errorf("unexpected account %+v", account)
+		}
+		if account.ActiveSessions != 2 {
+			t.Errorf("expected 2 active sessions, got %d", account.ActiveSessions)
+		}
+		if account.APITokenExpiresAt == nil || !account.APITokenExpiresAt.Equal(accessExpires) ||
+			account.RefreshTokenExpiresAt == nil || !account.RefreshTokenExpiresAt.Equal(refreshExpires) {
+			t.Errorf("unexpected token expiry %v / %v", account.APITokenExpiresAt, account.RefreshTokenExpiresAt)
+		}
+	})
+
+	t.Run("without_session", func(t *testing.T) {
+		mock.ExpectQuery("AS active_sessions").
+			WithArgs(posterID, 0).
+			WillReturnRows(sqlmock.NewRows([]string{"poster_id", "email", "username", "email_verified", "role", "created_ts", "active_sessions", "access_expires_ts", "expires_ts"}).
+				AddRow(posterID, "test@example.com", "testuser", true, "user", time.Now(), 0, nil, nil))
+
+		store := NewStore(db)
+		account, err := store.GetAccount(ctx, posterID, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if account.APITokenExpiresAt != nil || account.RefreshTokenExpiresAt != nil {
+			t.Errorf("expected no token expiry, got %+v", account)
+		}
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		mock.ExpectQuery("AS active_sessions").
+			WithArgs(posterID, 7).
+			WillReturnError(sql.ErrNoRows)
+
+		store := NewStore(db)
+		_, err := store.GetAccount(ctx, posterID, 7)
+		if err != ErrUserNotFound {
+			t.Errorf("expected ErrUserNotFound, got %v", err)
+		}
+	})
+}
+
+func TestUpdateUsername(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	posterID := int64(1)
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT to_jsonb\\(p\\) - 'email' FROM posters p").
+			WithArgs(posterID).
+			WillReturnRows(sqlmock.NewRows([]string{"image"}).AddRow(`{"username":"old.name"}`))
+		mock.ExpectQuery("UPDATE posters SET username = \\$1 WHERE poster_id = \\$2 RETURNING").
+			WithArgs("new.name", posterID).
+			WillReturnRows(sqlmock.NewRows([]string{"image"}).AddRow(`{"username":"new.name"}`))
+		mock.ExpectExec("INSERT INTO audit_events").
+			WithArgs(posterID, AuditPosterRename, "poster", "1", `{"username":"old.name"}`, `{"username":"new.name"}`, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		store := NewStore(db)
+		if err := store.UpdateUsername(ctx, posterID, "new.name"); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("invalid_username", func(t *testing.T) {
+		store := NewStore(db)
+		if err := store.UpdateUsername(ctx, posterID, "bad name!"); !errors.Is(err, ErrInvalidUsername) {
+			t.Errorf("expected ErrInvalidUsername, got %v", err)
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT to_jsonb\\(p\\) - 'email' FROM posters p").
+			WithArgs(posterID).
+			WillReturnRows(sqlmock.NewRows([]string{"image"}).AddRow(`{"username":"old.name"}`))
+		mock.ExpectQuery("UPDATE posters SET username").
+			WithArgs("taken", posterID).
+			WillReturnError(errors.New(`pq: duplicate key value violates unique constraint "posters_username_key"`))
+
+		store := NewStore(db)
+		if err := store.UpdateUsername(ctx, posterID, "taken"); err != ErrUsernameExists {
+			t.Errorf("expected ErrUsernameExists, got %v", err)
+		}
+	})
+}
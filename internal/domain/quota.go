@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Quota allows Limit actions per poster in any Window. A zero limit turns
+// it off.
+type Quota struct {
+	Limit  int
+	Window time.Duration
+}
+
+// Enabled reports whether the quota limits anything.
+func (q Quota) Enabled() bool {
+	return q.Limit > 0 && q.Window > 0
+}
+
+// Quotas are the per-poster limits the store checks itself, in the same
+// transaction as the insert they guard.
+type Quotas struct {
+	// MagicLinks covers sign-in and email change links together.
+	MagicLinks Quota
+	// Reviews covers every review a poster writes.
+	Reviews Quota
+	// BikeReviews covers a poster's reviews of any one bike.
+	BikeReviews Quota
+}
+
+// DefaultQuotas are the quotas used unless configured otherwise.
+func DefaultQuotas() Quotas {
+	return Quotas{
+		MagicLinks:  Quota{Limit: 2, Window: 24 * time.Hour},
+		Reviews:     Quota{Limit: 5, Window: time.Hour},
+		BikeReviews: Quota{Limit: 1, Window: 10 * time.Minute},
+	}
+}
+
+// checkQuota counts the rows query finds created since q.Window ago and,
+// once they reach q.Limit, returns exceeded set to retry when the oldest of
+// them leaves the window. query selects COUNT(*) and MIN(created_ts), and
+// takes the cutoff after args.
+func checkQuota(ctx context.Context, tx *sql.Tx, q Quota, exceeded *RateLimitedError, query string, args ...any) error {
+	if !q.Enabled() {
+		return nil
+	}
+
+	var count int
+	var oldest sql.NullTime
+	args = append(args, time.Now().Add(-q.Window))
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&count, &oldest); err != nil {
+		return fmt.Errorf("check %s quota: %w", exceeded.Code, err)
+	}
+	if count >= q.Limit {
+		return exceeded.After(time.Until(oldest.Time.Add(q.Window)))
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// openTestDB connects to the migrated database in TEST_DATABASE_URL, or
+// skips the test when it isn't set. Tests create their own rows and delete
+// them afterwards.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Ping(); err != nil {
+		t.Fatalf("ping db: %v", err)
+	}
+	return db
+}
+
+// runParallel calls fn n times at once and counts the outcomes.
+func runParallel(n int, fn func(i int) error) (ok int, errs []error) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	start := make(chan struct{})
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			err := fn(i)
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				ok++
+			} else {
+				errs = append(errs, err)
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	return ok, errs
+}
+
+func TestQuotasUnderConcurrency(t *testing.T) {
+	db := openTestDB(t)
+	store := NewStore(db)
+	ctx := context.Background()
+	run := time.Now().UnixNano() % 1e9
+	const parallel = 16
+
+	newPoster := func(t *testing.T, name string) (int64, string) {
+		t.Helper()
+		email := fmt.Sprintf("%s%d@example.com", name, run)
+		var id int64
+		if err := db.QueryRowContext(ctx, `
+			INSERT INTO posters (email, username, email_verified)
+			VALUES ($1, $2, TRUE)
+			RETURNING poster_id
+		`, email, fmt.Sprintf("%s%d", name, run)).Scan(&id); err != nil {
+			t.Fatalf("insert poster: %v", err)
+		}
+		t.Cleanup(func() { db.Exec(`DELETE FROM posters WHERE poster_id = $1`, id) })
+		return id, email
+	}
+	newBikes := func(t *testing.T, n int) []string {
+		t.Helper()
+		ids := make([]string, n)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("9%09d%02d", run, i)
+			if _, err := db.ExecContext(ctx, `INSERT INTO bikes (numerical_id) VALUES ($1)`, ids[i]); err != nil {
+				t.Fatalf("insert bike: %v", err)
+			}
+		}
+		// Registered after the poster's cleanup so it runs first, taking
+		// the reviews with it.
+		t.Cleanup(func() {
+			for _, id := range ids {
+				db.Exec(`DELETE FROM bikes WHERE numerical_id = $1`, id)
+			}
+		})
+		return ids
+	}
+	expect := func(t *testing.T, ok, want int, errs []error, target error) {
+		t.Helper()
+		if ok != want {
+			t.Errorf("expected %d to succeed, got %d", want, ok)
+		}
+		for _, err := range errs {
+			if !errors.Is(err, target) {
+				t.Errorf("expected %v, got %v", target, err)
+			}
+		}
+	}
+
+	t.Run("hourly_reviews", func(t *testing.T) {
+		posterID, _ := newPoster(t, "hourly")
+		bikes := newBikes(t, parallel)
+		ok, errs := runParallel(parallel, func(i int) error {
+			_, err := store.CreateReviewWithRatings(ctx, CreateReviewInput{PosterID: posterID, BikeID: bikes[i]})
+			return err
+		})
+		expect(t, ok, 5, errs, ErrHourlyRateLimitExceeded)
+	})
+
+	t.Run("reviews_per_bike", func(t *testing.T) {
+		posterID, _ := newPoster(t, "perbike")
+		bikes := newBikes(t, 1)
+		ok, errs := runParallel(parallel, func(int) error {
+			_, err := store.CreateReviewWithRatings(ctx, CreateReviewInput{PosterID: posterID, BikeID: bikes[0]})
+			return err
+		})
+		expect(t, ok, 1, errs, ErrTooFrequentReview)
+	})
+
+	t.Run("magic_links", func(t *testing.T) {
+		_, email := newPoster(t, "links")
+		ok, errs := runParallel(parallel, func(int) error {
+			_, _, err := store.CreateMagicLink(ctx, email, "")
+			return err
+		})
+		expect(t, ok, 2, errs, ErrRateLimitExceeded)
+	})
+}
@@ -45,7 +45,7 @@ func (s *Store) ListWindowedRatingAggregatesByBike(ctx context.Context, bikeID s
 			ROUND(AVG(rr.score)::numeric, 2) as avg_overall
 		FROM review_ratings rr
 		JOIN reviews r ON rr.review_id = r.review_id
-		WHERE r.bike_numerical_id = $1
+		WHERE r.bike_numerical_id = $1 AND r.hidden_ts IS NULL AND r.deleted_ts IS NULL
 		GROUP BY rr.subcategory
 		ORDER BY rr.subcategory
 	`, bikeID)
@@ -92,7 +92,7 @@ func RecomputeAggregatesForBike(ctx context.Context, tx *sql.Tx, bikeID string)
 		return err
 	}
 
-	// Recompute from review_ratings + reviews
+	// Recompute from review_ratings + reviews, leaving out hidden and deleted reviews
 	_, err := tx.ExecContext(ctx, `
 		INSERT INTO rating_aggregates (
 			bike_numerical_id, subcategory, rating_sum, rating_count, average_rating
@@ -105,7 +105,7 @@ func RecomputeAggregatesForBike(ctx context.Context, tx *sql.Tx, bikeID string)
 			ROUND(AVG(rr.score)::numeric, 2)     AS average_rating
 		FROM review_ratings rr
 		JOIN reviews r ON rr.review_id = r.review_id
-		WHERE r.bike_numerical_id = $1
+		WHERE r.bike_numerical_id = $1 AND r.hidden_ts IS NULL AND r.deleted_ts IS NULL
 		GROUP BY r.bike_numerical_id, rr.subcategory
 	`, bikeID)
 	return err
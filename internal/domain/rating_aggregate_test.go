@@ -111,7 +111,8 @@ func TestRecomputeAggregatesForBike(t *testing.T) {
 			WithArgs(bikeID).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 
-		mock.ExpectExec("INSERT INTO rating_aggregates").
+		// Hidden reviews don't count towards the aggregates.
+		mock.ExpectExec("INSERT INTO rating_aggregates .* WHERE r.bike_numerical_id = \\$1 AND r.hidden_ts IS NULL").
 			WithArgs(bikeID).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 
@@ -3,8 +3,9 @@ package domain
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,7 +17,7 @@ type ReviewWithRatings struct {
 	Comment         *string                     `json:"comment"`
 	CreatedAt       time.Time                   `json:"created_at"`
 	Ratings         map[RatingSubcategory]int16 `json:"ratings"`
-	BikeImg         *string                     `json:"bike_img"`
+	Images          []ReviewImage               `json:"images"`
 }
 
 type reviewRatingRow struct {
@@ -26,42 +27,170 @@ type reviewRatingRow struct {
 	BikeNumericalID string
 	Comment         *string
 	CreatedAt       time.Time
-	Subcategory     RatingSubcategory
-	Score           int16
-	BikeImg         *string
+	Subcategory     sql.NullString
+	Score           sql.NullInt16
+	Images          []byte
 }
 
-// all bikes
+type ReviewSort string
 
-// single bike
-func (s *Store) ListReviewsWithRatingsByBike(ctx context.Context, bikeID string) ([]ReviewWithRatings, error) {
-	rows, err := s.db.QueryContext(ctx, `
+const (
+	ReviewSortNewest  ReviewSort = "newest"
+	ReviewSortOldest  ReviewSort = "oldest"
+	ReviewSortHighest ReviewSort = "highest"
+	ReviewSortLowest  ReviewSort = "lowest"
+)
+
+// reviewSortKeys maps each sort to the expression it orders by inside the page
+// query, the same column once selected into the page, the cursor cast, and the
+// direction. Ties are broken by review_id in the same direction.
+var reviewSortKeys = map[ReviewSort]struct {
+	expr string
+	col  string
+	cast string
+	desc bool
+}{
+	ReviewSortNewest:  {"r.created_ts", "p.created_ts", "timestamptz", true},
+	ReviewSortOldest:  {"r.created_ts", "p.created_ts", "timestamptz", false},
+	ReviewSortHighest: {"COALESCE(ov.score, 0)", "p.overall_score", "smallint", true},
+	ReviewSortLowest:  {"COALESCE(ov.score, 0)", "p.overall_score", "smallint", false},
+}
+
+func ValidReviewSort(s ReviewSort) bool {
+	_, ok := reviewSortKeys[s]
+	return ok
+}
+
+type ListReviewsQuery struct {
+	Cursor string
+	Limit  int
+
+	HasComment *bool
+
+	Sort ReviewSort
+}
+
+// ListReviewsWithRatingsByBike returns one page of a bike's reviews in a stable
+// order, together with the cursor for the next page (empty on the last page).
+func (s *Store) ListReviewsWithRatingsByBike(ctx context.Context, bikeID string, q ListReviewsQuery) ([]ReviewWithRatings, string, error) {
+	return s.listReviewsWithRatings(ctx, "r.bike_numerical_id", bikeID, q)
+}
+
+// listReviewsWithRatings pages over reviews matching ownerCol = ownerID. The
+// page is selected first so LIMIT counts reviews rather than rating rows, and
+// the ratings are joined onto it afterwards.
+func (s *Store) listReviewsWithRatings(ctx context.Context, ownerCol string, ownerID any, q ListReviewsQuery) ([]ReviewWithRatings, string, error) {
+	if q.Sort == "" {
+		q.Sort = ReviewSortNewest
+	}
+	key, ok := reviewSortKeys[q.Sort]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown review sort %q", q.Sort)
+	}
+	limit := clampLimit(q.Limit)
+
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := []string{ownerCol + " = " + arg(ownerID), "r.hidden_ts IS NULL", "r.deleted_ts IS NULL"}
+
+	if q.HasComment != nil {
+		if *q.HasComment {
+			where = append(where, "(r.comment IS NOT NULL AND r.comment <> '')")
+		} else {
+			where = append(where, "(r.comment IS NULL OR r.comment = '')")
+		}
+	}
+
+	dir, cmp := "ASC", ">"
+	if key.desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor, string(q.Sort), key.cast, "bigint")
+		if err != nil {
+			return nil, "", err
+		}
+		where = append(where, fmt.Sprintf("(%s, r.review_id) %s (%s::%s, %s::bigint)",
+			key.expr, cmp, arg(c.Value), key.cast, arg(c.ID)))
+	}
+
+	query := fmt.Sprintf(`
+		WITH p AS (
+			SELECT
+				r.review_id,
+				r.poster_id,
+				r.bike_numerical_id,
+				r.comment,
+				r.created_ts,
+				%s AS images,
+				COALESCE(ov.score, 0) AS overall_score
+			FROM reviews r
+			JOIN bikes b ON b.numerical_id = r.bike_numerical_id AND b.deleted_ts IS NULL
+			LEFT JOIN review_ratings ov
+				ON ov.review_id = r.review_id
+				AND ov.subcategory = 'overall'
+			WHERE %s
+			ORDER BY %s %s, r.review_id %s
+			LIMIT %s
+		)
 		SELECT
-			r.review_id,
-			r.poster_id,
-			COALESCE(p.username, ''),
-			r.bike_numerical_id,
-			r.comment,
-			r.created_ts,
+			p.review_id,
+			p.poster_id,
+			COALESCE(po.username, ''),
+			p.bike_numerical_id,
+			p.comment,
+			p.created_ts,
 			rr.subcategory,
 			rr.score,
-			r.bike_img
-		FROM reviews r
-		LEFT JOIN posters p       ON p.poster_id = r.poster_id
-		JOIN review_ratings rr ON rr.review_id = r.review_id
-		WHERE r.bike_numerical_id = $1
-		ORDER BY r.review_id, rr.subcategory
-	`, bikeID)
+			p.images
+		FROM p
+		LEFT JOIN posters po        ON po.poster_id = p.poster_id
+		LEFT JOIN review_ratings rr ON rr.review_id = p.review_id
+		ORDER BY %s %s, p.review_id %s, rr.subcategory
+	`, reviewImagesAggSQL, strings.Join(where, " AND "), key.expr, dir, dir, arg(limit+1), key.col, dir, dir)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer rows.Close()
 
-	return buildReviewWithRatingsFromRows(rows)
+	reviews, err := buildReviewWithRatingsFromRows(rows)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(reviews) <= limit {
+		return reviews, "", nil
+	}
+	reviews = reviews[:limit]
+	last := reviews[limit-1]
+	return reviews, encodeCursor(pageCursor{
+		Sort:  string(q.Sort),
+		Value: reviewSortValue(last, q.Sort),
+		ID:    strconv.FormatInt(last.ReviewID, 10),
+	}), nil
 }
 
+func reviewSortValue(r ReviewWithRatings, sort ReviewSort) string {
+	switch sort {
+	case ReviewSortHighest, ReviewSortLowest:
+		return strconv.Itoa(int(r.Ratings[RatingSubcategoryOverall]))
+	default:
+		return r.CreatedAt.Format(time.RFC3339Nano)
+	}
+}
+
+// buildReviewWithRatingsFromRows folds one-row-per-rating results into reviews,
+// keeping the order in which each review first appears.
 func buildReviewWithRatingsFromRows(rows *sql.Rows) ([]ReviewWithRatings, error) {
-	reviewsMap := make(map[int64]*ReviewWithRatings)
+	var result []ReviewWithRatings
+	index := make(map[int64]int)
 
 	for rows.Next() {
 		var row reviewRatingRow
@@ -74,14 +203,18 @@ func buildReviewWithRatingsFromRows(rows *sql.Rows) ([]ReviewWithRatings, error)
 			&row.CreatedAt,
 			&row.Subcategory,
 			&row.Score,
-			&row.BikeImg,
+			&row.Images,
 		); err != nil {
 			return nil, err
 		}
 
-		r, ok := reviewsMap[row.ReviewID]
+		i, ok := index[row.ReviewID]
 		if !ok {
-			r = &ReviewWithRatings{
+			images, err := decodeReviewImages(row.Images)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, ReviewWithRatings{
 				ReviewID:        row.ReviewID,
 				PosterID:        row.PosterID.Int64, // Corrected based on instruction interpretation
 				PosterUsername:  row.PosterUsername,
@@ -89,31 +222,52 @@ func buildReviewWithRatingsFromRows(rows *sql.Rows) ([]ReviewWithRatings, error)
 				Comment:         row.Comment,
 				CreatedAt:       row.CreatedAt,
 				Ratings:         make(map[RatingSubcategory]int16),
-				BikeImg:         row.BikeImg,
-			}
-			reviewsMap[row.ReviewID] = r
+				Images:          images,
+			})
+			i = len(result) - 1
+			index[row.ReviewID] = i
+		}
+		// reviews without any rating come back with a single NULL rating row
+		if row.Subcategory.Valid {
+			result[i].Ratings[RatingSubcategory(row.Subcategory.String)] = row.Score.Int16
 		}
-		r.Ratings[row.Subcategory] = row.Score
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	result := make([]ReviewWithRatings, 0, len(reviewsMap))
-	for _, r := range reviewsMap {
-		result = append(result, *r)
-	}
 	return result, nil
 }
 
-var ErrTooFrequentReview = errors.New("review too frequent")
-var ErrHourlyRateLimitExceeded = errors.New("hourly review limit exceeded")
+var (
+	ErrReviewNotFound          = &NotFoundError{Code: "review_not_found", Message: "review not found"}
+	ErrTooFrequentReview       = &RateLimitedError{Code: "review_too_frequent", Message: "you reviewed this bike too recently"}
+	ErrHourlyRateLimitExceeded = &RateLimitedError{Code: "hourly_review_limit", Message: "you have reached the review limit"}
+	ErrInvalidScore            = &ValidationError{Code: "invalid_score", Message: "scores must be between 1 and 5"}
+	ErrCommentTooLong          = &ValidationError{Code: "comment_too_long", Message: "comments must be at most 500 characters"}
+)
+
+const (
+	MinScore = 1
+	MaxScore = 5
+	// MaxCommentLen is the size of reviews.comment, in characters.
+	MaxCommentLen = 500
+)
+
+func validateComment(comment *string) error {
+	if comment != nil && len([]rune(*comment)) > MaxCommentLen {
+		return ErrCommentTooLong.At("comment")
+	}
+	return nil
+}
 
 type CreateReviewInput struct {
 	PosterID int64
 	BikeID   string
 	Comment  *string
-	BikeImg  *string
+	Images   []ReviewImageInput
+	// StationID, when set, also records a sighting of the bike there.
+	StationID *string
 
 	Overall    *int16
 	Breaks     *int16
@@ -124,63 +278,84 @@ type CreateReviewInput struct {
 }
 
 func (s *Store) CreateReviewWithRatings(ctx context.Context, in CreateReviewInput) (int64, error) {
-	const minInterval = 10 * time.Minute
-	const maxHourlyReviews = 5
+	if err := validateComment(in.Comment); err != nil {
+		return 0, err
+	}
+	if err := validateReviewImages(in.Images, false); err != nil {
+		return 0, err
+	}
 
-	// 1. Check global hourly limit
-	var hourlyCount int
-	if err := s.db.QueryRowContext(ctx, `
-		SELECT COUNT(*)
-		FROM reviews
-		WHERE poster_id = $1 AND created_ts > NOW() - INTERVAL '1 hour'
-	`, in.PosterID).Scan(&hourlyCount); err != nil {
-		return 0, fmt.Errorf("check hourly limit: %w", err)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
 	}
-	if hourlyCount >= maxHourlyReviews {
-		return 0, ErrHourlyRateLimitExceeded
+	defer tx.Rollback()
+
+	// The checks below only hold if no other review by this poster is
+	// inserted between them and our own insert.
+	if err := lockPoster(ctx, tx, lockReviewQuota, in.PosterID); err != nil {
+		return 0, err
 	}
 
-	// 2. Check per-bike frequency
+	// Deleted bikes can't be reviewed; the share lock keeps the bike from
+	// being deleted before the review is in.
+	if err := tx.QueryRowContext(ctx, `
+		SELECT numerical_id
+		FROM bikes
+		WHERE numerical_id = $1 AND deleted_ts IS NULL
+		FOR SHARE
+	`, in.BikeID).Scan(new(string)); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrBikeNotFound
+		}
+		return 0, fmt.Errorf("load bike: %w", err)
+	}
 
-	var lastCreated time.Time
-	err := s.db.QueryRowContext(ctx, `
-		SELECT created_ts
+	// 1. Check the poster's overall quota
+	if err := checkQuota(ctx, tx, s.quotas.Reviews, ErrHourlyRateLimitExceeded, `
+		SELECT COUNT(*), MIN(created_ts)
 		FROM reviews
-		WHERE poster_id = $1 AND bike_numerical_id = $2
-		ORDER BY created_ts DESC
-		LIMIT 1
-	`, in.PosterID, in.BikeID).Scan(&lastCreated)
-
-	if err == nil {
-		if time.Since(lastCreated) < minInterval {
-			return 0, ErrTooFrequentReview
-		}
-	} else if err != sql.ErrNoRows {
-		return 0, fmt.Errorf("check last review time: %w", err)
+		WHERE poster_id = $1 AND created_ts > $2
+	`, in.PosterID); err != nil {
+		return 0, err
 	}
 
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return 0, fmt.Errorf("begin tx: %w", err)
+	// 2. Check the quota for this bike
+	if err := checkQuota(ctx, tx, s.quotas.BikeReviews, ErrTooFrequentReview, `
+		SELECT COUNT(*), MIN(created_ts)
+		FROM reviews
+		WHERE poster_id = $1 AND bike_numerical_id = $2 AND created_ts > $3
+	`, in.PosterID, in.BikeID); err != nil {
+		return 0, err
 	}
-	defer tx.Rollback()
 
-	// Insert review, now including bike_img
 	var reviewID int64
 	if err := tx.QueryRowContext(ctx, `
-		INSERT INTO reviews (poster_id, bike_numerical_id, bike_img, comment)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO reviews (poster_id, bike_numerical_id, comment)
+		VALUES ($1, $2, $3)
 		RETURNING review_id
-	`, in.PosterID, in.BikeID, in.BikeImg, in.Comment).Scan(&reviewID); err != nil {
+	`, in.PosterID, in.BikeID, in.Comment).Scan(&reviewID); err != nil {
 		return 0, fmt.Errorf("insert review: %w", err)
 	}
 
+	for i, img := range in.Images {
+		if err := insertReviewImage(ctx, tx, reviewID, i, img.URL, img.Caption); err != nil {
+			return 0, err
+		}
+	}
+
+	if in.StationID != nil {
+		if _, err := recordSighting(ctx, tx, in.BikeID, *in.StationID, in.PosterID, &reviewID); err != nil {
+			return 0, err
+		}
+	}
+
 	insertRating := func(sub RatingSubcategory, val *int16) error {
 		if val == nil {
 			return nil
 		}
-		if *val < 1 || *val > 5 {
-			return fmt.Errorf("invalid score %d for %s", *val, sub)
+		if *val < MinScore || *val > MaxScore {
+			return ErrInvalidScore.At(string(sub))
 		}
 		_, err := tx.ExecContext(ctx, `
 			INSERT INTO review_ratings (review_id, subcategory, score)
@@ -212,6 +387,14 @@ func (s *Store) CreateReviewWithRatings(ctx context.Context, in CreateReviewInpu
 		return 0, fmt.Errorf("recompute aggregates: %w", err)
 	}
 
+	after, err := snapshot(ctx, tx, reviewSnapshotSQL, reviewID)
+	if err != nil {
+		return 0, fmt.Errorf("snapshot review: %w", err)
+	}
+	if err := recordAudit(ctx, tx, in.PosterID, AuditReviewCreate, "review", strconv.FormatInt(reviewID, 10), nil, after); err != nil {
+		return 0, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return 0, fmt.Errorf("commit tx: %w", err)
 	}
@@ -224,7 +407,8 @@ type UpdateReviewInput struct {
 	PosterID int64 // for ownership check
 
 	Comment *string
-	BikeImg *string
+	// Images replaces the review's image list when set; see ReviewImageInput.
+	Images *[]ReviewImageInput
 
 	Overall    *int16
 	Breaks     *int16
@@ -234,42 +418,66 @@ type UpdateReviewInput struct {
 	Pedals     *int16
 }
 
-func (s *Store) UpdateReviewWithRatings(ctx context.Context, in UpdateReviewInput) error {
+// UpdateReviewWithRatings applies the changes in `in` to a review owned by
+// in.PosterID. It returns the blob keys of uploaded images the update
+// removed, to be deleted by the caller.
+func (s *Store) UpdateReviewWithRatings(ctx context.Context, in UpdateReviewInput) ([]string, error) {
+	if err := validateComment(in.Comment); err != nil {
+		return nil, err
+	}
+	if in.Images != nil {
+		if err := validateReviewImages(*in.Images, true); err != nil {
+			return nil, err
+		}
+	}
+
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("begin tx: %w", err)
+		return nil, fmt.Errorf("begin tx: %w", err)
 	}
 	defer tx.Rollback()
 
 	// ensure review belongs to poster
 	var bikeID string
 	if err := tx.QueryRowContext(ctx, `
-		SELECT bike_numerical_id
-		FROM reviews
-		WHERE review_id = $1 AND poster_id = $2
+		SELECT r.bike_numerical_id
+		FROM reviews r
+		JOIN bikes b ON b.numerical_id = r.bike_numerical_id AND b.deleted_ts IS NULL
+		WHERE r.review_id = $1 AND r.poster_id = $2 AND r.deleted_ts IS NULL
 	`, in.ReviewID, in.PosterID).Scan(&bikeID); err != nil {
 		if err == sql.ErrNoRows {
-			return sql.ErrNoRows
+			return nil, ErrReviewNotFound
 		}
-		return fmt.Errorf("load review: %w", err)
+		return nil, fmt.Errorf("load review: %w", err)
+	}
+
+	before, err := snapshot(ctx, tx, reviewSnapshotSQL, in.ReviewID)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot review: %w", err)
 	}
 
 	// update main review row
 	if _, err := tx.ExecContext(ctx, `
 		UPDATE reviews
-		SET comment = COALESCE($1, comment),
-		    bike_img = COALESCE($2, bike_img)
-		WHERE review_id = $3
-	`, in.Comment, in.BikeImg, in.ReviewID); err != nil {
-		return fmt.Errorf("update review: %w", err)
+		SET comment = COALESCE($1, comment)
+		WHERE review_id = $2
+	`, in.Comment, in.ReviewID); err != nil {
+		return nil, fmt.Errorf("update review: %w", err)
+	}
+
+	var removedKeys []string
+	if in.Images != nil {
+		if removedKeys, err = replaceReviewImages(ctx, tx, in.ReviewID, *in.Images); err != nil {
+			return nil, err
+		}
 	}
 
 	updateRating := func(sub RatingSubcategory, val *int16) error {
 		if val == nil {
 			return nil
 		}
-		if *val < 1 || *val > 5 {
-			return fmt.Errorf("invalid score %d for %s", *val, sub)
+		if *val < MinScore || *val > MaxScore {
+			return ErrInvalidScore.At(string(sub))
 		}
 		_, err := tx.ExecContext(ctx, `
 			INSERT INTO review_ratings (review_id, subcategory, score)
@@ -281,36 +489,46 @@ func (s *Store) UpdateReviewWithRatings(ctx context.Context, in UpdateReviewInpu
 	}
 
 	if err := updateRating(RatingSubcategoryOverall, in.Overall); err != nil {
-		return fmt.Errorf("update overall rating: %w", err)
+		return nil, fmt.Errorf("update overall rating: %w", err)
 	}
 	if err := updateRating(RatingSubcategoryBreaks, in.Breaks); err != nil {
-		return fmt.Errorf("update breaks rating: %w", err)
+		return nil, fmt.Errorf("update breaks rating: %w", err)
 	}
 	if err := updateRating(RatingSubcategorySeat, in.Seat); err != nil {
-		return fmt.Errorf("update seat rating: %w", err)
+		return nil, fmt.Errorf("update seat rating: %w", err)
 	}
 	if err := updateRating(RatingSubcategorySturdiness, in.Sturdiness); err != nil {
-		return fmt.Errorf("update sturdiness rating: %w", err)
+		return nil, fmt.Errorf("update sturdiness rating: %w", err)
 	}
 	if err := updateRating(RatingSubcategoryPower, in.Power); err != nil {
-		return fmt.Errorf("update power rating: %w", err)
+		return nil, fmt.Errorf("update power rating: %w", err)
 	}
 	if err := updateRating(RatingSubcategoryPedals, in.Pedals); err != nil {
-		return fmt.Errorf("update pedals rating: %w", err)
+		return nil, fmt.Errorf("update pedals rating: %w", err)
 	}
 
 	if err := RecomputeAggregatesForBike(ctx, tx, bikeID); err != nil {
-		return fmt.Errorf("recompute aggregates: %w", err)
+		return nil, fmt.Errorf("recompute aggregates: %w", err)
+	}
+
+	after, err := snapshot(ctx, tx, reviewSnapshotSQL, in.ReviewID)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot review: %w", err)
+	}
+	if err := recordAudit(ctx, tx, in.PosterID, AuditReviewUpdate, "review", strconv.FormatInt(in.ReviewID, 10), before, after); err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("commit tx: %w", err)
+		return nil, fmt.Errorf("commit tx: %w", err)
 	}
 
-	return nil
+	return removedKeys, nil
 }
 
-func (s *Store) GetReviewWithRatingsByID(ctx context.Context, reviewID int64) (*ReviewWithRatings, error) {
+// GetReviewWithRatingsByID returns a review that is not deleted. Hidden
+// reviews are only returned when includeHidden is set, for moderators.
+func (s *Store) GetReviewWithRatingsByID(ctx context.Context, reviewID int64, includeHidden bool) (*ReviewWithRatings, error) {
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT
 			r.review_id,
@@ -321,13 +539,15 @@ func (s *Store) GetReviewWithRatingsByID(ctx context.Context, reviewID int64) (*
 			r.created_ts,
 			rr.subcategory,
 			rr.score,
-			r.bike_img
+			`+reviewImagesAggSQL+`
 		FROM reviews r
+		JOIN bikes b              ON b.numerical_id = r.bike_numerical_id AND b.deleted_ts IS NULL
 		LEFT JOIN posters p       ON p.poster_id = r.poster_id
 		LEFT JOIN review_ratings rr ON rr.review_id = r.review_id
-		WHERE r.review_id = $1
+		WHERE r.review_id = $1 AND r.deleted_ts IS NULL
+			AND ($2 OR r.hidden_ts IS NULL)
 		ORDER BY rr.subcategory
-	`, reviewID)
+	`, reviewID, includeHidden)
 	if err != nil {
 		return nil, err
 	}
@@ -338,7 +558,7 @@ func (s *Store) GetReviewWithRatingsByID(ctx context.Context, reviewID int64) (*
 		return nil, err
 	}
 	if len(reviews) == 0 {
-		return nil, sql.ErrNoRows
+		return nil, ErrReviewNotFound
 	}
 	return &reviews[0], nil
 }
@@ -353,32 +573,35 @@ func (s *Store) DeleteReview(ctx context.Context, reviewID int64, posterID int64
 	// ensure review exists and belongs to poster, and get bike id for recompute
 	var bikeID string
 	if err := tx.QueryRowContext(ctx, `
-		SELECT bike_numerical_id
-		FROM reviews
-		WHERE review_id = $1 AND poster_id = $2
+		SELECT r.bike_numerical_id
+		FROM reviews r
+		JOIN bikes b ON b.numerical_id = r.bike_numerical_id AND b.deleted_ts IS NULL
+		WHERE r.review_id = $1 AND r.poster_id = $2 AND r.deleted_ts IS NULL
 	`, reviewID, posterID).Scan(&bikeID); err != nil {
 		if err == sql.ErrNoRows {
-			return sql.ErrNoRows
+			return ErrReviewNotFound
 		}
 		return fmt.Errorf("load review: %w", err)
 	}
 
-	// delete ratings first due to FK
-	if _, err := tx.ExecContext(ctx, `
-		DELETE FROM review_ratings
-		WHERE review_id = $1
-	`, reviewID); err != nil {
-		return fmt.Errorf("delete review_ratings: %w", err)
+	before, err := snapshot(ctx, tx, reviewSnapshotSQL, reviewID)
+	if err != nil {
+		return fmt.Errorf("snapshot review: %w", err)
 	}
 
-	// delete review
+	// soft delete; ratings stay with the row until it is purged
 	if _, err := tx.ExecContext(ctx, `
-		DELETE FROM reviews
+		UPDATE reviews
+		SET deleted_ts = NOW()
 		WHERE review_id = $1
 	`, reviewID); err != nil {
 		return fmt.Errorf("delete review: %w", err)
 	}
 
+	if err := recordAudit(ctx, tx, posterID, AuditReviewDelete, "review", strconv.FormatInt(reviewID, 10), before, nil); err != nil {
+		return err
+	}
+
 	if err := RecomputeAggregatesForBike(ctx, tx, bikeID); err != nil {
 		return fmt.Errorf("recompute aggregates: %w", err)
 	}
@@ -0,0 +1,333 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+var (
+	ErrTooManyReviewImages = &ValidationError{Code: "too_many_images", Message: "a review can have at most 10 images"}
+	ErrInvalidReviewImage  = &ValidationError{Code: "invalid_image", Message: "each image needs an http(s) url or the image_id of one of the review's images"}
+	ErrInvalidImageCaption = &ValidationError{Code: "invalid_image_caption", Message: "image captions must be at most 200 characters"}
+)
+
+const (
+	MaxReviewImages    = 10
+	MaxImageCaptionLen = 200
+	maxImageURLLen     = 2048
+)
+
+// ImagePathPrefix is where the API serves uploaded review photos; a photo's
+// URL is this prefix followed by its blob key.
+const ImagePathPrefix = "/images/"
+
+// ReviewImage is one photo of a review. Uploaded photos are served by the API
+// and have a thumbnail; linked ones only have their URL.
+type ReviewImage struct {
+	ImageID  int64   `json:"image_id"`
+	Position int16   `json:"position"`
+	URL      string  `json:"url"`
+	ThumbURL *string `json:"thumb_url"`
+	Caption  *string `json:"caption"`
+}
+
+// ReviewImageInput is one entry of the image list given when creating or
+// updating a review: an existing image of the review by id (to reorder or
+// recaption it), or the URL of a new linked image.
+type ReviewImageInput struct {
+	ImageID *int64
+	URL     string
+	Caption *string
+}
+
+// reviewImagesAggSQL folds the images of review r into a JSON array in
+// display order, decoded by decodeReviewImages.
+const reviewImagesAggSQL = `COALESCE((
+	SELECT jsonb_agg(jsonb_build_object(
+		'image_id', ri.image_id,
+		'position', ri.position,
+		'url', ri.url,
+		'image_key', ri.image_key,
+		'thumb_key', ri.thumb_key,
+		'caption', ri.caption
+	) ORDER BY ri.position)
+	FROM review_images ri
+	WHERE ri.review_id = r.review_id), '[]'::jsonb)`
+
+type reviewImageRow struct {
+	ImageID  int64   `json:"image_id"`
+	Position int16   `json:"position"`
+	URL      *string `json:"url"`
+	ImageKey *string `json:"image_key"`
+	ThumbKey *string `json:"thumb_key"`
+	Caption  *string `json:"caption"`
+}
+
+func (row reviewImageRow) image() ReviewImage {
+	img := ReviewImage{
+		ImageID:  row.ImageID,
+		Position: row.Position,
+		Caption:  row.Caption,
+	}
+	if row.ImageKey != nil {
+		img.URL = ImagePathPrefix + *row.ImageKey
+		if row.ThumbKey != nil {
+			thumb := ImagePathPrefix + *row.ThumbKey
+			img.ThumbURL = &thumb
+		}
+	} else if row.URL != nil {
+		img.URL = *row.URL
+	}
+	return img
+}
+
+func decodeReviewImages(b []byte) ([]ReviewImage, error) {
+	var rows []reviewImageRow
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &rows); err != nil {
+			return nil, fmt.Errorf("decode review images: %w", err)
+		}
+	}
+	images := make([]ReviewImage, 0, len(rows))
+	for _, row := range rows {
+		images = append(images, row.image())
+	}
+	return images, nil
+}
+
+// ValidImageURL reports whether s can be linked as a review image: an
+// absolute http(s) URL of reasonable length.
+func ValidImageURL(s string) bool {
+	if s == "" || len(s) > maxImageURLLen {
+		return false
+	}
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func validateCaption(caption *string) error {
+	if caption != nil && len([]rune(*caption)) > MaxImageCaptionLen {
+		return ErrInvalidImageCaption
+	}
+	return nil
+}
+
+// validateReviewImages checks an image list before it touches the database.
+// Ids of existing images are only checked against the review later.
+func validateReviewImages(images []ReviewImageInput, allowExisting bool) error {
+	if len(images) > MaxReviewImages {
+		return ErrTooManyReviewImages.At("images")
+	}
+	for i, img := range images {
+		if err := validateCaption(img.Caption); err != nil {
+			return ErrInvalidImageCaption.At(fmt.Sprintf("images[%d].caption", i))
+		}
+		if img.ImageID != nil {
+			if !allowExisting || img.URL != "" {
+				return ErrInvalidReviewImage.At(fmt.Sprintf("images[%d]", i))
+			}
+			continue
+		}
+		if !ValidImageURL(img.URL) {
+			return ErrInvalidReviewImage.At(fmt.Sprintf("images[%d].url", i))
+		}
+	}
+	return nil
+}
+
+func insertReviewImage(ctx context.Context, tx *sql.Tx, reviewID int64, position int, url string, caption *string) error {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO review_images (review_id, position, url, caption)
+		VALUES ($1, $2, $3, $4)
+	`, reviewID, position, url, caption); err != nil {
+		return fmt.Errorf("insert review image: %w", err)
+	}
+	return nil
+}
+
+// replaceReviewImages makes images the review's image list, in that order.
+// Images left out are deleted; the blob keys of deleted uploads are returned
+// so the caller can remove the files after the transaction commits.
+func replaceReviewImages(ctx context.Context, tx *sql.Tx, reviewID int64, images []ReviewImageInput) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT image_id, image_key, thumb_key
+		FROM review_images
+		WHERE review_id = $1
+	`, reviewID)
+	if err != nil {
+		return nil, fmt.Errorf("load review images: %w", err)
+	}
+	existing := make(map[int64][]string)
+	for rows.Next() {
+		var id int64
+		var imageKey, thumbKey sql.NullString
+		if err := rows.Scan(&id, &imageKey, &thumbKey); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		var keys []string
+		for _, k := range []sql.NullString{imageKey, thumbKey} {
+			if k.Valid {
+				keys = append(keys, k.String)
+			}
+		}
+		existing[id] = keys
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	kept := make(map[int64]bool)
+	for _, img := range images {
+		if img.ImageID == nil {
+			continue
+		}
+		if _, ok := existing[*img.ImageID]; !ok || kept[*img.ImageID] {
+			return nil, ErrInvalidReviewImage
+		}
+		kept[*img.ImageID] = true
+	}
+
+	var dropped []int64
+	for id := range existing {
+		if !kept[id] {
+			dropped = append(dropped, id)
+		}
+	}
+	sort.Slice(dropped, func(i, j int) bool { return dropped[i] < dropped[j] })
+
+	var removedKeys []string
+	for _, id := range dropped {
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM review_images
+			WHERE image_id = $1
+		`, id); err != nil {
+			return nil, fmt.Errorf("delete review image: %w", err)
+		}
+		removedKeys = append(removedKeys, existing[id]...)
+	}
+
+	for i, img := range images {
+		if img.ImageID == nil {
+			if err := insertReviewImage(ctx, tx, reviewID, i, img.URL, img.Caption); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE review_images
+			SET position = $1, caption = $2
+			WHERE image_id = $3
+		`, i, img.Caption, *img.ImageID); err != nil {
+			return nil, fmt.Errorf("update review image: %w", err)
+		}
+	}
+
+	return removedKeys, nil
+}
+
+// deleteReviewImages deletes the images of the reviews selected by reviewIDs,
+// a subquery over review_id taking args. Like replaceReviewImages it returns
+// the blob keys of deleted uploads, to be removed once the transaction
+// commits; hard-deleting reviews would otherwise cascade the rows away and
+// leave the files behind.
+func deleteReviewImages(ctx context.Context, tx *sql.Tx, reviewIDs string, args ...any) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		DELETE FROM review_images
+		WHERE review_id IN (`+reviewIDs+`)
+		RETURNING image_key, thumb_key
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("delete review images: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var imageKey, thumbKey sql.NullString
+		if err := rows.Scan(&imageKey, &thumbKey); err != nil {
+			return nil, err
+		}
+		for _, k := range []sql.NullString{imageKey, thumbKey} {
+			if k.Valid {
+				keys = append(keys, k.String)
+			}
+		}
+	}
+	return keys, rows.Err()
+}
+
+// AddReviewPhoto appends already-stored blobs as a new image of a review of
+// the given bike owned by posterID.
+func (s *Store) AddReviewPhoto(ctx context.Context, bikeID string, reviewID, posterID int64, imageKey, thumbKey string, caption *string) (*ReviewImage, error) {
+	if err := validateCaption(caption); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRowContext(ctx, `
+		SELECT r.review_id
+		FROM reviews r
+		JOIN bikes b ON b.numerical_id = r.bike_numerical_id AND b.deleted_ts IS NULL
+		WHERE r.review_id = $1 AND r.bike_numerical_id = $2 AND r.poster_id = $3 AND r.deleted_ts IS NULL
+	`, reviewID, bikeID, posterID).Scan(&reviewID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrReviewNotFound
+		}
+		return nil, fmt.Errorf("load review: %w", err)
+	}
+
+	// the snapshot locks the review, so concurrent uploads queue up for
+	// the next position
+	before, err := snapshot(ctx, tx, reviewSnapshotSQL, reviewID)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot review: %w", err)
+	}
+
+	var count, next int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(MAX(position) + 1, 0)
+		FROM review_images
+		WHERE review_id = $1
+	`, reviewID).Scan(&count, &next); err != nil {
+		return nil, fmt.Errorf("count review images: %w", err)
+	}
+	if count >= MaxReviewImages {
+		return nil, ErrTooManyReviewImages
+	}
+
+	row := reviewImageRow{ImageKey: &imageKey, ThumbKey: &thumbKey, Caption: caption}
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO review_images (review_id, position, image_key, thumb_key, caption)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING image_id, position
+	`, reviewID, next, imageKey, thumbKey, caption).Scan(&row.ImageID, &row.Position); err != nil {
+		return nil, fmt.Errorf("insert review image: %w", err)
+	}
+
+	after, err := snapshot(ctx, tx, reviewSnapshotSQL, reviewID)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot review: %w", err)
+	}
+	if err := recordAudit(ctx, tx, posterID, AuditReviewUpdate, "review", strconv.FormatInt(reviewID, 10), before, after); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+
+	img := row.image()
+	return &img, nil
+}
@@ -0,0 +1,232 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestAddReviewPhoto(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	bikeID := "0101"
+	reviewID := int64(1)
+	posterID := int64(2)
+	caption := "torn seat"
+
+	t.Run("appends_after_existing", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT r.review_id FROM reviews r JOIN bikes b ON b.numerical_id = r.bike_numerical_id AND b.deleted_ts IS NULL WHERE r.review_id = \\$1 AND r.bike_numerical_id = \\$2 AND r.poster_id = \\$3 AND r.deleted_ts IS NULL").
+			WithArgs(reviewID, bikeID, posterID).
+			WillReturnRows(sqlmock.NewRows([]string{"review_id"}).AddRow(reviewID))
+		mock.ExpectQuery("SELECT to_jsonb\\(r\\)").
+			WithArgs(reviewID).
+			WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{"images":[]}`))
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\), COALESCE\\(MAX\\(position\\) \\+ 1, 0\\) FROM review_images").
+			WithArgs(reviewID).
+			WillReturnRows(sqlmock.NewRows([]string{"count", "next"}).AddRow(2, 3))
+		mock.ExpectQuery("INSERT INTO review_images \\(review_id, position, image_key, thumb_key, caption\\)").
+			WithArgs(reviewID, 3, "new.jpg", "new_thumb.jpg", caption).
+			WillReturnRows(sqlmock.NewRows([]string{"image_id", "position"}).AddRow(9, 3))
+		mock.ExpectQuery("SELECT to_jsonb\\(r\\)").
+			WithArgs(reviewID).
+			WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{"images":[{"image_id":9}]}`))
+		mock.ExpectExec("INSERT INTO audit_events").
+			WithArgs(posterID, AuditReviewUpdate, "review", "1", `{"images":[]}`, `{"images":[{"image_id":9}]}`, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		store := NewStore(db)
+		img, err := store.AddReviewPhoto(ctx, bikeID, reviewID, posterID, "new.jpg", "new_thumb.jpg", &caption)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if img.ImageID != 9 || img.Position != 3 || img.URL != "/images/new.jpg" ||
+			img.ThumbURL == nil || *img.ThumbURL != "/images/new_thumb.jpg" {
+			t.Errorf("unexpected image %+v", img)
+		}
+	})
+
+	t.Run("full", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT r.review_id FROM reviews r JOIN bikes b").
+			WithArgs(reviewID, bikeID, posterID).
+			WillReturnRows(sqlmock.NewRows([]string{"review_id"}).AddRow(reviewID))
+		mock.ExpectQuery("SELECT to_jsonb\\(r\\)").
+			WithArgs(reviewID).
+			WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{}`))
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\)").
+			WithArgs(reviewID).
+			WillReturnRows(sqlmock.NewRows([]string{"count", "next"}).AddRow(MaxReviewImages, MaxReviewImages))
+		mock.ExpectRollback()
+
+		store := NewStore(db)
+		if _, err := store.AddReviewPhoto(ctx, bikeID, reviewID, posterID, "new.jpg", "new_thumb.jpg", nil); !errors.Is(err, ErrTooManyReviewImages) {
+			t.Errorf("expected ErrTooManyReviewImages, got %v", err)
+		}
+	})
+
+	t.Run("not_owner", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT r.review_id FROM reviews r JOIN bikes b").
+			WithArgs(reviewID, bikeID, posterID).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		store := NewStore(db)
+		if _, err := store.AddReviewPhoto(ctx, bikeID, reviewID, posterID, "new.jpg", "new_thumb.jpg", nil); !errors.Is(err, ErrReviewNotFound) {
+			t.Errorf("expected error %v, got %v", ErrReviewNotFound, err)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdateReviewImages(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	reviewID := int64(1)
+	posterID := int64(2)
+	bikeID := "0101"
+	kept := int64(11)
+	caption := "brake"
+
+	t.Run("reorders_adds_and_removes", func(t *testing.T) {
+		images := []ReviewImageInput{
+			{URL: "https://example.com/new.jpg"},
+			{ImageID: &kept, Caption: &caption},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT r.bike_numerical_id FROM reviews r JOIN bikes b ON b.numerical_id = r.bike_numerical_id AND b.deleted_ts IS NULL").
+			WithArgs(reviewID, posterID).
+			WillReturnRows(sqlmock.NewRows([]string{"bike_numerical_id"}).AddRow(bikeID))
+		mock.ExpectQuery("SELECT to_jsonb\\(r\\)").
+			WithArgs(reviewID).
+			WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{}`))
+		mock.ExpectExec("UPDATE reviews SET comment").
+			WithArgs(nil, reviewID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT image_id, image_key, thumb_key FROM review_images WHERE review_id = \\$1").
+			WithArgs(reviewID).
+			WillReturnRows(sqlmock.NewRows([]string{"image_id", "image_key", "thumb_key"}).
+				AddRow(10, "a.jpg", "a_thumb.jpg").
+				AddRow(kept, nil, nil).
+				AddRow(12, nil, nil))
+		mock.ExpectExec("DELETE FROM review_images WHERE image_id = \\$1").
+			WithArgs(10).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM review_images WHERE image_id = \\$1").
+			WithArgs(12).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO review_images").
+			WithArgs(reviewID, 0, "https://example.com/new.jpg", nil).
+			WillReturnResult(sqlmock.NewResult(13, 1))
+		mock.ExpectExec("UPDATE review_images SET position = \\$1, caption = \\$2 WHERE image_id = \\$3").
+			WithArgs(1, caption, kept).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM rating_aggregates").
+			WithArgs(bikeID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO rating_aggregates").
+			WithArgs(bikeID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT to_jsonb\\(r\\)").
+			WithArgs(reviewID).
+			WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{}`))
+		mock.ExpectExec("INSERT INTO audit_events").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		store := NewStore(db)
+		removed, err := store.UpdateReviewWithRatings(ctx, UpdateReviewInput{
+			ReviewID: reviewID,
+			PosterID: posterID,
+			Images:   &images,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if strings.Join(removed, ",") != "a.jpg,a_thumb.jpg" {
+			t.Errorf("expected removed blob keys, got %v", removed)
+		}
+	})
+
+	t.Run("foreign_image_id", func(t *testing.T) {
+		other := int64(99)
+		images := []ReviewImageInput{{ImageID: &other}}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT r.bike_numerical_id FROM reviews r JOIN bikes b ON b.numerical_id = r.bike_numerical_id AND b.deleted_ts IS NULL").
+			WithArgs(reviewID, posterID).
+			WillReturnRows(sqlmock.NewRows([]string{"bike_numerical_id"}).AddRow(bikeID))
+		mock.ExpectQuery("SELECT to_jsonb\\(r\\)").
+			WithArgs(reviewID).
+			WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{}`))
+		mock.ExpectExec("UPDATE reviews SET comment").
+			WithArgs(nil, reviewID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT image_id, image_key, thumb_key FROM review_images").
+			WithArgs(reviewID).
+			WillReturnRows(sqlmock.NewRows([]string{"image_id", "image_key", "thumb_key"}).AddRow(kept, nil, nil))
+		mock.ExpectRollback()
+
+		store := NewStore(db)
+		_, err := store.UpdateReviewWithRatings(ctx, UpdateReviewInput{
+			ReviewID: reviewID,
+			PosterID: posterID,
+			Images:   &images,
+		})
+		if !errors.Is(err, ErrInvalidReviewImage) {
+			t.Errorf("expected ErrInvalidReviewImage, got %v", err)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestValidateReviewImages(t *testing.T) {
+	id := int64(1)
+	long := strings.Repeat("x", MaxImageCaptionLen+1)
+
+	cases := []struct {
+		name          string
+		images        []ReviewImageInput
+		allowExisting bool
+		want          error
+	}{
+		{"link", []ReviewImageInput{{URL: "https://example.com/a.jpg"}}, false, nil},
+		{"not_http", []ReviewImageInput{{URL: "javascript:alert(1)"}}, false, ErrInvalidReviewImage},
+		{"relative", []ReviewImageInput{{URL: "/images/a.jpg"}}, false, ErrInvalidReviewImage},
+		{"existing_on_create", []ReviewImageInput{{ImageID: &id}}, false, ErrInvalidReviewImage},
+		{"existing_on_update", []ReviewImageInput{{ImageID: &id}}, true, nil},
+		{"id_and_url", []ReviewImageInput{{ImageID: &id, URL: "https://example.com/a.jpg"}}, true, ErrInvalidReviewImage},
+		{"long_caption", []ReviewImageInput{{URL: "https://example.com/a.jpg", Caption: &long}}, false, ErrInvalidImageCaption},
+		{"too_many", make([]ReviewImageInput, MaxReviewImages+1), false, ErrTooManyReviewImages},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := validateReviewImages(tc.images, tc.allowExisting); !errors.Is(err, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
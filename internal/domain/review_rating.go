@@ -16,3 +16,14 @@ type ReviewRating struct {
 	Subcategory RatingSubcategory `db:"subcategory"` // PK
 	Score       int16             `db:"score"`       // 1–5
 }
+
+// RatingSubcategories lists every subcategory in the order listings and
+// exports show them.
+var RatingSubcategories = []RatingSubcategory{
+	RatingSubcategoryOverall,
+	RatingSubcategoryBreaks,
+	RatingSubcategorySeat,
+	RatingSubcategorySturdiness,
+	RatingSubcategoryPower,
+	RatingSubcategoryPedals,
+}
@@ -3,6 +3,8 @@ package domain
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -20,35 +22,44 @@ func TestCreateReviewWithRatings(t *testing.T) {
 	posterID := int64(1)
 	bikeID := "0101"
 	comment := "great bike"
-	bikeImg := "img.jpg"
+	imageURL := "https://example.com/img.jpg"
 	score := int16(5)
 
 	in := CreateReviewInput{
 		PosterID: posterID,
 		BikeID:   bikeID,
 		Comment:  &comment,
-		BikeImg:  &bikeImg,
+		Images:   []ReviewImageInput{{URL: imageURL}},
 		Overall:  &score,
 	}
 
 	t.Run("success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT pg_advisory_xact_lock").
+			WithArgs(lockReviewQuota, posterID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT numerical_id FROM bikes WHERE numerical_id = \\$1 AND deleted_ts IS NULL FOR SHARE").
+			WithArgs(bikeID).
+			WillReturnRows(sqlmock.NewRows([]string{"numerical_id"}).AddRow(bikeID))
 		// New: Check global hourly limit
-		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM reviews").
-			WithArgs(posterID).
-			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
-
-		// Check last review time
-		mock.ExpectQuery("SELECT created_ts FROM reviews").
-			WithArgs(posterID, bikeID).
-			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\), MIN\\(created_ts\\) FROM reviews").
+			WithArgs(posterID, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"count", "min"}).AddRow(0, nil))
 
-		mock.ExpectBegin()
+		// Check the per-bike quota
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\), MIN\\(created_ts\\) FROM reviews WHERE poster_id = \\$1 AND bike_numerical_id").
+			WithArgs(posterID, bikeID, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"count", "min"}).AddRow(0, nil))
 
 		// Insert review
 		mock.ExpectQuery("INSERT INTO reviews").
-			WithArgs(posterID, bikeID, bikeImg, comment).
+			WithArgs(posterID, bikeID, comment).
 			WillReturnRows(sqlmock.NewRows([]string{"review_id"}).AddRow(1))
 
+		mock.ExpectExec("INSERT INTO review_images \\(review_id, position, url, caption\\)").
+			WithArgs(1, 0, imageURL, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
 		// Insert rating
 		mock.ExpectExec("INSERT INTO review_ratings").
 			WithArgs(1, RatingSubcategoryOverall, score).
@@ -62,6 +73,14 @@ func TestCreateReviewWithRatings(t *testing.T) {
 			WithArgs(bikeID).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 
+		// Audit the new review
+		mock.ExpectQuery("SELECT to_jsonb\\(r\\)").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"image"}).AddRow(`{"review_id":1}`))
+		mock.ExpectExec("INSERT INTO audit_events").
+			WithArgs(posterID, AuditReviewCreate, "review", "1", nil, `{"review_id":1}`, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
 		mock.ExpectCommit()
 
 		store := NewStore(db)
@@ -72,57 +91,136 @@ func TestCreateReviewWithRatings(t *testing.T) {
 		if id != 1 {
 			t.Errorf("expected review id 1, got %d", id)
 		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
 	})
 
 	t.Run("hourly_limit_exceeded", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT pg_advisory_xact_lock").
+			WithArgs(lockReviewQuota, posterID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT numerical_id FROM bikes WHERE numerical_id = \\$1 AND deleted_ts IS NULL FOR SHARE").
+			WithArgs(bikeID).
+			WillReturnRows(sqlmock.NewRows([]string{"numerical_id"}).AddRow(bikeID))
 		// Expect count >= 5
-		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM reviews").
-			WithArgs(posterID).
-			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\), MIN\\(created_ts\\) FROM reviews").
+			WithArgs(posterID, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"count", "min"}).AddRow(5, time.Now().Add(-30*time.Minute)))
 
 		store := NewStore(db)
 		_, err := store.CreateReviewWithRatings(ctx, in)
-		if err != ErrHourlyRateLimitExceeded {
+		var limited *RateLimitedError
+		if !errors.As(err, &limited) || !errors.Is(err, ErrHourlyRateLimitExceeded) {
 			t.Errorf("expected error %v, got %v", ErrHourlyRateLimitExceeded, err)
 		}
 	})
 
+	t.Run("deleted_bike", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT pg_advisory_xact_lock").
+			WithArgs(lockReviewQuota, posterID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT numerical_id FROM bikes WHERE numerical_id = \\$1 AND deleted_ts IS NULL").
+			WithArgs(bikeID).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		store := NewStore(db)
+		_, err := store.CreateReviewWithRatings(ctx, in)
+		if !errors.Is(err, ErrBikeNotFound) {
+			t.Errorf("expected error %v, got %v", ErrBikeNotFound, err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
 	t.Run("rate_limit_per_bike", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT pg_advisory_xact_lock").
+			WithArgs(lockReviewQuota, posterID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT numerical_id FROM bikes WHERE numerical_id = \\$1 AND deleted_ts IS NULL FOR SHARE").
+			WithArgs(bikeID).
+			WillReturnRows(sqlmock.NewRows([]string{"numerical_id"}).AddRow(bikeID))
 		// Hourly limit is fine
-		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM reviews").
-			WithArgs(posterID).
-			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\), MIN\\(created_ts\\) FROM reviews").
+			WithArgs(posterID, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"count", "min"}).AddRow(0, nil))
 
 		// Last review was recent (per bike)
-		mock.ExpectQuery("SELECT created_ts FROM reviews").
-			WithArgs(posterID, bikeID).
-			WillReturnRows(sqlmock.NewRows([]string{"created_ts"}).AddRow(time.Now()))
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\), MIN\\(created_ts\\) FROM reviews WHERE poster_id = \\$1 AND bike_numerical_id").
+			WithArgs(posterID, bikeID, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"count", "min"}).AddRow(1, time.Now()))
 
 		store := NewStore(db)
 		_, err := store.CreateReviewWithRatings(ctx, in)
-		if err != ErrTooFrequentReview {
+		if !errors.Is(err, ErrTooFrequentReview) {
 			t.Errorf("expected error %v, got %v", ErrTooFrequentReview, err)
 		}
 	})
 
+	t.Run("configured_quotas", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT pg_advisory_xact_lock").
+			WithArgs(lockReviewQuota, posterID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT numerical_id FROM bikes WHERE numerical_id = \\$1 AND deleted_ts IS NULL FOR SHARE").
+			WithArgs(bikeID).
+			WillReturnRows(sqlmock.NewRows([]string{"numerical_id"}).AddRow(bikeID))
+		// The overall quota is off, so only the per-bike one is counted
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\), MIN\\(created_ts\\) FROM reviews WHERE poster_id = \\$1 AND bike_numerical_id").
+			WithArgs(posterID, bikeID, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"count", "min"}).AddRow(3, time.Now().Add(-time.Hour)))
+
+		store := NewStore(db, WithQuotas(Quotas{
+			BikeReviews: Quota{Limit: 3, Window: 2 * time.Hour},
+		}))
+		_, err := store.CreateReviewWithRatings(ctx, in)
+		var limited *RateLimitedError
+		if !errors.As(err, &limited) || !errors.Is(err, ErrTooFrequentReview) {
+			t.Fatalf("expected error %v, got %v", ErrTooFrequentReview, err)
+		}
+		if limited.RetryAfter < 59*time.Minute || limited.RetryAfter > time.Hour {
+			t.Errorf("expected a retry after about an hour, got %v", limited.RetryAfter)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
 	t.Run("invalid_rating", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT pg_advisory_xact_lock").
+			WithArgs(lockReviewQuota, posterID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT numerical_id FROM bikes WHERE numerical_id = \\$1 AND deleted_ts IS NULL FOR SHARE").
+			WithArgs(bikeID).
+			WillReturnRows(sqlmock.NewRows([]string{"numerical_id"}).AddRow(bikeID))
 		// Hourly limit is fine
-		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM reviews").
-			WithArgs(posterID).
-			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\), MIN\\(created_ts\\) FROM reviews").
+			WithArgs(posterID, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"count", "min"}).AddRow(0, nil))
 
 		// Last review was long ago
-		mock.ExpectQuery("SELECT created_ts FROM reviews").
-			WithArgs(posterID, bikeID).
-			WillReturnRows(sqlmock.NewRows([]string{"created_ts"}).AddRow(time.Now().Add(-24 * time.Hour)))
-
-		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\), MIN\\(created_ts\\) FROM reviews WHERE poster_id = \\$1 AND bike_numerical_id").
+			WithArgs(posterID, bikeID, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"count", "min"}).AddRow(0, nil))
 
 		// Insert review
 		mock.ExpectQuery("INSERT INTO reviews").
-			WithArgs(posterID, bikeID, bikeImg, comment).
+			WithArgs(posterID, bikeID, comment).
 			WillReturnRows(sqlmock.NewRows([]string{"review_id"}).AddRow(1))
 
+		mock.ExpectExec("INSERT INTO review_images \\(review_id, position, url, caption\\)").
+			WithArgs(1, 0, imageURL, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
 		// Invalid score
 		invalidScore := int16(6)
 		inInvalid := in
@@ -130,12 +228,23 @@ func TestCreateReviewWithRatings(t *testing.T) {
 
 		store := NewStore(db)
 		_, err := store.CreateReviewWithRatings(ctx, inInvalid)
-		if err == nil {
-			t.Error("expected error for invalid rating, got nil")
+		if !errors.Is(err, ErrInvalidScore) {
+			t.Errorf("expected ErrInvalidScore, got %v", err)
 		}
 		// Expect rollback implicitly on error
 	})
 
+	t.Run("comment_too_long", func(t *testing.T) {
+		long := strings.Repeat("a", MaxCommentLen+1)
+		inLong := in
+		inLong.Comment = &long
+
+		store := NewStore(db)
+		_, err := store.CreateReviewWithRatings(ctx, inLong)
+		if !errors.Is(err, ErrCommentTooLong) {
+			t.Errorf("expected ErrCommentTooLong, got %v", err)
+		}
+	})
 }
 
 func TestUpdateReviewWithRatings(t *testing.T) {
@@ -163,13 +272,17 @@ func TestUpdateReviewWithRatings(t *testing.T) {
 		mock.ExpectBegin()
 
 		// Check ownership
-		mock.ExpectQuery("SELECT bike_numerical_id FROM reviews").
+		mock.ExpectQuery("SELECT r.bike_numerical_id FROM reviews r JOIN bikes b ON b.numerical_id = r.bike_numerical_id AND b.deleted_ts IS NULL").
 			WithArgs(reviewID, posterID).
 			WillReturnRows(sqlmock.NewRows([]string{"bike_numerical_id"}).AddRow(bikeID))
 
+		mock.ExpectQuery("SELECT to_jsonb\\(r\\) .* FROM reviews r WHERE r.review_id = \\$1 FOR UPDATE OF r").
+			WithArgs(reviewID).
+			WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{"comment":"old"}`))
+
 		// Update review
 		mock.ExpectExec("UPDATE reviews").
-			WithArgs(comment, sqlmock.AnyArg(), reviewID).
+			WithArgs(comment, reviewID).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 
 		// Update rating
@@ -185,10 +298,18 @@ func TestUpdateReviewWithRatings(t *testing.T) {
 			WithArgs(bikeID).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 
+		// Audit with before and after images
+		mock.ExpectQuery("SELECT to_jsonb\\(r\\)").
+			WithArgs(reviewID).
+			WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{"comment":"updated comment"}`))
+		mock.ExpectExec("INSERT INTO audit_events").
+			WithArgs(posterID, AuditReviewUpdate, "review", "1", `{"comment":"old"}`, `{"comment":"updated comment"}`, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
 		mock.ExpectCommit()
 
 		store := NewStore(db)
-		err := store.UpdateReviewWithRatings(ctx, in)
+		_, err := store.UpdateReviewWithRatings(ctx, in)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -196,15 +317,15 @@ func TestUpdateReviewWithRatings(t *testing.T) {
 
 	t.Run("not_found", func(t *testing.T) {
 		mock.ExpectBegin()
-		mock.ExpectQuery("SELECT bike_numerical_id FROM reviews").
+		mock.ExpectQuery("SELECT r.bike_numerical_id FROM reviews r JOIN bikes b ON b.numerical_id = r.bike_numerical_id AND b.deleted_ts IS NULL").
 			WithArgs(reviewID, posterID).
 			WillReturnError(sql.ErrNoRows)
 		mock.ExpectRollback()
 
 		store := NewStore(db)
-		err := store.UpdateReviewWithRatings(ctx, in)
-		if err != sql.ErrNoRows {
-			t.Errorf("expected error %v, got %v", sql.ErrNoRows, err)
+		_, err := store.UpdateReviewWithRatings(ctx, in)
+		if !errors.Is(err, ErrReviewNotFound) {
+			t.Errorf("expected error %v, got %v", ErrReviewNotFound, err)
 		}
 	})
 
@@ -226,20 +347,24 @@ func TestDeleteReview(t *testing.T) {
 		mock.ExpectBegin()
 
 		// Check ownership
-		mock.ExpectQuery("SELECT bike_numerical_id FROM reviews").
+		mock.ExpectQuery("SELECT r.bike_numerical_id FROM reviews r JOIN bikes b ON b.numerical_id = r.bike_numerical_id AND b.deleted_ts IS NULL").
 			WithArgs(reviewID, posterID).
 			WillReturnRows(sqlmock.NewRows([]string{"bike_numerical_id"}).AddRow(bikeID))
 
-		// Delete ratings
-		mock.ExpectExec("DELETE FROM review_ratings").
+		mock.ExpectQuery("SELECT to_jsonb\\(r\\)").
 			WithArgs(reviewID).
-			WillReturnResult(sqlmock.NewResult(0, 1))
+			WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{"review_id":1}`))
 
-		// Delete review
-		mock.ExpectExec("DELETE FROM reviews").
+		// Soft delete; ratings are kept until the review is purged
+		mock.ExpectExec("UPDATE reviews SET deleted_ts = NOW\\(\\) WHERE review_id = \\$1").
 			WithArgs(reviewID).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 
+		// The request id travels on the context into the audit event
+		mock.ExpectExec("INSERT INTO audit_events").
+			WithArgs(posterID, AuditReviewDelete, "review", "1", `{"review_id":1}`, nil, "req-1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
 		// Recompute aggregates
 		mock.ExpectExec("DELETE FROM rating_aggregates").
 			WithArgs(bikeID).
@@ -251,7 +376,7 @@ func TestDeleteReview(t *testing.T) {
 		mock.ExpectCommit()
 
 		store := NewStore(db)
-		err := store.DeleteReview(ctx, reviewID, posterID)
+		err := store.DeleteReview(WithRequestID(ctx, "req-1"), reviewID, posterID)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -259,15 +384,15 @@ func TestDeleteReview(t *testing.T) {
 
 	t.Run("not_found", func(t *testing.T) {
 		mock.ExpectBegin()
-		mock.ExpectQuery("SELECT bike_numerical_id FROM reviews").
+		mock.ExpectQuery("SELECT r.bike_numerical_id FROM reviews r JOIN bikes b ON b.numerical_id = r.bike_numerical_id AND b.deleted_ts IS NULL").
 			WithArgs(reviewID, posterID).
 			WillReturnError(sql.ErrNoRows)
 		mock.ExpectRollback()
 
 		store := NewStore(db)
 		err := store.DeleteReview(ctx, reviewID, posterID)
-		if err != sql.ErrNoRows {
-			t.Errorf("expected error %v, got %v", sql.ErrNoRows, err)
+		if !errors.Is(err, ErrReviewNotFound) {
+			t.Errorf("expected error %v, got %v", ErrReviewNotFound, err)
 		}
 	})
 
@@ -285,16 +410,16 @@ func TestGetReviewWithRatingsByID(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		rows := sqlmock.NewRows([]string{
-			"review_id", "poster_id", "username", "bike_numerical_id", "comment", "created_ts", "subcategory", "score", "bike_img",
+			"review_id", "poster_id", "username", "bike_numerical_id", "comment", "created_ts", "subcategory", "score", "images",
 		}).
-			AddRow(reviewID, 1, "user1", "0101", "comment", time.Now(), "overall", 5, "img.jpg")
+			AddRow(reviewID, 1, "user1", "0101", "comment", time.Now(), "overall", 5, `[{"image_id":1,"position":0,"url":null,"image_key":"abc.jpg","thumb_key":"abc_thumb.jpg","caption":"seat"}]`)
 
-		mock.ExpectQuery("SELECT .* FROM reviews r LEFT JOIN posters p .* LEFT JOIN review_ratings rr .*").
-			WithArgs(reviewID).
+		mock.ExpectQuery("SELECT .* FROM reviews r JOIN bikes b ON b.numerical_id = r.bike_numerical_id AND b.deleted_ts IS NULL LEFT JOIN posters p .* LEFT JOIN review_ratings rr .*").
+			WithArgs(reviewID, false).
 			WillReturnRows(rows)
 
 		store := NewStore(db)
-		review, err := store.GetReviewWithRatingsByID(ctx, reviewID)
+		review, err := store.GetReviewWithRatingsByID(ctx, reviewID, false)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 			return
@@ -302,17 +427,140 @@ func TestGetReviewWithRatingsByID(t *testing.T) {
 		if review.ReviewID != reviewID {
 			t.Errorf("expected review id %d, got %d", reviewID, review.ReviewID)
 		}
+		if len(review.Images) != 1 || review.Images[0].URL != "/images/abc.jpg" ||
+			review.Images[0].ThumbURL == nil || *review.Images[0].ThumbURL != "/images/abc_thumb.jpg" {
+			t.Errorf("unexpected images %+v", review.Images)
+		}
 	})
 
 	t.Run("not_found", func(t *testing.T) {
-		mock.ExpectQuery("SELECT .* FROM reviews r LEFT JOIN posters p .* LEFT JOIN review_ratings rr .*").
-			WithArgs(reviewID).
+		mock.ExpectQuery("SELECT .* FROM reviews r JOIN bikes b ON b.numerical_id = r.bike_numerical_id AND b.deleted_ts IS NULL LEFT JOIN posters p .* LEFT JOIN review_ratings rr .*").
+			WithArgs(reviewID, false).
 			WillReturnError(sql.ErrNoRows)
 
 		store := NewStore(db)
-		_, err := store.GetReviewWithRatingsByID(ctx, reviewID)
+		_, err := store.GetReviewWithRatingsByID(ctx, reviewID, false)
 		if err != sql.ErrNoRows {
 			t.Errorf("expected error %v, got %v", sql.ErrNoRows, err)
 		}
 	})
+
+	t.Run("hidden_only_for_moderators", func(t *testing.T) {
+		columns := []string{"review_id", "poster_id", "username", "bike_numerical_id", "comment", "created_ts", "subcategory", "score", "images"}
+
+		// Without includeHidden the hidden review is filtered out.
+		mock.ExpectQuery("WHERE r.review_id = \\$1 AND r.deleted_ts IS NULL AND \\(\\$2 OR r.hidden_ts IS NULL\\)").
+			WithArgs(reviewID, false).
+			WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectQuery("WHERE r.review_id = \\$1 AND r.deleted_ts IS NULL AND \\(\\$2 OR r.hidden_ts IS NULL\\)").
+			WithArgs(reviewID, true).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(reviewID, 1, "user1", "0101", "spam", time.Now(), "overall", 1, "[]"))
+
+		store := NewStore(db)
+		if _, err := store.GetReviewWithRatingsByID(ctx, reviewID, false); err != ErrReviewNotFound {
+			t.Errorf("expected ErrReviewNotFound, got %v", err)
+		}
+		review, err := store.GetReviewWithRatingsByID(ctx, reviewID, true)
+		if err != nil || review.ReviewID != reviewID {
+			t.Errorf("expected hidden review for moderator, got %+v, %v", review, err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
+
+func TestListReviewsWithRatingsByBike(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	bikeID := "0101"
+	columns := []string{"review_id", "poster_id", "username", "bike_numerical_id", "comment", "created_ts", "subcategory", "score", "images"}
+	now := time.Now()
+
+	t.Run("keeps_query_order", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(3, 1, "user1", bikeID, "newest", now, "breaks", 2, `[]`).
+			AddRow(3, 1, "user1", bikeID, "newest", now, "overall", 4, `[]`).
+			AddRow(1, 2, "user2", bikeID, "oldest", now.Add(-time.Hour), nil, nil, `[]`)
+
+		mock.ExpectQuery("WITH p AS \\(.* WHERE r.bike_numerical_id = \\$1 AND r.hidden_ts IS NULL AND r.deleted_ts IS NULL ORDER BY r.created_ts DESC, r.review_id DESC LIMIT \\$2 \\) .* ORDER BY p.created_ts DESC, p.review_id DESC, rr.subcategory").
+			WithArgs(bikeID, DefaultPageLimit+1).
+			WillReturnRows(rows)
+
+		store := NewStore(db)
+		reviews, cursor, err := store.ListReviewsWithRatingsByBike(ctx, bikeID, ListReviewsQuery{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(reviews) != 2 || reviews[0].ReviewID != 3 || reviews[1].ReviewID != 1 {
+			t.Fatalf("expected reviews [3 1], got %+v", reviews)
+		}
+		if reviews[0].Ratings[RatingSubcategoryOverall] != 4 || len(reviews[0].Ratings) != 2 {
+			t.Errorf("unexpected ratings %v", reviews[0].Ratings)
+		}
+		if len(reviews[1].Ratings) != 0 {
+			t.Errorf("expected no ratings for unrated review, got %v", reviews[1].Ratings)
+		}
+		if cursor != "" {
+			t.Errorf("expected no next cursor, got %q", cursor)
+		}
+	})
+
+	t.Run("filter_and_next_page", func(t *testing.T) {
+		hasComment := true
+		rows := sqlmock.NewRows(columns).
+			AddRow(7, 1, "user1", bikeID, "great", now, "overall", 5, `[]`).
+			AddRow(6, 1, "user1", bikeID, "fine", now, "overall", 3, `[]`)
+
+		mock.ExpectQuery("WHERE r.bike_numerical_id = \\$1 AND r.hidden_ts IS NULL AND r.deleted_ts IS NULL AND \\(r.comment IS NOT NULL AND r.comment <> ''\\) ORDER BY COALESCE\\(ov.score, 0\\) DESC, r.review_id DESC LIMIT \\$2").
+			WithArgs(bikeID, 2).
+			WillReturnRows(rows)
+
+		store := NewStore(db)
+		reviews, cursor, err := store.ListReviewsWithRatingsByBike(ctx, bikeID, ListReviewsQuery{
+			Limit:      1,
+			HasComment: &hasComment,
+			Sort:       ReviewSortHighest,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(reviews) != 1 || reviews[0].ReviewID != 7 {
+			t.Fatalf("expected review 7, got %+v", reviews)
+		}
+
+		mock.ExpectQuery("AND \\(COALESCE\\(ov.score, 0\\), r.review_id\\) < \\(\\$2::smallint, \\$3::bigint\\)").
+			WithArgs(bikeID, "5", "7", 2).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		reviews, cursor, err = store.ListReviewsWithRatingsByBike(ctx, bikeID, ListReviewsQuery{
+			Cursor: cursor,
+			Limit:  1,
+			Sort:   ReviewSortHighest,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(reviews) != 0 || cursor != "" {
+			t.Errorf("expected empty last page, got %d reviews and cursor %q", len(reviews), cursor)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("invalid_cursor", func(t *testing.T) {
+		store := NewStore(db)
+		_, _, err := store.ListReviewsWithRatingsByBike(ctx, bikeID, ListReviewsQuery{Cursor: "%%%"})
+		if err != ErrInvalidCursor {
+			t.Errorf("expected ErrInvalidCursor, got %v", err)
+		}
+	})
 }
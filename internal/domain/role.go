@@ -0,0 +1,28 @@
+package domain
+
+// Role is a poster's privilege level. Every role includes the powers of the
+// ones below it: user < moderator < admin.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+var roleRank = map[Role]int{
+	RoleUser:      0,
+	RoleModerator: 1,
+	RoleAdmin:     2,
+}
+
+func ValidRole(r Role) bool {
+	_, ok := roleRank[r]
+	return ok
+}
+
+// AtLeast reports whether r grants everything min does. Unknown roles grant nothing.
+func (r Role) AtLeast(min Role) bool {
+	rank, ok := roleRank[r]
+	return ok && rank >= roleRank[min]
+}
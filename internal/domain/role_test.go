@@ -0,0 +1,25 @@
+package domain
+
+import "testing"
+
+func TestRoleAtLeast(t *testing.T) {
+	tests := []struct {
+		role, min Role
+		expected  bool
+	}{
+		{RoleUser, RoleUser, true},
+		{RoleUser, RoleModerator, false},
+		{RoleModerator, RoleModerator, true},
+		{RoleModerator, RoleAdmin, false},
+		{RoleAdmin, RoleModerator, true},
+		{Role("root"), RoleUser, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role)+">="+string(tt.min), func(t *testing.T) {
+			if got := tt.role.AtLeast(tt.min); got != tt.expected {
+				t.Errorf("%q.AtLeast(%q) = %v; want %v", tt.role, tt.min, got, tt.expected)
+			}
+		})
+	}
+}
@@ -7,20 +7,39 @@ import (
 
 type Service interface {
 	// Auth
-	Register(ctx context.Context, username, email string) (string, error)
-	CreateMagicLink(ctx context.Context, identifier string) (string, string, error)
+	Register(ctx context.Context, username, email, deviceLabel string) (string, error)
+	CreateMagicLink(ctx context.Context, identifier, deviceLabel string) (string, string, error)
 	ConfirmMagicLink(ctx context.Context, token string) (*ConfirmResult, error)
 	GetPosterByAPIToken(ctx context.Context, token string) (*AuthPoster, error)
-	CheckMagicLinkStatus(ctx context.Context, token string) (string, error)
-	DeletePoster(ctx context.Context, posterID int64, deleteContent bool) error
+	CheckMagicLinkStatus(ctx context.Context, token string) (*TokenPair, error)
+	DeletePoster(ctx context.Context, posterID int64, deleteContent bool) ([]string, error)
+	RequestEmailChange(ctx context.Context, posterID int64, newEmail string) (string, error)
+
+	// Session
+	RefreshSession(ctx context.Context, refreshToken string) (*TokenPair, error)
+	ListSessions(ctx context.Context, posterID int64) ([]Session, error)
+	RevokeSession(ctx context.Context, posterID, sessionID int64) error
+	RevokeAllSessions(ctx context.Context, posterID int64) (int64, error)
+
+	// Poster
+	GetPosterProfile(ctx context.Context, username string) (*PosterProfile, error)
+	ListReviewsWithRatingsByPoster(ctx context.Context, username string, q ListReviewsQuery) ([]ReviewWithRatings, string, error)
+	GetAccount(ctx context.Context, posterID, sessionID int64) (*Account, error)
+	UpdateUsername(ctx context.Context, posterID int64, username string) error
+	ExportPosterData(ctx context.Context, posterID int64) (*PosterExport, error)
+	CreateDataExport(ctx context.Context, posterID int64, blobKey, format string, sizeBytes int64) (string, error)
+	GetDataExport(ctx context.Context, token string) (*DataExport, error)
 
 	// Bike
-	ListBikes(ctx context.Context) ([]Bike, error)
+	ListBikes(ctx context.Context, q ListBikesQuery) ([]Bike, string, error)
 	CreateBike(ctx context.Context, numericalID string, hashID *string, isElectric bool, creatorID int64) (*Bike, error)
 	GetBike(ctx context.Context, id string) (*Bike, error)
 	GetBikeDetails(ctx context.Context, id string) (*BikeDetails, error)
-	UpdateBike(ctx context.Context, id string, hashID *string, isElectric *bool) error
-	DeleteBike(ctx context.Context, id string) error
+	UpdateBike(ctx context.Context, id string, hashID *string, isElectric *bool, actorID int64) error
+	DeleteBike(ctx context.Context, id string, actorID int64) error
+	RestoreBike(ctx context.Context, id string, actorID int64) error
+	ImportBikes(ctx context.Context, rows []BikeImportRow, creatorID int64, dryRun bool) (*BikeImportResult, error)
+	ExportBikes(ctx context.Context, fn func(BikeExport) error) error
 
 	// Rating Aggregate
 
@@ -28,17 +47,57 @@ type Service interface {
 
 	// Review
 
-	ListReviewsWithRatingsByBike(ctx context.Context, bikeID string) ([]ReviewWithRatings, error)
+	ListReviewsWithRatingsByBike(ctx context.Context, bikeID string, q ListReviewsQuery) ([]ReviewWithRatings, string, error)
 	CreateReviewWithRatings(ctx context.Context, in CreateReviewInput) (int64, error)
-	UpdateReviewWithRatings(ctx context.Context, in UpdateReviewInput) error
-	GetReviewWithRatingsByID(ctx context.Context, reviewID int64) (*ReviewWithRatings, error)
+	UpdateReviewWithRatings(ctx context.Context, in UpdateReviewInput) ([]string, error)
+	GetReviewWithRatingsByID(ctx context.Context, reviewID int64, includeHidden bool) (*ReviewWithRatings, error)
 	DeleteReview(ctx context.Context, reviewID int64, posterID int64) error
+	RestoreReview(ctx context.Context, reviewID, actorID int64) error
+	AddReviewPhoto(ctx context.Context, bikeID string, reviewID, posterID int64, imageKey, thumbKey string, caption *string) (*ReviewImage, error)
+
+	// Issues
+
+	ReportBikeIssue(ctx context.Context, bikeID string, reporterID int64, issueType IssueType, note *string) (int64, error)
+	ListBikeIssues(ctx context.Context, bikeID string, q ListIssuesQuery) ([]BikeIssue, string, error)
+	ConfirmBikeIssue(ctx context.Context, issueID, posterID int64, status ConfirmationStatus) (IssueStatus, error)
+
+	// Stations
+
+	ImportStations(ctx context.Context, stations []Station) (*StationImportResult, error)
+	GetStation(ctx context.Context, stationID string) (*Station, error)
+	RecordSighting(ctx context.Context, bikeID, stationID string, posterID int64) (int64, error)
+	ListStationsNear(ctx context.Context, near GeoQuery, limit int) ([]NearbyStation, error)
+
+	// Moderation
+
+	ReportReview(ctx context.Context, reviewID, reporterID int64, reason string) (int64, error)
+	ListOpenReports(ctx context.Context, q ListReportsQuery) ([]ReviewReport, string, error)
+	ModerateReview(ctx context.Context, reviewID, moderatorID int64, action ModerationAction) error
+
+	// Audit
+
+	ListAuditEvents(ctx context.Context, q ListAuditEventsQuery) ([]AuditEvent, string, error)
 }
 
 type Store struct {
-	db *sql.DB
+	db     *sql.DB
+	quotas Quotas
+}
+
+// StoreOption configures optional parts of the store in NewStore.
+type StoreOption func(*Store)
+
+// WithQuotas replaces DefaultQuotas.
+func WithQuotas(q Quotas) StoreOption {
+	return func(s *Store) {
+		s.quotas = q
+	}
 }
 
-func NewStore(db *sql.DB) *Store {
-	return &Store{db: db}
+func NewStore(db *sql.DB, opts ...StoreOption) *Store {
+	s := &Store{db: db, quotas: DefaultQuotas()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
@@ -0,0 +1,256 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrSessionNotFound     = &NotFoundError{Code: "session_not_found", Message: "session not found"}
+	ErrInvalidRefreshToken = &UnauthorizedError{Code: "invalid_refresh_token", Message: "invalid or expired refresh token"}
+	ErrRefreshTokenReused  = &UnauthorizedError{Code: "refresh_token_reused", Message: "refresh token reused, session revoked"}
+)
+
+// accessTokenTTL is how long an access (api) token is accepted by the API.
+// Clients trade their refresh token for a new pair before it lapses.
+const accessTokenTTL = 15 * time.Minute
+
+// sessionTTL is how long a session survives without being refreshed. Every
+// refresh pushes it forward, so active devices stay logged in indefinitely.
+const sessionTTL = 2 * 30 * 24 * time.Hour
+
+// maxDeviceLabelLen caps labels, which usually come from a User-Agent.
+const maxDeviceLabelLen = 100
+
+// Session is one logged-in device of a poster.
+type Session struct {
+	SessionID   int64      `json:"session_id"`
+	DeviceLabel *string    `json:"device_label"`
+	CreatedAt   time.Time  `json:"created_ts"`
+	LastUsedAt  *time.Time `json:"last_used_ts"`
+	ExpiresAt   time.Time  `json:"expires_ts"`
+	Current     bool       `json:"current"`
+}
+
+// TokenPair is what a login or a refresh hands to the client: a short-lived
+// access token for the Authorization header and the refresh token that
+// replaces it. Each refresh token can be used once.
+type TokenPair struct {
+	APIToken              string    `json:"api_token"`
+	APITokenExpiresAt     time.Time `json:"api_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+func newTokenPair(now time.Time) (*TokenPair, error) {
+	access, err := randomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("generate api token: %w", err)
+	}
+	refresh, err := randomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("generate refresh token: %w", err)
+	}
+	return &TokenPair{
+		APIToken:              access,
+		APITokenExpiresAt:     now.Add(accessTokenTTL),
+		RefreshToken:          refresh,
+		RefreshTokenExpiresAt: now.Add(sessionTTL),
+	}, nil
+}
+
+// createSession starts a new session for the poster inside tx and returns
+// its first token pair.
+func createSession(ctx context.Context, tx *sql.Tx, posterID int64, deviceLabel string) (*TokenPair, error) {
+	pair, err := newTokenPair(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(deviceLabel) > maxDeviceLabelLen {
+		deviceLabel = deviceLabel[:maxDeviceLabelLen]
+	}
+
+	var sessionID int64
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO sessions (poster_id, access_token_hash, access_expires_ts, device_label, expires_ts)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING session_id
+	`, posterID, hashToken(pair.APIToken), pair.APITokenExpiresAt, nullIfEmpty(deviceLabel), pair.RefreshTokenExpiresAt).Scan(&sessionID); err != nil {
+		return nil, fmt.Errorf("insert session: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (token_hash, session_id)
+		VALUES ($1, $2)
+	`, hashToken(pair.RefreshToken), sessionID); err != nil {
+		return nil, fmt.Errorf("insert refresh token: %w", err)
+	}
+
+	return pair, nil
+}
+
+// RefreshSession rotates a refresh token: the presented one is retired and a
+// new pair is issued for the same session. Presenting a retired token means
+// it leaked, so the whole session (the token family) is revoked.
+func (s *Store) RefreshSession(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var sessionID int64
+	var rotated sql.NullTime
+	var expires time.Time
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT rt.session_id, rt.rotated_ts, s.expires_ts
+		FROM refresh_tokens rt
+		JOIN sessions s ON s.session_id = rt.session_id
+		WHERE rt.token_hash = $1
+		FOR UPDATE
+	`, hashToken(refreshToken)).Scan(&sessionID, &rotated, &expires)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, fmt.Errorf("load refresh token: %w", err)
+	}
+
+	if rotated.Valid {
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM sessions
+			WHERE session_id = $1
+		`, sessionID); err != nil {
+			return nil, fmt.Errorf("revoke session: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("commit tx: %w", err)
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	now := time.Now()
+	if now.After(expires) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET rotated_ts = NOW()
+		WHERE token_hash = $1
+	`, hashToken(refreshToken)); err != nil {
+		return nil, fmt.Errorf("rotate refresh token: %w", err)
+	}
+
+	pair, err := newTokenPair(now)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE sessions
+		SET access_token_hash = $1, access_expires_ts = $2, expires_ts = $3
+		WHERE session_id = $4
+	`, hashToken(pair.APIToken), pair.APITokenExpiresAt, pair.RefreshTokenExpiresAt, sessionID); err != nil {
+		return nil, fmt.Errorf("update session: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (token_hash, session_id)
+		VALUES ($1, $2)
+	`, hashToken(pair.RefreshToken), sessionID); err != nil {
+		return nil, fmt.Errorf("insert refresh token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+
+	return pair, nil
+}
+
+// touchSession records that a session was just used. Writes are skipped while
+// the previous one is under a minute old so busy clients don't hammer the row.
+func touchSession(ctx context.Context, db *sql.DB, sessionID int64) error {
+	if _, err := db.ExecContext(ctx, `
+		UPDATE sessions
+		SET last_used_ts = NOW()
+		WHERE session_id = $1
+		  AND (last_used_ts IS NULL OR last_used_ts < NOW() - INTERVAL '1 minute')
+	`, sessionID); err != nil {
+		return fmt.Errorf("touch session: %w", err)
+	}
+	return nil
+}
+
+func nullIfEmpty(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// ListSessions returns the poster's unexpired sessions, most recently created first.
+func (s *Store) ListSessions(ctx context.Context, posterID int64) ([]Session, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT session_id, device_label, created_ts, last_used_ts, expires_ts
+		FROM sessions
+		WHERE poster_id = $1 AND expires_ts > NOW()
+		ORDER BY created_ts DESC, session_id DESC
+	`, posterID)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Session
+	for rows.Next() {
+		var ss Session
+		var label sql.NullString
+		var lastUsed sql.NullTime
+		if err := rows.Scan(&ss.SessionID, &label, &ss.CreatedAt, &lastUsed, &ss.ExpiresAt); err != nil {
+			return nil, err
+		}
+		if label.Valid {
+			ss.DeviceLabel = &label.String
+		}
+		if lastUsed.Valid {
+			ss.LastUsedAt = &lastUsed.Time
+		}
+		out = append(out, ss)
+	}
+	return out, rows.Err()
+}
+
+// RevokeSession logs out a single session owned by the poster.
+func (s *Store) RevokeSession(ctx context.Context, posterID, sessionID int64) error {
+	res, err := s.db.ExecContext(ctx, `
+		DELETE FROM sessions
+		WHERE session_id = $1 AND poster_id = $2
+	`, sessionID, posterID)
+	if err != nil {
+		return fmt.Errorf("revoke session: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeAllSessions logs the poster out everywhere and returns how many
+// sessions were dropped.
+func (s *Store) RevokeAllSessions(ctx context.Context, posterID int64) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `
+		DELETE FROM sessions
+		WHERE poster_id = $1
+	`, posterID)
+	if err != nil {
+		return 0, fmt.Errorf("revoke sessions: %w", err)
+	}
+	return res.RowsAffected()
+}
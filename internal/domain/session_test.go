@@ -0,0 +1,198 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestListSessions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	posterID := int64(1)
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"session_id", "device_label", "created_ts", "last_used_ts", "expires_ts"}).
+			AddRow(2, "Pixel 8", time.Now(), time.Now(), time.Now().Add(time.Hour)).
+			AddRow(1, nil, time.Now(), nil, time.Now().Add(time.Hour))
+
+		mock.ExpectQuery("SELECT session_id, device_label, created_ts, last_used_ts, expires_ts FROM sessions WHERE poster_id = \\$1 AND expires_ts > NOW\\(\\)").
+			WithArgs(posterID).
+			WillReturnRows(rows)
+
+		store := NewStore(db)
+		sessions, err := store.ListSessions(ctx, posterID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(sessions) != 2 {
+			t.Fatalf("expected 2 sessions, got %d", len(sessions))
+		}
+		if sessions[0].DeviceLabel == nil || *sessions[0].DeviceLabel != "Pixel 8" {
+			t.Errorf("expected label Pixel 8, got %v", sessions[0].DeviceLabel)
+		}
+		if sessions[1].DeviceLabel != nil || sessions[1].LastUsedAt != nil {
+			t.Errorf("expected unlabeled, unused session, got %+v", sessions[1])
+		}
+	})
+}
+
+func TestRevokeSession(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	posterID := int64(1)
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec("DELETE FROM sessions WHERE session_id = \\$1 AND poster_id = \\$2").
+			WithArgs(int64(5), posterID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		store := NewStore(db)
+		if err := store.RevokeSession(ctx, posterID, 5); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("other_posters_session", func(t *testing.T) {
+		mock.ExpectExec("DELETE FROM sessions").
+			WithArgs(int64(6), posterID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		store := NewStore(db)
+		if err := store.RevokeSession(ctx, posterID, 6); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("expected ErrSessionNotFound, got %v", err)
+		}
+	})
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	posterID := int64(1)
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec("DELETE FROM sessions WHERE poster_id = \\$1").
+			WithArgs(posterID).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+
+		store := NewStore(db)
+		n, err := store.RevokeAllSessions(ctx, posterID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n != 3 {
+			t.Errorf("expected 3 sessions revoked, got %d", n)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
+
+func TestRefreshSession(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	refresh := "refresh_token"
+	columns := []string{"session_id", "rotated_ts", "expires_ts"}
+
+	t.Run("rotates", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT rt.session_id, rt.rotated_ts, s.expires_ts FROM refresh_tokens rt").
+			WithArgs(hashToken(refresh)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(7, nil, time.Now().Add(time.Hour)))
+		mock.ExpectExec("UPDATE refresh_tokens SET rotated_ts = NOW\\(\\)").
+			WithArgs(hashToken(refresh)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE sessions SET access_token_hash = \\$1, access_expires_ts = \\$2, expires_ts = \\$3").
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO refresh_tokens").
+			WithArgs(sqlmock.AnyArg(), 7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		store := NewStore(db)
+		pair, err := store.RefreshSession(ctx, refresh)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if pair.RefreshToken == refresh || pair.APIToken == "" {
+			t.Errorf("expected a fresh pair, got %+v", pair)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("reuse_revokes_family", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("FROM refresh_tokens rt").
+			WithArgs(hashToken(refresh)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(7, time.Now(), time.Now().Add(time.Hour)))
+		mock.ExpectExec("DELETE FROM sessions WHERE session_id = \\$1").
+			WithArgs(7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		store := NewStore(db)
+		if _, err := store.RefreshSession(ctx, refresh); !errors.Is(err, ErrRefreshTokenReused) {
+			t.Errorf("expected ErrRefreshTokenReused, got %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("FROM refresh_tokens rt").
+			WithArgs(hashToken("nope")).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		store := NewStore(db)
+		if _, err := store.RefreshSession(ctx, "nope"); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("expected ErrInvalidRefreshToken, got %v", err)
+		}
+	})
+
+	t.Run("session_expired", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("FROM refresh_tokens rt").
+			WithArgs(hashToken(refresh)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(7, nil, time.Now().Add(-time.Hour)))
+		mock.ExpectRollback()
+
+		store := NewStore(db)
+		if _, err := store.RefreshSession(ctx, refresh); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("expected ErrInvalidRefreshToken, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// RestoreBike undoes a soft delete. It returns ErrBikeNotFound unless the bike
+// exists and is deleted.
+func (s *Store) RestoreBike(ctx context.Context, id string, actorID int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := snapshot(ctx, tx, bikeSnapshotSQL, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrBikeNotFound
+		}
+		return fmt.Errorf("load bike: %w", err)
+	}
+
+	var after []byte
+	if err := tx.QueryRowContext(ctx, `
+		UPDATE bikes
+		SET deleted_ts = NULL
+		WHERE numerical_id = $1 AND deleted_ts IS NOT NULL
+		RETURNING to_jsonb(bikes)
+	`, id).Scan(&after); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrBikeNotFound
+		}
+		return fmt.Errorf("restore bike: %w", err)
+	}
+
+	if err := recordAudit(ctx, tx, actorID, AuditBikeRestore, "bike", id, before, after); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// RestoreReview undoes a soft delete and puts the review's ratings back into
+// its bike's aggregates. It returns ErrReviewNotFound unless the review exists
+// and is deleted.
+func (s *Store) RestoreReview(ctx context.Context, reviewID, actorID int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := snapshot(ctx, tx, reviewSnapshotSQL, reviewID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrReviewNotFound
+		}
+		return fmt.Errorf("load review: %w", err)
+	}
+
+	var bikeID string
+	if err := tx.QueryRowContext(ctx, `
+		UPDATE reviews
+		SET deleted_ts = NULL
+		WHERE review_id = $1 AND deleted_ts IS NOT NULL
+		RETURNING bike_numerical_id
+	`, reviewID).Scan(&bikeID); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrReviewNotFound
+		}
+		return fmt.Errorf("restore review: %w", err)
+	}
+
+	after, err := snapshot(ctx, tx, reviewSnapshotSQL, reviewID)
+	if err != nil {
+		return fmt.Errorf("snapshot review: %w", err)
+	}
+	if err := recordAudit(ctx, tx, actorID, AuditReviewRestore, "review", strconv.FormatInt(reviewID, 10), before, after); err != nil {
+		return err
+	}
+
+	if err := RecomputeAggregatesForBike(ctx, tx, bikeID); err != nil {
+		return fmt.Errorf("recompute aggregates: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// PurgeDeleted hard-deletes bikes and reviews that were soft deleted more than
+// retention ago. Purging a bike takes its reviews with it. Each purged row is
+// audited with the system as actor. The blob keys of the purged reviews'
+// photos are returned for the caller to delete.
+func (s *Store) PurgeDeleted(ctx context.Context, retention time.Duration) (bikes, reviews int64, blobKeys []string, err error) {
+	cutoff := time.Now().Add(-retention)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	blobKeys, err = deleteReviewImages(ctx, tx, `
+		SELECT review_id
+		FROM reviews
+		WHERE deleted_ts < $1
+			OR bike_numerical_id IN (SELECT numerical_id FROM bikes WHERE deleted_ts < $1)
+	`, cutoff)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	purgedReviews, err := purgeRows(ctx, tx, `
+		DELETE FROM reviews
+		WHERE deleted_ts < $1
+		RETURNING review_id::text, to_jsonb(reviews)
+	`, cutoff)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("purge reviews: %w", err)
+	}
+	for _, r := range purgedReviews {
+		if err := recordAudit(ctx, tx, auditSystem, AuditReviewPurge, "review", r.id, r.image, nil); err != nil {
+			return 0, 0, nil, err
+		}
+	}
+
+	purgedBikes, err := purgeRows(ctx, tx, `
+		DELETE FROM bikes
+		WHERE deleted_ts < $1
+		RETURNING numerical_id, to_jsonb(bikes)
+	`, cutoff)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("purge bikes: %w", err)
+	}
+	for _, b := range purgedBikes {
+		if err := recordAudit(ctx, tx, auditSystem, AuditBikePurge, "bike", b.id, b.image, nil); err != nil {
+			return 0, 0, nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return int64(len(purgedBikes)), int64(len(purgedReviews)), blobKeys, nil
+}
+
+type purgedRow struct {
+	id    string
+	image []byte
+}
+
+// purgeRows runs a DELETE returning the id and image of every removed row.
+// The rows are read in full so the transaction is free for the audit inserts.
+func purgeRows(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]purgedRow, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []purgedRow
+	for rows.Next() {
+		var r purgedRow
+		if err := rows.Scan(&r.id, &r.image); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
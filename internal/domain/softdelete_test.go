@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRestoreBike(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	store := NewStore(db)
+	id, actorID := "01", int64(1)
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT to_jsonb\\(b\\) FROM bikes b").
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{"deleted_ts":"x"}`))
+		mock.ExpectQuery("UPDATE bikes SET deleted_ts = NULL WHERE numerical_id = \\$1 AND deleted_ts IS NOT NULL RETURNING to_jsonb\\(bikes\\)").
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{"deleted_ts":null}`))
+		mock.ExpectExec("INSERT INTO audit_events").
+			WithArgs(actorID, AuditBikeRestore, "bike", id, `{"deleted_ts":"x"}`, `{"deleted_ts":null}`, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		if err := store.RestoreBike(ctx, id, actorID); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("not_deleted", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT to_jsonb\\(b\\) FROM bikes b").
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{"deleted_ts":null}`))
+		mock.ExpectQuery("UPDATE bikes SET deleted_ts = NULL").
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"img"}))
+		mock.ExpectRollback()
+
+		if err := store.RestoreBike(ctx, id, actorID); !errors.Is(err, ErrBikeNotFound) {
+			t.Errorf("expected ErrBikeNotFound, got %v", err)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRestoreReview(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	store := NewStore(db)
+	reviewID, actorID, bikeID := int64(7), int64(1), "0101"
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT to_jsonb\\(r\\)").
+		WithArgs(reviewID).
+		WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{"deleted_ts":"x"}`))
+	mock.ExpectQuery("UPDATE reviews SET deleted_ts = NULL WHERE review_id = \\$1 AND deleted_ts IS NOT NULL RETURNING bike_numerical_id").
+		WithArgs(reviewID).
+		WillReturnRows(sqlmock.NewRows([]string{"bike_numerical_id"}).AddRow(bikeID))
+	mock.ExpectQuery("SELECT to_jsonb\\(r\\)").
+		WithArgs(reviewID).
+		WillReturnRows(sqlmock.NewRows([]string{"img"}).AddRow(`{"deleted_ts":null}`))
+	mock.ExpectExec("INSERT INTO audit_events").
+		WithArgs(actorID, AuditReviewRestore, "review", "7", `{"deleted_ts":"x"}`, `{"deleted_ts":null}`, nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("DELETE FROM rating_aggregates").
+		WithArgs(bikeID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO rating_aggregates").
+		WithArgs(bikeID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := store.RestoreReview(ctx, reviewID, actorID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// cutoffNear matches a purge cutoff within a second of the expected time.
+type cutoffNear struct{ want time.Time }
+
+func (c cutoffNear) Match(v driver.Value) bool {
+	t, ok := v.(time.Time)
+	return ok && t.Sub(c.want).Abs() < time.Second
+}
+
+func TestPurgeDeleted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	store := NewStore(db)
+	retention := 30 * 24 * time.Hour
+	cutoff := cutoffNear{time.Now().Add(-retention)}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("DELETE FROM review_images WHERE review_id IN \\( SELECT review_id FROM reviews WHERE deleted_ts < \\$1 OR bike_numerical_id IN \\(SELECT numerical_id FROM bikes WHERE deleted_ts < \\$1\\) \\) RETURNING image_key, thumb_key").
+		WithArgs(cutoff).
+		WillReturnRows(sqlmock.NewRows([]string{"image_key", "thumb_key"}).AddRow("a.jpg", "a_thumb.jpg"))
+	mock.ExpectQuery("DELETE FROM reviews WHERE deleted_ts < \\$1 RETURNING review_id::text, to_jsonb\\(reviews\\)").
+		WithArgs(cutoff).
+		WillReturnRows(sqlmock.NewRows([]string{"review_id", "image"}).
+			AddRow("3", `{"review_id":3}`).
+			AddRow("4", `{"review_id":4}`))
+	for _, id := range []string{"3", "4"} {
+		mock.ExpectExec("INSERT INTO audit_events").
+			WithArgs(nil, AuditReviewPurge, "review", id, `{"review_id":`+id+`}`, nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mock.ExpectQuery("DELETE FROM bikes WHERE deleted_ts < \\$1 RETURNING numerical_id, to_jsonb\\(bikes\\)").
+		WithArgs(cutoff).
+		WillReturnRows(sqlmock.NewRows([]string{"numerical_id", "image"}).AddRow("0101", `{"numerical_id":"0101"}`))
+	mock.ExpectExec("INSERT INTO audit_events").
+		WithArgs(nil, AuditBikePurge, "bike", "0101", `{"numerical_id":"0101"}`, nil, nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	bikes, reviews, keys, err := store.PurgeDeleted(ctx, retention)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keys) != 2 || keys[0] != "a.jpg" || keys[1] != "a_thumb.jpg" {
+		t.Errorf("expected the purged photo's blob keys, got %v", keys)
+	}
+	if bikes != 1 || reviews != 2 {
+		t.Errorf("expected 1 bike and 2 reviews purged, got %d and %d", bikes, reviews)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,308 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+var (
+	ErrStationNotFound     = &NotFoundError{Code: "station_not_found", Message: "station not found"}
+	ErrInvalidStation      = &ValidationError{Code: "invalid_station", Message: "stations need an id of at most 64 characters, a name, a valid lat/lon and a non-negative capacity"}
+	ErrUnknownStation      = &ValidationError{Code: "unknown_station", Message: "unknown station"}
+	ErrInvalidAvailability = &ValidationError{Code: "invalid_availability", Message: "availability needs a station id, non-negative counts and a report time"}
+)
+
+const (
+	// MaxStationIDLen is in bytes, MaxStationNameLen in characters.
+	MaxStationIDLen   = 64
+	MaxStationNameLen = 200
+)
+
+// Station is a docking station bikes can be sighted at.
+type Station struct {
+	StationID string    `json:"station_id"`
+	Name      string    `json:"name"`
+	Lat       float64   `json:"lat"`
+	Lon       float64   `json:"lon"`
+	Capacity  *int32    `json:"capacity"`
+	CreatedAt time.Time `json:"created_ts"`
+	UpdatedAt time.Time `json:"updated_ts"`
+}
+
+// StationImportResult counts what an import did to the stations table.
+type StationImportResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+}
+
+func validStationID(id string) bool {
+	return id != "" && len(id) <= MaxStationIDLen && strings.TrimSpace(id) == id
+}
+
+func validateStation(st Station) error {
+	name := strings.TrimSpace(st.Name)
+	switch {
+	case !validStationID(st.StationID),
+		name == "" || len([]rune(name)) > MaxStationNameLen,
+		math.IsNaN(st.Lat) || st.Lat < -90 || st.Lat > 90,
+		math.IsNaN(st.Lon) || st.Lon < -180 || st.Lon > 180,
+		st.Capacity != nil && *st.Capacity < 0:
+		return ErrInvalidStation
+	}
+	return nil
+}
+
+// StationAvailability is how many bikes and free docks a station had when it
+// last reported to the operator.
+type StationAvailability struct {
+	StationID      string `json:"station_id"`
+	BikesAvailable int32  `json:"bikes_available"`
+	// EbikesAvailable is nil when the feed doesn't split bikes by type.
+	EbikesAvailable *int32    `json:"ebikes_available"`
+	DocksAvailable  *int32    `json:"docks_available"`
+	IsRenting       bool      `json:"is_renting"`
+	IsReturning     bool      `json:"is_returning"`
+	ReportedAt      time.Time `json:"reported_ts"`
+}
+
+// StationFeedResult counts what a feed import did.
+type StationFeedResult struct {
+	StationImportResult
+	// Snapshots is how many availability rows were recorded. Reports for
+	// unknown stations, or ones already recorded, are skipped.
+	Snapshots int `json:"snapshots"`
+}
+
+func validateAvailability(a StationAvailability) error {
+	nonNegative := func(n *int32) bool { return n == nil || *n >= 0 }
+	if !validStationID(a.StationID) || a.BikesAvailable < 0 ||
+		!nonNegative(a.EbikesAvailable) || !nonNegative(a.DocksAvailable) || a.ReportedAt.IsZero() {
+		return ErrInvalidAvailability
+	}
+	return nil
+}
+
+// ImportStations upserts stations by id in one transaction: either every
+// station is written or none is. Validation errors name the offending entry.
+func (s *Store) ImportStations(ctx context.Context, stations []Station) (*StationImportResult, error) {
+	res, err := s.ImportStationFeed(ctx, stations, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &res.StationImportResult, nil
+}
+
+// ImportStationFeed is ImportStations plus an availability snapshot per
+// station, all in one transaction.
+func (s *Store) ImportStationFeed(ctx context.Context, stations []Station, availability []StationAvailability) (*StationFeedResult, error) {
+	for i, st := range stations {
+		if validateStation(st) != nil {
+			return nil, ErrInvalidStation.At(fmt.Sprintf("stations[%d]", i))
+		}
+	}
+	for i, a := range availability {
+		if validateAvailability(a) != nil {
+			return nil, ErrInvalidAvailability.At(fmt.Sprintf("availability[%d]", i))
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	imported, err := upsertStations(ctx, tx, stations)
+	if err != nil {
+		return nil, err
+	}
+	res := StationFeedResult{StationImportResult: *imported}
+
+	for _, a := range availability {
+		r, err := tx.ExecContext(ctx, `
+			INSERT INTO station_availability
+				(station_id, bikes_available, ebikes_available, docks_available, is_renting, is_returning, reported_ts)
+			SELECT station_id, $2, $3, $4, $5, $6, $7
+			FROM stations
+			WHERE station_id = $1
+			ON CONFLICT (station_id, reported_ts) DO NOTHING
+		`, a.StationID, a.BikesAvailable, a.EbikesAvailable, a.DocksAvailable, a.IsRenting, a.IsReturning, a.ReportedAt)
+		if err != nil {
+			return nil, fmt.Errorf("insert availability %s: %w", a.StationID, err)
+		}
+		n, err := r.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("insert availability %s: %w", a.StationID, err)
+		}
+		res.Snapshots += int(n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit tx: %w", err)
+	}
+	return &res, nil
+}
+
+func upsertStations(ctx context.Context, tx *sql.Tx, stations []Station) (*StationImportResult, error) {
+	var res StationImportResult
+	for _, st := range stations {
+		// xmax is only zero on a freshly inserted row
+		var inserted bool
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO stations (station_id, name, lat, lon, capacity)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (station_id) DO UPDATE
+			SET name = EXCLUDED.name,
+				lat = EXCLUDED.lat,
+				lon = EXCLUDED.lon,
+				capacity = EXCLUDED.capacity,
+				updated_ts = NOW()
+			RETURNING xmax = 0
+		`, st.StationID, strings.TrimSpace(st.Name), st.Lat, st.Lon, st.Capacity).Scan(&inserted); err != nil {
+			return nil, fmt.Errorf("upsert station %s: %w", st.StationID, err)
+		}
+		if inserted {
+			res.Created++
+		} else {
+			res.Updated++
+		}
+	}
+	return &res, nil
+}
+
+func (s *Store) GetStation(ctx context.Context, stationID string) (*Station, error) {
+	var st Station
+	err := s.db.QueryRowContext(ctx, `
+		SELECT station_id, name, lat, lon, capacity, created_ts, updated_ts
+		FROM stations
+		WHERE station_id = $1
+	`, stationID).Scan(&st.StationID, &st.Name, &st.Lat, &st.Lon, &st.Capacity, &st.CreatedAt, &st.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrStationNotFound
+		}
+		return nil, fmt.Errorf("get station: %w", err)
+	}
+	return &st, nil
+}
+
+// RecordSighting notes that posterID saw a bike at a station just now.
+func (s *Store) RecordSighting(ctx context.Context, bikeID, stationID string, posterID int64) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM bikes WHERE numerical_id = $1 AND deleted_ts IS NULL)
+	`, bikeID).Scan(&exists); err != nil {
+		return 0, fmt.Errorf("check bike: %w", err)
+	}
+	if !exists {
+		return 0, ErrBikeNotFound
+	}
+
+	sightingID, err := recordSighting(ctx, tx, bikeID, stationID, posterID, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit tx: %w", err)
+	}
+	return sightingID, nil
+}
+
+// recordSighting stores a sighting and makes it the bike's last known
+// location. An unknown station yields ErrUnknownStation.
+func recordSighting(ctx context.Context, tx *sql.Tx, bikeID, stationID string, posterID int64, reviewID *int64) (int64, error) {
+	if !validStationID(stationID) {
+		return 0, ErrUnknownStation
+	}
+
+	var sightingID int64
+	var seenAt time.Time
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO bike_sightings (bike_numerical_id, station_id, poster_id, review_id)
+		SELECT $1, station_id, $3, $4
+		FROM stations
+		WHERE station_id = $2
+		RETURNING sighting_id, seen_ts
+	`, bikeID, stationID, posterID, reviewID).Scan(&sightingID, &seenAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrUnknownStation
+		}
+		return 0, fmt.Errorf("insert sighting: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE bikes
+		SET last_station_id = $2, last_seen_ts = $3
+		WHERE numerical_id = $1 AND (last_seen_ts IS NULL OR last_seen_ts <= $3)
+	`, bikeID, stationID, seenAt); err != nil {
+		return 0, fmt.Errorf("update last sighting: %w", err)
+	}
+	return sightingID, nil
+}
+
+// NearbyStation is a station found by a nearby search.
+type NearbyStation struct {
+	Station
+	DistanceM float64 `json:"distance_m"`
+	// BikeCount is how many bikes were last seen there.
+	BikeCount int64 `json:"bike_count"`
+}
+
+// ListStationsNear returns up to limit stations within the circle, nearest
+// first.
+func (s *Store) ListStationsNear(ctx context.Context, near GeoQuery, limit int) ([]NearbyStation, error) {
+	if err := near.validate(); err != nil {
+		return nil, err
+	}
+	limit = clampLimit(limit)
+
+	box := near.box()
+	args := []any{near.Lat, near.Lon, near.RadiusM, limit, box.minLat, box.maxLat}
+	where := "st.lat BETWEEN $5 AND $6"
+	if !box.allLon {
+		args = append(args, box.minLon, box.maxLon)
+		where += " AND st.lon BETWEEN $7 AND $8"
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT station_id, name, lat, lon, capacity, created_ts, updated_ts, distance_m, bike_count
+		FROM (
+			SELECT
+				st.*,
+				%s AS distance_m,
+				(SELECT COUNT(*) FROM bikes b WHERE b.last_station_id = st.station_id AND b.deleted_ts IS NULL) AS bike_count
+			FROM stations st
+			WHERE %s
+		) sd
+		WHERE distance_m <= $3
+		ORDER BY distance_m ASC, station_id ASC
+		LIMIT $4
+	`, haversineSQL("st.lat", "st.lon", "$1", "$2"), where), args...)
+	if err != nil {
+		return nil, fmt.Errorf("list nearby stations: %w", err)
+	}
+	defer rows.Close()
+
+	var out []NearbyStation
+	for rows.Next() {
+		var ns NearbyStation
+		if err := rows.Scan(&ns.StationID, &ns.Name, &ns.Lat, &ns.Lon, &ns.Capacity, &ns.CreatedAt, &ns.UpdatedAt,
+			&ns.DistanceM, &ns.BikeCount); err != nil {
+			return nil, err
+		}
+		out = append(out, ns)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,311 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestImportStations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	capacity := int32(27)
+
+	t.Run("creates_and_updates", func(t *testing.T) {
+		stations := []Station{
+			{StationID: "1", Name: " Gran Via ", Lat: 41.3979, Lon: 2.1801, Capacity: &capacity},
+			{StationID: "2", Name: "Marina", Lat: 41.3948, Lon: 2.1889},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO stations \\(station_id, name, lat, lon, capacity\\) .* ON CONFLICT \\(station_id\\) DO UPDATE .* RETURNING xmax = 0").
+			WithArgs("1", "Gran Via", 41.3979, 2.1801, capacity).
+			WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(true))
+		mock.ExpectQuery("INSERT INTO stations").
+			WithArgs("2", "Marina", 41.3948, 2.1889, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(false))
+		mock.ExpectCommit()
+
+		store := NewStore(db)
+		res, err := store.ImportStations(ctx, stations)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if res.Created != 1 || res.Updated != 1 {
+			t.Errorf("expected 1 created and 1 updated, got %+v", res)
+		}
+	})
+
+	t.Run("invalid_entry_writes_nothing", func(t *testing.T) {
+		stations := []Station{
+			{StationID: "1", Name: "Gran Via", Lat: 41.3979, Lon: 2.1801},
+			{StationID: "2", Name: "Nowhere", Lat: 91, Lon: 2.1889},
+		}
+
+		store := NewStore(db)
+		_, err := store.ImportStations(ctx, stations)
+		if !errors.Is(err, ErrInvalidStation) || !strings.Contains(err.Error(), "stations[1]") {
+			t.Errorf("expected ErrInvalidStation for stations[1], got %v", err)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestImportStationFeed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	reported := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	ebikes := int32(2)
+	docks := int32(20)
+
+	t.Run("upserts_and_records_snapshots", func(t *testing.T) {
+		stations := []Station{{StationID: "1", Name: "Gran Via", Lat: 41.3979, Lon: 2.1801}}
+		availability := []StationAvailability{
+			{StationID: "1", BikesAvailable: 5, EbikesAvailable: &ebikes, DocksAvailable: &docks, IsRenting: true, IsReturning: true, ReportedAt: reported},
+			{StationID: "99", BikesAvailable: 1, ReportedAt: reported},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO stations").
+			WithArgs("1", "Gran Via", 41.3979, 2.1801, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(true))
+		mock.ExpectExec("INSERT INTO station_availability .* SELECT station_id, \\$2, \\$3, \\$4, \\$5, \\$6, \\$7 FROM stations WHERE station_id = \\$1 ON CONFLICT \\(station_id, reported_ts\\) DO NOTHING").
+			WithArgs("1", int32(5), ebikes, docks, true, true, reported).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		// station 99 isn't in the stations table
+		mock.ExpectExec("INSERT INTO station_availability").
+			WithArgs("99", int32(1), nil, nil, false, false, reported).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		store := NewStore(db)
+		res, err := store.ImportStationFeed(ctx, stations, availability)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if res.Created != 1 || res.Updated != 0 || res.Snapshots != 1 {
+			t.Errorf("unexpected result %+v", res)
+		}
+	})
+
+	t.Run("invalid_availability_writes_nothing", func(t *testing.T) {
+		negative := int32(-1)
+		availability := []StationAvailability{{StationID: "1", BikesAvailable: 1, DocksAvailable: &negative, ReportedAt: reported}}
+
+		store := NewStore(db)
+		_, err := store.ImportStationFeed(ctx, nil, availability)
+		if !errors.Is(err, ErrInvalidAvailability) || !strings.Contains(err.Error(), "availability[0]") {
+			t.Errorf("expected ErrInvalidAvailability for availability[0], got %v", err)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestValidateStation(t *testing.T) {
+	negative := int32(-1)
+	cases := []struct {
+		name string
+		st   Station
+		want error
+	}{
+		{"valid", Station{StationID: "1", Name: "A", Lat: 41.4, Lon: 2.2}, nil},
+		{"no_id", Station{Name: "A", Lat: 41.4, Lon: 2.2}, ErrInvalidStation},
+		{"blank_name", Station{StationID: "1", Name: "  ", Lat: 41.4, Lon: 2.2}, ErrInvalidStation},
+		{"bad_lon", Station{StationID: "1", Name: "A", Lat: 41.4, Lon: -181}, ErrInvalidStation},
+		{"nan_lat", Station{StationID: "1", Name: "A", Lat: math.NaN(), Lon: 2.2}, ErrInvalidStation},
+		{"negative_capacity", Station{StationID: "1", Name: "A", Lat: 41.4, Lon: 2.2, Capacity: &negative}, ErrInvalidStation},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := validateStation(tc.st); err != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestRecordSighting(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	bikeID := "0101"
+	stationID := "42"
+	posterID := int64(1)
+	seen := time.Now()
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM bikes WHERE numerical_id = \\$1 AND deleted_ts IS NULL\\)").
+			WithArgs(bikeID).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery("INSERT INTO bike_sightings \\(bike_numerical_id, station_id, poster_id, review_id\\) SELECT .* FROM stations WHERE station_id = \\$2 RETURNING sighting_id, seen_ts").
+			WithArgs(bikeID, stationID, posterID, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"sighting_id", "seen_ts"}).AddRow(3, seen))
+		mock.ExpectExec("UPDATE bikes SET last_station_id = \\$2, last_seen_ts = \\$3 WHERE numerical_id = \\$1").
+			WithArgs(bikeID, stationID, seen).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		store := NewStore(db)
+		id, err := store.RecordSighting(ctx, bikeID, stationID, posterID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if id != 3 {
+			t.Errorf("expected sighting 3, got %d", id)
+		}
+	})
+
+	t.Run("unknown_station", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT EXISTS").
+			WithArgs(bikeID).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery("INSERT INTO bike_sightings").
+			WithArgs(bikeID, "nope", posterID, nil).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		store := NewStore(db)
+		if _, err := store.RecordSighting(ctx, bikeID, "nope", posterID); !errors.Is(err, ErrUnknownStation) {
+			t.Errorf("expected ErrUnknownStation, got %v", err)
+		}
+	})
+
+	t.Run("bike_not_found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT EXISTS").
+			WithArgs(bikeID).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectRollback()
+
+		store := NewStore(db)
+		if _, err := store.RecordSighting(ctx, bikeID, stationID, posterID); !errors.Is(err, ErrBikeNotFound) {
+			t.Errorf("expected error %v, got %v", ErrBikeNotFound, err)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateReviewRecordsSighting(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	posterID := int64(1)
+	bikeID := "0101"
+	stationID := "42"
+	seen := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT pg_advisory_xact_lock").
+		WithArgs(lockReviewQuota, posterID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT numerical_id FROM bikes WHERE numerical_id = \\$1 AND deleted_ts IS NULL FOR SHARE").
+		WithArgs(bikeID).
+		WillReturnRows(sqlmock.NewRows([]string{"numerical_id"}).AddRow(bikeID))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\), MIN\\(created_ts\\) FROM reviews").
+		WithArgs(posterID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "min"}).AddRow(0, nil))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\), MIN\\(created_ts\\) FROM reviews WHERE poster_id = \\$1 AND bike_numerical_id").
+		WithArgs(posterID, bikeID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "min"}).AddRow(0, nil))
+	mock.ExpectQuery("INSERT INTO reviews").
+		WithArgs(posterID, bikeID, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"review_id"}).AddRow(7))
+	mock.ExpectQuery("INSERT INTO bike_sightings").
+		WithArgs(bikeID, stationID, posterID, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"sighting_id", "seen_ts"}).AddRow(3, seen))
+	mock.ExpectExec("UPDATE bikes SET last_station_id").
+		WithArgs(bikeID, stationID, seen).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM rating_aggregates").
+		WithArgs(bikeID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO rating_aggregates").
+		WithArgs(bikeID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT to_jsonb\\(r\\)").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"image"}).AddRow(`{"review_id":7}`))
+	mock.ExpectExec("INSERT INTO audit_events").
+		WithArgs(posterID, AuditReviewCreate, "review", "7", nil, `{"review_id":7}`, nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	store := NewStore(db)
+	if _, err := store.CreateReviewWithRatings(ctx, CreateReviewInput{
+		PosterID:  posterID,
+		BikeID:    bikeID,
+		StationID: &stationID,
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestListStationsNear(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	near := GeoQuery{Lat: 41.3874, Lon: 2.1686, RadiusM: 800}
+	box := near.box()
+
+	mock.ExpectQuery("FROM stations st WHERE st.lat BETWEEN \\$5 AND \\$6 AND st.lon BETWEEN \\$7 AND \\$8 \\) sd WHERE distance_m <= \\$3 ORDER BY distance_m ASC, station_id ASC LIMIT \\$4").
+		WithArgs(near.Lat, near.Lon, near.RadiusM, 10, box.minLat, box.maxLat, box.minLon, box.maxLon).
+		WillReturnRows(sqlmock.NewRows([]string{"station_id", "name", "lat", "lon", "capacity", "created_ts", "updated_ts", "distance_m", "bike_count"}).
+			AddRow("1", "Gran Via", 41.3879, 2.1699, 27, time.Now(), time.Now(), 120.5, 3).
+			AddRow("2", "Marina", 41.3900, 2.1750, nil, time.Now(), time.Now(), 610.0, 0))
+
+	store := NewStore(db)
+	stations, err := store.ListStationsNear(ctx, near, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stations) != 2 || stations[0].DistanceM != 120.5 || stations[0].BikeCount != 3 || stations[1].Capacity != nil {
+		t.Errorf("unexpected stations %+v", stations)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// hashToken is how api and magic link tokens are stored and looked up: only
+// the SHA-256 of a token ever reaches the database.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// handoverKey derives the key sealing an api token for the polling device.
+// It differs from hashToken so the stored link hash can't open the seal.
+func handoverKey(magicToken string) []byte {
+	sum := sha256.Sum256([]byte("handover:" + magicToken))
+	return sum[:]
+}
+
+// sealHandover encrypts apiToken so that only the holder of the plaintext
+// magic token (the device that requested the link) can recover it.
+func sealHandover(magicToken, apiToken string) (string, error) {
+	gcm, err := handoverCipher(magicToken)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	return hex.EncodeToString(gcm.Seal(nonce, nonce, []byte(apiToken), nil)), nil
+}
+
+func openHandover(magicToken, sealed string) (string, error) {
+	gcm, err := handoverCipher(magicToken)
+	if err != nil {
+		return "", err
+	}
+	b, err := hex.DecodeString(sealed)
+	if err != nil || len(b) < gcm.NonceSize() {
+		return "", errors.New("malformed sealed token")
+	}
+	plain, err := gcm.Open(nil, b[:gcm.NonceSize()], b[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("open sealed token: %w", err)
+	}
+	return string(plain), nil
+}
+
+func handoverCipher(magicToken string) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(handoverKey(magicToken))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "testing"
+
+func TestHashToken(t *testing.T) {
+	// echo -n abc | sha256sum
+	want := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if got := hashToken("abc"); got != want {
+		t.Errorf("hashToken(abc) = %s; want %s", got, want)
+	}
+}
+
+func TestSealHandover(t *testing.T) {
+	sealed, err := sealHandover("magic", "api")
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+
+	got, err := openHandover("magic", sealed)
+	if err != nil || got != "api" {
+		t.Errorf("expected api, got %q, %v", got, err)
+	}
+
+	// The stored hash of the magic token must not open the seal
+	if _, err := openHandover(hashToken("magic"), sealed); err == nil {
+		t.Error("expected open with the wrong key to fail")
+	}
+}
@@ -0,0 +1,268 @@
+// Package gbfs reads the station_information and station_status feeds of the
+// General Bikeshare Feed Specification, versions 2.x and 3.x.
+package gbfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scardozos/rottenbikes/internal/domain"
+)
+
+// maxFeedBytes bounds a feed; a large city's station_status is a few
+// megabytes.
+const maxFeedBytes = 32 << 20
+
+// Feed is what an import writes: the stations and, if a status feed was
+// given, their current availability.
+type Feed struct {
+	Stations     []domain.Station
+	Availability []domain.StationAvailability
+}
+
+// Load reads and parses the station_information feed at infoSrc and, unless
+// statusSrc is empty, the station_status feed at statusSrc. Each source is an
+// http(s) URL or a file path.
+func Load(ctx context.Context, client *http.Client, infoSrc, statusSrc string) (*Feed, error) {
+	var feed Feed
+
+	info, err := open(ctx, client, infoSrc)
+	if err != nil {
+		return nil, err
+	}
+	defer info.Close()
+	if feed.Stations, err = ParseStationInformation(info); err != nil {
+		return nil, fmt.Errorf("%s: %w", infoSrc, err)
+	}
+
+	if statusSrc == "" {
+		return &feed, nil
+	}
+	status, err := open(ctx, client, statusSrc)
+	if err != nil {
+		return nil, err
+	}
+	defer status.Close()
+	if feed.Availability, err = ParseStationStatus(status); err != nil {
+		return nil, fmt.Errorf("%s: %w", statusSrc, err)
+	}
+	return &feed, nil
+}
+
+func open(ctx context.Context, client *http.Client, src string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+		f, err := os.Open(src)
+		if err != nil {
+			return nil, fmt.Errorf("open feed: %w", err)
+		}
+		return f, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", src, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", src, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetch %s: status %d", src, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// envelope is the wrapper every GBFS file shares.
+type envelope[T any] struct {
+	LastUpdated timestamp `json:"last_updated"`
+	Version     string    `json:"version"`
+	Data        struct {
+		Stations []T `json:"stations"`
+	} `json:"data"`
+}
+
+func decode[T any](r io.Reader) (*envelope[T], error) {
+	var env envelope[T]
+	if err := json.NewDecoder(io.LimitReader(r, maxFeedBytes)).Decode(&env); err != nil {
+		return nil, fmt.Errorf("decode feed: %w", err)
+	}
+	return &env, nil
+}
+
+type stationInformation struct {
+	StationID stationID     `json:"station_id"`
+	Name      localizedText `json:"name"`
+	Lat       float64       `json:"lat"`
+	Lon       float64       `json:"lon"`
+	Capacity  *int32        `json:"capacity"`
+}
+
+// ParseStationInformation reads a station_information feed.
+func ParseStationInformation(r io.Reader) ([]domain.Station, error) {
+	env, err := decode[stationInformation](r)
+	if err != nil {
+		return nil, err
+	}
+
+	stations := make([]domain.Station, 0, len(env.Data.Stations))
+	for _, st := range env.Data.Stations {
+		stations = append(stations, domain.Station{
+			StationID: string(st.StationID),
+			Name:      string(st.Name),
+			Lat:       st.Lat,
+			Lon:       st.Lon,
+			Capacity:  st.Capacity,
+		})
+	}
+	return stations, nil
+}
+
+type stationStatus struct {
+	StationID stationID `json:"station_id"`
+	// num_bikes_available in 2.x, num_vehicles_available in 3.x
+	NumBikesAvailable    *int32 `json:"num_bikes_available"`
+	NumVehiclesAvailable *int32 `json:"num_vehicles_available"`
+	// Not part of the spec, but Bicing and other PBSC systems split bikes
+	// by type this way.
+	NumBikesAvailableTypes *struct {
+		Ebike *int32 `json:"ebike"`
+	} `json:"num_bikes_available_types"`
+	NumDocksAvailable *int32    `json:"num_docks_available"`
+	IsRenting         flag      `json:"is_renting"`
+	IsReturning       flag      `json:"is_returning"`
+	LastReported      timestamp `json:"last_reported"`
+}
+
+// ParseStationStatus reads a station_status feed. Stations that don't say
+// when they last reported get the feed's last_updated time.
+func ParseStationStatus(r io.Reader) ([]domain.StationAvailability, error) {
+	env, err := decode[stationStatus](r)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]domain.StationAvailability, 0, len(env.Data.Stations))
+	for i, st := range env.Data.Stations {
+		bikes := st.NumVehiclesAvailable
+		if bikes == nil {
+			bikes = st.NumBikesAvailable
+		}
+		if bikes == nil {
+			return nil, fmt.Errorf("stations[%d]: no bike count", i)
+		}
+
+		a := domain.StationAvailability{
+			StationID:      string(st.StationID),
+			BikesAvailable: *bikes,
+			DocksAvailable: st.NumDocksAvailable,
+			IsRenting:      bool(st.IsRenting),
+			IsReturning:    bool(st.IsReturning),
+			ReportedAt:     time.Time(st.LastReported),
+		}
+		if st.NumBikesAvailableTypes != nil {
+			a.EbikesAvailable = st.NumBikesAvailableTypes.Ebike
+		}
+		if a.ReportedAt.IsZero() {
+			a.ReportedAt = time.Time(env.LastUpdated)
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+// timestamp is a POSIX time in 2.x and an RFC 3339 string in 3.x.
+type timestamp time.Time
+
+func (t *timestamp) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	if bytes.HasPrefix(b, []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		parsed, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return err
+		}
+		*t = timestamp(parsed)
+		return nil
+	}
+	secs, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %s", b)
+	}
+	*t = timestamp(time.Unix(secs, 0).UTC())
+	return nil
+}
+
+// localizedText is a plain string in 2.x and a list of translations in 3.x,
+// of which the first is kept.
+type localizedText string
+
+func (l *localizedText) UnmarshalJSON(b []byte) error {
+	if bytes.HasPrefix(b, []byte(`[`)) {
+		var texts []struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(b, &texts); err != nil {
+			return err
+		}
+		if len(texts) > 0 {
+			*l = localizedText(texts[0].Text)
+		}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*l = localizedText(s)
+	return nil
+}
+
+// stationID is a string in the spec, but some feeds publish bare numbers.
+type stationID string
+
+func (id *stationID) UnmarshalJSON(b []byte) error {
+	if bytes.HasPrefix(b, []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*id = stationID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	*id = stationID(n.String())
+	return nil
+}
+
+// flag is a boolean, or 0/1 in older feeds.
+type flag bool
+
+func (f *flag) UnmarshalJSON(b []byte) error {
+	switch string(b) {
+	case "true", "1":
+		*f = true
+	case "false", "0", "null":
+		*f = false
+	default:
+		return errors.New("invalid boolean " + string(b))
+	}
+	return nil
+}
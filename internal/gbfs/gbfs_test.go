@@ -0,0 +1,119 @@
+package gbfs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadV2(t *testing.T) {
+	feed, err := Load(context.Background(), http.DefaultClient, "testdata/v2_station_information.json", "testdata/v2_station_status.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(feed.Stations) != 2 {
+		t.Fatalf("expected 2 stations, got %d", len(feed.Stations))
+	}
+	st := feed.Stations[0]
+	if st.StationID != "1" || st.Name != "GRAN VIA CORTS CATALANES, 760" || st.Lat != 41.3979779 || st.Lon != 2.1801069 ||
+		st.Capacity == nil || *st.Capacity != 46 {
+		t.Errorf("unexpected station %+v", st)
+	}
+
+	if len(feed.Availability) != 2 {
+		t.Fatalf("expected 2 statuses, got %d", len(feed.Availability))
+	}
+	a := feed.Availability[0]
+	if a.StationID != "1" || a.BikesAvailable != 12 || a.EbikesAvailable == nil || *a.EbikesAvailable != 3 ||
+		a.DocksAvailable == nil || *a.DocksAvailable != 33 || !a.IsRenting || !a.IsReturning ||
+		!a.ReportedAt.Equal(time.Unix(1740830385, 0)) {
+		t.Errorf("unexpected availability %+v", a)
+	}
+	// no last_reported falls back to the feed's last_updated
+	closed := feed.Availability[1]
+	if closed.IsRenting || closed.IsReturning || !closed.ReportedAt.Equal(time.Unix(1740830400, 0)) {
+		t.Errorf("unexpected availability %+v", closed)
+	}
+}
+
+func TestLoadV3(t *testing.T) {
+	feed, err := Load(context.Background(), http.DefaultClient, "testdata/v3_station_information.json", "testdata/v3_station_status.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(feed.Stations) != 2 {
+		t.Fatalf("expected 2 stations, got %d", len(feed.Stations))
+	}
+	if st := feed.Stations[0]; st.StationID != "pza-catalunya" || st.Name != "Plaça de Catalunya" {
+		t.Errorf("unexpected station %+v", st)
+	}
+	if st := feed.Stations[1]; st.Capacity != nil {
+		t.Errorf("expected no capacity, got %d", *st.Capacity)
+	}
+
+	if len(feed.Availability) != 2 {
+		t.Fatalf("expected 2 statuses, got %d", len(feed.Availability))
+	}
+	a := feed.Availability[0]
+	reported := time.Date(2025, 3, 1, 10, 59, 30, 0, time.UTC)
+	if a.BikesAvailable != 7 || a.EbikesAvailable != nil || !a.ReportedAt.Equal(reported) {
+		t.Errorf("unexpected availability %+v", a)
+	}
+	if a := feed.Availability[1]; a.DocksAvailable != nil || !a.IsRenting || a.IsReturning {
+		t.Errorf("unexpected availability %+v", a)
+	}
+}
+
+func TestLoadOverHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/station_information.json":
+			http.ServeFile(w, r, "testdata/v2_station_information.json")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	feed, err := Load(context.Background(), srv.Client(), srv.URL+"/station_information.json", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(feed.Stations) != 2 || feed.Availability != nil {
+		t.Errorf("unexpected feed %+v", feed)
+	}
+
+	_, err = Load(context.Background(), srv.Client(), srv.URL+"/station_information.json", srv.URL+"/station_status.json")
+	if err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("expected a 404 error, got %v", err)
+	}
+}
+
+func TestParseStationStatusErrors(t *testing.T) {
+	cases := map[string]string{
+		"no_bike_count": `{"last_updated": 1, "data": {"stations": [{"station_id": "1", "is_renting": true}]}}`,
+		"bad_flag":      `{"last_updated": 1, "data": {"stations": [{"station_id": "1", "num_bikes_available": 1, "is_renting": "yes"}]}}`,
+		"bad_timestamp": `{"last_updated": "yesterday", "data": {"stations": []}}`,
+	}
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseStationStatus(strings.NewReader(body)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	_, err := Load(context.Background(), http.DefaultClient, "testdata/nope.json", "")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a not-exist error, got %v", err)
+	}
+}
@@ -12,6 +12,7 @@ export const AuthProvider = ({ children }) => {
     const [userToken, setUserToken] = useState(null);
     const [userId, setUserId] = useState(null);
     const [username, setUsername] = useState(null);
+    const [role, setRole] = useState(null);
     const [lastUsername, setLastUsername] = useState(null);
     const { showToast } = useToast();
     const { t } = useContext(LanguageContext);
@@ -23,6 +24,7 @@ export const AuthProvider = ({ children }) => {
                 console.log('[AuthContext] Fetched current user:', res.data);
                 setUserId(res.data.poster_id);
                 setUsername(res.data.username);
+                setRole(res.data.role);
             }
         } catch (e) {
             console.log('[AuthContext] Failed to fetch current user:', e);
@@ -50,8 +52,8 @@ export const AuthProvider = ({ children }) => {
             return response.data.magic_token;
         } catch (e) {
             console.log('register error', e);
-            if (e.response && e.response.data && e.response.data.error) {
-                throw new Error(e.response.data.error);
+            if (e.response?.data?.error?.message) {
+                throw new Error(e.response.data.error.message);
             }
             throw e;
         }
@@ -79,8 +81,8 @@ export const AuthProvider = ({ children }) => {
             return response.data.magic_token;
         } catch (e) {
             console.log('requestLogin error', e);
-            if (e.response && e.response.data && e.response.data.error) {
-                throw new Error(e.response.data.error);
+            if (e.response?.data?.error?.message) {
+                throw new Error(e.response.data.error.message);
             }
             throw e;
         }
@@ -93,8 +95,8 @@ export const AuthProvider = ({ children }) => {
             showToast(t('confirmation_successful'), 'success');
         } catch (e) {
             console.log('confirmAttempt error', e);
-            if (e.response && e.response.data && e.response.data.error) {
-                throw new Error(e.response.data.error);
+            if (e.response?.data?.error?.message) {
+                throw new Error(e.response.data.error.message);
             }
             throw e;
         }
@@ -104,17 +106,18 @@ export const AuthProvider = ({ children }) => {
         try {
             console.log(`Exchanging magic token for API token...`);
             const response = await api.get(`/auth/confirm/${magicToken}`);
-            const { api_token } = response.data;
+            const { api_token, refresh_token } = response.data;
 
             console.log(`Login confirmed, storing API token.`);
             showToast(t('login_confirmed_success'), 'success');
             setUserToken(api_token);
             await storage.setItem('userToken', api_token);
+            await storage.setItem('refreshToken', refresh_token);
             fetchCurrentUser();
         } catch (e) {
             console.log('completeLogin error', e);
-            if (e.response && e.response.data && e.response.data.error) {
-                throw new Error(e.response.data.error);
+            if (e.response?.data?.error?.message) {
+                throw new Error(e.response.data.error.message);
             }
             throw e;
         }
@@ -123,11 +126,12 @@ export const AuthProvider = ({ children }) => {
     const checkLoginStatus = async (magicToken) => {
         try {
             const response = await api.get(`/auth/poll?token=${magicToken}`);
-            const { api_token } = response.data;
+            const { api_token, refresh_token } = response.data;
             if (api_token) {
                 showToast(t('login_confirmed_success'), 'success');
                 setUserToken(api_token);
                 await storage.setItem('userToken', api_token);
+                await storage.setItem('refreshToken', refresh_token);
                 fetchCurrentUser();
                 return true;
             }
@@ -144,7 +148,9 @@ export const AuthProvider = ({ children }) => {
         setUserToken(null);
         setUserId(null);
         setUsername(null);
+        setRole(null);
         await storage.deleteItem('userToken');
+        await storage.deleteItem('refreshToken');
     };
 
     const isLoggedIn = async () => {
@@ -177,6 +183,7 @@ export const AuthProvider = ({ children }) => {
             userToken,
             userId,
             username,
+            role,
             lastUsername
         }}>
             {children}
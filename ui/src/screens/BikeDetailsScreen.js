@@ -54,6 +54,8 @@ const BikeDetailsScreen = ({ route, navigation }) => {
     const [sortOrder, setSortOrder] = useState('desc'); // 'asc' | 'desc'
     const [timeWindow, setTimeWindow] = useState('2w'); // '1w', '2w', 'overall'
     const [isModalRendered, setIsModalRendered] = useState(false);
+    const [modalReviews, setModalReviews] = useState([]);
+    const [modalCursor, setModalCursor] = useState(null);
     const slideAnim = useRef(new Animated.Value(Dimensions.get('window').height)).current;
     const fadeAnim = useRef(new Animated.Value(0)).current;
 
@@ -122,7 +124,7 @@ const BikeDetailsScreen = ({ route, navigation }) => {
 
 
 
-    const { userId, userToken } = useContext(AuthContext);
+    const { userId, userToken, role } = useContext(AuthContext);
     const { validatedBikeId } = useSession();
     const { t } = useContext(LanguageContext);
     const { showToast } = useToast();
@@ -133,6 +135,8 @@ const BikeDetailsScreen = ({ route, navigation }) => {
     // Using string comparison to handle leading zeros
     console.log('[BikeDetails] ValidatedID:', validatedBikeId, 'CurrentBikeID:', bike.numerical_id);
     const isReviewAllowed = validatedBikeId != null && String(validatedBikeId) === String(bike.numerical_id);
+    // Mirrors the API: bikes are edited by their creator or a moderator
+    const canEditBike = (bike.creator_id != null && bike.creator_id === userId) || role === 'moderator' || role === 'admin';
 
     const fetchData = useCallback(async (currentId) => {
         setLoading(true);
@@ -185,7 +189,7 @@ const BikeDetailsScreen = ({ route, navigation }) => {
     const renderHeader = () => (
         <View>
             <Text style={styles.title}>{t('bike_title', { numerical_id: bike.numerical_id })}</Text>
-            {isReviewAllowed && (
+            {isReviewAllowed && canEditBike && (
                 <Text
                     style={styles.updateLink}
                     onPress={() => navigation.navigate('UpdateBike', { bikeId: bike.numerical_id })}
@@ -301,17 +305,34 @@ const BikeDetailsScreen = ({ route, navigation }) => {
         );
     };
 
-    const getSortedReviews = () => {
-        return [...reviews].sort((a, b) => {
-            let diff = 0;
-            if (sortBy === 'date') {
-                diff = new Date(a.created_at) - new Date(b.created_at);
-            } else {
-                diff = (a.ratings?.overall || 0) - (b.ratings?.overall || 0);
-            }
-            return sortOrder === 'asc' ? diff : -diff;
-        });
-    }
+    // The modal pages through GET /bikes/{id}/reviews, sorted server-side.
+    const reviewSortParam = () => {
+        if (sortBy === 'date') {
+            return sortOrder === 'asc' ? 'oldest' : 'newest';
+        }
+        return sortOrder === 'asc' ? 'lowest' : 'highest';
+    };
+
+    const fetchModalReviews = async (cursor) => {
+        try {
+            const res = await api.get(`/bikes/${bike.numerical_id}/reviews`, {
+                params: { sort: reviewSortParam(), cursor: cursor || undefined },
+            });
+            const page = res.data?.reviews || [];
+            setModalReviews(prev => cursor ? [...prev, ...page] : page);
+            setModalCursor(res.data?.next_cursor || null);
+        } catch (e) {
+            console.log("Failed to fetch reviews", e);
+        }
+    };
+
+    React.useEffect(() => {
+        if (isModalRendered) {
+            fetchModalReviews(null);
+        }
+    }, [isModalRendered, sortBy, sortOrder]);
+
+    const totalReviews = bike.review_count ?? reviews.length;
 
     // Default view shows only top 3, always sorted by date (newest)
     const previewReviews = [...reviews]
@@ -336,10 +357,10 @@ const BikeDetailsScreen = ({ route, navigation }) => {
                     ListFooterComponent={
                         reviews.length === 0 ? (
                             <Text style={styles.emptyText}>{t('no_reviews')}</Text>
-                        ) : reviews.length > 3 ? (
+                        ) : totalReviews > 3 ? (
                             <TouchableOpacity style={styles.seeAllButton} onPress={openModal}>
                                 <Text style={styles.seeAllText}>
-                                    {t('see_all_reviews', { count: reviews.length })}
+                                    {t('see_all_reviews', { count: totalReviews })}
                                 </Text>
                             </TouchableOpacity>
                         ) : null
@@ -417,7 +438,9 @@ const BikeDetailsScreen = ({ route, navigation }) => {
                         </View>
 
                         <FlatList
-                            data={getSortedReviews()}
+                            data={modalReviews}
+                            onEndReached={() => modalCursor && fetchModalReviews(modalCursor)}
+                            onEndReachedThreshold={0.5}
                             keyExtractor={item => item.review_id ? 'modal-' + item.review_id.toString() : Math.random().toString()}
                             renderItem={(props) => renderReviewItem(props, 'modal')}
                             contentContainerStyle={{ paddingBottom: 40 }}
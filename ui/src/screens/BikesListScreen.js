@@ -11,14 +11,17 @@ const BikesListScreen = ({ navigation }) => {
     const [filteredBikes, setFilteredBikes] = useState([]);
     const [searchQuery, setSearchQuery] = useState('');
     const [loading, setLoading] = useState(true);
+    const [nextCursor, setNextCursor] = useState(null);
+    const [loadingMore, setLoadingMore] = useState(false);
     const { theme } = useContext(ThemeContext);
     const { t } = useContext(LanguageContext);
 
     const fetchBikes = async () => {
         try {
             const bikesRes = await api.get('/bikes');
-            setBikes(bikesRes.data || []);
-            setFilteredBikes(bikesRes.data || []);
+            setBikes(bikesRes.data?.bikes || []);
+            setFilteredBikes(bikesRes.data?.bikes || []);
+            setNextCursor(bikesRes.data?.next_cursor || null);
         } catch (e) {
             console.error('Fetch bikes error:', e);
         } finally {
@@ -26,6 +29,20 @@ const BikesListScreen = ({ navigation }) => {
         }
     };
 
+    const fetchMoreBikes = async () => {
+        if (!nextCursor || loadingMore) return;
+        setLoadingMore(true);
+        try {
+            const bikesRes = await api.get('/bikes', { params: { cursor: nextCursor } });
+            setBikes(prev => [...prev, ...(bikesRes.data?.bikes || [])]);
+            setNextCursor(bikesRes.data?.next_cursor || null);
+        } catch (e) {
+            console.error('Fetch more bikes error:', e);
+        } finally {
+            setLoadingMore(false);
+        }
+    };
+
     useFocusEffect(
         useCallback(() => {
             fetchBikes();
@@ -99,6 +116,9 @@ const BikesListScreen = ({ navigation }) => {
                     data={filteredBikes}
                     keyExtractor={item => item.numerical_id.toString()}
                     renderItem={renderItem}
+                    onEndReached={fetchMoreBikes}
+                    onEndReachedThreshold={0.5}
+                    ListFooterComponent={loadingMore ? <ActivityIndicator color={theme.colors.primary} /> : null}
                     ListEmptyComponent={
                         <View style={styles.emptyContainer}>
                             <Text style={styles.emptyText}>
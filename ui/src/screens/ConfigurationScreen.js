@@ -1,4 +1,4 @@
-import React, { useContext, useState } from 'react';
+import React, { useContext, useEffect, useState } from 'react';
 import { View, ScrollView, Text, Switch, StyleSheet, Button, TouchableOpacity, Modal, TextInput, Alert, Platform } from 'react-native';
 import { ThemeContext } from '../context/ThemeContext';
 import { AuthContext } from '../context/AuthContext';
@@ -15,6 +15,13 @@ const ConfigurationScreen = ({ navigation }) => {
     const [isDeleting, setIsDeleting] = useState(false);
     const [deleteContent, setDeleteContent] = useState(false);
     const { showToast } = useToast();
+    const [account, setAccount] = useState(null);
+
+    useEffect(() => {
+        api.get('/me')
+            .then(res => setAccount(res.data))
+            .catch(error => console.error('Failed to load account:', error));
+    }, []);
 
     const styles = createStyles(theme);
 
@@ -80,6 +87,22 @@ const ConfigurationScreen = ({ navigation }) => {
 
             <View style={styles.section}>
                 <Text style={styles.sectionTitle}>{t('account')}</Text>
+                {account && (
+                    <>
+                        <View style={styles.row}>
+                            <Text style={styles.label}>{t('username')}</Text>
+                            <Text style={styles.label}>{account.username}</Text>
+                        </View>
+                        <View style={styles.row}>
+                            <Text style={styles.label}>{t('email')}</Text>
+                            <Text style={styles.label}>{account.email}</Text>
+                        </View>
+                        <View style={styles.row}>
+                            <Text style={styles.label}>{t('member_since')}</Text>
+                            <Text style={styles.label}>{new Date(account.created_ts).toLocaleDateString()}</Text>
+                        </View>
+                    </>
+                )}
                 <TouchableOpacity style={styles.infoButton} onPress={() => navigation.navigate('Privacy')}>
                     <Text style={styles.infoButtonText}>{t('privacy_and_terms_title')}</Text>
                 </TouchableOpacity>
@@ -58,7 +58,7 @@ const CreateBikeScreen = ({ route, navigation }) => {
         } catch (e) {
 
             console.error(e);
-            const errMsg = e.response?.data?.error || t('error');
+            const errMsg = e.response?.data?.error?.message || t('error');
             showToast(errMsg, "error");
         } finally {
             setLoading(false);
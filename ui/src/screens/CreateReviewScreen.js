@@ -114,7 +114,7 @@ const CreateReviewScreen = ({ route, navigation }) => {
             });
         } catch (e) {
             console.error(e);
-            const errMsg = e.response?.data?.error || t('failed_submit_review');
+            const errMsg = e.response?.data?.error?.message || t('failed_submit_review');
             showToast(errMsg, "error");
         } finally {
             setLoading(false);
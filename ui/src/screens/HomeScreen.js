@@ -166,9 +166,8 @@ const WebScannerLocal = ({ navigation, theme, validateBike, t }) => {
         // Delay for navigation safety
         setTimeout(async () => {
             try {
-                const response = await api.get('/bikes');
-                const bikes = response.data || [];
-                const bike = bikes.find(b => b.hash_id === data);
+                const response = await api.get('/bikes', { params: { hash_id: data, limit: 1 } });
+                const bike = response.data?.bikes?.[0];
 
                 if (bike) {
                     showToast(t('found_bike', { id: bike.numerical_id }), "success");
@@ -184,7 +183,7 @@ const WebScannerLocal = ({ navigation, theme, validateBike, t }) => {
                     }
                 }
             } catch (e) {
-                const errMsg = e.response?.data?.error || t('scan_lookup_failed');
+                const errMsg = e.response?.data?.error?.message || t('scan_lookup_failed');
                 showToast(errMsg, "error");
                 isScanning.current = false;
             }
@@ -269,9 +268,8 @@ const NativeScannerLocal = ({ navigation, theme, validateBike, t }) => {
         setScanned(true);
 
         try {
-            const response = await api.get('/bikes');
-            const bikes = response.data || [];
-            const bike = bikes.find(b => b.hash_id === data);
+            const response = await api.get('/bikes', { params: { hash_id: data, limit: 1 } });
+            const bike = response.data?.bikes?.[0];
 
             if (bike) {
                 showToast(t('found_bike', { id: bike.numerical_id }), "success");
@@ -302,7 +300,7 @@ const NativeScannerLocal = ({ navigation, theme, validateBike, t }) => {
             }
         } catch (e) {
             console.error("error during scan lookup", e);
-            const errMsg = e.response?.data?.error || t('scan_lookup_failed');
+            const errMsg = e.response?.data?.error?.message || t('scan_lookup_failed');
             showToast(errMsg, "error");
             isScanning.current = false;
             setScanned(false);
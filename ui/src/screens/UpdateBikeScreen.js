@@ -76,7 +76,7 @@ const UpdateBikeScreen = ({ route, navigation }) => {
             navigation.goBack(); // Return to details
         } catch (e) {
             console.error(e);
-            const errMsg = e.response?.data?.error || t('error');
+            const errMsg = e.response?.data?.error?.message || t('error');
             showToast(errMsg, "error");
         } finally {
             setSubmitting(false);
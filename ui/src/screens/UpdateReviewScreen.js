@@ -123,7 +123,7 @@ const UpdateReviewScreen = ({ route, navigation }) => {
             navigation.goBack();
         } catch (e) {
             console.error(e);
-            const errMsg = e.response?.data?.error || t('failed_update_review');
+            const errMsg = e.response?.data?.error?.message || t('failed_update_review');
             showToast(errMsg, "error");
         } finally {
             setLoading(false);
@@ -23,4 +23,44 @@ api.interceptors.request.use(
     }
 );
 
+// Access tokens are short-lived. On a 401 the stored refresh token is traded
+// for a new pair once and the request retried. Concurrent 401s share the same
+// refresh: a refresh token can only be used once, replaying it logs out.
+let refreshing = null;
+
+const refreshTokens = async () => {
+    const refreshToken = await storage.getItem('refreshToken');
+    if (!refreshToken) {
+        throw new Error('no refresh token');
+    }
+    const res = await axios.post(`${API_URL}/auth/refresh`, { refresh_token: refreshToken });
+    await storage.setItem('userToken', res.data.api_token);
+    await storage.setItem('refreshToken', res.data.refresh_token);
+    return res.data.api_token;
+};
+
+api.interceptors.response.use(
+    (response) => response,
+    async (error) => {
+        const original = error.config;
+        if (!error.response || error.response.status !== 401 || !original || original._retried) {
+            return Promise.reject(error);
+        }
+        original._retried = true;
+
+        try {
+            if (!refreshing) {
+                refreshing = refreshTokens().finally(() => {
+                    refreshing = null;
+                });
+            }
+            const token = await refreshing;
+            original.headers.Authorization = `Bearer ${token}`;
+            return api(original);
+        } catch (e) {
+            return Promise.reject(error);
+        }
+    }
+);
+
 export default api;
@@ -76,6 +76,7 @@ export default {
     // Settings
     appearance: 'Aparença',
     dark_mode: 'Mode Fosc',
+    member_since: 'Membre des de',
     account: 'Compte',
 
     // Bike Details
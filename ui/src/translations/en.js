@@ -76,6 +76,7 @@ export default {
     // Settings
     appearance: 'Appearance',
     dark_mode: 'Dark Mode',
+    member_since: 'Member since',
     account: 'Account',
 
     // Bike Details
@@ -76,6 +76,7 @@ export default {
     // Settings
     appearance: 'Apariencia',
     dark_mode: 'Modo Oscuro',
+    member_since: 'Miembro desde',
     account: 'Cuenta',
 
     // Bike Details